@@ -0,0 +1,300 @@
+package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// anthropicRequest is the subset of an Anthropic Messages request body mapped
+// onto a Prompt.
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	System        json.RawMessage    `json:"system"`
+	Messages      []anthropicMessage `json:"messages"`
+	Tools         []anthropicTool    `json:"tools"`
+	MaxTokens     int                `json:"max_tokens"`
+	Temperature   *float64           `json:"temperature"`
+	TopP          *float64           `json:"top_p"`
+	StopSequences []string           `json:"stop_sequences"`
+}
+
+type anthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// anthropicBlock is one content block of a message: text, reasoning
+// (thinking), a tool call (tool_use) or a tool result (tool_result).
+type anthropicBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text"`
+	Thinking     string                 `json:"thinking"`
+	ID           string                 `json:"id"`
+	Name         string                 `json:"name"`
+	Input        json.RawMessage        `json:"input"`
+	ToolUseID    string                 `json:"tool_use_id"`
+	Content      json.RawMessage        `json:"content"`
+	CacheControl *anthropicCacheControl `json:"cache_control"`
+}
+
+// anthropicCacheControl is a prompt-cache marker on a content block.
+type anthropicCacheControl struct {
+	Type string `json:"type"`
+	TTL  string `json:"ttl"` // "5m" or "1h"; empty means the 5 minute default
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicResponse is the subset of an Anthropic Messages response body
+// mapped onto a Completion and Usage.
+type anthropicResponse struct {
+	Model      string           `json:"model"`
+	Content    []anthropicBlock `json:"content"`
+	StopReason string           `json:"stop_reason"`
+	Usage      *AnthropicUsage  `json:"usage"`
+}
+
+// parseAnthropicPrompt maps a raw Anthropic Messages request body onto a
+// Prompt with Vendor "anthropic". The system prompt becomes a leading system
+// message and tool_result blocks become tool messages, matching the OpenAI
+// shape the rest of the SDK records. Each message carrying a cache_control
+// marker becomes a CacheBreakpoint.
+func parseAnthropicPrompt(body []byte) (Prompt, error) {
+	var req anthropicRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return Prompt{}, fmt.Errorf("triage: invalid Anthropic request body: %w", err)
+	}
+
+	p := Prompt{
+		Vendor:      "anthropic",
+		Model:       req.Model,
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stop:        req.StopSequences,
+	}
+	if system := contentText(req.System); system != "" {
+		p.Messages = append(p.Messages, Message{Role: "system", Content: system})
+		p.addAnthropicBreakpoint(req.System)
+	}
+	for _, m := range req.Messages {
+		p.Messages = append(p.Messages, anthropicMessages(m.Role, m.Content)...)
+		p.addAnthropicBreakpoint(m.Content)
+	}
+	for _, t := range req.Tools {
+		def := ToolDef{Type: "function", Function: ToolFunction{Name: t.Name, Description: t.Description}}
+		if len(t.InputSchema) > 0 {
+			def.Function.Parameters = t.InputSchema
+		}
+		p.Tools = append(p.Tools, def)
+	}
+	return p, nil
+}
+
+// addAnthropicBreakpoint adds a CacheBreakpoint at the last message if any
+// block of raw, the content just appended, carries a cache_control marker.
+// Plain string content can't carry one.
+func (p *Prompt) addAnthropicBreakpoint(raw json.RawMessage) {
+	var blocks []anthropicBlock
+	if json.Unmarshal(raw, &blocks) != nil || len(p.Messages) == 0 {
+		return
+	}
+	marked := false
+	var ttl time.Duration
+	for _, b := range blocks {
+		if b.CacheControl == nil {
+			continue
+		}
+		marked = true
+		if d, err := time.ParseDuration(b.CacheControl.TTL); err == nil && d > ttl {
+			ttl = d
+		}
+	}
+	if marked {
+		p.CacheBreakpoints = append(p.CacheBreakpoints, CacheBreakpoint{MessageIndex: len(p.Messages) - 1, TTL: ttl})
+	}
+}
+
+// parseAnthropicCompletion maps a raw Anthropic Messages response body onto a
+// Completion and Usage.
+func parseAnthropicCompletion(body []byte) (Completion, Usage, error) {
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Completion{}, Usage{}, fmt.Errorf("triage: invalid Anthropic response body: %w", err)
+	}
+
+	c := Completion{Model: resp.Model, FinishReason: resp.StopReason}
+	if m := anthropicAssistantMessage(resp.Content); !m.empty() {
+		c.Messages = []Message{m}
+	}
+	var u Usage
+	if resp.Usage != nil {
+		u = FromAnthropicUsage(*resp.Usage)
+	}
+	return c, u, nil
+}
+
+// anthropicMessages converts one Anthropic message, whose content is a string
+// or an array of blocks, into SDK messages. Each tool_result block becomes
+// its own tool message following the message's text.
+func anthropicMessages(role string, raw json.RawMessage) []Message {
+	var blocks []anthropicBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return []Message{{Role: role, Content: contentText(raw)}}
+	}
+	msg := anthropicAssistantMessage(blocks)
+	msg.Role = role
+	var results []Message
+	for _, b := range blocks {
+		if b.Type == "tool_result" {
+			results = append(results, Message{Role: "tool", Content: contentText(b.Content), ToolCallID: b.ToolUseID})
+		}
+	}
+	if msg.empty() && len(results) > 0 {
+		return results
+	}
+	return append([]Message{msg}, results...)
+}
+
+// anthropicAssistantMessage joins the text and thinking blocks of a content
+// array into Content and Reasoning and converts its tool_use blocks into tool
+// calls. Redacted thinking carries no readable text and is skipped.
+func anthropicAssistantMessage(blocks []anthropicBlock) Message {
+	m := Message{Role: "assistant"}
+	var texts, thoughts []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if b.Text != "" {
+				texts = append(texts, b.Text)
+			}
+		case "thinking":
+			if b.Thinking != "" {
+				thoughts = append(thoughts, b.Thinking)
+			}
+		case "tool_use":
+			m.ToolCalls = append(m.ToolCalls, ToolCall{
+				ID:       b.ID,
+				Type:     "function",
+				Function: ToolCallFunction{Name: b.Name, Arguments: string(b.Input)},
+			})
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	m.Reasoning = strings.Join(thoughts, "\n")
+	return m
+}
+
+// empty reports whether m has no content, reasoning or tool calls.
+func (m Message) empty() bool {
+	return m.Content == "" && m.Reasoning == "" && len(m.ToolCalls) == 0
+}
+
+// anthropicEvent is one server-sent event of a streamed Messages response.
+type anthropicEvent struct {
+	Type    string `json:"type"`
+	Index   int    `json:"index"`
+	Message struct {
+		Model string          `json:"model"`
+		Usage *AnthropicUsage `json:"usage"`
+	} `json:"message"`
+	ContentBlock anthropicBlock `json:"content_block"`
+	Delta        struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		Thinking    string `json:"thinking"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage *AnthropicUsage `json:"usage"`
+}
+
+// anthropicStream reassembles a streamed Messages response from its
+// server-sent events. Like openAIStream it is fed raw bytes as they pass
+// through.
+type anthropicStream struct {
+	sse     sseBuffer
+	onChunk func(token bool) // called once per content delta, always with token
+
+	completion Completion
+	blocks     []anthropicBlock // indexed by content block index
+	args       map[int]*strings.Builder
+	usage      AnthropicUsage
+}
+
+// Write consumes raw SSE bytes. It never fails.
+func (s *anthropicStream) Write(p []byte) (int, error) {
+	s.sse.feed(p, s.event)
+	return len(p), nil
+}
+
+func (s *anthropicStream) event(data []byte) {
+	var ev anthropicEvent
+	if json.Unmarshal(data, &ev) != nil {
+		return
+	}
+	switch ev.Type {
+	case "message_start":
+		s.completion.Model = ev.Message.Model
+		if ev.Message.Usage != nil {
+			s.usage = *ev.Message.Usage
+		}
+	case "content_block_start":
+		if !validStreamIndex(ev.Index) {
+			return
+		}
+		for len(s.blocks) <= ev.Index {
+			s.blocks = append(s.blocks, anthropicBlock{})
+		}
+		s.blocks[ev.Index] = ev.ContentBlock
+	case "content_block_delta":
+		if s.onChunk != nil {
+			s.onChunk(true)
+		}
+		if ev.Index < 0 || ev.Index >= len(s.blocks) {
+			return
+		}
+		switch ev.Delta.Type {
+		case "text_delta":
+			s.blocks[ev.Index].Text += ev.Delta.Text
+		case "thinking_delta":
+			s.blocks[ev.Index].Thinking += ev.Delta.Thinking
+		case "input_json_delta":
+			if s.args == nil {
+				s.args = make(map[int]*strings.Builder)
+			}
+			if s.args[ev.Index] == nil {
+				s.args[ev.Index] = &strings.Builder{}
+			}
+			s.args[ev.Index].WriteString(ev.Delta.PartialJSON)
+		}
+	case "message_delta":
+		if ev.Delta.StopReason != "" {
+			s.completion.FinishReason = ev.Delta.StopReason
+		}
+		if ev.Usage != nil {
+			// message_delta carries cumulative output tokens only.
+			s.usage.OutputTokens = ev.Usage.OutputTokens
+		}
+	}
+}
+
+// result returns the reassembled completion and usage.
+func (s *anthropicStream) result() (Completion, Usage) {
+	c := s.completion
+	blocks := make([]anthropicBlock, len(s.blocks))
+	copy(blocks, s.blocks)
+	for i, args := range s.args {
+		blocks[i].Input = json.RawMessage(args.String())
+	}
+	if m := anthropicAssistantMessage(blocks); !m.empty() {
+		c.Messages = []Message{m}
+	}
+	return c, FromAnthropicUsage(s.usage)
+}
@@ -0,0 +1,221 @@
+package triage
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Request parsing
+// ---------------------------------------------------------------------------
+
+func TestParseAnthropicPrompt_MapsMessagesAndTools(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4-20250514",
+		"max_tokens": 1024,
+		"system": [{"type": "text", "text": "Be brief."}],
+		"stop_sequences": ["END"],
+		"tools": [{"name": "lookup", "description": "Look up an order", "input_schema": {"type": "object"}}],
+		"messages": [
+			{"role": "user", "content": "Where is order 7?"},
+			{"role": "assistant", "content": [
+				{"type": "text", "text": "Checking."},
+				{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {"id": 7}}
+			]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": "shipped"}]}
+		]
+	}`
+
+	p, err := parseAnthropicPrompt([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Vendor != "anthropic" || p.Model != "claude-sonnet-4-20250514" || p.MaxTokens != 1024 {
+		t.Errorf("vendor/model/max_tokens: got %q/%q/%d", p.Vendor, p.Model, p.MaxTokens)
+	}
+	if len(p.Stop) != 1 || p.Stop[0] != "END" {
+		t.Errorf("stop: got %v", p.Stop)
+	}
+	if len(p.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %+v", p.Messages)
+	}
+	if p.Messages[0].Role != "system" || p.Messages[0].Content != "Be brief." {
+		t.Errorf("system: got %+v", p.Messages[0])
+	}
+	if m := p.Messages[2]; m.Content != "Checking." || len(m.ToolCalls) != 1 ||
+		m.ToolCalls[0].ID != "toolu_1" || m.ToolCalls[0].Function.Arguments != `{"id": 7}` {
+		t.Errorf("tool call: got %+v", m)
+	}
+	if m := p.Messages[3]; m.Role != "tool" || m.ToolCallID != "toolu_1" || m.Content != "shipped" {
+		t.Errorf("tool result: got %+v", m)
+	}
+	if len(p.Tools) != 1 || p.Tools[0].Function.Name != "lookup" || p.Tools[0].Function.Parameters == nil {
+		t.Errorf("tools: got %+v", p.Tools)
+	}
+}
+
+func TestParseAnthropicPrompt_CacheControlBreakpoints(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4",
+		"system": [{"type": "text", "text": "Long instructions.", "cache_control": {"type": "ephemeral"}}],
+		"messages": [
+			{"role": "user", "content": "Plain strings can't be marked."},
+			{"role": "assistant", "content": "Ok."},
+			{"role": "user", "content": [{"type": "text", "text": "Doc", "cache_control": {"type": "ephemeral", "ttl": "1h"}}]}
+		]
+	}`
+
+	p, err := parseAnthropicPrompt([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CacheBreakpoint{{MessageIndex: 0}, {MessageIndex: 3, TTL: time.Hour}}
+	if len(p.CacheBreakpoints) != len(want) {
+		t.Fatalf("breakpoints: got %+v, want %+v", p.CacheBreakpoints, want)
+	}
+	for i, bp := range want {
+		if p.CacheBreakpoints[i] != bp {
+			t.Errorf("breakpoint %d: got %+v, want %+v", i, p.CacheBreakpoints[i], bp)
+		}
+	}
+}
+
+func TestParseAnthropicPrompt_InvalidBody(t *testing.T) {
+	if _, err := parseAnthropicPrompt([]byte("{")); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Response parsing
+// ---------------------------------------------------------------------------
+
+func TestParseAnthropicCompletion_MapsContentAndUsage(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4-20250514",
+		"content": [{"type": "text", "text": "Hello!"}],
+		"stop_reason": "max_tokens",
+		"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 20}
+	}`
+
+	c, u, err := parseAnthropicCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.FinishReason != "max_tokens" || len(c.Messages) != 1 || c.Messages[0].Content != "Hello!" {
+		t.Errorf("completion: got %+v", c)
+	}
+	if u.PromptTokens != 30 || u.CompletionTokens != 5 || u.TotalTokens != 35 || u.CacheReadTokens != 20 {
+		t.Errorf("usage: got %+v", u)
+	}
+}
+
+func TestParseAnthropicCompletion_ThinkingBecomesReasoning(t *testing.T) {
+	body := `{
+		"model": "claude-sonnet-4",
+		"content": [
+			{"type": "thinking", "thinking": "The user wants a greeting.", "signature": "sig"},
+			{"type": "redacted_thinking", "data": "opaque"},
+			{"type": "text", "text": "Hi!"}
+		],
+		"stop_reason": "end_turn"
+	}`
+
+	c, _, err := parseAnthropicCompletion([]byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Messages) != 1 || c.Messages[0].Reasoning != "The user wants a greeting." || c.Messages[0].Content != "Hi!" {
+		t.Errorf("messages: got %+v", c.Messages)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Streaming
+// ---------------------------------------------------------------------------
+
+func TestAnthropicStream_Reassembles(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":12,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup","input":{}}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"id\""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":":7}"}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}`,
+		`{"type":"message_stop"}`,
+	}
+	var raw strings.Builder
+	for _, e := range events {
+		raw.WriteString("event: x\ndata: " + e + "\n\n")
+	}
+
+	chunks := 0
+	s := &anthropicStream{onChunk: func(bool) { chunks++ }}
+	// Feed in small pieces to exercise events split across writes.
+	data := raw.String()
+	for len(data) > 0 {
+		n := min(7, len(data))
+		s.Write([]byte(data[:n]))
+		data = data[n:]
+	}
+
+	c, u := s.result()
+	if c.Model != "claude-sonnet-4" || c.FinishReason != "tool_use" {
+		t.Errorf("model/finish: got %q/%q", c.Model, c.FinishReason)
+	}
+	if len(c.Messages) != 1 || c.Messages[0].Content != "Hello" {
+		t.Fatalf("messages: got %+v", c.Messages)
+	}
+	if calls := c.Messages[0].ToolCalls; len(calls) != 1 || calls[0].Function.Arguments != `{"id":7}` {
+		t.Errorf("tool calls: got %+v", calls)
+	}
+	if u.PromptTokens != 12 || u.CompletionTokens != 9 {
+		t.Errorf("usage: got %+v", u)
+	}
+	if chunks != 4 {
+		t.Errorf("chunks: got %d, want 4", chunks)
+	}
+}
+
+func TestAnthropicStream_ThinkingDeltas(t *testing.T) {
+	events := []string{
+		`{"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":5}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Look it "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"up."}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Done."}}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":7}}`,
+	}
+	s := &anthropicStream{}
+	for _, e := range events {
+		s.Write([]byte("data: " + e + "\n\n"))
+	}
+
+	c, _ := s.result()
+	if len(c.Messages) != 1 || c.Messages[0].Reasoning != "Look it up." || c.Messages[0].Content != "Done." {
+		t.Errorf("messages: got %+v", c.Messages)
+	}
+}
+
+func TestAnthropicStream_IgnoresOutOfRangeIndex(t *testing.T) {
+	s := &anthropicStream{}
+	for _, e := range []string{
+		`{"type":"content_block_start","index":-1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_start","index":1000000000,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":-1,"delta":{"type":"text_delta","text":"x"}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+	} {
+		s.Write([]byte("data: " + e + "\n\n"))
+	}
+
+	c, _ := s.result()
+	if len(c.Messages) != 1 || c.Messages[0].Content != "Hi" {
+		t.Errorf("messages: got %+v", c.Messages)
+	}
+}
@@ -0,0 +1,67 @@
+package triage
+
+import (
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// requestModel returns the model name used for span naming and
+// gen_ai.request.model. Azure OpenAI requests address a deployment rather
+// than a model, so the deployment stands in when Model is empty.
+func requestModel(prompt Prompt) string {
+	if prompt.Model == "" && prompt.Vendor == VendorAzureOpenAI {
+		return prompt.Deployment
+	}
+	return prompt.Model
+}
+
+// azureAttributes records an Azure OpenAI call's deployment, API version and
+// resource name. The resource is taken from the endpoint host
+// ({resource}.openai.azure.com).
+func azureAttributes(prompt Prompt) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if prompt.Deployment != "" {
+		attrs = append(attrs, attribute.String(AttrAzureDeployment, prompt.Deployment))
+	}
+	if prompt.APIVersion != "" {
+		attrs = append(attrs, attribute.String(AttrAzureAPIVersion, prompt.APIVersion))
+	}
+	host, _ := splitEndpoint(prompt.Endpoint)
+	if resource := azureResource(host); resource != "" {
+		attrs = append(attrs, attribute.String(AttrAzureResource, resource))
+	}
+	return attrs
+}
+
+// azureResource returns the Azure resource name encoded in an Azure OpenAI
+// host, or "".
+func azureResource(host string) string {
+	for _, suffix := range []string{".openai.azure.com", ".cognitiveservices.azure.com", ".services.ai.azure.com"} {
+		if resource, ok := strings.CutSuffix(host, suffix); ok && !strings.Contains(resource, ".") {
+			return resource
+		}
+	}
+	return ""
+}
+
+// applyAzureURL fills in the Azure OpenAI vendor, deployment and API version
+// of prompt from an Azure request URL
+// (/openai/deployments/{deployment}/chat/completions?api-version=...).
+// Other URLs leave prompt unchanged.
+func applyAzureURL(prompt *Prompt, u *url.URL) {
+	rest, ok := strings.CutPrefix(u.Path, "/openai/deployments/")
+	if !ok && azureResource(u.Hostname()) == "" {
+		return
+	}
+	prompt.Vendor = VendorAzureOpenAI
+	if ok {
+		if deployment, _, _ := strings.Cut(rest, "/"); deployment != "" {
+			prompt.Deployment = deployment
+		}
+	}
+	if v := u.Query().Get("api-version"); v != "" {
+		prompt.APIVersion = v
+	}
+}
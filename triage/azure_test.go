@@ -0,0 +1,119 @@
+package triage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Vendor and URL mapping
+// ---------------------------------------------------------------------------
+
+func TestNormalizeVendor(t *testing.T) {
+	for in, want := range map[string]string{
+		"azure":        VendorAzureOpenAI,
+		"Azure_OpenAI": VendorAzureOpenAI,
+		"az.ai.openai": VendorAzureOpenAI,
+		"openai":       "openai",
+		"":             "",
+	} {
+		if got := normalizeVendor(in); got != want {
+			t.Errorf("normalizeVendor(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyAzureURL(t *testing.T) {
+	u, _ := url.Parse("https://acme-eu.openai.azure.com/openai/deployments/chat-prod/chat/completions?api-version=2024-10-21")
+	p := Prompt{Vendor: "openai"}
+	applyAzureURL(&p, u)
+	if p.Vendor != VendorAzureOpenAI || p.Deployment != "chat-prod" || p.APIVersion != "2024-10-21" {
+		t.Errorf("got vendor %q, deployment %q, api version %q", p.Vendor, p.Deployment, p.APIVersion)
+	}
+
+	u, _ = url.Parse("https://api.openai.com/v1/chat/completions")
+	p = Prompt{Vendor: "openai"}
+	applyAzureURL(&p, u)
+	if p.Vendor != "openai" || p.Deployment != "" {
+		t.Errorf("non-Azure URL should leave the prompt unchanged, got %+v", p)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Span attributes
+// ---------------------------------------------------------------------------
+
+func TestLogPrompt_AzureAttributes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor:     "azure",
+		Model:      "gpt-4o",
+		Deployment: "chat-prod",
+		APIVersion: "2024-10-21",
+		Endpoint:   "https://acme-eu.openai.azure.com",
+	})
+	llmSpan.End()
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "az.ai.openai.chat gpt-4o" {
+		t.Errorf("span name: got %q", span.Name)
+	}
+	attrs := attrMap(span.Attributes)
+	want := map[string]any{
+		"gen_ai.system":        VendorAzureOpenAI,
+		"gen_ai.request.model": "gpt-4o",
+		AttrAzureDeployment:    "chat-prod",
+		AttrAzureAPIVersion:    "2024-10-21",
+		AttrAzureResource:      "acme-eu",
+		AttrServerAddress:      "acme-eu.openai.azure.com",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], v)
+		}
+	}
+}
+
+func TestLogPrompt_AzureDeploymentNamesSpanWithoutModel(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: VendorAzureOpenAI, Deployment: "chat-prod"})
+	llmSpan.End()
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "az.ai.openai.chat chat-prod" {
+		t.Errorf("span name: got %q", span.Name)
+	}
+	if got := attrMap(span.Attributes)["gen_ai.request.model"]; got != "chat-prod" {
+		t.Errorf("request model: got %v", got)
+	}
+}
+
+func TestTransport_RecordsAzureCall(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	client, base := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"model":"gpt-4o-2024-08-06","choices":[{"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)
+	})
+
+	req, err := http.NewRequest(http.MethodPost, base+"/openai/deployments/chat-prod/chat/completions?api-version=2024-10-21",
+		strings.NewReader(`{"messages":[{"role":"user","content":"Hello"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doPost(t, client, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.system"] != VendorAzureOpenAI || attrs[AttrAzureDeployment] != "chat-prod" || attrs[AttrAzureAPIVersion] != "2024-10-21" {
+		t.Errorf("azure attributes: got %v", attrs)
+	}
+}
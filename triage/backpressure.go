@@ -0,0 +1,109 @@
+package triage
+
+import (
+	"math"
+	"sync/atomic"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultMaxQueueSize is the batch span processor's default queue capacity,
+// used when WithMaxQueueSize is not set.
+const defaultMaxQueueSize = 2048
+
+// QueuePressure describes the export queue crossing the high-water mark set
+// with WithQueueHighWaterMark, or draining back below half of it.
+type QueuePressure struct {
+	High     bool  // true when the queue filled past the mark, false once it drained
+	Depth    int64 // spans waiting for export
+	Capacity int64 // spans the queue holds before new ones are dropped
+}
+
+// QueuePressureHandler is called synchronously from span end or export when
+// the queue's pressure state changes. It should return quickly.
+type QueuePressureHandler func(QueuePressure)
+
+// WithQueueHighWaterMark registers a callback invoked when the number of
+// spans waiting for export reaches fraction (0 < fraction <= 1) of the queue
+// capacity, so the application can shed optional telemetry before spans start
+// dropping:
+//
+//	triage.WithQueueHighWaterMark(0.8, func(p triage.QueuePressure) {
+//	    captureContent.Store(!p.High)
+//	})
+//
+// The callback fires again with High false once the queue drains below half
+// the mark; the gap keeps it from flapping around the threshold.
+// QueueUnderPressure reports the current state.
+func WithQueueHighWaterMark(fraction float64, h QueuePressureHandler) Option {
+	return func(c *config) {
+		c.queueHighWater = fraction
+		c.queueHandler = h
+	}
+}
+
+// queueHigh is set while the export queue is above its high-water mark.
+var queueHigh atomic.Bool
+
+// QueueUnderPressure reports whether the export queue is currently above the
+// high-water mark set with WithQueueHighWaterMark. Always false if no mark is
+// set.
+func QueueUnderPressure() bool {
+	return queueHigh.Load()
+}
+
+// checkQueuePressure compares the queue depth against the configured
+// high-water mark and notifies the handler on state changes. Called after
+// spans enter (span end) or leave (export) the queue.
+func checkQueuePressure() {
+	cfg := globalCfg
+	if cfg == nil || cfg.queueHighWater <= 0 {
+		return
+	}
+	capacity := cfg.queueCapacity()
+	high := int64(math.Ceil(cfg.queueHighWater * float64(capacity)))
+	depth := stats.queueDepth()
+
+	var p QueuePressure
+	switch {
+	case depth >= high && queueHigh.CompareAndSwap(false, true):
+		p = QueuePressure{High: true, Depth: depth, Capacity: capacity}
+	case depth < high/2 && queueHigh.CompareAndSwap(true, false):
+		p = QueuePressure{High: false, Depth: depth, Capacity: capacity}
+	default:
+		return
+	}
+	sdkLogger().Debug("triage: export queue pressure changed", "high", p.High, "depth", p.Depth, "capacity", p.Capacity)
+	if cfg.queueHandler != nil {
+		guard("queue pressure handler", func() { cfg.queueHandler(p) })
+	}
+}
+
+// queueCapacity returns the export queue capacity: WithMaxQueueSize, or the
+// batch span processor's default.
+func (c *config) queueCapacity() int64 {
+	if c.maxQueueSize <= 0 {
+		return defaultMaxQueueSize
+	}
+	return int64(c.maxQueueSize)
+}
+
+// queueLimiter sits in front of the batch span processor and drops ended
+// spans, counting them, once capacity spans are waiting for export. The batch
+// processor's own queue, of the same capacity, then never overflows: it drops
+// spans silently, which would leave them counted as queued forever.
+type queueLimiter struct {
+	sdktrace.SpanProcessor
+	capacity int64
+}
+
+func (q *queueLimiter) OnEnd(s sdktrace.ReadOnlySpan) {
+	// triageSpanProcessor has already counted s as ended, so the depth
+	// includes it.
+	if s.SpanContext().IsSampled() && stats.queueDepth() > q.capacity {
+		stats.dropped.Add(1)
+		checkQueuePressure()
+		return
+	}
+	q.SpanProcessor.OnEnd(s)
+}
@@ -0,0 +1,130 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// zeroQueueDepth offsets the process-wide span counters so the export queue
+// starts empty, undoing the offset when the test completes.
+func zeroQueueDepth(t *testing.T) {
+	t.Helper()
+	depth := stats.queueDepth()
+	stats.exported.Add(depth)
+	t.Cleanup(func() {
+		stats.exported.Add(-depth)
+		queueHigh.Store(false)
+	})
+}
+
+// ---------------------------------------------------------------------------
+// High-water mark
+// ---------------------------------------------------------------------------
+
+func TestQueuePressure_FiresOnCrossingAndDrain(t *testing.T) {
+	tp, _ := newTestProvider(t)
+	var events []QueuePressure
+	globalCfg = &config{maxQueueSize: 10, queueHighWater: 0.5, queueHandler: func(p QueuePressure) {
+		events = append(events, p)
+	}}
+	t.Cleanup(func() { globalCfg = nil })
+	zeroQueueDepth(t)
+
+	// Spans end without being exported, so they accumulate in the queue.
+	for range 4 {
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		span.End()
+	}
+	if len(events) != 0 || QueueUnderPressure() {
+		t.Fatalf("below the mark: got %+v", events)
+	}
+	_, span := tp.Tracer("test").Start(context.Background(), "op")
+	span.End()
+	if len(events) != 1 || !events[0].High || events[0].Depth != 5 || events[0].Capacity != 10 {
+		t.Fatalf("crossing: got %+v", events)
+	}
+	if !QueueUnderPressure() {
+		t.Error("QueueUnderPressure should report true above the mark")
+	}
+
+	// Draining to 2 stays within the hysteresis band; draining to 1 clears it.
+	exp := &countingExporter{SpanExporter: &failingExporter{}}
+	_ = exp.ExportSpans(context.Background(), make([]sdktrace.ReadOnlySpan, 3))
+	if len(events) != 1 {
+		t.Fatalf("within hysteresis band: got %+v", events)
+	}
+	_ = exp.ExportSpans(context.Background(), make([]sdktrace.ReadOnlySpan, 1))
+	if len(events) != 2 || events[1].High || events[1].Depth != 1 {
+		t.Fatalf("drain: got %+v", events)
+	}
+	if QueueUnderPressure() {
+		t.Error("QueueUnderPressure should report false after draining")
+	}
+}
+
+func TestQueuePressure_DisabledByDefault(t *testing.T) {
+	tp, _ := newTestProvider(t)
+	globalCfg = &config{maxQueueSize: 1}
+	t.Cleanup(func() { globalCfg = nil })
+	zeroQueueDepth(t)
+
+	for range 3 {
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		span.End()
+	}
+	if QueueUnderPressure() {
+		t.Error("no pressure should be reported without a high-water mark")
+	}
+}
+
+func TestWithQueueHighWaterMark_RejectsOutOfRange(t *testing.T) {
+	for _, f := range []float64{-0.1, 1.5} {
+		_, err := resolveConfig(WithAPIKey("tsk_test"), WithQueueHighWaterMark(f, nil))
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("fraction %v: got %v, want ErrInvalidConfig", f, err)
+		}
+	}
+}
+
+func TestQueuePressure_ClearsAfterQueueOverflow(t *testing.T) {
+	var events []QueuePressure
+	globalCfg = &config{maxQueueSize: 2, queueHighWater: 1, queueHandler: func(p QueuePressure) {
+		events = append(events, p)
+	}}
+	t.Cleanup(func() { globalCfg = nil })
+	zeroQueueDepth(t)
+	dropped := stats.dropped.Load()
+
+	// The recorder stands in for the batch processor and never exports, so
+	// spans past the capacity overflow.
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSpanProcessor(&queueLimiter{SpanProcessor: recorder, capacity: 2}),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	for range 5 {
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		span.End()
+	}
+	if got := len(recorder.Ended()); got != 2 {
+		t.Errorf("spans admitted: got %d, want 2", got)
+	}
+	if got := stats.dropped.Load() - dropped; got != 3 {
+		t.Errorf("overflow drops: got %d, want 3", got)
+	}
+	if depth := stats.queueDepth(); depth != 2 {
+		t.Errorf("queue depth: got %d, want 2", depth)
+	}
+
+	// Exporting what the queue held drains it completely.
+	exp := &countingExporter{SpanExporter: tracetest.NewInMemoryExporter()}
+	_ = exp.ExportSpans(context.Background(), recorder.Ended())
+	if QueueUnderPressure() || len(events) != 2 || events[1].High || events[1].Depth != 0 {
+		t.Errorf("pressure should clear once the queue drains, got %+v", events)
+	}
+}
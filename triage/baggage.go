@@ -0,0 +1,77 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// WithContextBaggage makes WithUser, WithTenant and WithSession also write
+// their values into OpenTelemetry Baggage (as triage.user.id,
+// triage.tenant.id, triage.session.id, ...), so they cross service
+// boundaries with the W3C baggage header that Init's default propagators
+// send. On the receiving side, spans started from a context carrying that
+// baggage record the values as triage attributes, unless the triage context
+// sets them. Off by default.
+//
+// Both services need the option. Enable it only between trusted services:
+// baggage is client-controlled, and a spoofed user ID would be recorded
+// as-is.
+func WithContextBaggage(b bool) Option {
+	return func(c *config) { c.contextBaggage = b }
+}
+
+// isContextBaggageEnabled reports whether WithContextBaggage is on.
+func isContextBaggageEnabled() bool {
+	return globalCfg != nil && globalCfg.contextBaggage
+}
+
+// withContextBaggage returns ctx with tc's baggage-carried fields set as
+// baggage members, if WithContextBaggage is on. Values that are not valid
+// baggage are skipped.
+func withContextBaggage(ctx context.Context, tc triageContext) context.Context {
+	if !isContextBaggageEnabled() {
+		return ctx
+	}
+	b := baggage.FromContext(ctx)
+	for _, f := range baggageContextFields {
+		v := *f.field(&tc)
+		if v == "" {
+			continue
+		}
+		m, err := baggage.NewMemberRaw(f.baggage, v)
+		if err != nil {
+			continue
+		}
+		if nb, err := b.SetMember(m); err == nil {
+			b = nb
+		}
+	}
+	return baggage.ContextWithBaggage(ctx, b)
+}
+
+// contextFromBaggage returns ctx carrying the triage context fields found in
+// its baggage, if WithContextBaggage is on. Values already set on ctx take
+// precedence.
+func contextFromBaggage(ctx context.Context) context.Context {
+	if !isContextBaggageEnabled() {
+		return ctx
+	}
+	b := baggage.FromContext(ctx)
+	if b.Len() == 0 {
+		return ctx
+	}
+	tc := getFromContext(ctx).clone()
+	changed := false
+	for _, f := range baggageContextFields {
+		if p := f.field(&tc); *p == "" {
+			if v := b.Member(f.baggage).Value(); v != "" {
+				*p, changed = v, true
+			}
+		}
+	}
+	if !changed {
+		return ctx
+	}
+	return setInContext(ctx, tc)
+}
@@ -0,0 +1,78 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestContextBaggage_WrittenByHelpers(t *testing.T) {
+	newGlobalTestProvider(t)
+	globalCfg = &config{contextBaggage: true}
+
+	ctx := WithUser(context.Background(), "u_1", UserRole("admin"))
+	ctx = WithTenant(ctx, "org_1", Workspace("ws_1"))
+	ctx = WithSession(ctx, "sess 1")
+
+	b := baggage.FromContext(ctx)
+	for key, want := range map[string]string{
+		AttrUserID:          "u_1",
+		AttrUserRole:        "admin",
+		AttrTenantID:        "org_1",
+		AttrTenantWorkspace: "ws_1",
+		AttrSessionID:       "sess 1",
+	} {
+		if got := b.Member(key).Value(); got != want {
+			t.Errorf("baggage %s: got %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestContextBaggage_OffByDefault(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	ctx := WithUser(context.Background(), "u_1")
+	if n := baggage.FromContext(ctx).Len(); n != 0 {
+		t.Errorf("expected no baggage without WithContextBaggage, got %d members", n)
+	}
+}
+
+func TestContextBaggage_CrossesServiceBoundary(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{contextBaggage: true}
+
+	// Caller.
+	ctx := WithTenant(WithUser(context.Background(), "u_1"), "org_1")
+	h := http.Header{}
+	propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(h))
+
+	// Callee: only the baggage header arrives.
+	remote := propagation.Baggage{}.Extract(context.Background(), propagation.HeaderCarrier(h))
+	remote = WithTenant(remote, "org_override")
+	wf, _ := StartWorkflow(remote, "handle")
+	wf.End()
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrUserID] != "u_1" {
+		t.Errorf("user from baggage: got %v", attrs[AttrUserID])
+	}
+	if attrs[AttrTenantID] != "org_override" {
+		t.Errorf("the triage context should take precedence over baggage, got %v", attrs[AttrTenantID])
+	}
+}
+
+func TestContextBaggage_IgnoredWhenDisabled(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	m, _ := baggage.NewMemberRaw(AttrUserID, "spoofed")
+	b, _ := baggage.New(m)
+	wf, _ := StartWorkflow(baggage.ContextWithBaggage(context.Background(), b), "handle")
+	wf.End()
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrUserID]; ok {
+		t.Error("baggage should be ignored without WithContextBaggage")
+	}
+}
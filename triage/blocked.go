@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrBlocked matches every *BlockedError with errors.Is, for callers that
+// only need to know a request was blocked:
+//
+//	if errors.Is(err, triage.ErrBlocked) { return http.StatusForbidden }
+var ErrBlocked = errors.New("triage: request blocked by guardrail")
+
+// BlockedError reports a request blocked by a guardrail policy. Return it to
+// callers so blocks surface the same way through every application's error
+// handling; errors.As recovers the details.
+type BlockedError struct {
+	PolicyID string // policy that blocked the request
+	Rule     string // rule within the policy that matched
+	Reason   string // human-readable explanation, safe to show the caller
+	TraceID  string // trace the block was recorded in, if any
+	TraceURL string // link to the trace, if WithTraceURLTemplate is set
+}
+
+func (e *BlockedError) Error() string {
+	msg := fmt.Sprintf("triage: blocked by policy %q", e.PolicyID)
+	if e.Rule != "" {
+		msg += fmt.Sprintf(" (rule %q)", e.Rule)
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Is reports whether target is ErrBlocked.
+func (e *BlockedError) Is(target error) bool {
+	return target == ErrBlocked
+}
+
+// NewBlockedError records a guardrail block on the current span — a
+// triage.guardrail.blocked event, the policy and rule as attributes, and an
+// error status — and returns the matching error for the application to
+// return:
+//
+//	if verdict.Block {
+//	    return triage.NewBlockedError(ctx, "pii-egress", "ssn", "response contained an SSN")
+//	}
+func NewBlockedError(ctx context.Context, policyID, rule, reason string) *BlockedError {
+	e := &BlockedError{PolicyID: policyID, Rule: rule, Reason: reason}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		e.TraceID = sc.TraceID().String()
+		e.TraceURL = TraceURL(ctx)
+	}
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		attrs := []attribute.KeyValue{
+			attribute.Bool(AttrGuardrailBlocked, true),
+			attribute.String(AttrGuardrailPolicyID, policyID),
+		}
+		if rule != "" {
+			attrs = append(attrs, attribute.String(AttrGuardrailRule, rule))
+		}
+		span.AddEvent(guardrailBlockedEventName, trace.WithAttributes(attrs...))
+		span.SetAttributes(attrs...)
+		recordSpanError(span, e)
+	}
+	return e
+}
+
+// WithTraceURLTemplate sets the link format used by TraceURL and
+// BlockedError.TraceURL; "{trace_id}" is replaced with the hex trace ID:
+//
+//	triage.WithTraceURLTemplate("https://app.triageai.dev/traces/{trace_id}")
+func WithTraceURLTemplate(tmpl string) Option {
+	return func(c *config) { c.traceURLTemplate = tmpl }
+}
+
+// TraceURL returns a link to the trace carried by ctx, or "" if ctx has no
+// trace or WithTraceURLTemplate is not set.
+func TraceURL(ctx context.Context) string {
+	cfg := globalCfg
+	sc := trace.SpanContextFromContext(ctx)
+	if cfg == nil || cfg.traceURLTemplate == "" || !sc.HasTraceID() {
+		return ""
+	}
+	return strings.ReplaceAll(cfg.traceURLTemplate, "{trace_id}", sc.TraceID().String())
+}
@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestNewBlockedError_RecordsOnSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, traceURLTemplate: "https://app.example.com/traces/{trace_id}"}
+
+	wf, ctx := StartWorkflow(context.Background(), "answer")
+	err := NewBlockedError(ctx, "pii-egress", "ssn", "response contained an SSN")
+	wf.End()
+
+	span := exporter.GetSpans()[0]
+	attrs := attrMap(span.Attributes)
+	if attrs[AttrGuardrailBlocked] != true || attrs[AttrGuardrailPolicyID] != "pii-egress" || attrs[AttrGuardrailRule] != "ssn" {
+		t.Errorf("guardrail attributes: got %v", attrs)
+	}
+	if attrs[AttrErrorType] != "blocked" {
+		t.Errorf("error type: got %v, want blocked", attrs[AttrErrorType])
+	}
+	if span.Status.Code != codes.Error {
+		t.Errorf("status: got %v, want Error", span.Status.Code)
+	}
+	if len(span.Events) == 0 || span.Events[0].Name != guardrailBlockedEventName {
+		t.Errorf("events: got %+v", span.Events)
+	}
+
+	traceID := span.SpanContext.TraceID().String()
+	if err.TraceID != traceID {
+		t.Errorf("trace ID: got %q, want %q", err.TraceID, traceID)
+	}
+	if err.TraceURL != "https://app.example.com/traces/"+traceID {
+		t.Errorf("trace URL: got %q", err.TraceURL)
+	}
+}
+
+func TestBlockedError_MatchesErrBlocked(t *testing.T) {
+	err := fmt.Errorf("handler: %w", NewBlockedError(context.Background(), "jailbreak", "", "prompt injection detected"))
+
+	if !errors.Is(err, ErrBlocked) {
+		t.Error("errors.Is should match ErrBlocked")
+	}
+	var be *BlockedError
+	if !errors.As(err, &be) || be.PolicyID != "jailbreak" {
+		t.Fatalf("errors.As: got %+v", be)
+	}
+	if be.TraceID != "" || be.TraceURL != "" {
+		t.Errorf("no trace in context: got %q / %q", be.TraceID, be.TraceURL)
+	}
+	if msg := be.Error(); !strings.Contains(msg, `"jailbreak"`) || !strings.Contains(msg, "prompt injection detected") || strings.Contains(msg, "rule") {
+		t.Errorf("message: got %q", msg)
+	}
+}
+
+func TestTraceURL_EmptyWithoutTemplate(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "answer")
+	defer wf.End()
+	if got := TraceURL(ctx); got != "" {
+		t.Errorf("got %q, want empty", got)
+	}
+}
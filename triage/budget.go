@@ -0,0 +1,211 @@
+package triage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceBudget counts the SDK spans created in one trace (within this
+// process) and those elided by the WithMaxSpansPerTrace and
+// WithMaxTraceDepth limits, and the content bytes captured against
+// WithMaxContentBytesPerTrace. It is created by the first SDK span of a trace
+// and travels down the context to its descendants (see admitSpan).
+type traceBudget struct {
+	spans  atomic.Int64
+	elided atomic.Int64
+
+	contentBytes  atomic.Int64
+	contentCutoff atomic.Bool // set once the content budget is exceeded
+}
+
+// traceBudgetKey and spanDepthKey are unexported context keys for the trace
+// budget and the nesting depth of the current SDK span.
+type (
+	traceBudgetKey struct{}
+	spanDepthKey   struct{}
+)
+
+// WithMaxSpansPerTrace caps the number of SDK spans (workflows, tasks,
+// agents, tools and LLM calls) created in one trace. Beyond the cap, start
+// calls return a no-op handle and the unchanged context, and the trace's
+// first span records a triage.spans_elided event with the number of spans
+// left out. Protects against runaway agent loops producing enormous traces.
+// Zero (the default) means unlimited.
+func WithMaxSpansPerTrace(n int) Option {
+	return func(c *config) { c.maxSpansPerTrace = n }
+}
+
+// WithMaxTraceDepth caps how deeply SDK spans may nest in one trace; spans
+// below the limit are elided like those beyond WithMaxSpansPerTrace. Guards
+// against unbounded recursion in agents calling agents. Zero (the default)
+// means unlimited.
+func WithMaxTraceDepth(n int) Option {
+	return func(c *config) { c.maxTraceDepth = n }
+}
+
+// maxTrackedTraces bounds traceBudgets.
+const maxTrackedTraces = 10_000
+
+// traceBudgets holds the budgets of traces whose first SDK span has a parent
+// the SDK didn't create (a remote caller or the application's own span), so
+// that SDK spans started from sibling contexts of that parent share one
+// budget. Entries are removed when the trace's local root span ends; once
+// the map is full, an arbitrary entry is evicted.
+var traceBudgets = struct {
+	sync.Mutex
+	byTrace map[trace.TraceID]*traceBudget
+}{byTrace: map[trace.TraceID]*traceBudget{}}
+
+// budgetForTrace returns the budget of trace id, creating it if needed, and
+// whether it was created.
+func budgetForTrace(id trace.TraceID) (_ *traceBudget, created bool) {
+	traceBudgets.Lock()
+	defer traceBudgets.Unlock()
+	if b := traceBudgets.byTrace[id]; b != nil {
+		return b, false
+	}
+	if len(traceBudgets.byTrace) >= maxTrackedTraces {
+		for k := range traceBudgets.byTrace {
+			delete(traceBudgets.byTrace, k)
+			break
+		}
+	}
+	b := &traceBudget{}
+	traceBudgets.byTrace[id] = b
+	return b, true
+}
+
+// releaseTraceBudget forgets the budget of trace id, if any. It is called as
+// a local root span ends.
+func releaseTraceBudget(id trace.TraceID) {
+	if !budgetsEnabled(globalCfg) {
+		return
+	}
+	traceBudgets.Lock()
+	delete(traceBudgets.byTrace, id)
+	traceBudgets.Unlock()
+}
+
+// budgetsEnabled reports whether cfg sets any per-trace limit.
+func budgetsEnabled(cfg *config) bool {
+	return cfg != nil && (cfg.maxSpansPerTrace > 0 || cfg.maxTraceDepth > 0 || cfg.maxContentBytes > 0)
+}
+
+// WithMaxContentBytesPerTrace caps the prompt and completion content
+// captured in one trace at n bytes (e.g. 1 << 20). Once a call would exceed
+// it, that call and every later one in the trace record content as SHA-256
+// digests ("sha256:…") instead of text, with triage.content.hashed set; the
+// span that crossed the limit records a triage.content_budget_exceeded
+// event. Bounds worst-case trace size for pathological conversations. Zero
+// (the default) means unlimited.
+func WithMaxContentBytesPerTrace(n int) Option {
+	return func(c *config) { c.maxContentBytes = n }
+}
+
+// admitSpan decides whether a new SDK span may be created under ctx. If so,
+// it returns the context to start the span from (carrying the budget and the
+// span's depth) and, when this span opened the budget, the budget it must
+// report on End. Otherwise it counts the span as elided and returns ok=false.
+//
+// The budget travels down the context from the trace's first SDK span. When
+// that span has a parent the SDK didn't create, the budget is also looked up
+// by trace ID, so SDK spans started from other contexts of the same trace
+// share it.
+func admitSpan(ctx context.Context) (_ context.Context, owned *traceBudget, ok bool) {
+	cfg := globalCfg
+	if !budgetsEnabled(cfg) {
+		return ctx, nil, true
+	}
+
+	b, _ := ctx.Value(traceBudgetKey{}).(*traceBudget)
+	if b == nil {
+		created := true
+		if parent := trace.SpanContextFromContext(ctx); parent.IsValid() {
+			b, created = budgetForTrace(parent.TraceID())
+		} else {
+			b = &traceBudget{}
+		}
+		if created {
+			owned = b
+		}
+		ctx = context.WithValue(ctx, traceBudgetKey{}, b)
+	}
+	depth, _ := ctx.Value(spanDepthKey{}).(int)
+	depth++
+
+	if (cfg.maxTraceDepth > 0 && depth > cfg.maxTraceDepth) ||
+		(cfg.maxSpansPerTrace > 0 && b.spans.Add(1) > int64(cfg.maxSpansPerTrace)) {
+		b.elided.Add(1)
+		return ctx, nil, false
+	}
+	return context.WithValue(ctx, spanDepthKey{}, depth), owned, true
+}
+
+// report records the number of elided spans on span, the trace's first SDK
+// span, just before it ends. Safe to call on a nil traceBudget (no-op).
+func (b *traceBudget) report(span trace.Span) {
+	if b == nil || span == nil {
+		return
+	}
+	if n := b.elided.Load(); n > 0 {
+		span.AddEvent(spansElidedEventName, trace.WithAttributes(attribute.Int64(AttrSpansElided, n)))
+		span.SetAttributes(attribute.Int64(AttrSpansElided, n))
+	}
+}
+
+// traceBudgetFrom returns the trace budget carried by ctx, or nil.
+func traceBudgetFrom(ctx context.Context) *traceBudget {
+	b, _ := ctx.Value(traceBudgetKey{}).(*traceBudget)
+	return b
+}
+
+// admitContent charges n bytes of captured content against the trace's
+// content budget and reports whether the content may be recorded as text.
+// The first call to exceed the budget records a
+// triage.content_budget_exceeded event on span; every later call is refused.
+// Safe to call on a nil traceBudget (always admits).
+func (b *traceBudget) admitContent(n int, span trace.Span) bool {
+	cfg := globalCfg
+	if b == nil || cfg == nil || cfg.maxContentBytes <= 0 {
+		return true
+	}
+	if b.contentCutoff.Load() {
+		return false
+	}
+	if b.contentBytes.Add(int64(n)) <= int64(cfg.maxContentBytes) {
+		return true
+	}
+	if b.contentCutoff.CompareAndSwap(false, true) {
+		span.AddEvent(contentBudgetEventName, trace.WithAttributes(
+			attribute.Int(AttrContentBudgetBytes, cfg.maxContentBytes),
+		))
+	}
+	return false
+}
+
+// contentDigest returns a short SHA-256 digest of s ("sha256:<16 hex>") that
+// stands in for content that must not be recorded, while still allowing
+// equal values to be matched.
+func contentDigest(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// messagesContentBytes returns the size of the content captured for msgs:
+// text, reasoning and tool call arguments.
+func messagesContentBytes(msgs []Message) int {
+	n := 0
+	for _, m := range msgs {
+		n += len(m.Content) + len(m.Reasoning)
+		for _, tc := range m.ToolCalls {
+			n += len(tc.Function.Arguments)
+		}
+	}
+	return n
+}
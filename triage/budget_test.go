@@ -0,0 +1,173 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestMaxSpansPerTrace_ElidesAndSummarizes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{maxSpansPerTrace: 3}
+
+	wf, ctx := StartWorkflow(context.Background(), "agent-loop")
+	for i := 0; i < 5; i++ {
+		tool, _ := StartTool(ctx, "search")
+		tool.End()
+	}
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	wf.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans (workflow + 2 tools), got %d", len(spans))
+	}
+	root := spans[len(spans)-1]
+	if root.Name != "agent-loop" {
+		t.Fatalf("expected the workflow span last, got %q", root.Name)
+	}
+	if got := attrMap(root.Attributes)[AttrSpansElided]; got != int64(4) {
+		t.Errorf("elided count: got %v, want 4", got)
+	}
+	if len(root.Events) != 1 || root.Events[0].Name != spansElidedEventName {
+		t.Errorf("expected a %s event, got %+v", spansElidedEventName, root.Events)
+	}
+}
+
+func TestMaxTraceDepth_ElidesDeepSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{maxTraceDepth: 2}
+
+	wf, ctx := StartWorkflow(context.Background(), "recursive")
+	agent, agentCtx := StartAgent(ctx, "level-2")
+	inner, innerCtx := StartAgent(agentCtx, "level-3")
+	llmSpan, _ := LogPrompt(innerCtx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	inner.End()
+	agent.End()
+
+	sibling, _ := StartTool(ctx, "level-2-tool")
+	sibling.End()
+	wf.End()
+
+	spans := exporter.GetSpans()
+	names := make([]string, len(spans))
+	for i, s := range spans {
+		names[i] = s.Name
+	}
+	if len(spans) != 3 {
+		t.Fatalf("expected workflow, agent and sibling tool, got %v", names)
+	}
+	if got := attrMap(spans[2].Attributes)[AttrSpansElided]; got != int64(2) {
+		t.Errorf("elided count: got %v, want 2", got)
+	}
+}
+
+func TestTraceLimits_UnlimitedByDefault(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "op")
+	for i := 0; i < 10; i++ {
+		task, _ := StartTask(ctx, "step")
+		task.End()
+	}
+	wf.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 11 {
+		t.Errorf("expected 11 spans, got %d", len(spans))
+	}
+	if _, ok := attrMap(spans[10].Attributes)[AttrSpansElided]; ok {
+		t.Error("no elision attribute expected without limits")
+	}
+}
+
+func TestTraceLimits_NegativeIsInvalid(t *testing.T) {
+	if _, err := resolveConfig(WithAPIKey("k"), WithMaxTraceDepth(-1)); err == nil {
+		t.Error("expected an error for a negative depth limit")
+	}
+}
+
+func TestMaxContentBytesPerTrace_HashesAfterBudget(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, maxContentBytes: 20}
+
+	wf, ctx := StartWorkflow(context.Background(), "chat")
+	for _, text := range []string{"first question", "second question", "third"} {
+		llmSpan, _ := LogPrompt(ctx, Prompt{
+			Vendor:   "openai",
+			Model:    "gpt-4o",
+			Messages: []Message{{Role: "user", Content: text}},
+		})
+		llmSpan.LogCompletion(Completion{}, Usage{})
+	}
+	wf.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 3 LLM spans and the workflow, got %d", len(spans))
+	}
+	first := attrMap(spans[0].Attributes)
+	if first["gen_ai.prompt.0.content"] != "first question" || first[AttrContentHashed] != nil {
+		t.Errorf("first call should be recorded as text, got %v", first)
+	}
+	for i, s := range spans[1:3] {
+		attrs := attrMap(s.Attributes)
+		if attrs["gen_ai.prompt.0.content"] != contentDigest([]string{"second question", "third"}[i]) {
+			t.Errorf("span %d: expected a digest, got %v", i+1, attrs["gen_ai.prompt.0.content"])
+		}
+		if attrs[AttrContentHashed] != true {
+			t.Errorf("span %d: expected %s", i+1, AttrContentHashed)
+		}
+	}
+	if len(spans[1].Events) != 1 || spans[1].Events[0].Name != contentBudgetEventName {
+		t.Errorf("expected a %s event on the span that crossed the budget, got %+v", contentBudgetEventName, spans[1].Events)
+	}
+	if len(spans[2].Events) != 0 {
+		t.Errorf("the cutoff event should be recorded once, got %+v", spans[2].Events)
+	}
+}
+
+func TestMaxContentBytesPerTrace_PerTrace(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, maxContentBytes: 10}
+
+	for range 2 {
+		wf, ctx := StartWorkflow(context.Background(), "chat")
+		llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Messages: []Message{{Role: "user", Content: "hello"}}})
+		llmSpan.LogCompletion(Completion{}, Usage{})
+		wf.End()
+	}
+
+	for _, s := range exporter.GetSpans() {
+		if v, ok := attrMap(s.Attributes)["gen_ai.prompt.0.content"]; ok && v != "hello" {
+			t.Errorf("each trace has its own budget, got %v", v)
+		}
+	}
+}
+
+func TestMaxSpansPerTrace_SharedAcrossSiblingContexts(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{maxSpansPerTrace: 2}
+
+	// LLM calls made directly under the application's own root span, each
+	// from the root's context, belong to one trace and share its budget.
+	ctx, root := otel.Tracer("app").Start(context.Background(), "request")
+	for range 3 {
+		llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(Completion{}, Usage{})
+	}
+	root.End()
+
+	if spans := exporter.GetSpans(); len(spans) != 3 {
+		t.Fatalf("expected 2 LLM spans and the root, got %d", len(spans))
+	}
+	traceBudgets.Lock()
+	_, tracked := traceBudgets.byTrace[root.SpanContext().TraceID()]
+	traceBudgets.Unlock()
+	if tracked {
+		t.Error("the trace budget should be released when the root span ends")
+	}
+}
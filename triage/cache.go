@@ -0,0 +1,61 @@
+package triage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// CacheBreakpoint marks the end of a provider prompt-cache block, such as an
+// Anthropic cache_control marker. The block spans the messages after the
+// previous breakpoint up to and including MessageIndex.
+type CacheBreakpoint struct {
+	MessageIndex int
+	TTL          time.Duration // cache lifetime requested for the block; 0 if unspecified
+}
+
+// cacheAttributes records the prompt's cache key and breakpoint structure.
+// Each block gets a content hash so a cache-miss regression after a prompt
+// edit can be traced to the block that changed, even when trace content is
+// disabled.
+func cacheAttributes(prompt Prompt) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if prompt.CacheKey != "" {
+		attrs = append(attrs, attribute.String(AttrPromptCacheKey, prompt.CacheKey))
+	}
+	if len(prompt.CacheBreakpoints) == 0 {
+		return attrs
+	}
+	attrs = append(attrs, attribute.Int(AttrPromptCacheBreakpoints, len(prompt.CacheBreakpoints)))
+
+	from := 0
+	for i, bp := range prompt.CacheBreakpoints {
+		prefix := fmt.Sprintf("%s.%d", AttrPromptCacheBreakpointPrefix, i)
+		attrs = append(attrs, attribute.Int(prefix+".message_index", bp.MessageIndex))
+		if bp.TTL > 0 {
+			attrs = append(attrs, attribute.Int64(prefix+".ttl_seconds", int64(bp.TTL/time.Second)))
+		}
+		if bp.MessageIndex >= from && bp.MessageIndex < len(prompt.Messages) {
+			attrs = append(attrs, attribute.String(prefix+".hash", hashMessages(prompt.Messages[from:bp.MessageIndex+1])))
+			from = bp.MessageIndex + 1
+		}
+	}
+	return attrs
+}
+
+// hashMessages returns a short, stable hex digest of the messages' roles,
+// content, and tool calls.
+func hashMessages(msgs []Message) string {
+	h := sha256.New()
+	for _, m := range msgs {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00", m.Role, m.Content, m.ToolCallID)
+		for _, tc := range m.ToolCalls {
+			fmt.Fprintf(h, "%s\x00%s\x00%s\x00", tc.ID, tc.Function.Name, tc.Function.Arguments)
+		}
+		h.Write([]byte{0x1e})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,72 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func cachedPrompt(system string) Prompt {
+	return Prompt{
+		Vendor: "anthropic",
+		Model:  "claude-sonnet-4-5",
+		Messages: []Message{
+			{Role: "system", Content: system},
+			{Role: "user", Content: "reference document ..."},
+			{Role: "user", Content: "question"},
+		},
+		CacheKey: "tenant-42",
+		CacheBreakpoints: []CacheBreakpoint{
+			{MessageIndex: 0, TTL: time.Hour},
+			{MessageIndex: 1},
+		},
+	}
+}
+
+func TestLogPrompt_RecordsCacheStructure(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), cachedPrompt("You are helpful."))
+	llmSpan.LogCompletion(Completion{}, Usage{CacheReadTokens: 1200, CacheWriteTokens: 300})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrPromptCacheKey] != "tenant-42" {
+		t.Errorf("cache key: got %v", attrs[AttrPromptCacheKey])
+	}
+	if attrs[AttrPromptCacheBreakpoints] != int64(2) {
+		t.Errorf("breakpoints: got %v, want 2", attrs[AttrPromptCacheBreakpoints])
+	}
+	if attrs[AttrPromptCacheBreakpointPrefix+".0.ttl_seconds"] != int64(3600) {
+		t.Errorf("ttl: got %v, want 3600", attrs[AttrPromptCacheBreakpointPrefix+".0.ttl_seconds"])
+	}
+	if _, ok := attrs[AttrPromptCacheBreakpointPrefix+".1.ttl_seconds"]; ok {
+		t.Error("ttl should be omitted when unspecified")
+	}
+	if attrs[AttrGenAIUsageCacheReadTokens] != int64(1200) || attrs[AttrGenAIUsageCacheWriteTokens] != int64(300) {
+		t.Errorf("cache usage: got read=%v write=%v", attrs[AttrGenAIUsageCacheReadTokens], attrs[AttrGenAIUsageCacheWriteTokens])
+	}
+}
+
+func TestCacheAttributes_HashIdentifiesChangedBlock(t *testing.T) {
+	before := attrMap(cacheAttributes(cachedPrompt("You are helpful.")))
+	after := attrMap(cacheAttributes(cachedPrompt("You are very helpful.")))
+
+	h0 := AttrPromptCacheBreakpointPrefix + ".0.hash"
+	h1 := AttrPromptCacheBreakpointPrefix + ".1.hash"
+	if before[h0] == after[h0] {
+		t.Error("expected block 0 hash to change when the system prompt changes")
+	}
+	if before[h1] != after[h1] {
+		t.Error("expected block 1 hash to be unaffected by a change in block 0")
+	}
+}
+
+func TestCacheAttributes_OutOfRangeBreakpointHasNoHash(t *testing.T) {
+	attrs := attrMap(cacheAttributes(Prompt{
+		Messages:         []Message{{Role: "user", Content: "hi"}},
+		CacheBreakpoints: []CacheBreakpoint{{MessageIndex: 5}},
+	}))
+	if _, ok := attrs[AttrPromptCacheBreakpointPrefix+".0.hash"]; ok {
+		t.Error("expected no hash for an out-of-range breakpoint")
+	}
+}
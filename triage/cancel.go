@@ -0,0 +1,97 @@
+package triage
+
+import (
+	"context"
+	"errors"
+)
+
+// FinishReasonCancelled is the finish reason recorded by LogCancelled, for a
+// response cut short because the caller gave up on it.
+const FinishReasonCancelled = "cancelled"
+
+// afterFunc schedules the cancellation watch of a stream.
+var afterFunc = context.AfterFunc // replaced in tests
+
+// LogCancelled records a response cut short because the caller's context was
+// cancelled or timed out, then ends the span. Pass the partial response and
+// usage received so far; for a stream recorded with AppendDelta, the parts
+// left empty are taken from the deltas, as with LogCompletion. The span
+// records finish reason "cancelled" and the context's error, with error.type
+// "cancelled" or "timeout".
+//
+// Streams are handled automatically: once a streamed span has recorded its
+// first chunk (RecordChunk, AppendDelta, NewStreamReader, ...), cancelling
+// the context passed to LogPrompt ends it this way, with the partial
+// response, even if the consumer stops reading. Calls after the span has
+// ended are no-ops. Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) LogCancelled(completion Completion, usage Usage) {
+	if ls == nil || ls.span == nil || ls.ended.Load() {
+		return
+	}
+	cause := context.Cause(ls.ctx)
+	if cause == nil {
+		cause = context.Canceled
+	}
+	ls.SetError(cause)
+	completion.FinishReason = FinishReasonCancelled
+	ls.LogCompletion(completion, usage)
+}
+
+// watchCancellation arranges for the span to be ended by LogCancelled if its
+// context is cancelled before it ends. RecordChunk calls it for the first
+// chunk of a stream.
+func (ls *LLMSpan) watchCancellation() {
+	if ls.ctx == nil || ls.ctx.Done() == nil {
+		return
+	}
+	stop := afterFunc(ls.ctx, ls.streamCancelled)
+	ls.stream.mu.Lock()
+	ls.stream.stopWatch = stop
+	ls.stream.mu.Unlock()
+}
+
+// streamCancelled ends a stream whose context was cancelled, with the partial
+// response reported by the stream's reader, if any.
+func (ls *LLMSpan) streamCancelled() {
+	ls.stream.mu.Lock()
+	partial := ls.stream.partial
+	ls.stream.mu.Unlock()
+
+	var (
+		completion Completion
+		usage      Usage
+	)
+	if partial != nil {
+		completion, usage = partial()
+	}
+	ls.LogCancelled(completion, usage)
+}
+
+// onStreamCancelled sets the function streamCancelled uses to collect the
+// partial response of a stream the SDK reassembles.
+func (ls *LLMSpan) onStreamCancelled(partial func() (Completion, Usage)) {
+	if ls == nil || ls.span == nil {
+		return
+	}
+	ls.stream.mu.Lock()
+	ls.stream.partial = partial
+	ls.stream.mu.Unlock()
+}
+
+// stopWatchingCancellation releases the watch set by watchCancellation.
+func (ls *LLMSpan) stopWatchingCancellation() {
+	ls.stream.mu.Lock()
+	stop := ls.stream.stopWatch
+	ls.stream.stopWatch = nil
+	ls.stream.mu.Unlock()
+	if stop != nil {
+		stop()
+	}
+}
+
+// isCancellation reports whether err, a stream read error, or the state of
+// ctx means the caller gave up on the response.
+func isCancellation(ctx context.Context, err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		(ctx != nil && ctx.Err() != nil)
+}
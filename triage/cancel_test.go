@@ -0,0 +1,295 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+// cancelledContext returns a context that is already cancelled.
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
+
+func TestCancelled_BeforeLogPrompt(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx := WithUser(cancelledContext(), "u_1")
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{Model: "gpt-4o"}, Usage{PromptTokens: 3})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if attrMap(spans[0].Attributes)[AttrUserID] != "u_1" {
+		t.Error("triage context should still be applied from a cancelled context")
+	}
+}
+
+func TestCancelled_BetweenPromptAndCompletion(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	cancel()
+	llmSpan.LogCompletion(Completion{}, Usage{CompletionTokens: 7})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if attrMap(spans[0].Attributes)["gen_ai.usage.output_tokens"] != int64(7) {
+		t.Error("completion attributes should be recorded after cancellation")
+	}
+}
+
+func TestCancelled_SetErrorAndEnd(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	func() {
+		llmSpan, ctx := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+		defer llmSpan.End()
+		llmSpan.SetError(ctx.Err()) // the provider call fails fast
+	}()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected the deferred End to export the span, got %d", len(spans))
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("status: got %v, want Error", spans[0].Status.Code)
+	}
+	if got := attrMap(spans[0].Attributes)[AttrErrorType]; got != "timeout" {
+		t.Errorf("error.type: got %v, want %q", got, "timeout")
+	}
+}
+
+func TestLLMSpan_EndAfterLogCompletionIsNoop(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	llmSpan.End()
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("expected exactly 1 span, got %d", n)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	cases := map[error]string{
+		context.Canceled:                               "cancelled",
+		context.DeadlineExceeded:                       "timeout",
+		errors.Join(errors.New("x"), context.Canceled): "cancelled",
+		errors.New("rate limited"):                     "*errors.errorString",
+	}
+	for err, want := range cases {
+		if got := errorType(err); got != want {
+			t.Errorf("errorType(%v): got %q, want %q", err, got, want)
+		}
+	}
+}
+
+func TestCancelled_WorkflowHierarchy(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(cancelledContext(), "pipeline")
+	tool, _ := StartTool(ctx, "search")
+	tool.End()
+	wf.End()
+
+	if n := len(exporter.GetSpans()); n != 2 {
+		t.Errorf("expected 2 spans, got %d", n)
+	}
+}
+
+func TestCancelled_ShutdownStillFlushes(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	ctx := cancelledContext()
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	fs, err := ShutdownWithStats(ctx)
+	if err != nil {
+		t.Fatalf("ShutdownWithStats with a cancelled context failed: %v", err)
+	}
+	if fs.SpansFlushed != 1 {
+		t.Errorf("got %+v, want 1 flushed", fs)
+	}
+}
+
+// trackCancellations makes the cancellation watches started during the test
+// joinable: the returned WaitGroup is done once every watch has either been
+// stopped or finished ending its span.
+func trackCancellations(t *testing.T) *sync.WaitGroup {
+	t.Helper()
+	wg := &sync.WaitGroup{}
+	prev := afterFunc
+	afterFunc = func(ctx context.Context, f func()) func() bool {
+		wg.Add(1)
+		stop := context.AfterFunc(ctx, func() {
+			defer wg.Done()
+			f()
+		})
+		return func() bool {
+			if stop() {
+				wg.Done()
+				return true
+			}
+			return false
+		}
+	}
+	t.Cleanup(func() {
+		wg.Wait()
+		afterFunc = prev
+	})
+	return wg
+}
+
+func TestLogCancelled_RecordsPartialResponse(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	cancel()
+	llmSpan.LogCancelled(Completion{Messages: []Message{{Role: "assistant", Content: "Partial"}}}, Usage{CompletionTokens: 2})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	for k, want := range map[string]any{
+		AttrGenAIResponseFinishReason: "cancelled",
+		AttrErrorType:                 "cancelled",
+		"gen_ai.completion.0.content": "Partial",
+		"gen_ai.usage.output_tokens":  int64(2),
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], want)
+		}
+	}
+	if spans[0].Status.Code != codes.Error {
+		t.Errorf("status: got %v", spans[0].Status.Code)
+	}
+}
+
+func TestLogCancelled_Timeout(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCancelled(Completion{}, Usage{})
+
+	if got := attrMap(exporter.GetSpans()[0].Attributes)[AttrErrorType]; got != "timeout" {
+		t.Errorf("error.type: got %v", got)
+	}
+}
+
+func TestCancelled_MidStreamEndsSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	watches := trackCancellations(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.AppendDelta(Delta{Content: "Once upon"})
+	llmSpan.AppendDelta(Delta{Content: " a time"})
+	cancel()
+	watches.Wait()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatal("a cancelled stream should end its span without LogCompletion or End")
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAIResponseFinishReason] != "cancelled" || attrs["gen_ai.completion.0.content"] != "Once upon a time" {
+		t.Errorf("finish/content: got %v/%v", attrs[AttrGenAIResponseFinishReason], attrs["gen_ai.completion.0.content"])
+	}
+
+	llmSpan.End() // no-op
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("expected 1 span, got %d", n)
+	}
+}
+
+func TestCancelled_AfterStreamCompletesIsIgnored(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	watches := trackCancellations(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.AppendDelta(Delta{Content: "Done", FinishReason: "stop"})
+	llmSpan.End()
+	cancel()
+	watches.Wait()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || attrMap(spans[0].Attributes)[AttrGenAIResponseFinishReason] != "stop" {
+		t.Errorf("a completed stream should keep its finish reason, got %+v", spans)
+	}
+}
+
+func TestCancelled_StreamReaderAbandoned(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	watches := trackCancellations(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	r := NewStreamReader(llmSpan, io.NopCloser(strings.NewReader(openAISSE)), StreamOpenAIChat)
+	defer r.Close()
+	first := strings.Index(openAISSE, "\n\n") + 2
+	if _, err := io.ReadFull(r, make([]byte, first)); err != nil {
+		t.Fatal(err)
+	}
+	cancel() // the consumer gives up without reading further
+	watches.Wait()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatal("expected the abandoned stream's span to end on cancellation")
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAIResponseFinishReason] != "cancelled" || attrs["gen_ai.completion.0.content"] != "Hel" {
+		t.Errorf("finish/content: got %v/%v", attrs[AttrGenAIResponseFinishReason], attrs["gen_ai.completion.0.content"])
+	}
+}
+
+func TestCancelled_NoChunkLeavesSpanOpen(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	watches := trackCancellations(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	cancel()
+	watches.Wait()
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Fatalf("a call that hasn't streamed should be left to the caller, got %d spans", n)
+	}
+	llmSpan.End()
+}
@@ -0,0 +1,187 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultClientTimeout bounds backend API calls when no HTTP client is
+// supplied via WithHTTPClient.
+const defaultClientTimeout = 30 * time.Second
+
+// Client calls the Triage backend's REST API for operations that fall outside
+// the OTLP export pipeline, such as annotating traces after they have been
+// exported. It is safe for concurrent use.
+type Client struct {
+	apiKey     string
+	endpoint   string
+	httpClient *http.Client
+	logger     *slog.Logger // for background work on the client's behalf
+}
+
+// APIError is returned by Client methods when the backend responds with a
+// non-2xx status code.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("triage: backend returned %d: %s", e.StatusCode, e.Body)
+}
+
+// NewClient creates a backend API client. It accepts the same options as
+// Init and resolves the API key and endpoint with the same precedence
+// (explicit option > environment variable > default):
+//
+//	client, err := triage.NewClient(triage.WithAPIKey("tsk_..."))
+func NewClient(opts ...Option) (*Client, error) {
+	cfg, err := resolveConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newClientFromConfig(cfg), nil
+}
+
+// newClientFromConfig builds a Client from an already-resolved config.
+func newClientFromConfig(cfg *config) *Client {
+	hc := cfg.httpClient
+	if hc == nil {
+		hc = &http.Client{Timeout: defaultClientTimeout}
+	}
+	return &Client{
+		apiKey:     cfg.apiKey,
+		endpoint:   strings.TrimRight(cfg.endpoint, "/"),
+		httpClient: hc,
+		logger:     cfg.log(),
+	}
+}
+
+// annotationRequest is the wire format for POST /v1/annotations.
+type annotationRequest struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id,omitempty"`
+	Attributes map[string]any `json:"attributes"`
+}
+
+// Annotate attaches attributes to an already-exported trace or span — e.g. a
+// human reviewer marking a conversation as a confirmed injection attempt
+// hours later. traceID and spanID are hex strings; an empty spanID annotates
+// the trace as a whole.
+//
+//	err := client.Annotate(ctx, traceID, "", map[string]any{
+//	    "triage.review.verdict": "confirmed_injection",
+//	})
+func (c *Client) Annotate(ctx context.Context, traceID, spanID string, attrs map[string]any) error {
+	if traceID == "" {
+		return errors.New("triage: Annotate requires a trace ID")
+	}
+	if len(attrs) == 0 {
+		return errors.New("triage: Annotate requires at least one attribute")
+	}
+	return c.do(ctx, http.MethodPost, annotationsPath, annotationRequest{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		Attributes: attrs,
+	}, nil)
+}
+
+// AnnotationSelector chooses the traces labeled by AnnotateBulk. At least one
+// of SessionID or UserID must be set; TimeRange further narrows the match by
+// root span start time.
+type AnnotationSelector struct {
+	SessionID string
+	UserID    string
+	TimeRange TimeRange
+}
+
+// bulkAnnotationRequest is the wire format for POST /v1/annotations/bulk.
+type bulkAnnotationRequest struct {
+	SessionID string     `json:"session_id,omitempty"`
+	UserID    string     `json:"user_id,omitempty"`
+	Start     *time.Time `json:"start,omitempty"`
+	End       *time.Time `json:"end,omitempty"`
+	Labels    []string   `json:"labels"`
+}
+
+// AnnotateBulk applies labels to every trace matching sel and returns the
+// number of traces labeled — e.g. marking a whole session as a confirmed
+// incident during post-incident cleanup:
+//
+//	n, err := client.AnnotateBulk(ctx, triage.AnnotationSelector{
+//	    UserID:    "user_123",
+//	    TimeRange: triage.TimeRange{Start: incidentStart, End: incidentEnd},
+//	}, "confirmed_incident")
+func (c *Client) AnnotateBulk(ctx context.Context, sel AnnotationSelector, labels ...string) (int, error) {
+	if sel.SessionID == "" && sel.UserID == "" {
+		return 0, errors.New("triage: AnnotateBulk requires a session ID or user ID")
+	}
+	if len(labels) == 0 {
+		return 0, errors.New("triage: AnnotateBulk requires at least one label")
+	}
+	req := bulkAnnotationRequest{SessionID: sel.SessionID, UserID: sel.UserID, Labels: labels}
+	if !sel.TimeRange.Start.IsZero() {
+		start := sel.TimeRange.Start.UTC()
+		req.Start = &start
+	}
+	if !sel.TimeRange.End.IsZero() {
+		end := sel.TimeRange.End.UTC()
+		req.End = &end
+	}
+	var res struct {
+		Annotated int `json:"annotated"`
+	}
+	if err := c.do(ctx, http.MethodPost, bulkAnnotationsPath, req, &res); err != nil {
+		return 0, err
+	}
+	return res.Annotated, nil
+}
+
+// do sends a JSON request to the backend and decodes a JSON response into out
+// (if non-nil).
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("triage: failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.endpoint+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("triage: failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("User-Agent", sdkName+"/"+Version)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("triage: request to %s failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(msg))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("triage: failed to decode response from %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestBackend starts an httptest server that records the last request
+// body and replies with the given status, and returns a Client pointed at it.
+func newTestBackend(t *testing.T, status int, reply any) (*Client, *http.Request, *map[string]any) {
+	t.Helper()
+	var lastReq http.Request
+	var lastBody map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastReq = *r
+		lastBody = nil
+		_ = json.NewDecoder(r.Body).Decode(&lastBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if reply != nil {
+			_ = json.NewEncoder(w).Encode(reply)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := NewClient(WithAPIKey("tsk_test"), WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &lastReq, &lastBody
+}
+
+// ---------------------------------------------------------------------------
+// Construction
+// ---------------------------------------------------------------------------
+
+func TestNewClient_MissingApiKeyReturnsError(t *testing.T) {
+	if _, err := NewClient(); err == nil {
+		t.Fatal("expected error for missing API key, got nil")
+	}
+}
+
+func TestNewClient_EnvFallback(t *testing.T) {
+	t.Setenv(EnvAPIKey, "tsk_env")
+	t.Setenv(EnvEndpoint, "https://env.io/")
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.apiKey != "tsk_env" {
+		t.Errorf("api key: got %q, want %q", client.apiKey, "tsk_env")
+	}
+	if client.endpoint != "https://env.io" {
+		t.Errorf("endpoint: got %q, want %q", client.endpoint, "https://env.io")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Annotate
+// ---------------------------------------------------------------------------
+
+func TestAnnotate_SendsRequest(t *testing.T) {
+	client, req, body := newTestBackend(t, http.StatusAccepted, nil)
+
+	err := client.Annotate(context.Background(), "trace123", "span456", map[string]any{
+		"triage.review.verdict": "confirmed_injection",
+	})
+	if err != nil {
+		t.Fatalf("Annotate failed: %v", err)
+	}
+
+	if req.Method != http.MethodPost || req.URL.Path != annotationsPath {
+		t.Errorf("request: got %s %s, want POST %s", req.Method, req.URL.Path, annotationsPath)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer tsk_test" {
+		t.Errorf("authorization: got %q", got)
+	}
+	if (*body)["trace_id"] != "trace123" || (*body)["span_id"] != "span456" {
+		t.Errorf("body ids: got %v", *body)
+	}
+	attrs, _ := (*body)["attributes"].(map[string]any)
+	if attrs["triage.review.verdict"] != "confirmed_injection" {
+		t.Errorf("attributes: got %v", attrs)
+	}
+}
+
+func TestAnnotate_NonSuccessReturnsAPIError(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusNotFound, map[string]string{"detail": "trace not found"})
+
+	err := client.Annotate(context.Background(), "trace123", "", map[string]any{"k": "v"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("status: got %d, want %d", apiErr.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestAnnotate_ValidatesArguments(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusOK, nil)
+
+	if err := client.Annotate(context.Background(), "", "", map[string]any{"k": "v"}); err == nil {
+		t.Error("expected error for empty trace ID")
+	}
+	if err := client.Annotate(context.Background(), "trace123", "", nil); err == nil {
+		t.Error("expected error for empty attributes")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// AnnotateBulk
+// ---------------------------------------------------------------------------
+
+func TestAnnotateBulk_SendsRequest(t *testing.T) {
+	client, req, body := newTestBackend(t, http.StatusOK, map[string]int{"annotated": 7})
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	n, err := client.AnnotateBulk(context.Background(), AnnotationSelector{
+		SessionID: "sess_1",
+		TimeRange: TimeRange{Start: start},
+	}, "confirmed_incident", "escalated")
+	if err != nil {
+		t.Fatalf("AnnotateBulk failed: %v", err)
+	}
+	if n != 7 {
+		t.Errorf("annotated: got %d, want 7", n)
+	}
+
+	if req.Method != http.MethodPost || req.URL.Path != bulkAnnotationsPath {
+		t.Errorf("request: got %s %s, want POST %s", req.Method, req.URL.Path, bulkAnnotationsPath)
+	}
+	if (*body)["session_id"] != "sess_1" || (*body)["start"] != "2025-03-01T00:00:00Z" {
+		t.Errorf("body: got %v", *body)
+	}
+	if _, ok := (*body)["user_id"]; ok {
+		t.Errorf("unset user_id should be omitted, got %v", *body)
+	}
+	if _, ok := (*body)["end"]; ok {
+		t.Errorf("unset end should be omitted, got %v", *body)
+	}
+	labels, _ := (*body)["labels"].([]any)
+	if len(labels) != 2 || labels[0] != "confirmed_incident" || labels[1] != "escalated" {
+		t.Errorf("labels: got %v", labels)
+	}
+}
+
+func TestAnnotateBulk_ValidatesArguments(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusOK, nil)
+
+	if _, err := client.AnnotateBulk(context.Background(), AnnotationSelector{}, "false_positive"); err == nil {
+		t.Error("expected error without a session or user")
+	}
+	if _, err := client.AnnotateBulk(context.Background(), AnnotationSelector{UserID: "user_123"}); err == nil {
+		t.Error("expected error without labels")
+	}
+}
+
+func TestAnnotateBulk_NonSuccessReturnsAPIError(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusForbidden, map[string]string{"detail": "forbidden"})
+
+	_, err := client.AnnotateBulk(context.Background(), AnnotationSelector{UserID: "user_123"}, "false_positive")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 *APIError, got %v", err)
+	}
+}
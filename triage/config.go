@@ -1,10 +1,18 @@
 package triage
 
 import (
+	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
 )
 
 // config holds resolved SDK configuration. Fields are unexported to enforce
@@ -16,6 +24,62 @@ type config struct {
 	environment  string
 	enabled      bool
 	traceContent bool
+
+	profilerLabels bool
+	chunkEvents    bool
+	outputScanning bool
+	serving        Serving
+
+	truncationHandler TruncationHandler
+	riskThreshold     float64
+	riskHandler       RiskHandler
+	responseHeaders   []string // nil means defaultResponseHeaders
+	errorHandler      func(error)
+	errorScrubber     func(string) string
+	disabledKinds     map[string]bool
+	maxSpansPerTrace  int
+	maxTraceDepth     int
+	maxContentBytes   int    // per-trace budget for captured content; 0 is unlimited
+	attrNamespace     string // "" keeps the triage.* namespace
+	genkit            bool   // map Genkit spans at export
+
+	httpClient        *http.Client
+	dataset           *datasetCapture
+	sessionTracking   bool
+	contextBaggage    bool        // mirror user/tenant/session into W3C Baggage
+	transcriptWebhook string      // "" disables transcript delivery
+	transcriptHeader  http.Header // sent with each transcript delivery
+
+	profiles map[string][]Option
+
+	tenantResolver TenantKeyResolver
+
+	shutdownTimeout time.Duration
+	exportTimeout   time.Duration // 0 uses the OTLP exporter default (10s)
+
+	propagator propagation.TextMapPropagator // nil leaves the global propagator untouched
+
+	logger *slog.Logger // nil uses slog.Default()
+
+	sampleRatio  float64 // fraction of new traces sampled; parent decisions are honored
+	maxQueueSize int     // 0 uses the batch processor default (2048)
+
+	queueHighWater float64 // fraction of maxQueueSize; 0 disables the pressure signal
+	queueHandler   QueuePressureHandler
+
+	traceURLTemplate string        // "{trace_id}" is replaced; "" disables trace links
+	batchTimeout     time.Duration // 0 uses the batch processor default (5s)
+
+	pricing     []ModelPrice // overrides searched before filePricing
+	pricingFile string
+	filePricing []ModelPrice // loaded from pricingFile, searched before defaultPricing
+
+	models []ModelCapabilities // overrides searched before defaultModels
+
+	otlpPath      string // path appended to the endpoint for trace export
+	metrics       bool   // export metrics alongside traces
+	collectorMode bool   // export without credentials to an OpenTelemetry Collector
+	insecure      bool   // plaintext HTTP export
 }
 
 // Option configures the Triage SDK. Pass options to Init().
@@ -51,15 +115,205 @@ func WithTraceContent(b bool) Option {
 	return func(c *config) { c.traceContent = b }
 }
 
-// resolveConfig merges explicit options > env vars > defaults and returns a
-// validated config. Returns an error if the API key is missing.
+// WithProfilerLabels controls whether workflow spans attach pprof labels
+// (workflow name, session ID) to the calling goroutine for their duration, so
+// CPU profiles can be sliced by pipeline and conversation. Off by default.
+func WithProfilerLabels(b bool) Option {
+	return func(c *config) { c.profilerLabels = b }
+}
+
+// WithStreamChunkEvents records a span event for every chunk reported via
+// LLMSpan.RecordChunk, with its arrival offset from the start of the call.
+// Off by default; the compact chunk-gap histogram is always recorded. Spans
+// keep at most 128 events, so very long streams are truncated.
+func WithStreamChunkEvents(b bool) Option {
+	return func(c *config) { c.chunkEvents = b }
+}
+
+// WithDisabledSpanKinds suppresses spans of the given kinds (SpanKindTask,
+// SpanKindAgent, SpanKindTool, SpanKindWorkflow) to control volume in chatty
+// agent loops. Start calls for a disabled kind return a no-op handle and the
+// unchanged context, so LLM spans attach to the nearest enabled ancestor. LLM
+// spans are always recorded. Replaces any kinds set via
+// TRIAGE_DISABLED_SPAN_KINDS.
+func WithDisabledSpanKinds(kinds ...string) Option {
+	return func(c *config) {
+		c.disabledKinds = make(map[string]bool, len(kinds))
+		for _, k := range kinds {
+			if k = strings.ToLower(strings.TrimSpace(k)); k != "" {
+				c.disabledKinds[k] = true
+			}
+		}
+	}
+}
+
+// WithHTTPClient sets the HTTP client used by the backend API Client (see
+// NewClient). Defaults to a client with a 30-second timeout.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *config) { c.httpClient = hc }
+}
+
+// WithDatasetCapture mirrors prompts/completions of sampleRate (0..1) of all
+// LLM calls into the named backend dataset, with PII redacted, so production
+// traffic can seed eval datasets. Per-workflow CaptureDataset settings take
+// precedence. Nothing is captured when trace content is disabled.
+func WithDatasetCapture(dataset string, sampleRate float64) Option {
+	return func(c *config) { c.dataset = &datasetCapture{name: dataset, sampleRate: sampleRate} }
+}
+
+// WithSessionTracking enables the in-memory SessionManager (see Sessions),
+// which records each session's prompts and completions so transcripts can be
+// assembled and exported. Off by default.
+func WithSessionTracking(b bool) Option {
+	return func(c *config) { c.sessionTracking = b }
+}
+
+// WithShutdownTimeout bounds how long the shutdown function returned by Init
+// waits for pending spans to flush. Defaults to 5 seconds; size it to fit
+// within the pod's termination grace period.
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(c *config) { c.shutdownTimeout = d }
+}
+
+// WithExportTimeout bounds each OTLP export request (one batch of spans).
+// Raise it for high-latency links to a distant endpoint; lower it for
+// latency-sensitive edge deployments. Defaults to the OTLP exporter's 10
+// seconds.
+func WithExportTimeout(d time.Duration) Option {
+	return func(c *config) { c.exportTimeout = d }
+}
+
+// WithPropagators sets the text-map propagators Init registers globally (via
+// otel.SetTextMapPropagator) so trace context flows across service
+// boundaries. Defaults to W3C Trace Context and Baggage. Passing no
+// propagators leaves the global propagator untouched, for applications that
+// configure propagation themselves.
+func WithPropagators(ps ...propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		if len(ps) == 0 {
+			c.propagator = nil
+			return
+		}
+		c.propagator = propagation.NewCompositeTextMapPropagator(ps...)
+	}
+}
+
+// WithLogger routes the SDK's log output (initialization, warnings, export
+// errors) through l instead of the default slog logger. When set, Init also
+// installs an OpenTelemetry error handler that logs to l.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) { c.logger = l }
+}
+
+// WithSampleRatio samples ratio (0..1) of new traces. Spans with a parent
+// follow the parent's sampling decision. Defaults to 1 (sample everything).
+func WithSampleRatio(ratio float64) Option {
+	return func(c *config) { c.sampleRatio = ratio }
+}
+
+// WithMaxQueueSize sets how many ended spans may wait for export before new
+// ones are dropped. Defaults to 2048.
+func WithMaxQueueSize(n int) Option {
+	return func(c *config) { c.maxQueueSize = n }
+}
+
+// WithBatchTimeout sets the maximum delay before a partial batch of spans is
+// exported. Defaults to 5 seconds.
+func WithBatchTimeout(d time.Duration) Option {
+	return func(c *config) { c.batchTimeout = d }
+}
+
+// WithOTLPPath sets the URL path spans are exported to, appended to the
+// endpoint. Defaults to "/v1/traces".
+func WithOTLPPath(path string) Option {
+	return func(c *config) { c.otlpPath = path }
+}
+
+// WithMetrics makes Init also set up an OpenTelemetry MeterProvider that
+// exports metrics over OTLP/HTTP to the same endpoint and with the same
+// credentials as traces, and register it globally, so the SDK's metrics
+// (token usage, cost, truncations, sampling) and any other instrumentation
+// using the global meter provider reach Triage without a second telemetry
+// setup.
+// Metrics go to "/v1/metrics", next to the trace path (see WithOTLPPath).
+// They are exported every minute and flushed at shutdown. Off by default, for
+// applications that configure their own meter provider.
+func WithMetrics(b bool) Option {
+	return func(c *config) { c.metrics = b }
+}
+
+// WithCollectorMode exports spans to a generic OpenTelemetry Collector at the
+// endpoint without a bearer header; the collector is responsible for
+// authenticating and forwarding to Triage. No API key is required in this
+// mode:
+//
+//	triage.Init(
+//	    triage.WithCollectorMode(),
+//	    triage.WithEndpoint("http://otel-collector:4318"),
+//	)
+func WithCollectorMode() Option {
+	return func(c *config) { c.collectorMode = true }
+}
+
+// WithInsecure exports over plaintext HTTP instead of TLS, for local
+// collectors and mock servers during development. An endpoint given without
+// a scheme (e.g. "localhost:4318") is then treated as http://. Never use it
+// against a remote endpoint: the API key would be sent in the clear.
+func WithInsecure() Option {
+	return func(c *config) { c.insecure = true }
+}
+
+// WithProfile registers options that apply only when the resolved environment
+// (WithEnvironment or TRIAGE_ENVIRONMENT) equals name, so one artifact can
+// carry different endpoints and capture policies per environment:
+//
+//	triage.Init(
+//	    triage.WithProfile("development", triage.WithEnabled(false)),
+//	    triage.WithProfile("staging", triage.WithEndpoint("https://staging.triageai.dev")),
+//	    triage.WithProfile("production", triage.WithTraceContent(false)),
+//	)
+//
+// The matching profile's options are applied after all other options and
+// environment variables. A profile cannot change the environment itself.
+func WithProfile(name string, opts ...Option) Option {
+	return func(c *config) {
+		if c.profiles == nil {
+			c.profiles = make(map[string][]Option)
+		}
+		c.profiles[name] = append(c.profiles[name], opts...)
+	}
+}
+
+// resolveConfig merges the environment's profile > explicit options > env
+// vars > defaults and returns a validated config. Returns an error wrapping
+// ErrMissingAPIKey, ErrInvalidEndpoint or ErrInvalidConfig if the
+// configuration is unusable.
 func resolveConfig(opts ...Option) (*config, error) {
+	cfg, errs := buildConfig(opts...)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return cfg, nil
+}
+
+// buildConfig resolves the configuration like resolveConfig, but returns
+// every validation problem instead of stopping at the first.
+func buildConfig(opts ...Option) (*config, []error) {
+	var errs []error
 	cfg := &config{
 		endpoint:     DefaultEndpoint,
 		appName:      defaultAppName(),
 		environment:  "development",
 		enabled:      true,
 		traceContent: true,
+
+		shutdownTimeout: defaultShutdownTimeout,
+		sampleRatio:     1,
+		otlpPath:        defaultOTLPTracesPath,
+		propagator: propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		),
 	}
 
 	// Layer 2: env var overrides.
@@ -81,20 +335,132 @@ func resolveConfig(opts ...Option) (*config, error) {
 	if v, ok := envBool(EnvTraceContent); ok {
 		cfg.traceContent = v
 	}
+	if v := os.Getenv(EnvSampleRatio); v != "" {
+		ratio, err := strconv.ParseFloat(v, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			errs = append(errs, fmt.Errorf("%w: %s=%q must be a number between 0 and 1", ErrInvalidConfig, EnvSampleRatio, v))
+		} else {
+			cfg.sampleRatio = ratio
+		}
+	}
+	if v := os.Getenv(EnvMaxQueueSize); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			errs = append(errs, fmt.Errorf("%w: %s=%q must be a positive integer", ErrInvalidConfig, EnvMaxQueueSize, v))
+		} else {
+			cfg.maxQueueSize = n
+		}
+	}
+	if v := os.Getenv(EnvBatchTimeout); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("%w: %s=%q must be a positive duration such as \"5s\"", ErrInvalidConfig, EnvBatchTimeout, v))
+		} else {
+			cfg.batchTimeout = d
+		}
+	}
+
+	if v, ok := envBool(EnvMetrics); ok {
+		cfg.metrics = v
+	}
+	if v := os.Getenv(EnvPricingFile); v != "" {
+		cfg.pricingFile = v
+	}
+	if v := os.Getenv(EnvDisabledSpanKinds); v != "" {
+		WithDisabledSpanKinds(strings.Split(v, ",")...)(cfg)
+	}
+	if v := os.Getenv(EnvAttributeNamespace); v != "" {
+		cfg.attrNamespace = v
+	}
 
 	// Layer 3: explicit options (highest priority).
 	for _, opt := range opts {
 		opt(cfg)
 	}
 
-	if cfg.apiKey == "" {
-		return nil, fmt.Errorf(
-			"triage: API key is required. Pass triage.WithAPIKey() to Init() "+
-				"or set the %s environment variable", EnvAPIKey,
-		)
+	// Layer 4: the profile for the resolved environment.
+	if profile, ok := cfg.profiles[cfg.environment]; ok {
+		env := cfg.environment
+		for _, opt := range profile {
+			opt(cfg)
+		}
+		cfg.environment = env
 	}
+	cfg.profiles = nil
 
-	return cfg, nil
+	cfg.endpoint = normalizeEndpoint(cfg.endpoint, cfg.insecure)
+	if err := validateEndpoint(cfg.endpoint); err != nil {
+		errs = append(errs, err)
+	}
+	if !strings.HasPrefix(cfg.otlpPath, "/") {
+		cfg.otlpPath = "/" + cfg.otlpPath
+	}
+	if cfg.sampleRatio < 0 || cfg.sampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("%w: sample ratio %v must be between 0 and 1", ErrInvalidConfig, cfg.sampleRatio))
+	}
+	if cfg.queueHighWater < 0 || cfg.queueHighWater > 1 {
+		errs = append(errs, fmt.Errorf("%w: queue high-water mark %v must be between 0 and 1", ErrInvalidConfig, cfg.queueHighWater))
+	}
+	if cfg.maxSpansPerTrace < 0 || cfg.maxTraceDepth < 0 || cfg.maxContentBytes < 0 {
+		errs = append(errs, fmt.Errorf("%w: trace span, depth and content limits must not be negative", ErrInvalidConfig))
+	}
+	if cfg.transcriptWebhook != "" {
+		if u, err := url.Parse(cfg.transcriptWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%w: transcript webhook %q must be an http(s) URL", ErrInvalidConfig, cfg.transcriptWebhook))
+		}
+	}
+	if cfg.attrNamespace != "" && !validNamespace.MatchString(cfg.attrNamespace) {
+		errs = append(errs, fmt.Errorf("%w: attribute namespace %q must be dot-separated words, e.g. \"acme.triage\"", ErrInvalidConfig, cfg.attrNamespace))
+	}
+	for kind := range cfg.disabledKinds {
+		switch kind {
+		case SpanKindWorkflow, SpanKindTask, SpanKindAgent, SpanKindTool:
+		default:
+			errs = append(errs, fmt.Errorf("%w: unknown span kind %q (want workflow, task, agent or tool)", ErrInvalidConfig, kind))
+		}
+	}
+
+	if cfg.pricingFile != "" {
+		prices, err := loadPricingFile(cfg.pricingFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrInvalidConfig, err))
+		}
+		cfg.filePricing = prices
+	}
+
+	if cfg.apiKey == "" && !cfg.collectorMode {
+		errs = append(errs, fmt.Errorf(
+			"%w: pass triage.WithAPIKey() to Init() or set the %s environment "+
+				"variable (or use WithCollectorMode)", ErrMissingAPIKey, EnvAPIKey,
+		))
+	}
+
+	return cfg, errs
+}
+
+// validateEndpoint checks that ep is an absolute http(s) URL.
+func validateEndpoint(ep string) error {
+	u, err := url.Parse(ep)
+	if err != nil {
+		return fmt.Errorf("%w %q: %w", ErrInvalidEndpoint, ep, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w %q: must be an http:// or https:// URL", ErrInvalidEndpoint, ep)
+	}
+	return nil
+}
+
+// normalizeEndpoint adds a scheme to an endpoint given as host[:port] —
+// http:// when insecure, https:// otherwise — and trims trailing slashes.
+func normalizeEndpoint(ep string, insecure bool) string {
+	ep = strings.TrimRight(ep, "/")
+	if ep == "" || strings.Contains(ep, "://") {
+		return ep
+	}
+	if insecure {
+		return "http://" + ep
+	}
+	return "https://" + ep
 }
 
 // envBool reads a boolean from an environment variable.
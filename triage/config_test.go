@@ -1,7 +1,9 @@
 package triage
 
 import (
+	"errors"
 	"testing"
+	"time"
 )
 
 // ---------------------------------------------------------------------------
@@ -170,6 +172,79 @@ func TestEnvironment_EnvFallback(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// Environment profiles
+// ---------------------------------------------------------------------------
+
+func testProfiles() []Option {
+	return []Option{
+		WithAPIKey("k"),
+		WithProfile("staging", WithEndpoint("https://staging.example")),
+		WithProfile("production", WithTraceContent(false), WithEndpoint("https://prod.example")),
+	}
+}
+
+func TestProfile_SelectedByEnvVar(t *testing.T) {
+	t.Setenv(EnvEnvironment, "production")
+	cfg, err := resolveConfig(testProfiles()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.endpoint != "https://prod.example" || cfg.traceContent {
+		t.Errorf("production profile not applied: endpoint=%q traceContent=%v", cfg.endpoint, cfg.traceContent)
+	}
+}
+
+func TestProfile_OtherProfilesIgnored(t *testing.T) {
+	t.Setenv(EnvEnvironment, "staging")
+	cfg, err := resolveConfig(testProfiles()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.endpoint != "https://staging.example" || !cfg.traceContent {
+		t.Errorf("expected only staging profile: endpoint=%q traceContent=%v", cfg.endpoint, cfg.traceContent)
+	}
+}
+
+func TestProfile_NoMatchUsesBase(t *testing.T) {
+	cfg, err := resolveConfig(testProfiles()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.endpoint != DefaultEndpoint {
+		t.Errorf("got %q, want %q", cfg.endpoint, DefaultEndpoint)
+	}
+}
+
+func TestProfile_OverridesBaseOptions(t *testing.T) {
+	cfg, err := resolveConfig(
+		WithAPIKey("k"),
+		WithEnvironment("production"),
+		WithTraceContent(true),
+		WithProfile("production", WithTraceContent(false)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.traceContent {
+		t.Error("expected profile to override base option")
+	}
+}
+
+func TestProfile_CannotChangeEnvironment(t *testing.T) {
+	cfg, err := resolveConfig(
+		WithAPIKey("k"),
+		WithEnvironment("production"),
+		WithProfile("production", WithEnvironment("staging")),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.environment != "production" {
+		t.Errorf("got %q, want %q", cfg.environment, "production")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Boolean fields (enabled, traceContent)
 // ---------------------------------------------------------------------------
@@ -253,3 +328,192 @@ func TestTraceContent_DefaultIsTrue(t *testing.T) {
 		t.Error("expected traceContent to default to true")
 	}
 }
+
+func TestProfilerLabels_DefaultIsFalse(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.profilerLabels {
+		t.Error("expected profilerLabels to default to false")
+	}
+}
+
+func TestProfilerLabels_ExplicitArg(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"), WithProfilerLabels(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.profilerLabels {
+		t.Error("expected profilerLabels to be true")
+	}
+}
+
+func TestShutdownTimeout_Default(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.shutdownTimeout != defaultShutdownTimeout {
+		t.Errorf("got %v, want %v", cfg.shutdownTimeout, defaultShutdownTimeout)
+	}
+}
+
+func TestShutdownTimeout_ExplicitArg(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"), WithShutdownTimeout(20*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.shutdownTimeout != 20*time.Second {
+		t.Errorf("got %v, want %v", cfg.shutdownTimeout, 20*time.Second)
+	}
+}
+
+func TestExportTimeout_ExplicitArg(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"), WithExportTimeout(45*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.exportTimeout != 45*time.Second {
+		t.Errorf("got %v, want %v", cfg.exportTimeout, 45*time.Second)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Sampling and queue tuning
+// ---------------------------------------------------------------------------
+
+func TestSampleRatio_DefaultIsOne(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.sampleRatio != 1 {
+		t.Errorf("got %v, want 1", cfg.sampleRatio)
+	}
+}
+
+func TestTuning_EnvFallback(t *testing.T) {
+	t.Setenv(EnvSampleRatio, "0.25")
+	t.Setenv(EnvMaxQueueSize, "8192")
+	t.Setenv(EnvBatchTimeout, "2s")
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.sampleRatio != 0.25 {
+		t.Errorf("sampleRatio: got %v, want 0.25", cfg.sampleRatio)
+	}
+	if cfg.maxQueueSize != 8192 {
+		t.Errorf("maxQueueSize: got %d, want 8192", cfg.maxQueueSize)
+	}
+	if cfg.batchTimeout != 2*time.Second {
+		t.Errorf("batchTimeout: got %v, want 2s", cfg.batchTimeout)
+	}
+}
+
+func TestMetrics_EnvFallback(t *testing.T) {
+	t.Setenv(EnvMetrics, "true")
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.metrics {
+		t.Error("TRIAGE_METRICS=true should enable metrics")
+	}
+	cfg, err = resolveConfig(WithAPIKey("k"), WithMetrics(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.metrics {
+		t.Error("WithMetrics(false) should override TRIAGE_METRICS")
+	}
+}
+
+func TestTuning_ExplicitOverridesEnv(t *testing.T) {
+	t.Setenv(EnvSampleRatio, "0.25")
+	t.Setenv(EnvMaxQueueSize, "8192")
+	t.Setenv(EnvBatchTimeout, "2s")
+	cfg, err := resolveConfig(WithAPIKey("k"),
+		WithSampleRatio(0.5), WithMaxQueueSize(100), WithBatchTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.sampleRatio != 0.5 || cfg.maxQueueSize != 100 || cfg.batchTimeout != time.Second {
+		t.Errorf("explicit options should win: got ratio=%v queue=%d timeout=%v",
+			cfg.sampleRatio, cfg.maxQueueSize, cfg.batchTimeout)
+	}
+}
+
+func TestTuning_InvalidEnvReturnsError(t *testing.T) {
+	cases := map[string]string{
+		EnvSampleRatio:  "1.5",
+		EnvMaxQueueSize: "lots",
+		EnvBatchTimeout: "5",
+	}
+	for key, val := range cases {
+		t.Run(key, func(t *testing.T) {
+			t.Setenv(key, val)
+			if _, err := resolveConfig(WithAPIKey("k")); err == nil {
+				t.Errorf("expected error for %s=%q", key, val)
+			}
+		})
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Insecure export
+// ---------------------------------------------------------------------------
+
+func TestEndpoint_SchemeAddedWhenMissing(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"), WithEndpoint("collector.internal:4318"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.endpoint != "https://collector.internal:4318" {
+		t.Errorf("got %q, want %q", cfg.endpoint, "https://collector.internal:4318")
+	}
+}
+
+func TestInsecure_SchemelessEndpointUsesHTTP(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("k"), WithEndpoint("localhost:4318/"), WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.endpoint != "http://localhost:4318" {
+		t.Errorf("got %q, want %q", cfg.endpoint, "http://localhost:4318")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Span kind toggles
+// ---------------------------------------------------------------------------
+
+func TestDisabledSpanKinds_EnvFallback(t *testing.T) {
+	t.Setenv(EnvDisabledSpanKinds, "tool, Task")
+	cfg, err := resolveConfig(WithAPIKey("k"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.disabledKinds[SpanKindTool] || !cfg.disabledKinds[SpanKindTask] || len(cfg.disabledKinds) != 2 {
+		t.Errorf("got %v, want tool and task", cfg.disabledKinds)
+	}
+}
+
+func TestDisabledSpanKinds_ExplicitReplacesEnv(t *testing.T) {
+	t.Setenv(EnvDisabledSpanKinds, "tool")
+	cfg, err := resolveConfig(WithAPIKey("k"), WithDisabledSpanKinds(SpanKindAgent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.disabledKinds[SpanKindTool] || !cfg.disabledKinds[SpanKindAgent] {
+		t.Errorf("got %v, want only agent", cfg.disabledKinds)
+	}
+}
+
+func TestDisabledSpanKinds_UnknownKindReturnsError(t *testing.T) {
+	_, err := resolveConfig(WithAPIKey("k"), WithDisabledSpanKinds("llm"))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
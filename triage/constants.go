@@ -6,9 +6,13 @@ const (
 	AttrUserRole        = "triage.user.role"
 	AttrTenantID        = "triage.tenant.id"
 	AttrTenantName      = "triage.tenant.name"
+	AttrTenantWorkspace = "triage.tenant.workspace"
+	AttrTenantProject   = "triage.tenant.project"
 	AttrSessionID       = "triage.session.id"
 	AttrSessionTurn     = "triage.session.turn_number"
 	AttrSessionHash     = "triage.session.history_hash"
+	AttrConversationID  = "triage.conversation.id"
+	AttrTrafficClass    = "triage.traffic.class"
 	AttrInputRaw        = "triage.input.raw"
 	AttrInputSanitized  = "triage.input.sanitized"
 	AttrTemplateID      = "triage.template.id"
@@ -16,6 +20,19 @@ const (
 	AttrChunkACLs       = "triage.chunk_acls"
 )
 
+// Prompt template variables recorded by RenderTemplate. Each variable is
+// recorded as AttrTemplateVariablePrefix + name.
+const (
+	AttrTemplateVariables      = "triage.template.variables"
+	AttrTemplateVariablePrefix = "triage.template.var."
+)
+
+// Evaluation run span attributes.
+const (
+	AttrEvalRunID     = "triage.eval.run_id"
+	AttrEvalDatasetID = "triage.eval.dataset_id"
+)
+
 // SDK metadata span attributes.
 const (
 	AttrSDKName    = "triage.sdk.name"
@@ -31,28 +48,367 @@ const (
 	EnvEnvironment  = "TRIAGE_ENVIRONMENT"
 	EnvEnabled      = "TRIAGE_ENABLED"
 	EnvTraceContent = "TRIAGE_TRACE_CONTENT"
+	EnvSampleRatio  = "TRIAGE_SAMPLE_RATIO"
+	EnvMaxQueueSize = "TRIAGE_MAX_QUEUE_SIZE"
+	EnvBatchTimeout = "TRIAGE_BATCH_TIMEOUT"
+	EnvPricingFile  = "TRIAGE_PRICING_FILE"
+	EnvMetrics      = "TRIAGE_METRICS"
+
+	EnvDisabledSpanKinds  = "TRIAGE_DISABLED_SPAN_KINDS" // comma-separated
+	EnvAttributeNamespace = "TRIAGE_ATTRIBUTE_NAMESPACE"
 )
 
 // Layer 1: gen_ai semantic convention attributes (matching Python/TypeScript OpenLLMetry).
 const (
-	AttrGenAISystem               = "gen_ai.system"
-	AttrGenAIRequestModel         = "gen_ai.request.model"
-	AttrGenAIResponseModel        = "gen_ai.response.model"
-	AttrGenAIRequestTemperature   = "gen_ai.request.temperature"
-	AttrGenAIRequestTopP          = "gen_ai.request.top_p"
-	AttrGenAIRequestMaxTokens     = "gen_ai.request.max_tokens"
-	AttrGenAIRequestStopSequences = "gen_ai.request.stop_sequences"
-	AttrGenAIUsageInputTokens     = "gen_ai.usage.input_tokens"
-	AttrGenAIUsageOutputTokens    = "gen_ai.usage.output_tokens"
-	AttrGenAIUsageTotalTokens     = "gen_ai.usage.total_tokens"
-	AttrGenAIUsageReasoningTokens = "gen_ai.usage.reasoning_tokens"
-	AttrGenAIUsageCacheReadTokens = "gen_ai.usage.cache_read_tokens"
-	AttrGenAIUsageCacheWriteTokens = "gen_ai.usage.cache_write_tokens"
-	AttrGenAIResponseFinishReason = "gen_ai.response.finish_reason"
+	AttrGenAISystem                 = "gen_ai.system"
+	AttrGenAIOperationName          = "gen_ai.operation.name"
+	AttrGenAIRequestModel           = "gen_ai.request.model"
+	AttrGenAIResponseModel          = "gen_ai.response.model"
+	AttrGenAIRequestTemperature     = "gen_ai.request.temperature"
+	AttrGenAIRequestTopP            = "gen_ai.request.top_p"
+	AttrGenAIRequestMaxTokens       = "gen_ai.request.max_tokens"
+	AttrGenAIRequestStopSequences   = "gen_ai.request.stop_sequences"
+	AttrGenAIUsageInputTokens       = "gen_ai.usage.input_tokens"
+	AttrGenAIUsageOutputTokens      = "gen_ai.usage.output_tokens"
+	AttrGenAIUsageTotalTokens       = "gen_ai.usage.total_tokens"
+	AttrGenAIUsageReasoningTokens   = "gen_ai.usage.reasoning_tokens"
+	AttrGenAIUsageCacheReadTokens   = "gen_ai.usage.cache_read_tokens"
+	AttrGenAIUsageCacheWriteTokens  = "gen_ai.usage.cache_write_tokens"
+	AttrGenAIUsageAudioInputTokens  = "gen_ai.usage.audio_input_tokens"
+	AttrGenAIUsageAudioOutputTokens = "gen_ai.usage.audio_output_tokens"
+	AttrGenAIUsageImageTokens       = "gen_ai.usage.image_tokens"
+	AttrGenAIResponseFinishReason   = "gen_ai.response.finish_reason"
+	AttrGenAIRequestServiceTier     = "gen_ai.request.service_tier"
+	AttrGenAIResponseServiceTier    = "gen_ai.response.service_tier"
+
+	// Prefix of the built-in tool calls recorded from Completion.BuiltinToolCalls
+	// (gen_ai.response.builtin_tool_call.{i}.type, .id, .status, ...).
+	AttrGenAIResponseBuiltinToolCall = "gen_ai.response.builtin_tool_call"
+)
+
+// Estimated cost attributes, in USD, and the cost metric (see
+// LLMSpan.LogCompletion).
+const (
+	AttrCostInput  = "triage.cost.input_usd"
+	AttrCostOutput = "triage.cost.output_usd"
+	AttrCostTotal  = "triage.cost.total_usd"
+	costMetricName = "triage.llm.cost"
+)
+
+// Model capability attributes, derived from the model registry.
+const (
+	AttrContextWindow      = "triage.context.window"
+	AttrContextUtilization = "triage.context.utilization" // (input + output tokens) / context window
+	AttrModelWarnings      = "triage.model.warnings"
+)
+
+// Guardrail block attributes and event name, recorded by NewBlockedError.
+const (
+	AttrGuardrailBlocked      = "triage.guardrail.blocked"
+	AttrGuardrailPolicyID     = "triage.guardrail.policy_id"
+	AttrGuardrailRule         = "triage.guardrail.rule"
+	guardrailBlockedEventName = "triage.guardrail.blocked"
+)
+
+// Feedback span attributes and event names.
+const (
+	AttrFeedbackRating      = "triage.feedback.rating"
+	AttrFeedbackComment     = "triage.feedback.comment"
+	AttrFeedbackTargetTrace = "triage.feedback.target_trace_id"
+	AttrFeedbackTargetSpan  = "triage.feedback.target_span_id"
+	feedbackName            = "triage.feedback"
+)
+
+// Evaluation span attributes and event names.
+const (
+	AttrScorePrefix    = "triage.score." // followed by the score name
+	AttrScoreName      = "triage.score.name"
+	AttrScoreValue     = "triage.score.value"
+	AttrScoreEvaluator = "triage.score.evaluator"
+	AttrScoreReason    = "triage.score.reason"
+	scoreEventName     = "triage.score"
+
+	AttrGroundTruth       = "triage.ground_truth"
+	AttrGroundTruthFormat = "triage.ground_truth.format"
+)
+
+// Azure OpenAI attributes (see VendorAzureOpenAI).
+const (
+	AttrAzureDeployment = "triage.azure.deployment"
+	AttrAzureAPIVersion = "triage.azure.api_version"
+	AttrAzureResource   = "triage.azure.resource"
+)
+
+// Provider endpoint attributes.
+const (
+	AttrServerAddress  = "server.address"
+	AttrServerPort     = "server.port"
+	AttrProviderRegion = "triage.provider.region"
+)
+
+// AttrResponseFinishCategory is the provider's finish reason normalized to
+// "stop", "length", "tool_calls" or "content_filter", so stop reasons compare
+// across vendors.
+const AttrResponseFinishCategory = "triage.response.finish_category"
+
+// Token usage metric (see LLMSpan.LogCompletion), following the gen_ai
+// semantic conventions. Each data point is labeled with the token type.
+const (
+	AttrGenAITokenType   = "gen_ai.token.type" // "input", "output" or "total"
+	tokenUsageMetricName = "gen_ai.client.token.usage"
+)
+
+// Truncation detection.
+const (
+	AttrResponseTruncated = "triage.response.truncated"
+	truncationEventName   = "triage.truncated"
+	truncationMetricName  = "triage.llm.truncations"
+)
+
+// Server-side metrics reported by self-hosted inference servers.
+const (
+	AttrServerQueueMs            = "triage.server.queue_ms"
+	AttrServerPrefillMs          = "triage.server.prefill_ms"
+	AttrServerDecodeMs           = "triage.server.decode_ms"
+	AttrServerInferenceMs        = "triage.server.inference_ms"
+	AttrServerClientOverheadMs   = "triage.server.client_overhead_ms" // span duration minus server time
+	AttrServerCachedPromptTokens = "triage.server.cached_prompt_tokens"
+	AttrServerKVCacheHitRatio    = "triage.server.kv_cache_hit_ratio"
+)
+
+// AttrResponseHeaderPrefix prefixes captured provider response headers, per
+// the OTel HTTP semantic conventions (lowercased header name appended).
+const AttrResponseHeaderPrefix = "http.response.header."
+
+// Self-hosted serving attributes.
+const (
+	AttrServingEngine        = "triage.serving.engine"
+	AttrServingEngineVersion = "triage.serving.engine_version"
+	AttrServingGPU           = "triage.serving.gpu"
+	AttrServingQuantization  = "triage.serving.quantization"
+	AttrServingDeployment    = "triage.serving.deployment"
+)
+
+// Prompt cache attributes.
+const (
+	AttrPromptCacheKey              = "gen_ai.request.cache_key"
+	AttrPromptCacheBreakpoints      = "triage.prompt_cache.breakpoints"
+	AttrPromptCacheBreakpointPrefix = "triage.prompt_cache.breakpoint" // followed by .{i}.message_index/.ttl_seconds/.hash
+)
+
+// Streaming chunk timing attributes and event names.
+const (
+	AttrStreamChunkGapHistogram = "triage.stream.chunk_gap_histogram" // counts per chunkGapBucketsMs bucket
+	AttrStreamMaxChunkGapMs     = "triage.stream.max_chunk_gap_ms"
+	AttrStreamChunks            = "triage.stream.chunks"
+	AttrStreamDurationMs        = "triage.stream.duration_ms" // from LogPrompt to the last chunk
+	AttrStreamBytes             = "triage.stream.bytes"
+	AttrStreamChunkGapP50Ms     = "triage.stream.chunk_gap_p50_ms"
+	AttrStreamChunkGapP90Ms     = "triage.stream.chunk_gap_p90_ms"
+	AttrStreamChunkGapP99Ms     = "triage.stream.chunk_gap_p99_ms"
+	AttrStreamChunkIndex        = "triage.stream.chunk.index"
+	AttrStreamChunkOffsetMs     = "triage.stream.chunk.offset_ms"
+	streamChunkEventName        = "triage.stream.chunk"
+)
+
+// Streaming latency attributes, in seconds (OTel GenAI semantic conventions),
+// recorded once MarkFirstToken has been called.
+const (
+	AttrGenAIServerTimeToFirstToken   = "gen_ai.server.time_to_first_token"
+	AttrGenAIServerTimePerOutputToken = "gen_ai.server.time_per_output_token"
+)
+
+// Span link attributes.
+const (
+	AttrLinkType         = "triage.link.type"
+	linkTypePreviousTurn = "previous_turn"
+	linkTypeOrigin       = "origin"
+
+	AttrOriginTraceID = "triage.origin.trace_id"
+	AttrOriginSpanID  = "triage.origin.span_id"
+)
+
+// HTTP server attributes set by Middleware (OTel semantic conventions).
+const (
+	AttrHTTPRoute              = "http.route"
+	AttrHTTPRequestMethod      = "http.request.method"
+	AttrHTTPResponseStatusCode = "http.response.status_code"
+)
+
+// gRPC server attributes set by the triagegrpc server interceptors (OTel
+// semantic conventions).
+const (
+	AttrRPCSystem         = "rpc.system"
+	AttrRPCService        = "rpc.service"
+	AttrRPCMethod         = "rpc.method"
+	AttrRPCGRPCStatusCode = "rpc.grpc.status_code"
+)
+
+// MCP tool call attributes set by CallMCPTool.
+const (
+	AttrMCPServer     = "triage.mcp.server"
+	AttrMCPMethodName = "mcp.method.name"
+	AttrGenAIToolName = "gen_ai.tool.name"
+)
+
+// Messaging consumer attributes set by StartConsumerSpan (OTel semantic
+// conventions).
+const (
+	AttrMessagingSystem          = "messaging.system"
+	AttrMessagingDestinationName = "messaging.destination.name"
+	AttrMessagingOperationType   = "messaging.operation.type"
+)
+
+// Tool span input and output (OpenLLMetry conventions).
+const (
+	AttrEntityInput  = "traceloop.entity.input"
+	AttrEntityOutput = "traceloop.entity.output"
+)
+
+// Tool loop attributes (see RunToolLoop).
+const (
+	AttrLoopIteration    = "triage.loop.iteration" // on each LLM and tool span
+	AttrLoopIterations   = "triage.loop.iterations"
+	AttrLoopToolCalls    = "triage.loop.tool_calls"
+	AttrLoopStopReason   = "triage.loop.stop_reason"
+	AttrLoopInputTokens  = "triage.loop.usage.input_tokens"
+	AttrLoopOutputTokens = "triage.loop.usage.output_tokens"
+	AttrLoopTotalTokens  = "triage.loop.usage.total_tokens"
+)
+
+// Workflow usage rollups, summed over every LLM call beneath the workflow.
+// The cost is omitted if any call's model has no known price.
+const (
+	AttrWorkflowLLMCalls     = "triage.workflow.llm_calls"
+	AttrWorkflowInputTokens  = "triage.workflow.input_tokens"
+	AttrWorkflowOutputTokens = "triage.workflow.output_tokens"
+	AttrWorkflowTotalTokens  = "triage.workflow.total_tokens"
+	AttrWorkflowCost         = "triage.workflow.cost_usd"
+)
+
+// Shared library attributes (see Library).
+const (
+	AttrLibraryName    = "triage.library.name"
+	AttrLibraryVersion = "triage.library.version"
+)
+
+// Redaction audit attributes, recorded when the SDK redacts captured
+// content. Per-field details are recorded under AttrRedactionPrefix + the
+// field's attribute key, followed by .detectors, .count and .positions.
+// Redactions made to a dataset record are recorded on its LLM span under
+// triage.redaction.dataset, without positions; dataset records and webhook
+// transcripts also count them in their Redactions field.
+const (
+	AttrRedactionApplied = "triage.redaction.applied"
+	AttrRedactionPrefix  = "triage.redaction."
+)
+
+// Multi-round LLM call attributes and event name (see LLMSpan.LogRound).
+const (
+	AttrLLMRounds      = "triage.llm.rounds" // model rounds in the call, including the final one
+	AttrRoundIndex     = "triage.round.index"
+	AttrRoundToolCalls = "triage.round.tool_calls"
+	roundEventName     = "gen_ai.round"
+)
+
+// Agent reasoning step events (see AddReasoningStep).
+const (
+	AttrReasoningStep      = "triage.reasoning.step"
+	reasoningStepEventName = "triage.reasoning_step"
+)
+
+// Output leak attributes, set when completion content contains PII or
+// secrets (see WithOutputScanning).
+const (
+	AttrOutputLeakDetected = "triage.output.leak.detected"
+	AttrOutputLeakTypes    = "triage.output.leak.types"
+	AttrOutputLeakCount    = "triage.output.leak.count"
+)
+
+// Retrieved document attributes (see WithRetrievedDocuments).
+const (
+	AttrRetrievalDocumentCount = "triage.retrieval.document_count"
+	AttrRetrievalDocumentIDs   = "triage.retrieval.document_ids"
+	AttrRetrievalDocuments     = "triage.retrieval.documents" // JSON array of Document
+)
+
+// Session risk attributes (see AddRiskScore).
+const (
+	AttrSessionRiskScore = "triage.session.risk_score" // accumulated risk of the session
+	AttrRiskSource       = "triage.risk.source"
+	AttrRiskScore        = "triage.risk.score"
+	riskEventName        = "triage.risk"
+)
+
+// Workflow and task time budgets (see WorkflowTimeout and TaskTimeout).
+const (
+	AttrDeadline              = "triage.deadline" // RFC 3339 timestamp
+	AttrTimeoutMs             = "triage.timeout_ms"
+	AttrDeadlineExceeded      = "triage.deadline.exceeded"
+	AttrDeadlineOverrunMs     = "triage.deadline.overrun_ms"
+	deadlineExceededEventName = "deadline_exceeded"
+)
+
+// Trace size guards (see WithMaxSpansPerTrace, WithMaxTraceDepth and
+// WithMaxContentBytesPerTrace).
+const (
+	AttrSpansElided      = "triage.trace.spans_elided"
+	spansElidedEventName = "triage.spans_elided"
+
+	AttrContentHashed      = "triage.content.hashed"
+	AttrContentBudgetBytes = "triage.content.budget_bytes"
+	contentBudgetEventName = "triage.content_budget_exceeded"
+)
+
+// Sampling decision attributes, recorded on the local root span of sampled
+// traces, and the counter of dropped traces.
+const (
+	AttrSamplingRule        = "triage.sampling.rule"
+	AttrSamplingRatio       = "triage.sampling.ratio"
+	droppedTracesMetricName = "triage.sampling.dropped_traces"
+)
+
+// Retry event attributes (see DoWithRetry).
+const (
+	AttrRetryAttempt      = "triage.retry.attempt"
+	AttrRetryStatusCode   = "triage.retry.status_code"
+	AttrRetryDelayMs      = "triage.retry.delay_ms"
+	AttrRetryAfterHonored = "triage.retry.retry_after"
+	retryEventName        = "triage.retry"
+)
+
+// Error attributes (OTel semantic conventions).
+const (
+	AttrErrorType        = "error.type"
+	AttrExceptionType    = "exception.type"
+	AttrExceptionMessage = "exception.message"
+	exceptionEventName   = "exception"
+)
+
+// AttrAutoRoot marks a root workflow span created by EnsureRootSpan.
+const AttrAutoRoot = "triage.root.auto"
+
+// Human review span attributes.
+const (
+	AttrReviewRequired = "triage.review.required"
+	AttrReviewReason   = "triage.review.reason"
+)
+
+// Replay span attributes.
+const (
+	AttrReplayOriginalTrace = "triage.replay.original_trace_id"
+	AttrReplayOriginalSpan  = "triage.replay.original_span_id"
+	AttrReplayOriginalModel = "triage.replay.original_model"
+	replaySpanName          = "triage.replay"
 )
 
 // Defaults.
 const (
 	DefaultEndpoint       = "https://api.triageai.dev"
 	defaultOTLPTracesPath = "/v1/traces"
+	otlpMetricsPath       = "/v1/metrics"
+	annotationsPath       = "/v1/annotations"
+	bulkAnnotationsPath   = "/v1/annotations/bulk"
+	reviewsPath           = "/v1/reviews"
+	traceSearchPath       = "/v1/traces/search"
 )
@@ -2,7 +2,6 @@ package triage
 
 import (
 	"context"
-	"encoding/json"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
@@ -19,14 +18,23 @@ type triageContext struct {
 	userRole           string
 	tenantID           string
 	tenantName         string
+	tenantWorkspace    string
+	tenantProject      string
 	sessionID          string
 	sessionTurnNumber  *int
 	sessionHistoryHash string
+	conversationID     string
+	trafficClass       string
 	inputRaw           string
 	inputSanitized     string
 	templateID         string
 	templateVersion    string
-	chunkACLs          string // JSON-serialized
+	templateVars       []attribute.KeyValue // variable names, set by RenderTemplate
+	templateValues     []attribute.KeyValue // variable values, recorded on LLM spans only
+	chunkACLs          string               // JSON-serialized
+	evalRunID          string
+	evalDatasetID      string
+	extraAttrs         []attribute.KeyValue // set by SetSpanAttributes
 }
 
 // clone returns a shallow copy of the context so callers can mutate the copy
@@ -60,6 +68,16 @@ func TenantName(name string) TenantOption {
 	return func(tc *triageContext) { tc.tenantName = name }
 }
 
+// Workspace sets the workspace within the tenant organization.
+func Workspace(id string) TenantOption {
+	return func(tc *triageContext) { tc.tenantWorkspace = id }
+}
+
+// Project sets the project within the tenant workspace.
+func Project(id string) TenantOption {
+	return func(tc *triageContext) { tc.tenantProject = id }
+}
+
 // SessionOption configures optional fields for WithSession.
 type SessionOption func(*triageContext)
 
@@ -125,6 +143,12 @@ func getTriageAttrs(ctx context.Context) []attribute.KeyValue {
 	if tc.tenantName != "" {
 		attrs = append(attrs, attribute.String(AttrTenantName, tc.tenantName))
 	}
+	if tc.tenantWorkspace != "" {
+		attrs = append(attrs, attribute.String(AttrTenantWorkspace, tc.tenantWorkspace))
+	}
+	if tc.tenantProject != "" {
+		attrs = append(attrs, attribute.String(AttrTenantProject, tc.tenantProject))
+	}
 	if tc.sessionID != "" {
 		attrs = append(attrs, attribute.String(AttrSessionID, tc.sessionID))
 	}
@@ -134,6 +158,12 @@ func getTriageAttrs(ctx context.Context) []attribute.KeyValue {
 	if tc.sessionHistoryHash != "" {
 		attrs = append(attrs, attribute.String(AttrSessionHash, tc.sessionHistoryHash))
 	}
+	if tc.conversationID != "" {
+		attrs = append(attrs, attribute.String(AttrConversationID, tc.conversationID))
+	}
+	if tc.trafficClass != "" {
+		attrs = append(attrs, attribute.String(AttrTrafficClass, tc.trafficClass))
+	}
 	if tc.inputRaw != "" {
 		attrs = append(attrs, attribute.String(AttrInputRaw, tc.inputRaw))
 	}
@@ -146,9 +176,17 @@ func getTriageAttrs(ctx context.Context) []attribute.KeyValue {
 	if tc.templateVersion != "" {
 		attrs = append(attrs, attribute.String(AttrTemplateVersion, tc.templateVersion))
 	}
+	attrs = append(attrs, tc.templateVars...)
 	if tc.chunkACLs != "" {
 		attrs = append(attrs, attribute.String(AttrChunkACLs, tc.chunkACLs))
 	}
+	if tc.evalRunID != "" {
+		attrs = append(attrs, attribute.String(AttrEvalRunID, tc.evalRunID))
+	}
+	if tc.evalDatasetID != "" {
+		attrs = append(attrs, attribute.String(AttrEvalDatasetID, tc.evalDatasetID))
+	}
+	attrs = append(attrs, tc.extraAttrs...)
 	return attrs
 }
 
@@ -174,12 +212,20 @@ func WithUser(ctx context.Context, userID string, opts ...UserOption) context.Co
 		}
 	}
 
-	return setInContext(ctx, tc)
+	return setInContext(withContextBaggage(ctx, tc), tc)
 }
 
-// WithTenant attaches tenant/organization identity to the context.
+// WithTenant attaches tenant/organization identity to the context. For
+// hierarchical tenancy, pass Workspace and Project to identify where in the
+// organization the request belongs; a new tenant ID clears the workspace and
+// project of the previous one:
+//
+//	ctx = triage.WithTenant(ctx, "org_456", triage.Workspace("ws_eu"), triage.Project("proj_9"))
 func WithTenant(ctx context.Context, tenantID string, opts ...TenantOption) context.Context {
 	tc := getFromContext(ctx).clone()
+	if tc.tenantID != tenantID {
+		tc.tenantWorkspace, tc.tenantProject = "", ""
+	}
 	tc.tenantID = tenantID
 	for _, o := range opts {
 		o(&tc)
@@ -190,9 +236,15 @@ func WithTenant(ctx context.Context, tenantID string, opts ...TenantOption) cont
 		if tc.tenantName != "" {
 			span.SetAttributes(attribute.String(AttrTenantName, tc.tenantName))
 		}
+		if tc.tenantWorkspace != "" {
+			span.SetAttributes(attribute.String(AttrTenantWorkspace, tc.tenantWorkspace))
+		}
+		if tc.tenantProject != "" {
+			span.SetAttributes(attribute.String(AttrTenantProject, tc.tenantProject))
+		}
 	}
 
-	return setInContext(ctx, tc)
+	return setInContext(withContextBaggage(ctx, tc), tc)
 }
 
 // WithSession attaches conversation session metadata to the context.
@@ -213,6 +265,45 @@ func WithSession(ctx context.Context, sessionID string, opts ...SessionOption) c
 		}
 	}
 
+	return setInContext(withContextBaggage(ctx, tc), tc)
+}
+
+// WithConversation attaches a logical conversation/thread ID to the context.
+// It is distinct from the session set by WithSession: one infrastructure
+// session may contain several conversations (e.g. chat threads in a sidebar),
+// and collapsing them into one ID breaks per-conversation analysis.
+func WithConversation(ctx context.Context, conversationID string) context.Context {
+	tc := getFromContext(ctx).clone()
+	tc.conversationID = conversationID
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String(AttrConversationID, tc.conversationID))
+	}
+
+	return setInContext(ctx, tc)
+}
+
+// Traffic classes for WithTrafficClass.
+const (
+	TrafficLive   = "live"
+	TrafficCanary = "canary"
+	TrafficShadow = "shadow"
+)
+
+// WithTrafficClass marks all spans created with the returned context as the
+// given class of traffic (recorded as triage.traffic.class), so shadow or
+// canary requests served by a production process can be excluded from
+// alerting without changing the process-wide environment:
+//
+//	ctx = triage.WithTrafficClass(ctx, triage.TrafficShadow)
+func WithTrafficClass(ctx context.Context, class string) context.Context {
+	tc := getFromContext(ctx).clone()
+	tc.trafficClass = class
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String(AttrTrafficClass, tc.trafficClass))
+	}
+
 	return setInContext(ctx, tc)
 }
 
@@ -238,6 +329,7 @@ func WithInput(ctx context.Context, raw string, opts ...InputOption) context.Con
 func WithTemplate(ctx context.Context, templateID string, opts ...TemplateOption) context.Context {
 	tc := getFromContext(ctx).clone()
 	tc.templateID = templateID
+	tc.templateVars, tc.templateValues = nil, nil // variables belong to the previous template
 	for _, o := range opts {
 		o(&tc)
 	}
@@ -258,7 +350,7 @@ func WithTemplate(ctx context.Context, templateID string, opts ...TemplateOption
 func WithChunkACLs(ctx context.Context, acls []map[string]any) context.Context {
 	tc := getFromContext(ctx).clone()
 
-	data, err := json.Marshal(acls)
+	data, err := safeMarshal("WithChunkACLs", acls)
 	if err != nil {
 		// Don't break the user's application for a telemetry failure.
 		return setInContext(ctx, tc)
@@ -271,3 +363,44 @@ func WithChunkACLs(ctx context.Context, acls []map[string]any) context.Context {
 
 	return setInContext(ctx, tc)
 }
+
+// SetSpanAttributes sets attrs on the current span and returns a context that
+// carries them to every span subsequently created from it — for one-off
+// annotations that don't merit a dedicated helper:
+//
+//	ctx = triage.SetSpanAttributes(ctx, attribute.String("app.feature_flag", "new-ranker"))
+//
+// Setting a key again replaces its earlier value for future spans.
+func SetSpanAttributes(ctx context.Context, attrs ...attribute.KeyValue) context.Context {
+	if len(attrs) == 0 {
+		return ctx
+	}
+	tc := getFromContext(ctx).clone()
+	tc.extraAttrs = mergeAttributes(tc.extraAttrs, attrs)
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attrs...)
+	}
+
+	return setInContext(ctx, tc)
+}
+
+// mergeAttributes returns a new slice with base followed by attrs, where a
+// key in attrs replaces the same key in base. base is never modified, so
+// contexts sharing it stay isolated.
+func mergeAttributes(base, attrs []attribute.KeyValue) []attribute.KeyValue {
+	merged := make([]attribute.KeyValue, 0, len(base)+len(attrs))
+	index := make(map[attribute.Key]int, len(base)+len(attrs))
+	for _, kv := range append(base[:len(base):len(base)], attrs...) {
+		if !kv.Valid() {
+			continue
+		}
+		if i, ok := index[kv.Key]; ok {
+			merged[i] = kv
+			continue
+		}
+		index[kv.Key] = len(merged)
+		merged = append(merged, kv)
+	}
+	return merged
+}
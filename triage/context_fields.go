@@ -0,0 +1,98 @@
+package triage
+
+// contextField is a string triage context field and the keys that carry it
+// across process boundaries. An empty key means the mechanism doesn't carry
+// the field. The session turn, the only non-string field, is carried
+// separately as HeaderSessionTurn and envSessionTurn.
+type contextField struct {
+	baggage string // W3C Baggage key (see WithContextBaggage)
+	header  string // Propagator carrier key and context header
+	env     string // subprocess environment variable (see SubprocessEnv)
+	field   func(*triageContext) *string
+	rename  func(HeaderNames) string // non-nil for the headers read by Middleware (see WithContextHeaders)
+}
+
+// contextFields lists every triage context field carried across process
+// boundaries. Raw input, template variables and chunk ACLs are deliberately
+// not carried: they can be large, hold user content, and environment
+// variables are visible to anything that can inspect the process.
+var contextFields = []contextField{
+	{
+		baggage: AttrUserID, header: HeaderUser, env: "TRIAGE_CTX_USER_ID",
+		field:  func(tc *triageContext) *string { return &tc.userID },
+		rename: func(n HeaderNames) string { return n.User },
+	},
+	{
+		baggage: AttrUserRole, header: HeaderUserRole, env: "TRIAGE_CTX_USER_ROLE",
+		field: func(tc *triageContext) *string { return &tc.userRole },
+	},
+	{
+		baggage: AttrTenantID, header: HeaderTenant, env: "TRIAGE_CTX_TENANT_ID",
+		field:  func(tc *triageContext) *string { return &tc.tenantID },
+		rename: func(n HeaderNames) string { return n.Tenant },
+	},
+	{
+		baggage: AttrTenantName, header: HeaderTenantName, env: "TRIAGE_CTX_TENANT_NAME",
+		field: func(tc *triageContext) *string { return &tc.tenantName },
+	},
+	{
+		baggage: AttrTenantWorkspace, header: HeaderTenantWorkspace, env: "TRIAGE_CTX_TENANT_WORKSPACE",
+		field: func(tc *triageContext) *string { return &tc.tenantWorkspace },
+	},
+	{
+		baggage: AttrTenantProject, header: HeaderTenantProject, env: "TRIAGE_CTX_TENANT_PROJECT",
+		field: func(tc *triageContext) *string { return &tc.tenantProject },
+	},
+	{
+		baggage: AttrSessionID, header: HeaderSession, env: "TRIAGE_CTX_SESSION_ID",
+		field:  func(tc *triageContext) *string { return &tc.sessionID },
+		rename: func(n HeaderNames) string { return n.Session },
+	},
+	{
+		header: HeaderSessionHash, env: "TRIAGE_CTX_SESSION_HISTORY_HASH",
+		field: func(tc *triageContext) *string { return &tc.sessionHistoryHash },
+	},
+	{
+		header: HeaderConversation, env: "TRIAGE_CTX_CONVERSATION_ID",
+		field: func(tc *triageContext) *string { return &tc.conversationID },
+	},
+	{
+		header: HeaderTrafficClass, env: "TRIAGE_CTX_TRAFFIC_CLASS",
+		field: func(tc *triageContext) *string { return &tc.trafficClass },
+	},
+	{
+		header: HeaderTemplate, env: "TRIAGE_CTX_TEMPLATE_ID",
+		field: func(tc *triageContext) *string { return &tc.templateID },
+	},
+	{
+		header: HeaderTemplateVersion, env: "TRIAGE_CTX_TEMPLATE_VERSION",
+		field: func(tc *triageContext) *string { return &tc.templateVersion },
+	},
+	{
+		header: HeaderEvalRun, env: "TRIAGE_CTX_EVAL_RUN_ID",
+		field: func(tc *triageContext) *string { return &tc.evalRunID },
+	},
+	{
+		header: HeaderEvalDataset, env: "TRIAGE_CTX_EVAL_DATASET_ID",
+		field: func(tc *triageContext) *string { return &tc.evalDatasetID },
+	},
+}
+
+// The contextFields carried by each mechanism.
+var (
+	baggageContextFields = filterContextFields(func(f contextField) bool { return f.baggage != "" })
+	propagatorFields     = filterContextFields(func(f contextField) bool { return f.header != "" })
+	envContextFields     = filterContextFields(func(f contextField) bool { return f.env != "" })
+	headerContextFields  = filterContextFields(func(f contextField) bool { return f.rename != nil })
+)
+
+// filterContextFields returns the contextFields for which keep reports true.
+func filterContextFields(keep func(contextField) bool) []contextField {
+	var fields []contextField
+	for _, f := range contextFields {
+		if keep(f) {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
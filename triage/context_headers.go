@@ -0,0 +1,125 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+)
+
+// Triage context headers: the wire format every Triage SDK uses to carry the
+// user, tenant and session between services. Trace context itself travels in
+// the standard W3C traceparent header.
+const (
+	HeaderUser    = "X-Triage-User"
+	HeaderTenant  = "X-Triage-Tenant"
+	HeaderSession = "X-Triage-Session"
+)
+
+// Further Triage context headers, carried with the ones above by Propagator
+// (and so by InjectMessage and StartConsumerSpan). Allow them in CORS and
+// proxy configurations along with HeaderUser, HeaderTenant and
+// HeaderSession.
+const (
+	HeaderUserRole        = "X-Triage-User-Role"
+	HeaderTenantName      = "X-Triage-Tenant-Name"
+	HeaderTenantWorkspace = "X-Triage-Tenant-Workspace"
+	HeaderTenantProject   = "X-Triage-Tenant-Project"
+	HeaderSessionHash     = "X-Triage-Session-Hash"
+	HeaderSessionTurn     = "X-Triage-Session-Turn"
+	HeaderConversation    = "X-Triage-Conversation"
+	HeaderTrafficClass    = "X-Triage-Traffic-Class"
+	HeaderTemplate        = "X-Triage-Template"
+	HeaderTemplateVersion = "X-Triage-Template-Version"
+	HeaderEvalRun         = "X-Triage-Eval-Run"
+	HeaderEvalDataset     = "X-Triage-Eval-Dataset"
+)
+
+// HeaderNames renames the Triage context headers read by Middleware and the
+// triagegrpc server interceptors (see WithContextHeaderNames), for services
+// whose edge already forwards the user, tenant or session under other names.
+// Empty fields keep the default header.
+type HeaderNames struct {
+	User    string // default HeaderUser
+	Tenant  string // default HeaderTenant
+	Session string // default HeaderSession
+}
+
+// defaultHeaderNames holds the default header of each headerContextFields
+// entry.
+var defaultHeaderNames = HeaderNames{}.resolve()
+
+// resolve returns the header to read for each headerContextFields entry.
+func (n HeaderNames) resolve() []string {
+	headers := make([]string, len(headerContextFields))
+	for i, f := range headerContextFields {
+		headers[i] = f.header
+		if v := f.rename(n); v != "" {
+			headers[i] = v
+		}
+	}
+	return headers
+}
+
+// SetContextHeaders sets the Triage context headers on h from the user,
+// tenant and session carried by ctx, for outbound calls to services that read
+// them (with Middleware's WithContextHeaders or another Triage SDK):
+//
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+//	triage.SetContextHeaders(ctx, req.Header)
+//
+// Fields not set on ctx are left untouched.
+func SetContextHeaders(ctx context.Context, h http.Header) {
+	tc := getFromContext(ctx)
+	for _, f := range headerContextFields {
+		if v := *f.field(&tc); v != "" {
+			h.Set(f.header, v)
+		}
+	}
+}
+
+// ContextFromHeaders returns ctx carrying the user, tenant and session from
+// the Triage context headers in h. Values already set on ctx take
+// precedence. Only call it for requests from trusted services: the headers
+// are client-controlled.
+func ContextFromHeaders(ctx context.Context, h http.Header) context.Context {
+	return contextFromHeaderLookup(ctx, defaultHeaderNames, h.Get)
+}
+
+// ContextFromHeaderNames is ContextFromHeaders reading the Triage context
+// from the given headers instead of the defaults. The same trust caveat
+// applies.
+func ContextFromHeaderNames(ctx context.Context, h http.Header, names HeaderNames) context.Context {
+	return contextFromHeaderLookup(ctx, names.resolve(), h.Get)
+}
+
+// contextFromHeaderLookup returns ctx carrying the triage context fields
+// found by looking up headers, which holds one name per headerContextFields
+// entry. Values are set through the public helpers, so they get the same
+// span attributes and baggage as values set in code. Values already set on
+// ctx take precedence.
+func contextFromHeaderLookup(ctx context.Context, headers []string, get func(string) string) context.Context {
+	tc := getFromContext(ctx)
+	for i, f := range headerContextFields {
+		if *f.field(&tc) != "" {
+			continue
+		}
+		if v := get(headers[i]); v != "" {
+			ctx = withHeaderField(ctx, f.header, v)
+		}
+	}
+	return ctx
+}
+
+// withHeaderField sets the field of a headerContextFields entry through its
+// public helper. The helpers can't be referenced from the table itself: they
+// read baggageContextFields, which is derived from it.
+func withHeaderField(ctx context.Context, header, v string) context.Context {
+	switch header {
+	case HeaderUser:
+		return WithUser(ctx, v)
+	case HeaderTenant:
+		return WithTenant(ctx, v)
+	case HeaderSession:
+		return WithSession(ctx, v)
+	}
+	return ctx
+}
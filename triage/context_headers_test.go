@@ -0,0 +1,66 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// ---------------------------------------------------------------------------
+// Context headers
+// ---------------------------------------------------------------------------
+
+func TestContextHeaders_RoundTrip(t *testing.T) {
+	ctx := WithUser(context.Background(), "u_1")
+	ctx = WithTenant(ctx, "t_1")
+	ctx = WithSession(ctx, "s_1")
+
+	h := http.Header{}
+	SetContextHeaders(ctx, h)
+	if h.Get("X-Triage-User") != "u_1" || h.Get("X-Triage-Tenant") != "t_1" || h.Get("X-Triage-Session") != "s_1" {
+		t.Fatalf("headers: got %v", h)
+	}
+
+	tc := getFromContext(ContextFromHeaders(context.Background(), h))
+	if tc.userID != "u_1" || tc.tenantID != "t_1" || tc.sessionID != "s_1" {
+		t.Errorf("restored context: got %+v", tc)
+	}
+}
+
+func TestSetContextHeaders_SkipsUnsetFields(t *testing.T) {
+	h := http.Header{}
+	SetContextHeaders(WithUser(context.Background(), "u_1"), h)
+	if len(h) != 1 {
+		t.Errorf("expected only the user header, got %v", h)
+	}
+}
+
+func TestContextFromHeaders_ContextTakesPrecedence(t *testing.T) {
+	h := http.Header{}
+	h.Set(HeaderUser, "from_header")
+	h.Set(HeaderTenant, "t_1")
+
+	tc := getFromContext(ContextFromHeaders(WithUser(context.Background(), "from_ctx"), h))
+	if tc.userID != "from_ctx" || tc.tenantID != "t_1" {
+		t.Errorf("got user %q, tenant %q", tc.userID, tc.tenantID)
+	}
+}
+
+func TestContextFromHeaders_MirrorsBaggage(t *testing.T) {
+	newGlobalTestProvider(t)
+	globalCfg = &config{contextBaggage: true}
+
+	h := http.Header{}
+	h.Set(HeaderUser, "u_1")
+	h.Set(HeaderTenant, "t_1")
+	h.Set(HeaderSession, "s_1")
+
+	b := baggage.FromContext(ContextFromHeaders(context.Background(), h))
+	for key, want := range map[string]string{AttrUserID: "u_1", AttrTenantID: "t_1", AttrSessionID: "s_1"} {
+		if got := b.Member(key).Value(); got != want {
+			t.Errorf("baggage %s: got %q, want %q", key, got, want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // ---------------------------------------------------------------------------
@@ -64,6 +66,29 @@ func TestWithTenant_SetsName(t *testing.T) {
 	}
 }
 
+func TestWithTenant_SetsHierarchy(t *testing.T) {
+	ctx := WithTenant(context.Background(), "org_1", Workspace("ws_eu"), Project("proj_9"))
+	attrs := attrMap(getTriageAttrs(ctx))
+	if attrs[AttrTenantWorkspace] != "ws_eu" || attrs[AttrTenantProject] != "proj_9" {
+		t.Errorf("got workspace %v, project %v", attrs[AttrTenantWorkspace], attrs[AttrTenantProject])
+	}
+
+	// Narrowing to another project of the same tenant keeps the workspace.
+	attrs = attrMap(getTriageAttrs(WithTenant(ctx, "org_1", Project("proj_10"))))
+	if attrs[AttrTenantWorkspace] != "ws_eu" || attrs[AttrTenantProject] != "proj_10" {
+		t.Errorf("same tenant: got workspace %v, project %v", attrs[AttrTenantWorkspace], attrs[AttrTenantProject])
+	}
+
+	// A different tenant doesn't inherit the previous tenant's hierarchy.
+	attrs = attrMap(getTriageAttrs(WithTenant(ctx, "org_2")))
+	if _, ok := attrs[AttrTenantWorkspace]; ok {
+		t.Errorf("workspace should be cleared for a new tenant, got %v", attrs[AttrTenantWorkspace])
+	}
+	if _, ok := attrs[AttrTenantProject]; ok {
+		t.Errorf("project should be cleared for a new tenant, got %v", attrs[AttrTenantProject])
+	}
+}
+
 // ---------------------------------------------------------------------------
 // WithSession
 // ---------------------------------------------------------------------------
@@ -99,6 +124,57 @@ func TestWithSession_OmittedOptionalsDoNotOverwrite(t *testing.T) {
 	}
 }
 
+// ---------------------------------------------------------------------------
+// WithConversation
+// ---------------------------------------------------------------------------
+
+func TestWithConversation_SetsConversationID(t *testing.T) {
+	ctx := WithConversation(context.Background(), "conv_1")
+	attrs := attrMap(getTriageAttrs(ctx))
+	if attrs[AttrConversationID] != "conv_1" {
+		t.Errorf("got %v, want %q", attrs[AttrConversationID], "conv_1")
+	}
+}
+
+func TestWithConversation_IndependentOfSession(t *testing.T) {
+	ctx := WithSession(context.Background(), "sess_1")
+	ctxA := WithConversation(ctx, "conv_a")
+	ctxB := WithConversation(ctx, "conv_b")
+
+	a, b := attrMap(getTriageAttrs(ctxA)), attrMap(getTriageAttrs(ctxB))
+	if a[AttrSessionID] != "sess_1" || b[AttrSessionID] != "sess_1" {
+		t.Error("both conversations should keep the session ID")
+	}
+	if a[AttrConversationID] != "conv_a" || b[AttrConversationID] != "conv_b" {
+		t.Errorf("conversation IDs: got %v / %v", a[AttrConversationID], b[AttrConversationID])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// WithTrafficClass
+// ---------------------------------------------------------------------------
+
+func TestWithTrafficClass_SetsClass(t *testing.T) {
+	ctx := WithTrafficClass(context.Background(), TrafficShadow)
+	attrs := attrMap(getTriageAttrs(ctx))
+	if attrs[AttrTrafficClass] != "shadow" {
+		t.Errorf("got %v, want %q", attrs[AttrTrafficClass], "shadow")
+	}
+}
+
+func TestWithTrafficClass_OverridesParent(t *testing.T) {
+	parent := WithTrafficClass(WithUser(context.Background(), "user_1"), TrafficLive)
+	child := WithTrafficClass(parent, TrafficCanary)
+
+	if got := attrMap(getTriageAttrs(parent))[AttrTrafficClass]; got != "live" {
+		t.Errorf("parent: got %v, want %q", got, "live")
+	}
+	attrs := attrMap(getTriageAttrs(child))
+	if attrs[AttrTrafficClass] != "canary" || attrs[AttrUserID] != "user_1" {
+		t.Errorf("child: got %v", attrs)
+	}
+}
+
 // ---------------------------------------------------------------------------
 // WithInput
 // ---------------------------------------------------------------------------
@@ -249,3 +325,40 @@ func TestContextScoping_OverrideDoesNotAffectParent(t *testing.T) {
 		t.Errorf("parent: got %v, want %q", parentAttrs[AttrTenantID], "org_1")
 	}
 }
+
+// ---------------------------------------------------------------------------
+// SetSpanAttributes
+// ---------------------------------------------------------------------------
+
+func TestSetSpanAttributes_CurrentAndChildSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "pipeline")
+	ctx = SetSpanAttributes(ctx, attribute.String("app.flag", "new-ranker"), attribute.Int("app.shard", 3))
+	tool, _ := StartTool(ctx, "search")
+	tool.End()
+	wf.End()
+
+	for _, s := range exporter.GetSpans() {
+		attrs := attrMap(s.Attributes)
+		if attrs["app.flag"] != "new-ranker" || attrs["app.shard"] != int64(3) {
+			t.Errorf("span %q: got flag=%v shard=%v", s.Name, attrs["app.flag"], attrs["app.shard"])
+		}
+	}
+}
+
+func TestSetSpanAttributes_LaterValueWinsWithoutAffectingParent(t *testing.T) {
+	parent := SetSpanAttributes(context.Background(), attribute.String("app.flag", "a"), attribute.String("app.other", "x"))
+	child := SetSpanAttributes(parent, attribute.String("app.flag", "b"))
+
+	childAttrs := attrMap(getTriageAttrs(child))
+	if childAttrs["app.flag"] != "b" || childAttrs["app.other"] != "x" {
+		t.Errorf("child: got %v", childAttrs)
+	}
+	if got := attrMap(getTriageAttrs(parent))["app.flag"]; got != "a" {
+		t.Errorf("parent: got %v, want %q", got, "a")
+	}
+	if n := len(getTriageAttrs(child)); n != 2 {
+		t.Errorf("expected 2 attributes, got %d", n)
+	}
+}
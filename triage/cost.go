@@ -0,0 +1,176 @@
+package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ModelPrice is the price of a model in USD per 1K tokens. Model matches the
+// exact model name or, failing that, the longest prefix followed only by a
+// date or version suffix (so "gpt-4o" also prices "gpt-4o-2024-08-06", but
+// "gpt-4.1" doesn't price "gpt-4.1-nano"). ValidFrom and ValidUntil
+// optionally bound the date range the rates apply to; zero values leave that
+// side open. ServiceTier optionally restricts the price to calls processed
+// in that tier (e.g. "flex", "priority"); tier-specific prices win over
+// tier-less ones.
+type ModelPrice struct {
+	Model       string    `json:"model"`
+	ServiceTier string    `json:"service_tier,omitempty"`
+	InputPer1K  float64   `json:"input_per_1k"`
+	OutputPer1K float64   `json:"output_per_1k"`
+	ValidFrom   time.Time `json:"valid_from"`
+	ValidUntil  time.Time `json:"valid_until"`
+}
+
+// Cost is the estimated USD cost of one LLM call.
+type Cost struct {
+	Input  float64
+	Output float64
+	Total  float64
+}
+
+// defaultPricing is the built-in list-price table. Entries supplied via
+// WithPricing or a pricing file take precedence.
+var defaultPricing = []ModelPrice{
+	{Model: "gpt-4o", InputPer1K: 0.0025, OutputPer1K: 0.01},
+	{Model: "gpt-4o-mini", InputPer1K: 0.00015, OutputPer1K: 0.0006},
+	{Model: "gpt-4.1", InputPer1K: 0.002, OutputPer1K: 0.008},
+	{Model: "gpt-4.1-mini", InputPer1K: 0.0004, OutputPer1K: 0.0016},
+	{Model: "o3-mini", InputPer1K: 0.0011, OutputPer1K: 0.0044},
+	{Model: "claude-sonnet-4", InputPer1K: 0.003, OutputPer1K: 0.015},
+	{Model: "claude-3-5-haiku", InputPer1K: 0.0008, OutputPer1K: 0.004},
+	{Model: "claude-opus-4", InputPer1K: 0.015, OutputPer1K: 0.075},
+}
+
+// WithPricing adds or overrides model prices used to estimate the cost of
+// each LLM call (recorded as triage.cost.* span attributes), e.g. for
+// negotiated enterprise rates or models missing from the built-in table:
+//
+//	triage.WithPricing(triage.ModelPrice{Model: "gpt-4o", InputPer1K: 0.002, OutputPer1K: 0.008})
+func WithPricing(prices ...ModelPrice) Option {
+	return func(c *config) { c.pricing = append(c.pricing, prices...) }
+}
+
+// WithPricingFile loads model prices from a JSON file holding an array of
+// ModelPrice objects. Entries from WithPricing take precedence over the file.
+func WithPricingFile(path string) Option {
+	return func(c *config) { c.pricingFile = path }
+}
+
+// loadPricingFile reads a JSON array of ModelPrice from path.
+func loadPricingFile(path string) ([]ModelPrice, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing file: %w", err)
+	}
+	var prices []ModelPrice
+	if err := json.Unmarshal(data, &prices); err != nil {
+		return nil, fmt.Errorf("invalid pricing file %s: %w", path, err)
+	}
+	return prices, nil
+}
+
+// EstimateCost returns the estimated cost of a call to model with the given
+// usage, priced as of now. Returns false if the model has no known price.
+func EstimateCost(model string, usage Usage) (Cost, bool) {
+	return estimateCost(model, "", usage)
+}
+
+// estimateCost is EstimateCost for a call processed in the given service tier.
+func estimateCost(model, tier string, usage Usage) (Cost, bool) {
+	var overrides, file []ModelPrice
+	if globalCfg != nil {
+		overrides, file = globalCfg.pricing, globalCfg.filePricing
+	}
+	p, ok := lookupPrice(model, tier, time.Now(), overrides, file, defaultPricing)
+	if !ok {
+		return Cost{}, false
+	}
+	c := Cost{
+		Input:  float64(usage.PromptTokens) / 1000 * p.InputPer1K,
+		Output: float64(usage.CompletionTokens) / 1000 * p.OutputPer1K,
+	}
+	c.Total = c.Input + c.Output
+	return c, true
+}
+
+// lookupPrice finds the price for model in the given service tier in effect
+// at t, searching each table in order and returning the first match. Within a
+// table, a longer model match wins, then a tier-specific price.
+func lookupPrice(model, tier string, t time.Time, tables ...[]ModelPrice) (ModelPrice, bool) {
+	if model == "" {
+		return ModelPrice{}, false
+	}
+	for _, table := range tables {
+		var best ModelPrice
+		found := false
+		for _, p := range table {
+			if !p.matches(model) || !p.activeAt(t) {
+				continue
+			}
+			if p.ServiceTier != "" && p.ServiceTier != tier {
+				continue
+			}
+			if !found || len(p.Model) > len(best.Model) ||
+				(len(p.Model) == len(best.Model) && best.ServiceTier == "" && p.ServiceTier != "") {
+				best, found = p, true
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+// modelVersionSuffix matches the date and version suffixes a provider appends
+// to a model name: "-2024-08-06", "-20241022", "@20241022", "-0613", "-002",
+// "-latest", "-v1:0" and the like, possibly several in a row.
+var modelVersionSuffix = regexp.MustCompile(`^(?:[-@](?:\d{4}-\d{2}-\d{2}|\d{8}|\d{3,4}|latest|v\d+(?::\d+)?))+$`)
+
+// matches reports whether the price applies to model: an exact match, or
+// p.Model followed by a date or version suffix.
+func (p ModelPrice) matches(model string) bool {
+	if p.Model == "" {
+		return false
+	}
+	rest, ok := strings.CutPrefix(model, p.Model)
+	return ok && (rest == "" || modelVersionSuffix.MatchString(rest))
+}
+
+// activeAt reports whether t falls within the price's validity range.
+func (p ModelPrice) activeAt(t time.Time) bool {
+	if !p.ValidFrom.IsZero() && t.Before(p.ValidFrom) {
+		return false
+	}
+	if !p.ValidUntil.IsZero() && !t.Before(p.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// recordCost adds the call's estimated cost to the triage.llm.cost counter on
+// the global OTel meter provider, labeled with the vendor, model, workflow
+// name and template ID, so spend can be broken down by pipeline or prompt
+// template (e.g. "what does summarize-ticket cost per month") without
+// querying traces. Unset dimensions are omitted.
+func (ls *LLMSpan) recordCost(model string, cost Cost) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAISystem, ls.vendor),
+		attribute.String(AttrGenAIRequestModel, model),
+	}
+	if ls.workflow != "" {
+		attrs = append(attrs, attribute.String("traceloop.workflow.name", ls.workflow))
+	}
+	if ls.templateID != "" {
+		attrs = append(attrs, attribute.String(AttrTemplateID, ls.templateID))
+	}
+	sdkInstruments().cost.Add(ls.ctx, cost.Total, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,252 @@
+package triage
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// ---------------------------------------------------------------------------
+// Price lookup
+// ---------------------------------------------------------------------------
+
+func TestLookupPrice_ExactAndPrefix(t *testing.T) {
+	table := []ModelPrice{
+		{Model: "gpt-4o", InputPer1K: 1},
+		{Model: "gpt-4o-mini", InputPer1K: 2},
+	}
+	now := time.Now()
+
+	if p, ok := lookupPrice("gpt-4o-mini", "", now, table); !ok || p.InputPer1K != 2 {
+		t.Errorf("exact match: got %+v (ok=%v)", p, ok)
+	}
+	if p, ok := lookupPrice("gpt-4o-mini-2024-07-18", "", now, table); !ok || p.InputPer1K != 2 {
+		t.Errorf("longest prefix: got %+v (ok=%v)", p, ok)
+	}
+	if p, ok := lookupPrice("gpt-4o-2024-08-06", "", now, table); !ok || p.InputPer1K != 1 {
+		t.Errorf("prefix: got %+v (ok=%v)", p, ok)
+	}
+	if _, ok := lookupPrice("llama-3", "", now, table); ok {
+		t.Error("expected no price for unknown model")
+	}
+	for _, model := range []string{"gpt-4o-nano", "gpt-4o-mini-tts", "gpt-4oo"} {
+		if p, ok := lookupPrice(model, "", now, table); ok {
+			t.Errorf("%s: a prefix without a version suffix should not match, got %+v", model, p)
+		}
+	}
+	for _, model := range []string{"gpt-4o-20241120", "gpt-4o@20241120", "gpt-4o-latest", "gpt-4o-0613", "gpt-4o-v1:0"} {
+		if _, ok := lookupPrice(model, "", now, table); !ok {
+			t.Errorf("%s: expected the version suffix to match", model)
+		}
+	}
+}
+
+func TestLookupPrice_DateRange(t *testing.T) {
+	cutover := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	table := []ModelPrice{
+		{Model: "gpt-4o", InputPer1K: 5, ValidUntil: cutover},
+		{Model: "gpt-4o", InputPer1K: 2.5, ValidFrom: cutover},
+	}
+
+	if p, _ := lookupPrice("gpt-4o", "", cutover.Add(-time.Hour), table); p.InputPer1K != 5 {
+		t.Errorf("before cutover: got %v, want 5", p.InputPer1K)
+	}
+	if p, _ := lookupPrice("gpt-4o", "", cutover, table); p.InputPer1K != 2.5 {
+		t.Errorf("at cutover: got %v, want 2.5", p.InputPer1K)
+	}
+}
+
+func TestLookupPrice_OverridesWin(t *testing.T) {
+	overrides := []ModelPrice{{Model: "gpt-4o", InputPer1K: 0.001}}
+	if p, _ := lookupPrice("gpt-4o-2024-08-06", "", time.Now(), overrides, defaultPricing); p.InputPer1K != 0.001 {
+		t.Errorf("got %v, want override rate 0.001", p.InputPer1K)
+	}
+}
+
+func TestLookupPrice_ServiceTier(t *testing.T) {
+	table := []ModelPrice{
+		{Model: "gpt-4o", InputPer1K: 2.5},
+		{Model: "gpt-4o", ServiceTier: "flex", InputPer1K: 1.25},
+	}
+
+	if p, _ := lookupPrice("gpt-4o", "flex", time.Now(), table); p.InputPer1K != 1.25 {
+		t.Errorf("flex: got %v, want 1.25", p.InputPer1K)
+	}
+	if p, _ := lookupPrice("gpt-4o", "default", time.Now(), table); p.InputPer1K != 2.5 {
+		t.Errorf("default tier: got %v, want 2.5", p.InputPer1K)
+	}
+	if p, _ := lookupPrice("gpt-4o", "", time.Now(), table); p.InputPer1K != 2.5 {
+		t.Errorf("no tier: got %v, want 2.5", p.InputPer1K)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Configuration
+// ---------------------------------------------------------------------------
+
+func TestPricingFile_LoadedAfterExplicitPrices(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	data := `[{"model": "acme-llm", "input_per_1k": 0.5, "output_per_1k": 1},
+	          {"model": "gpt-4o", "input_per_1k": 9, "output_per_1k": 9},
+	          {"model": "gpt-4o-2024-08-06", "input_per_1k": 8, "output_per_1k": 8}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvPricingFile, path)
+
+	cfg, err := resolveConfig(WithAPIKey("k"), WithPricing(ModelPrice{Model: "gpt-4o", InputPer1K: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := lookupPrice("acme-llm", "", time.Now(), cfg.pricing, cfg.filePricing); p.InputPer1K != 0.5 {
+		t.Errorf("file price: got %v, want 0.5", p.InputPer1K)
+	}
+	if p, _ := lookupPrice("gpt-4o", "", time.Now(), cfg.pricing, cfg.filePricing); p.InputPer1K != 1 {
+		t.Errorf("explicit price should win over file: got %v, want 1", p.InputPer1K)
+	}
+	// A longer file entry doesn't win over a matching WithPricing entry.
+	if p, _ := lookupPrice("gpt-4o-2024-08-06", "", time.Now(), cfg.pricing, cfg.filePricing); p.InputPer1K != 1 {
+		t.Errorf("explicit price should win over a longer file match: got %v, want 1", p.InputPer1K)
+	}
+}
+
+func TestPricingFile_InvalidReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pricing.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolveConfig(WithAPIKey("k"), WithPricingFile(path)); err == nil {
+		t.Error("expected error for invalid pricing file")
+	}
+	if _, err := resolveConfig(WithAPIKey("k"), WithPricingFile(filepath.Join(t.TempDir(), "missing.json"))); err == nil {
+		t.Error("expected error for missing pricing file")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Span attributes
+// ---------------------------------------------------------------------------
+
+func TestLogCompletion_RecordsCost(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, pricing: []ModelPrice{{Model: "acme-llm", InputPer1K: 1, OutputPer1K: 2}}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "acme", Model: "acme-llm"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 500, CompletionTokens: 250})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if !approxEqual(attrs[AttrCostInput].(float64), 0.5) {
+		t.Errorf("input cost: got %v, want 0.5", attrs[AttrCostInput])
+	}
+	if !approxEqual(attrs[AttrCostOutput].(float64), 0.5) {
+		t.Errorf("output cost: got %v, want 0.5", attrs[AttrCostOutput])
+	}
+	if !approxEqual(attrs[AttrCostTotal].(float64), 1) {
+		t.Errorf("total cost: got %v, want 1", attrs[AttrCostTotal])
+	}
+}
+
+func TestLogCompletion_CostUsesResponseServiceTier(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, pricing: []ModelPrice{
+		{Model: "acme-llm", InputPer1K: 2},
+		{Model: "acme-llm", ServiceTier: "flex", InputPer1K: 1},
+	}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "acme", Model: "acme-llm", ServiceTier: "auto"})
+	llmSpan.LogCompletion(Completion{ServiceTier: "flex"}, Usage{PromptTokens: 1000})
+
+	if got := attrMap(exporter.GetSpans()[0].Attributes)[AttrCostTotal]; got != 1.0 {
+		t.Errorf("total cost: got %v, want 1 (flex rate)", got)
+	}
+}
+
+func TestLogCompletion_NoCostForUnknownModel(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "local", Model: "my-finetune"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrCostTotal]; ok {
+		t.Error("expected no cost attribute for an unpriced model")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Cost attribution
+// ---------------------------------------------------------------------------
+
+func TestLogCompletion_CostMetricByWorkflowAndTemplate(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	globalCfg = &config{traceContent: true, pricing: []ModelPrice{{Model: "acme-llm", InputPer1K: 1}}}
+
+	wf, ctx := StartWorkflow(context.Background(), "summarize-ticket")
+	ctx = WithTemplate(ctx, "ticket-summary")
+	for range 2 {
+		llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "acme", Model: "acme-llm"})
+		llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 500})
+	}
+	wf.End()
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	var sum metricdata.Sum[float64]
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == costMetricName {
+				sum, _ = m.Data.(metricdata.Sum[float64])
+			}
+		}
+	}
+	if len(sum.DataPoints) != 1 {
+		t.Fatalf("expected 1 %s data point, got %+v", costMetricName, sum.DataPoints)
+	}
+	dp := sum.DataPoints[0]
+	if !approxEqual(dp.Value, 1) {
+		t.Errorf("cost: got %v, want 1", dp.Value)
+	}
+	for key, want := range map[string]string{
+		"traceloop.workflow.name": "summarize-ticket",
+		AttrTemplateID:            "ticket-summary",
+		AttrGenAIRequestModel:     "acme-llm",
+	} {
+		if v, ok := dp.Attributes.Value(attribute.Key(key)); !ok || v.AsString() != want {
+			t.Errorf("label %s: got %v, want %s", key, v.AsString(), want)
+		}
+	}
+
+	// The LLM spans carry the same dimensions as attributes.
+	llmSpans := 0
+	for _, s := range exporter.GetSpans() {
+		if s.Name != "acme.chat acme-llm" {
+			continue
+		}
+		llmSpans++
+		attrs := attrMap(s.Attributes)
+		if attrs["traceloop.workflow.name"] != "summarize-ticket" || attrs[AttrTemplateID] != "ticket-summary" {
+			t.Errorf("span dimensions: got %v / %v", attrs["traceloop.workflow.name"], attrs[AttrTemplateID])
+		}
+	}
+	if llmSpans != 2 {
+		t.Errorf("expected 2 LLM spans, got %d", llmSpans)
+	}
+}
@@ -0,0 +1,210 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	// datasetQueueSize bounds the number of records waiting to be uploaded.
+	// When full, new records are dropped rather than blocking the caller.
+	datasetQueueSize = 256
+
+	// datasetUploadTimeout bounds a single record upload.
+	datasetUploadTimeout = 10 * time.Second
+
+	// datasetRedactionField names dataset capture in the redaction audit
+	// attributes of the LLM span: triage.redaction.dataset.*.
+	datasetRedactionField = "dataset"
+)
+
+// DatasetRecord is one prompt/completion pair mirrored into a dataset.
+type DatasetRecord struct {
+	TraceID    string           `json:"trace_id,omitempty"`
+	SpanID     string           `json:"span_id,omitempty"`
+	Vendor     string           `json:"vendor,omitempty"`
+	Model      string           `json:"model,omitempty"`
+	Input      []DatasetMessage `json:"input"`
+	Output     []DatasetMessage `json:"output"`
+	CapturedAt time.Time        `json:"captured_at"`
+
+	// Redactions counts the PII redacted from Input and Output by detector
+	// (e.g. "email"). The redacted values are never recorded.
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// DatasetMessage is the dataset wire form of a Message.
+type DatasetMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content,omitempty"`
+}
+
+// AddDatasetRecord appends a record to the named dataset on the backend.
+func (c *Client) AddDatasetRecord(ctx context.Context, dataset string, rec DatasetRecord) error {
+	if dataset == "" {
+		return errors.New("triage: AddDatasetRecord requires a dataset name")
+	}
+	return c.do(ctx, http.MethodPost, "/v1/datasets/"+url.PathEscape(dataset)+"/records", rec, nil)
+}
+
+// datasetCapture names the dataset LLM calls are mirrored into and the
+// fraction of calls to capture.
+type datasetCapture struct {
+	name       string
+	sampleRate float64
+}
+
+// datasetCaptureKey is an unexported context key for per-workflow dataset
+// capture settings.
+type datasetCaptureKey struct{}
+
+// CaptureDataset is a WorkflowOption that mirrors prompts/completions of all
+// LLM calls inside the workflow into the named dataset, sampling sampleRate
+// (0..1) of them. It overrides the global WithDatasetCapture setting:
+//
+//	wf, ctx := triage.StartWorkflow(ctx, "summarize", triage.CaptureDataset("summaries", 0.05))
+func CaptureDataset(name string, sampleRate float64) WorkflowOption {
+	return func(wc *workflowConfig) {
+		wc.dataset = &datasetCapture{name: name, sampleRate: sampleRate}
+	}
+}
+
+// datasetCaptureFor resolves the dataset capture settings for an LLM call —
+// per-workflow settings in ctx win over the global config — and applies
+// sampling. Returns nil if the call should not be captured.
+//
+// Dataset capture never overrides WithTraceContent(false): if content capture
+// is disabled, nothing is mirrored.
+func datasetCaptureFor(ctx context.Context) *datasetCapture {
+	if datasets == nil || !isTraceContentEnabled() {
+		return nil
+	}
+	dc, ok := ctx.Value(datasetCaptureKey{}).(*datasetCapture)
+	if !ok {
+		if globalCfg == nil || globalCfg.dataset == nil {
+			return nil
+		}
+		dc = globalCfg.dataset
+	}
+	if dc.name == "" || dc.sampleRate <= 0 {
+		return nil
+	}
+	if dc.sampleRate < 1 && rand.Float64() >= dc.sampleRate {
+		return nil
+	}
+	return dc
+}
+
+// newDatasetRecord builds a redacted dataset record from an LLM call, and
+// reports what was redacted.
+func newDatasetRecord(ls *LLMSpan, completion Completion) (DatasetRecord, []redactionMatch) {
+	sc := ls.span.SpanContext()
+	rec := DatasetRecord{
+		TraceID:    sc.TraceID().String(),
+		SpanID:     sc.SpanID().String(),
+		Vendor:     ls.vendor,
+		Model:      ls.model,
+		CapturedAt: time.Now().UTC(),
+	}
+	if completion.Model != "" {
+		rec.Model = completion.Model
+	}
+	var redacted []redactionMatch
+	for _, m := range ls.messages {
+		rec.Input = append(rec.Input, DatasetMessage{Role: m.Role, Content: redactPIIInto(m.Content, &redacted)})
+	}
+	for _, m := range completion.Messages {
+		rec.Output = append(rec.Output, DatasetMessage{Role: m.Role, Content: redactPIIInto(m.Content, &redacted)})
+	}
+	rec.Redactions = redactionCounts(redacted)
+	return rec, redacted
+}
+
+// datasetSink uploads dataset records to the backend from a background
+// goroutine so the caller's LLM path never waits on the network. The
+// goroutine is started by the first record, so apps that never capture a
+// dataset don't run it.
+type datasetSink struct {
+	client  *Client
+	records chan datasetItem
+	done    chan struct{}
+
+	mu      sync.Mutex // guards started, closed and sends on records
+	started bool
+	closed  bool
+}
+
+type datasetItem struct {
+	dataset string
+	record  DatasetRecord
+}
+
+// newDatasetSink returns a sink uploading with client.
+func newDatasetSink(client *Client) *datasetSink {
+	return &datasetSink{
+		client:  client,
+		records: make(chan datasetItem, datasetQueueSize),
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *datasetSink) run() {
+	defer close(s.done)
+	for item := range s.records {
+		guard("dataset upload", func() { s.upload(item) })
+	}
+}
+
+func (s *datasetSink) upload(item datasetItem) {
+	ctx, cancel := context.WithTimeout(context.Background(), datasetUploadTimeout)
+	defer cancel()
+	if err := s.client.AddDatasetRecord(ctx, item.dataset, item.record); err != nil {
+		s.client.logger.Warn("triage: dataset record upload failed", "dataset", item.dataset, "error", err)
+	}
+}
+
+// enqueue queues a record for upload, dropping it if the queue is full.
+func (s *datasetSink) enqueue(dataset string, rec DatasetRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if !s.started {
+		s.started = true
+		go s.run()
+	}
+	select {
+	case s.records <- datasetItem{dataset: dataset, record: rec}:
+	default:
+		s.client.logger.Warn("triage: dataset capture queue full — dropping record", "dataset", dataset)
+	}
+}
+
+// shutdown stops accepting records and waits for queued uploads to finish or
+// ctx to expire.
+func (s *datasetSink) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.records)
+	}
+	started := s.started
+	s.mu.Unlock()
+	if !started {
+		return nil
+	}
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("triage: dataset capture flush: %w", ctx.Err())
+	}
+}
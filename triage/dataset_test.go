@@ -0,0 +1,192 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// datasetBackend is a fake backend that records dataset uploads by path.
+type datasetBackend struct {
+	mu      sync.Mutex
+	records map[string][]DatasetRecord
+}
+
+// newDatasetTestSink points the global dataset sink at a fake backend and
+// returns the backend for assertions. Callers flush with datasets.shutdown.
+func newDatasetTestSink(t *testing.T, cfg *config) *datasetBackend {
+	t.Helper()
+	backend := &datasetBackend{records: map[string][]DatasetRecord{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rec DatasetRecord
+		_ = json.NewDecoder(r.Body).Decode(&rec)
+		backend.mu.Lock()
+		backend.records[r.URL.Path] = append(backend.records[r.URL.Path], rec)
+		backend.mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.apiKey = "tsk_test"
+	cfg.endpoint = srv.URL
+	globalCfg = cfg
+	datasets = newDatasetSink(newClientFromConfig(cfg))
+	t.Cleanup(func() { datasets = nil })
+	return backend
+}
+
+func (b *datasetBackend) get(path string) []DatasetRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.records[path]
+}
+
+func logTestCall(ctx context.Context, input, output string) {
+	llmSpan, _ := LogPrompt(ctx, Prompt{
+		Vendor:   "openai",
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: input}},
+	})
+	llmSpan.LogCompletion(Completion{
+		Model:    "gpt-4o-2024-08-06",
+		Messages: []Message{{Role: "assistant", Content: output}},
+	}, Usage{})
+}
+
+// ---------------------------------------------------------------------------
+// Global capture
+// ---------------------------------------------------------------------------
+
+func TestDatasetCapture_GlobalMirrorsRedactedRecord(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	backend := newDatasetTestSink(t, &config{
+		traceContent: true,
+		dataset:      &datasetCapture{name: "prod-chat", sampleRate: 1},
+	})
+
+	logTestCall(context.Background(), "my email is jane@example.com", "noted")
+	if err := datasets.shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := backend.get("/v1/datasets/prod-chat/records")
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record, got %d", len(recs))
+	}
+	rec := recs[0]
+	if rec.Input[0].Content != "my email is [REDACTED_EMAIL]" {
+		t.Errorf("input not redacted: %q", rec.Input[0].Content)
+	}
+	if rec.Output[0].Content != "noted" {
+		t.Errorf("output: got %q, want %q", rec.Output[0].Content, "noted")
+	}
+	if rec.Model != "gpt-4o-2024-08-06" || rec.Vendor != "openai" {
+		t.Errorf("model/vendor: got %q/%q", rec.Model, rec.Vendor)
+	}
+	if rec.TraceID == "" || rec.SpanID == "" {
+		t.Error("expected trace and span IDs on the record")
+	}
+	if !reflect.DeepEqual(rec.Redactions, map[string]int{"email": 1}) {
+		t.Errorf("redactions: got %v", rec.Redactions)
+	}
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrRedactionApplied] != true || attrs["triage.redaction.dataset.count"] != int64(1) {
+		t.Errorf("expected the dataset redaction on the span, got %v", attrs)
+	}
+	if _, ok := attrs["triage.redaction.dataset.positions"]; ok {
+		t.Error("dataset redactions should not record positions")
+	}
+}
+
+func TestDatasetCapture_ZeroSampleRateCapturesNothing(t *testing.T) {
+	newGlobalTestProvider(t)
+	backend := newDatasetTestSink(t, &config{
+		traceContent: true,
+		dataset:      &datasetCapture{name: "prod-chat", sampleRate: 0},
+	})
+
+	logTestCall(context.Background(), "hello", "hi")
+	_ = datasets.shutdown(context.Background())
+
+	if n := len(backend.get("/v1/datasets/prod-chat/records")); n != 0 {
+		t.Errorf("expected no records, got %d", n)
+	}
+}
+
+func TestDatasetCapture_DisabledWithoutTraceContent(t *testing.T) {
+	newGlobalTestProvider(t)
+	backend := newDatasetTestSink(t, &config{
+		traceContent: false,
+		dataset:      &datasetCapture{name: "prod-chat", sampleRate: 1},
+	})
+
+	logTestCall(context.Background(), "hello", "hi")
+	_ = datasets.shutdown(context.Background())
+
+	if n := len(backend.get("/v1/datasets/prod-chat/records")); n != 0 {
+		t.Errorf("expected no records when trace content is disabled, got %d", n)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Per-workflow capture
+// ---------------------------------------------------------------------------
+
+func TestDatasetCapture_WorkflowOptionOverridesGlobal(t *testing.T) {
+	newGlobalTestProvider(t)
+	backend := newDatasetTestSink(t, &config{
+		traceContent: true,
+		dataset:      &datasetCapture{name: "prod-chat", sampleRate: 1},
+	})
+
+	wf, ctx := StartWorkflow(context.Background(), "summarize", CaptureDataset("summaries", 1))
+	logTestCall(ctx, "summarize this", "summary")
+	wf.End()
+	_ = datasets.shutdown(context.Background())
+
+	if n := len(backend.get("/v1/datasets/summaries/records")); n != 1 {
+		t.Errorf("expected 1 record in workflow dataset, got %d", n)
+	}
+	if n := len(backend.get("/v1/datasets/prod-chat/records")); n != 0 {
+		t.Errorf("expected no records in global dataset, got %d", n)
+	}
+}
+
+func TestDatasetCapture_WorkflowOptionWithoutGlobal(t *testing.T) {
+	newGlobalTestProvider(t)
+	backend := newDatasetTestSink(t, &config{traceContent: true})
+
+	logTestCall(context.Background(), "outside", "workflow")
+	wf, ctx := StartWorkflow(context.Background(), "summarize", CaptureDataset("summaries", 1))
+	logTestCall(ctx, "inside", "workflow")
+	wf.End()
+	_ = datasets.shutdown(context.Background())
+
+	recs := backend.get("/v1/datasets/summaries/records")
+	if len(recs) != 1 || recs[0].Input[0].Content != "inside" {
+		t.Errorf("expected only the in-workflow call to be captured, got %+v", recs)
+	}
+}
+
+func TestDatasetSink_EnqueueAfterShutdownIsNoop(t *testing.T) {
+	sink := newDatasetSink(newClientFromConfig(&config{apiKey: "k", endpoint: "http://127.0.0.1:0"}))
+	_ = sink.shutdown(context.Background())
+
+	// Should not panic.
+	sink.enqueue("ds", DatasetRecord{})
+}
+
+func TestDatasetSink_StartsOnFirstRecord(t *testing.T) {
+	sink := newDatasetSink(newClientFromConfig(&config{apiKey: "k", endpoint: "http://127.0.0.1:0"}))
+	if sink.started {
+		t.Error("the upload goroutine should not run before a record is captured")
+	}
+	if err := sink.shutdown(context.Background()); err != nil {
+		t.Errorf("shutting down an idle sink: %v", err)
+	}
+}
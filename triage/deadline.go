@@ -0,0 +1,60 @@
+package triage
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spanDeadline is the time budget of a workflow or task span, checked when
+// the span ends.
+type spanDeadline struct {
+	timeout time.Duration // configured timeout; 0 if only the context's deadline applies
+	at      time.Time
+}
+
+// newSpanDeadline resolves a span's deadline from its configured timeout and
+// ctx's deadline, whichever is earlier. timeout <= 0 uses ctx's deadline
+// alone. Returns nil if neither applies.
+func newSpanDeadline(ctx context.Context, timeout time.Duration) *spanDeadline {
+	d := &spanDeadline{}
+	if timeout > 0 {
+		d.timeout = timeout
+		d.at = time.Now().Add(timeout)
+	}
+	if at, ok := ctx.Deadline(); ok && (d.at.IsZero() || at.Before(d.at)) {
+		d.at = at
+	}
+	if d.at.IsZero() {
+		return nil
+	}
+	return d
+}
+
+// attributes returns the span attributes recording the time budget.
+func (d *spanDeadline) attributes() []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String(AttrDeadline, d.at.UTC().Format(time.RFC3339Nano))}
+	if d.timeout > 0 {
+		attrs = append(attrs, attribute.Int64(AttrTimeoutMs, d.timeout.Milliseconds()))
+	}
+	return attrs
+}
+
+// check records a deadline_exceeded event on span if it is ending after its
+// deadline. Safe to call on a nil spanDeadline (no-op).
+func (d *spanDeadline) check(span trace.Span) {
+	if d == nil || span == nil {
+		return
+	}
+	now := time.Now()
+	if !now.After(d.at) {
+		return
+	}
+	overrun := now.Sub(d.at).Milliseconds()
+	span.AddEvent(deadlineExceededEventName, trace.WithTimestamp(now), trace.WithAttributes(
+		attribute.Int64(AttrDeadlineOverrunMs, overrun),
+	))
+	span.SetAttributes(attribute.Bool(AttrDeadlineExceeded, true))
+}
@@ -0,0 +1,153 @@
+package triage
+
+import (
+	"strings"
+	"sync"
+)
+
+// Delta is one chunk of a streamed LLM response, as passed to AppendDelta.
+// String fields other than Content, Reasoning and tool call names and
+// arguments replace earlier values when set; the fragments are
+// concatenated.
+type Delta struct {
+	Role         string          // Message role, usually sent by the first chunk only
+	Content      string          // Text fragment
+	Reasoning    string          // Reasoning ("thinking") fragment
+	ToolCalls    []ToolCallDelta // Tool call fragments
+	Model        string          // Model that generated the response
+	ServiceTier  string          // Tier that processed the request
+	FinishReason string          // Stop reason, usually sent by the last chunk only
+	Usage        *Usage          // Token usage, usually sent by the last chunk only
+}
+
+// ToolCallDelta is a fragment of a streamed tool call. Fragments with the
+// same Index belong to the same call; their Name and Arguments are
+// concatenated, while ID and Type replace earlier values when set. Fragments
+// with an Index outside 0..127 are ignored.
+type ToolCallDelta struct {
+	Index     int    // Position of the call in the message
+	ID        string // Call ID
+	Type      string // "function"
+	Name      string // Function name fragment
+	Arguments string // JSON argument fragment
+}
+
+// deltaState accumulates the chunks recorded by AppendDelta.
+type deltaState struct {
+	mu         sync.Mutex
+	appended   bool
+	completion Completion
+	role       string
+	content    strings.Builder
+	reasoning  strings.Builder
+	toolCalls  []ToolCall
+	usage      Usage
+}
+
+// AppendDelta records one chunk of a streamed response, so streaming callers
+// need not reassemble the response themselves:
+//
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//	defer llmSpan.End()
+//	for chunk := range stream {
+//	    llmSpan.AppendDelta(triage.Delta{Content: chunk.Text})
+//	}
+//
+// End, or LogCompletion, records the accumulated content, reasoning and tool
+// calls as the completion message, with the last model, finish reason and
+// usage reported. Each call also marks a chunk as RecordChunk does, so don't
+// call both for the same chunk, and the first delta carrying output marks the
+// first token (see MarkFirstToken). Calls after the span has ended are no-ops.
+// Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) AppendDelta(d Delta) {
+	if ls == nil || ls.span == nil || ls.ended.Load() {
+		return
+	}
+	ls.recordStreamChunk(d.Content != "" || d.Reasoning != "" || len(d.ToolCalls) > 0)
+	ls.deltas.add(d)
+}
+
+func (s *deltaState) add(d Delta) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appended = true
+	if d.Role != "" {
+		s.role = d.Role
+	}
+	s.content.WriteString(d.Content)
+	s.reasoning.WriteString(d.Reasoning)
+	for _, tc := range d.ToolCalls {
+		if !validStreamIndex(tc.Index) {
+			continue
+		}
+		for len(s.toolCalls) <= tc.Index {
+			s.toolCalls = append(s.toolCalls, ToolCall{})
+		}
+		call := &s.toolCalls[tc.Index]
+		if tc.ID != "" {
+			call.ID = tc.ID
+		}
+		if tc.Type != "" {
+			call.Type = tc.Type
+		}
+		call.Function.Name += tc.Name
+		call.Function.Arguments += tc.Arguments
+	}
+	if d.Model != "" {
+		s.completion.Model = d.Model
+	}
+	if d.ServiceTier != "" {
+		s.completion.ServiceTier = d.ServiceTier
+	}
+	if d.FinishReason != "" {
+		s.completion.FinishReason = d.FinishReason
+	}
+	if d.Usage != nil {
+		s.usage = *d.Usage
+	}
+}
+
+// merge fills the parts of completion and usage left empty by the caller
+// from the accumulated deltas. It returns them unchanged if AppendDelta was
+// never called.
+func (s *deltaState) merge(completion Completion, usage Usage) (Completion, Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.appended {
+		return completion, usage
+	}
+	if len(completion.Messages) == 0 {
+		m := Message{
+			Role:      s.role,
+			Content:   s.content.String(),
+			Reasoning: s.reasoning.String(),
+			ToolCalls: s.toolCalls,
+		}
+		if m.Role == "" {
+			m.Role = "assistant"
+		}
+		if !m.empty() {
+			completion.Messages = []Message{m}
+		}
+	}
+	if completion.Model == "" {
+		completion.Model = s.completion.Model
+	}
+	if completion.ServiceTier == "" {
+		completion.ServiceTier = s.completion.ServiceTier
+	}
+	if completion.FinishReason == "" {
+		completion.FinishReason = s.completion.FinishReason
+	}
+	if usage == (Usage{}) {
+		usage = s.usage
+	}
+	return completion, usage
+}
+
+// hasDeltas reports whether AppendDelta was called.
+func (s *deltaState) hasDeltas() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appended
+}
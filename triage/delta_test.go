@@ -0,0 +1,105 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAppendDelta_MaterializedOnEnd(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	ls.AppendDelta(Delta{Role: "assistant", Content: "It is ", Model: "gpt-4o-2024-08-06"})
+	ls.AppendDelta(Delta{Content: "sunny.", ToolCalls: []ToolCallDelta{{Index: 0, ID: "call_1", Type: "function", Name: "get_", Arguments: `{"city":`}}})
+	ls.AppendDelta(Delta{ToolCalls: []ToolCallDelta{{Index: 0, Name: "weather", Arguments: `"Paris"}`}}, FinishReason: "tool_calls"})
+	ls.AppendDelta(Delta{Usage: &Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}})
+	ls.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	for k, want := range map[string]any{
+		"gen_ai.completion.0.role":                            "assistant",
+		"gen_ai.completion.0.content":                         "It is sunny.",
+		"gen_ai.completion.0.tool_calls.0.id":                 "call_1",
+		"gen_ai.completion.0.tool_calls.0.function.name":      "get_weather",
+		"gen_ai.completion.0.tool_calls.0.function.arguments": `{"city":"Paris"}`,
+		"gen_ai.response.model":                               "gpt-4o-2024-08-06",
+		AttrGenAIResponseFinishReason:                         "tool_calls",
+		"llm.usage.total_tokens":                              int64(15),
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], want)
+		}
+	}
+	if _, ok := attrs[AttrStreamMaxChunkGapMs]; !ok {
+		t.Error("deltas should be recorded as chunks")
+	}
+}
+
+func TestAppendDelta_LogCompletionFillsGaps(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	ls.AppendDelta(Delta{Content: "Hello"})
+	ls.LogCompletion(Completion{FinishReason: "stop"}, Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4})
+	ls.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hello" || attrs[AttrGenAIResponseFinishReason] != "stop" {
+		t.Errorf("content/finish: got %v/%v", attrs["gen_ai.completion.0.content"], attrs[AttrGenAIResponseFinishReason])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(4) {
+		t.Errorf("the caller's usage should be kept, got %v", attrs["llm.usage.total_tokens"])
+	}
+}
+
+func TestAppendDelta_ExplicitMessagesWin(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	ls.AppendDelta(Delta{Content: "partial"})
+	ls.LogCompletion(Completion{Messages: []Message{{Role: "assistant", Content: "final"}}}, Usage{})
+
+	if got := attrMap(exporter.GetSpans()[0].Attributes)["gen_ai.completion.0.content"]; got != "final" {
+		t.Errorf("content: got %v", got)
+	}
+}
+
+func TestAppendDelta_AfterEndIsNoop(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	ls.End()
+	ls.AppendDelta(Delta{Content: "late"})
+	ls.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if _, ok := attrMap(spans[0].Attributes)["gen_ai.completion.0.content"]; ok {
+		t.Error("a delta appended after End should not be recorded")
+	}
+}
+
+func TestAppendDelta_IgnoresOutOfRangeToolCallIndex(t *testing.T) {
+	var s deltaState
+	s.add(Delta{ToolCalls: []ToolCallDelta{{Index: -1, Name: "x"}, {Index: 1 << 30, Name: "y"}, {Index: 0, Name: "lookup"}}})
+
+	c, _ := s.merge(Completion{}, Usage{})
+	if len(c.Messages) != 1 || len(c.Messages[0].ToolCalls) != 1 || c.Messages[0].ToolCalls[0].Function.Name != "lookup" {
+		t.Errorf("tool calls: got %+v", c.Messages)
+	}
+}
+
+func TestAppendDelta_NilSpanIsNoop(t *testing.T) {
+	var ls *LLMSpan
+	ls.AppendDelta(Delta{Content: "x"}) // must not panic
+}
@@ -0,0 +1,65 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Document is a retrieved document (or chunk) passed to the model as
+// context in a RAG pipeline.
+type Document struct {
+	ID        string   `json:"id"`
+	SourceURI string   `json:"source_uri,omitempty"` // where the document came from, e.g. "s3://kb/faq.md"
+	Hash      string   `json:"hash,omitempty"`       // content hash, to detect changed or poisoned documents
+	Score     float64  `json:"score,omitempty"`      // retriever relevance score
+	ACL       []string `json:"acl,omitempty"`        // principals allowed to read the document
+}
+
+// retrievedDocumentsKey is an unexported context key for the attributes of
+// the documents recorded by WithRetrievedDocuments.
+type retrievedDocumentsKey struct{}
+
+// WithRetrievedDocuments records the documents a retrieval step returned on
+// the current span (typically the retrieval task or tool span) and on every
+// LLM span started from the returned context, so "which documents influenced
+// this answer" is a single query on the LLM span:
+//
+//	docs := retrieve(ctx, query)
+//	ctx = triage.WithRetrievedDocuments(ctx, docs)
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//
+// Documents are recorded as triage.retrieval.document_ids plus the full
+// JSON list in triage.retrieval.documents. They carry no content, so they
+// are recorded even when trace content is disabled.
+func WithRetrievedDocuments(ctx context.Context, docs []Document) context.Context {
+	attrs := retrievedDocumentAttributes(docs)
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attrs...)
+	}
+	return context.WithValue(ctx, retrievedDocumentsKey{}, attrs)
+}
+
+// retrievedDocumentAttributes returns the span attributes for docs.
+func retrievedDocumentAttributes(docs []Document) []attribute.KeyValue {
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrRetrievalDocumentCount, len(docs)),
+		attribute.StringSlice(AttrRetrievalDocumentIDs, ids),
+	}
+	if data, err := safeMarshal("WithRetrievedDocuments", docs); err == nil {
+		attrs = append(attrs, attribute.String(AttrRetrievalDocuments, string(data)))
+	}
+	return attrs
+}
+
+// retrievedDocumentsFromContext returns the attributes recorded by
+// WithRetrievedDocuments, if any.
+func retrievedDocumentsFromContext(ctx context.Context) []attribute.KeyValue {
+	attrs, _ := ctx.Value(retrievedDocumentsKey{}).([]attribute.KeyValue)
+	return attrs
+}
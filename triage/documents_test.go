@@ -0,0 +1,55 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestWithRetrievedDocuments_RetrievalAndLLMSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	docs := []Document{
+		{ID: "faq-12", SourceURI: "s3://kb/faq.md", Hash: "abc123", Score: 0.91, ACL: []string{"group:support"}},
+		{ID: "policy-3", Score: 0.42},
+	}
+	tool, ctx := StartTool(context.Background(), "vector-search")
+	ctx = WithRetrievedDocuments(ctx, docs)
+	tool.End()
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	for _, s := range spans {
+		attrs := attrMap(s.Attributes)
+		if attrs[AttrRetrievalDocumentCount] != int64(2) {
+			t.Errorf("%s: document_count: got %v", s.Name, attrs[AttrRetrievalDocumentCount])
+		}
+		if !reflect.DeepEqual(attrs[AttrRetrievalDocumentIDs], []string{"faq-12", "policy-3"}) {
+			t.Errorf("%s: document_ids: got %v", s.Name, attrs[AttrRetrievalDocumentIDs])
+		}
+		var got []Document
+		if err := json.Unmarshal([]byte(attrs[AttrRetrievalDocuments].(string)), &got); err != nil {
+			t.Fatalf("%s: documents: %v", s.Name, err)
+		}
+		if !reflect.DeepEqual(got, docs) {
+			t.Errorf("%s: documents: got %+v", s.Name, got)
+		}
+	}
+}
+
+func TestWithRetrievedDocuments_NotOnOtherSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx := WithRetrievedDocuments(context.Background(), []Document{{ID: "d1"}})
+	task, _ := StartTask(ctx, "post-process")
+	task.End()
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrRetrievalDocumentIDs]; ok {
+		t.Error("documents should only be recorded on the retrieval and LLM spans")
+	}
+}
@@ -0,0 +1,173 @@
+package triage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// defaultTracerProvider is the global provider before anything calls
+// otel.SetTracerProvider. It discards spans.
+var defaultTracerProvider = otel.GetTracerProvider()
+
+// early holds spans created through the SDK before Init, when enabled with
+// BufferEarlySpans.
+var early struct {
+	tp  atomic.Pointer[sdktrace.TracerProvider] // nil unless buffering
+	mu  sync.Mutex                              // guards buf
+	buf *earlySpanBuffer
+}
+
+// BufferEarlySpans keeps up to n spans created through the SDK (LLM calls,
+// workflows, tools, ...) in memory while Init has not run and no other
+// OpenTelemetry tracer provider is registered, and exports them once Init
+// runs. Without it, telemetry from instrumentation that runs before main's
+// Init is discarded. Spans beyond n are dropped.
+//
+// Call it before any instrumented code runs, e.g. from an init function:
+//
+//	func init() { triage.BufferEarlySpans(256) }
+//
+// Buffered spans are exported with the resource (service name, environment)
+// configured by Init. They are discarded if Init disables the SDK. Since
+// whether Init enables trace content isn't known yet, spans recorded while
+// buffering don't capture prompt, completion or other content. Calling
+// BufferEarlySpans with n <= 0 stops buffering and discards what was kept.
+func BufferEarlySpans(n int) {
+	early.mu.Lock()
+	defer early.mu.Unlock()
+	if early.buf != nil {
+		early.buf.discard()
+	}
+	early.tp.Store(nil)
+	early.buf = nil
+	if n <= 0 {
+		return
+	}
+	early.buf = &earlySpanBuffer{limit: n}
+	early.tp.Store(sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSpanProcessor(early.buf),
+	))
+}
+
+// sdkTracer returns the tracer the SDK creates its spans with: the global
+// provider's, or the early buffering provider's while BufferEarlySpans is in
+// effect and the global provider is a no-op.
+func sdkTracer() trace.Tracer {
+	if tp := earlyTracerProvider(); tp != nil {
+		return tp.Tracer(llmTracerName)
+	}
+	return otel.GetTracerProvider().Tracer(llmTracerName)
+}
+
+// earlyTracerProvider returns the early buffering provider, or nil if spans
+// should go to the global provider. It takes no lock, so once buffering
+// stops it costs SDK spans only an atomic load.
+func earlyTracerProvider() *sdktrace.TracerProvider {
+	tp := early.tp.Load()
+	if tp == nil || !isNoopTracerProvider(otel.GetTracerProvider()) {
+		return nil
+	}
+	return tp
+}
+
+// isNoopTracerProvider reports whether tp discards every span: the unset
+// global provider or one of OpenTelemetry's no-op providers.
+func isNoopTracerProvider(tp trace.TracerProvider) bool {
+	switch tp.(type) {
+	case noop.TracerProvider, *noop.TracerProvider:
+		return true
+	}
+	return tp == defaultTracerProvider || tp == trace.NewNoopTracerProvider()
+}
+
+// flushEarlySpans hands buffered spans to next, the processor created by
+// Init, and stops buffering. Spans started early that end later are
+// forwarded to next as they end. It returns how many spans were flushed and
+// how many were dropped over the limit.
+func flushEarlySpans(next sdktrace.SpanProcessor, res *resource.Resource) (flushed, dropped int) {
+	early.mu.Lock()
+	defer early.mu.Unlock()
+	b := early.buf
+	early.tp.Store(nil)
+	early.buf = nil
+	if b == nil {
+		return 0, 0
+	}
+	return b.flush(next, res)
+}
+
+// discardEarlySpans stops buffering and drops any buffered spans.
+func discardEarlySpans() {
+	BufferEarlySpans(0)
+}
+
+// earlySpanBuffer is the span processor behind BufferEarlySpans. It keeps
+// ended spans until flush, then forwards them to Init's processor.
+type earlySpanBuffer struct {
+	mu      sync.Mutex
+	limit   int
+	spans   []sdktrace.ReadOnlySpan
+	dropped int
+	next    sdktrace.SpanProcessor // set by flush
+	res     *resource.Resource
+	closed  bool // discarded; later spans are dropped
+}
+
+var _ sdktrace.SpanProcessor = (*earlySpanBuffer)(nil)
+
+func (b *earlySpanBuffer) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (b *earlySpanBuffer) OnEnd(s sdktrace.ReadOnlySpan) {
+	defer recoverPanic("early span buffer OnEnd")
+	if !s.SpanContext().IsSampled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.next != nil:
+		b.next.OnEnd(rebasedSpan{ReadOnlySpan: s, res: b.res})
+	case b.closed || len(b.spans) >= b.limit:
+		b.dropped++
+		stats.dropped.Add(1)
+	default:
+		b.spans = append(b.spans, s)
+	}
+}
+
+func (b *earlySpanBuffer) Shutdown(context.Context) error   { return nil }
+func (b *earlySpanBuffer) ForceFlush(context.Context) error { return nil }
+
+func (b *earlySpanBuffer) flush(next sdktrace.SpanProcessor, res *resource.Resource) (flushed, dropped int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, s := range b.spans {
+		next.OnEnd(rebasedSpan{ReadOnlySpan: s, res: res})
+	}
+	flushed, dropped = len(b.spans), b.dropped
+	b.spans, b.next, b.res = nil, next, res
+	return flushed, dropped
+}
+
+func (b *earlySpanBuffer) discard() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats.dropped.Add(int64(len(b.spans)))
+	b.spans, b.closed = nil, true
+}
+
+// rebasedSpan is a span recorded before Init, reported with Init's resource.
+type rebasedSpan struct {
+	sdktrace.ReadOnlySpan
+	res *resource.Resource
+}
+
+func (s rebasedSpan) Resource() *resource.Resource { return s.res }
@@ -0,0 +1,128 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// bufferEarlySpansForTest enables early buffering with the global provider
+// unset, undoing both when the test ends.
+func bufferEarlySpansForTest(t *testing.T, n int) {
+	t.Helper()
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	BufferEarlySpans(n)
+	t.Cleanup(func() { BufferEarlySpans(0) })
+}
+
+func TestBufferEarlySpans_FlushesAtInit(t *testing.T) {
+	bufferEarlySpansForTest(t, 2)
+
+	ctx := WithUser(context.Background(), "u-1")
+	for range 3 {
+		llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(Completion{}, Usage{})
+	}
+
+	exporter := tracetest.NewInMemoryExporter()
+	res := resource.NewSchemaless(attribute.String("service.name", "svc"))
+	flushed, dropped := flushEarlySpans(sdktrace.NewSimpleSpanProcessor(exporter), res)
+	if flushed != 2 || dropped != 1 {
+		t.Fatalf("flushed/dropped: got %d/%d, want 2/1", flushed, dropped)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 exported spans, got %d", len(spans))
+	}
+	for _, s := range spans {
+		if s.Resource != res {
+			t.Errorf("expected Init's resource, got %v", s.Resource)
+		}
+		if got := attrMap(s.Attributes)[AttrUserID]; got != "u-1" {
+			t.Errorf("triage context should be recorded, got %v", got)
+		}
+	}
+}
+
+func TestBufferEarlySpans_ForwardsSpansEndingAfterInit(t *testing.T) {
+	bufferEarlySpansForTest(t, 10)
+
+	wf, _ := StartWorkflow(context.Background(), "startup")
+
+	exporter := tracetest.NewInMemoryExporter()
+	flushEarlySpans(sdktrace.NewSimpleSpanProcessor(exporter), resource.Empty())
+	if len(exporter.GetSpans()) != 0 {
+		t.Fatal("an open span should not be exported at flush")
+	}
+
+	wf.End()
+	if spans := exporter.GetSpans(); len(spans) != 1 || spans[0].Name != "startup" {
+		t.Errorf("expected the workflow span once it ends, got %d spans", len(spans))
+	}
+	if earlyTracerProvider() != nil {
+		t.Error("buffering should stop after flush")
+	}
+}
+
+func TestBufferEarlySpans_DoesNotCaptureContent(t *testing.T) {
+	prev := globalCfg
+	globalCfg = nil
+	t.Cleanup(func() { globalCfg = prev })
+	bufferEarlySpansForTest(t, 10)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor: "openai", Model: "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "my SSN is 123-45-6789"}},
+	})
+	llmSpan.LogCompletion(Completion{Messages: []Message{{Role: "assistant", Content: "noted"}}}, Usage{})
+
+	exporter := tracetest.NewInMemoryExporter()
+	flushEarlySpans(sdktrace.NewSimpleSpanProcessor(exporter), resource.Empty())
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	for _, k := range []string{"gen_ai.prompt.0.content", "gen_ai.completion.0.content"} {
+		if v, ok := attrs[k]; ok {
+			t.Errorf("%s should not be captured before Init, got %v", k, v)
+		}
+	}
+	if !isTraceContentEnabled() {
+		t.Error("content capture should resume once buffering stops")
+	}
+}
+
+func TestBufferEarlySpans_IgnoredWithRegisteredProvider(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	BufferEarlySpans(10)
+	t.Cleanup(func() { BufferEarlySpans(0) })
+
+	task, _ := StartTask(context.Background(), "step")
+	task.End()
+
+	if len(exporter.GetSpans()) != 1 {
+		t.Error("spans should go to the registered provider")
+	}
+	if flushed, _ := flushEarlySpans(sdktrace.NewSimpleSpanProcessor(tracetest.NewInMemoryExporter()), resource.Empty()); flushed != 0 {
+		t.Errorf("nothing should be buffered, got %d", flushed)
+	}
+}
+
+func TestBufferEarlySpans_DisabledByDefault(t *testing.T) {
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	task, _ := StartTask(context.Background(), "step")
+	task.End()
+
+	if task.span.SpanContext().IsValid() {
+		t.Error("without BufferEarlySpans, early spans should be no-ops")
+	}
+}
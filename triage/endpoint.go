@@ -0,0 +1,68 @@
+package triage
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// providerEndpointAttributes records the provider host, port and region that
+// served a call, for data residency audits. The region is inferred from
+// well-known regional hosts (AWS Bedrock, Google Vertex AI) when not given.
+func providerEndpointAttributes(endpoint, region string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	host, port := splitEndpoint(endpoint)
+	if host != "" {
+		attrs = append(attrs, attribute.String(AttrServerAddress, host))
+	}
+	if port > 0 {
+		attrs = append(attrs, attribute.Int(AttrServerPort, port))
+	}
+	if region == "" {
+		region = inferRegion(host)
+	}
+	if region != "" {
+		attrs = append(attrs, attribute.String(AttrProviderRegion, region))
+	}
+	return attrs
+}
+
+// splitEndpoint extracts the host and explicit port from a URL or host[:port].
+func splitEndpoint(endpoint string) (string, int) {
+	if endpoint == "" {
+		return "", 0
+	}
+	hostPort := endpoint
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return "", 0
+		}
+		hostPort = u.Host
+	} else if i := strings.IndexByte(hostPort, '/'); i >= 0 {
+		hostPort = hostPort[:i]
+	}
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return hostPort, 0
+	}
+	port, _ := strconv.Atoi(portStr)
+	return host, port
+}
+
+// inferRegion returns the cloud region encoded in well-known provider hosts:
+// bedrock-runtime.{region}.amazonaws.com and {region}-aiplatform.googleapis.com.
+func inferRegion(host string) string {
+	if rest, ok := strings.CutPrefix(host, "bedrock-runtime."); ok {
+		if region, ok := strings.CutSuffix(rest, ".amazonaws.com"); ok && !strings.Contains(region, ".") {
+			return region
+		}
+	}
+	if region, ok := strings.CutSuffix(host, "-aiplatform.googleapis.com"); ok && !strings.Contains(region, ".") {
+		return region
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSplitEndpoint(t *testing.T) {
+	cases := []struct {
+		in   string
+		host string
+		port int
+	}{
+		{"https://eu.api.openai.com/v1", "eu.api.openai.com", 0},
+		{"http://vllm.internal:8000/v1", "vllm.internal", 8000},
+		{"api.anthropic.com", "api.anthropic.com", 0},
+		{"localhost:11434/api", "localhost", 11434},
+		{"", "", 0},
+	}
+	for _, c := range cases {
+		host, port := splitEndpoint(c.in)
+		if host != c.host || port != c.port {
+			t.Errorf("splitEndpoint(%q): got (%q, %d), want (%q, %d)", c.in, host, port, c.host, c.port)
+		}
+	}
+}
+
+func TestInferRegion(t *testing.T) {
+	cases := map[string]string{
+		"bedrock-runtime.eu-central-1.amazonaws.com": "eu-central-1",
+		"europe-west4-aiplatform.googleapis.com":     "europe-west4",
+		"api.openai.com":                             "",
+	}
+	for host, want := range cases {
+		if got := inferRegion(host); got != want {
+			t.Errorf("inferRegion(%q): got %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestLogPrompt_RecordsProviderEndpoint(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor:   "bedrock",
+		Model:    "anthropic.claude-3-5-sonnet",
+		Endpoint: "https://bedrock-runtime.eu-west-1.amazonaws.com",
+	})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrServerAddress] != "bedrock-runtime.eu-west-1.amazonaws.com" {
+		t.Errorf("server.address: got %v", attrs[AttrServerAddress])
+	}
+	if attrs[AttrProviderRegion] != "eu-west-1" {
+		t.Errorf("region: got %v, want %q", attrs[AttrProviderRegion], "eu-west-1")
+	}
+}
+
+func TestLogPrompt_ExplicitRegionWins(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor:   "azure",
+		Endpoint: "https://my-resource.openai.azure.com",
+		Region:   "swedencentral",
+	})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if got := attrMap(exporter.GetSpans()[0].Attributes)[AttrProviderRegion]; got != "swedencentral" {
+		t.Errorf("region: got %v, want %q", got, "swedencentral")
+	}
+}
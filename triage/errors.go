@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Errors returned (wrapped) by Init, NewClient and Validate. Use errors.Is to
+// branch on the failure cause:
+//
+//	if errors.Is(err, triage.ErrMissingAPIKey) { ... }
+var (
+	ErrMissingAPIKey   = errors.New("triage: API key is required")
+	ErrInvalidEndpoint = errors.New("triage: invalid endpoint")
+	ErrInvalidConfig   = errors.New("triage: invalid configuration")
+	ErrExporterInit    = errors.New("triage: failed to create OTLP exporter")
+)
+
+// ErrInternalPanic is wrapped by errors reported to the error handler (see
+// WithErrorHandler) when the SDK recovers from a panic in its own code.
+var ErrInternalPanic = errors.New("triage: recovered internal panic")
+
+// ValidationSummary is the result of Validate: every configuration problem
+// that would make Init fail, plus warnings about settings that are valid but
+// likely unintended.
+type ValidationSummary struct {
+	Errors   []error
+	Warnings []string
+}
+
+// OK reports whether Init would accept the configuration.
+func (v ValidationSummary) OK() bool {
+	return len(v.Errors) == 0
+}
+
+// Err returns all errors joined, or nil if the configuration is valid.
+func (v ValidationSummary) Err() error {
+	return errors.Join(v.Errors...)
+}
+
+// String renders the summary as one problem per line, for startup logs.
+func (v ValidationSummary) String() string {
+	if v.OK() && len(v.Warnings) == 0 {
+		return "triage: configuration OK"
+	}
+	var b strings.Builder
+	for _, err := range v.Errors {
+		fmt.Fprintf(&b, "error: %v\n", err)
+	}
+	for _, w := range v.Warnings {
+		fmt.Fprintf(&b, "warning: %s\n", w)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Validate resolves the configuration Init would use with opts (including
+// environment variables) and reports every problem at once, without
+// initializing anything:
+//
+//	if s := triage.Validate(opts...); !s.OK() {
+//	    log.Fatal(s)
+//	}
+func Validate(opts ...Option) ValidationSummary {
+	cfg, errs := buildConfig(opts...)
+	s := ValidationSummary{Errors: errs}
+	if !cfg.enabled {
+		s.Warnings = append(s.Warnings, "SDK is disabled; Init will not export any spans")
+	}
+	if cfg.sampleRatio == 0 {
+		s.Warnings = append(s.Warnings, "sample ratio is 0; new traces will not be sampled")
+	}
+	if cfg.insecure && !isLoopbackEndpoint(cfg.endpoint) {
+		s.Warnings = append(s.Warnings, "WithInsecure is set for a non-local endpoint; credentials will be sent in plaintext")
+	}
+	return s
+}
+
+// isLoopbackEndpoint reports whether ep points at localhost or a loopback IP.
+func isLoopbackEndpoint(ep string) bool {
+	u, err := url.Parse(ep)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
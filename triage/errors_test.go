@@ -0,0 +1,76 @@
+package triage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Typed errors
+// ---------------------------------------------------------------------------
+
+func TestInit_MissingAPIKeyIsTyped(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	_, err := Init()
+	if !errors.Is(err, ErrMissingAPIKey) {
+		t.Errorf("expected ErrMissingAPIKey, got %v", err)
+	}
+}
+
+func TestResolveConfig_InvalidEndpointIsTyped(t *testing.T) {
+	for _, ep := range []string{"ftp://example.com", "not a url", "http://[::1"} {
+		_, err := resolveConfig(WithAPIKey("k"), WithEndpoint(ep))
+		if !errors.Is(err, ErrInvalidEndpoint) {
+			t.Errorf("%q: expected ErrInvalidEndpoint, got %v", ep, err)
+		}
+	}
+}
+
+func TestResolveConfig_InvalidEnvIsTyped(t *testing.T) {
+	t.Setenv(EnvSampleRatio, "abc")
+	_, err := resolveConfig(WithAPIKey("k"))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Validation summary
+// ---------------------------------------------------------------------------
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	t.Setenv(EnvMaxQueueSize, "-1")
+	s := Validate(WithEndpoint("ftp://example.com"))
+
+	if s.OK() {
+		t.Fatal("expected validation to fail")
+	}
+	for _, target := range []error{ErrMissingAPIKey, ErrInvalidEndpoint, ErrInvalidConfig} {
+		if !errors.Is(s.Err(), target) {
+			t.Errorf("expected %v in %v", target, s.Err())
+		}
+	}
+	if !strings.Contains(s.String(), "error: ") {
+		t.Errorf("expected errors in summary, got %q", s.String())
+	}
+}
+
+func TestValidate_OKWithWarnings(t *testing.T) {
+	s := Validate(WithAPIKey("k"), WithEndpoint("http://collector.example:4318"), WithInsecure(), WithSampleRatio(0))
+
+	if !s.OK() {
+		t.Fatalf("expected valid config, got %v", s.Err())
+	}
+	if len(s.Warnings) != 2 {
+		t.Errorf("expected 2 warnings, got %v", s.Warnings)
+	}
+}
+
+func TestValidate_LocalInsecureNoWarning(t *testing.T) {
+	s := Validate(WithAPIKey("k"), WithEndpoint("localhost:4318"), WithInsecure())
+	if !s.OK() || len(s.Warnings) != 0 {
+		t.Errorf("expected clean summary, got %s", s)
+	}
+}
@@ -0,0 +1,122 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScoreOption configures optional fields for LogScore.
+type ScoreOption func(*score)
+
+// score holds the optional metadata recorded alongside an evaluation score.
+type score struct {
+	evaluator string
+	reason    string
+}
+
+// ScoreEvaluator records which evaluator produced the score (e.g.
+// "llm-judge-gpt-4o", "perspective-api").
+func ScoreEvaluator(name string) ScoreOption {
+	return func(s *score) { s.evaluator = name }
+}
+
+// ScoreReason records the evaluator's explanation for the score. Only
+// captured when trace content is enabled, since judges often quote the
+// completion.
+func ScoreReason(reason string) ScoreOption {
+	return func(s *score) { s.reason = reason }
+}
+
+// LogScore records an evaluation score (faithfulness, toxicity, relevance,
+// ...) on the current span so inline eval results travel with the trace.
+//
+// The score is written twice: as a triage.score.<name> attribute for direct
+// filtering, and as a "triage.score" event carrying the evaluator and reason.
+// No-op if ctx carries no recording span.
+//
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//	triage.LogScore(ctx, "faithfulness", 0.92, triage.ScoreEvaluator("ragas"))
+func LogScore(ctx context.Context, name string, value float64, opts ...ScoreOption) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || name == "" {
+		return
+	}
+
+	var s score
+	for _, o := range opts {
+		o(&s)
+	}
+
+	eventAttrs := []attribute.KeyValue{
+		attribute.String(AttrScoreName, name),
+		attribute.Float64(AttrScoreValue, value),
+	}
+	if s.evaluator != "" {
+		eventAttrs = append(eventAttrs, attribute.String(AttrScoreEvaluator, s.evaluator))
+	}
+	if s.reason != "" && isTraceContentEnabled() {
+		eventAttrs = append(eventAttrs, attribute.String(AttrScoreReason, s.reason))
+	}
+
+	span.SetAttributes(attribute.Float64(AttrScorePrefix+name, value))
+	span.AddEvent(scoreEventName, trace.WithAttributes(eventAttrs...))
+}
+
+// LogGroundTruth records the known-correct answer for the current LLM call so
+// offline scoring can compute accuracy for flows where the answer is known
+// (classification, extraction). Strings are recorded as-is; any other value
+// is JSON-serialized, with triage.ground_truth.format set to "text" or
+// "json" accordingly.
+//
+// Ground truth is content, so nothing is recorded when trace content is
+// disabled. No-op if ctx carries no recording span.
+//
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//	triage.LogGroundTruth(ctx, map[string]any{"category": "billing"})
+func LogGroundTruth(ctx context.Context, expected any) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || !isTraceContentEnabled() {
+		return
+	}
+
+	if s, ok := expected.(string); ok {
+		span.SetAttributes(
+			attribute.String(AttrGroundTruth, s),
+			attribute.String(AttrGroundTruthFormat, "text"),
+		)
+		return
+	}
+
+	data, err := safeMarshal("LogGroundTruth", expected)
+	if err != nil {
+		// Don't break the user's application for a telemetry failure.
+		return
+	}
+	span.SetAttributes(
+		attribute.String(AttrGroundTruth, string(data)),
+		attribute.String(AttrGroundTruthFormat, "json"),
+	)
+}
+
+// WithEvalRun tags all spans created with the returned context as part of an
+// evaluation run, so eval batch traffic is kept apart from production traffic
+// and grouped per run for side-by-side comparison in the backend:
+//
+//	ctx = triage.WithEvalRun(ctx, "run_2024_06_01", "support-golden-set")
+//	results := triage.Replay(ctx, records, candidateModel)
+func WithEvalRun(ctx context.Context, runID, datasetID string) context.Context {
+	tc := getFromContext(ctx).clone()
+	tc.evalRunID = runID
+	tc.evalDatasetID = datasetID
+
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(attribute.String(AttrEvalRunID, tc.evalRunID))
+		if tc.evalDatasetID != "" {
+			span.SetAttributes(attribute.String(AttrEvalDatasetID, tc.evalDatasetID))
+		}
+	}
+
+	return setInContext(ctx, tc)
+}
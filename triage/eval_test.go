@@ -0,0 +1,175 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// LogScore
+// ---------------------------------------------------------------------------
+
+func TestLogScore_SetsAttributeAndEvent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	LogScore(ctx, "faithfulness", 0.92, ScoreEvaluator("ragas"), ScoreReason("cites sources"))
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	span := exporter.GetSpans()[0]
+	attrs := attrMap(span.Attributes)
+	if attrs["triage.score.faithfulness"] != 0.92 {
+		t.Errorf("score attribute: got %v, want %v", attrs["triage.score.faithfulness"], 0.92)
+	}
+
+	if len(span.Events) != 1 || span.Events[0].Name != scoreEventName {
+		t.Fatalf("expected one %q event, got %+v", scoreEventName, span.Events)
+	}
+	ev := attrMap(span.Events[0].Attributes)
+	if ev[AttrScoreName] != "faithfulness" {
+		t.Errorf("name: got %v, want %q", ev[AttrScoreName], "faithfulness")
+	}
+	if ev[AttrScoreEvaluator] != "ragas" {
+		t.Errorf("evaluator: got %v, want %q", ev[AttrScoreEvaluator], "ragas")
+	}
+	if ev[AttrScoreReason] != "cites sources" {
+		t.Errorf("reason: got %v, want %q", ev[AttrScoreReason], "cites sources")
+	}
+}
+
+func TestLogScore_MultipleScores(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "eval")
+	LogScore(ctx, "toxicity", 0.01)
+	LogScore(ctx, "relevance", 0.8)
+	wf.End()
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs["triage.score.toxicity"] != 0.01 {
+		t.Errorf("toxicity: got %v", attrs["triage.score.toxicity"])
+	}
+	if attrs["triage.score.relevance"] != 0.8 {
+		t.Errorf("relevance: got %v", attrs["triage.score.relevance"])
+	}
+}
+
+func TestLogScore_ReasonOmittedWithoutTraceContent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	wf, ctx := StartWorkflow(context.Background(), "eval")
+	LogScore(ctx, "toxicity", 0.7, ScoreReason("quotes a slur"))
+	wf.End()
+
+	ev := attrMap(exporter.GetSpans()[0].Events[0].Attributes)
+	if _, ok := ev[AttrScoreReason]; ok {
+		t.Error("reason should be omitted when trace content is disabled")
+	}
+}
+
+func TestLogScore_NoSpanIsNoop(t *testing.T) {
+	// Should not panic.
+	LogScore(context.Background(), "faithfulness", 1)
+}
+
+// ---------------------------------------------------------------------------
+// LogGroundTruth
+// ---------------------------------------------------------------------------
+
+func TestLogGroundTruth_String(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	LogGroundTruth(ctx, "billing")
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrGroundTruth] != "billing" {
+		t.Errorf("ground truth: got %v, want %q", attrs[AttrGroundTruth], "billing")
+	}
+	if attrs[AttrGroundTruthFormat] != "text" {
+		t.Errorf("format: got %v, want %q", attrs[AttrGroundTruthFormat], "text")
+	}
+}
+
+func TestLogGroundTruth_StructuredValue(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	LogGroundTruth(ctx, map[string]any{"name": "Ada", "age": 36})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrGroundTruth] != `{"age":36,"name":"Ada"}` {
+		t.Errorf("ground truth: got %v", attrs[AttrGroundTruth])
+	}
+	if attrs[AttrGroundTruthFormat] != "json" {
+		t.Errorf("format: got %v, want %q", attrs[AttrGroundTruthFormat], "json")
+	}
+}
+
+func TestLogGroundTruth_OmittedWithoutTraceContent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	LogGroundTruth(ctx, "billing")
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrGroundTruth]; ok {
+		t.Error("ground truth should be omitted when trace content is disabled")
+	}
+}
+
+func TestLogGroundTruth_UnmarshalableValueDropped(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	LogGroundTruth(ctx, make(chan int))
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrGroundTruth]; ok {
+		t.Error("unmarshalable ground truth should be dropped")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// WithEvalRun
+// ---------------------------------------------------------------------------
+
+func TestWithEvalRun_TagsChildSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx := WithEvalRun(context.Background(), "run_1", "golden")
+	wf, ctx := StartWorkflow(ctx, "eval")
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	wf.End()
+
+	for _, s := range exporter.GetSpans() {
+		attrs := attrMap(s.Attributes)
+		if attrs[AttrEvalRunID] != "run_1" {
+			t.Errorf("%s: run_id got %v, want %q", s.Name, attrs[AttrEvalRunID], "run_1")
+		}
+		if attrs[AttrEvalDatasetID] != "golden" {
+			t.Errorf("%s: dataset_id got %v, want %q", s.Name, attrs[AttrEvalDatasetID], "golden")
+		}
+	}
+}
+
+func TestWithEvalRun_SetsOnCurrentSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "eval")
+	_ = WithEvalRun(ctx, "run_2", "")
+	wf.End()
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrEvalRunID] != "run_2" {
+		t.Errorf("run_id: got %v, want %q", attrs[AttrEvalRunID], "run_2")
+	}
+	if _, ok := attrs[AttrEvalDatasetID]; ok {
+		t.Error("empty dataset ID should not be recorded")
+	}
+}
@@ -0,0 +1,40 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AddReasoningStep records an intermediate agent decision or thought as a
+// timestamped "triage.reasoning_step" event on the current span (typically
+// an agent or LLM span), a lightweight way to audit how an agent got to its
+// answer without creating a span per thought:
+//
+//	triage.AddReasoningStep(ctx, "user asked about billing; routing to invoices tool")
+//
+// The step text is content, so it is only recorded when trace content is
+// enabled; otherwise the event is recorded without it, keeping the timeline.
+// No-op if ctx carries no recording span.
+func AddReasoningStep(ctx context.Context, step string) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	var attrs []attribute.KeyValue
+	if isTraceContentEnabled() {
+		attrs = append(attrs, attribute.String(AttrReasoningStep, step))
+	}
+	span.AddEvent(reasoningStepEventName, trace.WithAttributes(attrs...))
+}
+
+// AddEvent records a timestamped event with the given name and attributes on
+// the current span. No-op if ctx carries no recording span or name is empty.
+func AddEvent(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || name == "" {
+		return
+	}
+	span.AddEvent(name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,72 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestAddReasoningStep_RecordsEvents(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	agent, ctx := StartAgent(context.Background(), "router")
+	AddReasoningStep(ctx, "question is about billing")
+	AddReasoningStep(ctx, "calling invoices tool")
+	agent.End()
+
+	events := exporter.GetSpans()[0].Events
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	for i, want := range []string{"question is about billing", "calling invoices tool"} {
+		if events[i].Name != reasoningStepEventName {
+			t.Errorf("event %d name: got %q", i, events[i].Name)
+		}
+		if got := attrMap(events[i].Attributes)[AttrReasoningStep]; got != want {
+			t.Errorf("event %d step: got %v, want %q", i, got, want)
+		}
+	}
+	if events[1].Time.Before(events[0].Time) {
+		t.Error("events should be timestamped in order")
+	}
+}
+
+func TestAddReasoningStep_OmitsTextWithoutTraceContent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	agent, ctx := StartAgent(context.Background(), "router")
+	AddReasoningStep(ctx, "secret plan")
+	agent.End()
+
+	events := exporter.GetSpans()[0].Events
+	if len(events) != 1 {
+		t.Fatalf("expected the event to be kept, got %d events", len(events))
+	}
+	if _, ok := attrMap(events[0].Attributes)[AttrReasoningStep]; ok {
+		t.Error("step text should not be recorded when trace content is disabled")
+	}
+}
+
+func TestAddEvent_CustomAttributes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	task, ctx := StartTask(context.Background(), "plan")
+	AddEvent(ctx, "plan.selected", attribute.String("plan", "b"), attribute.Int("candidates", 3))
+	AddEvent(ctx, "") // ignored
+	task.End()
+
+	events := exporter.GetSpans()[0].Events
+	if len(events) != 1 || events[0].Name != "plan.selected" {
+		t.Fatalf("expected one plan.selected event, got %+v", events)
+	}
+	if attrs := attrMap(events[0].Attributes); attrs["plan"] != "b" || attrs["candidates"] != int64(3) {
+		t.Errorf("attributes: got %v", attrs)
+	}
+}
+
+func TestAddEvent_NoSpanIsNoop(t *testing.T) {
+	AddEvent(context.Background(), "x")
+	AddReasoningStep(context.Background(), "y")
+}
@@ -0,0 +1,59 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// exportedContextVersion is the format version written by ExportContext.
+const exportedContextVersion = 1
+
+// exportedContext is the serialized form of a context, keyed like a
+// TextMapCarrier.
+type exportedContext struct {
+	Version int               `json:"v"`
+	Fields  map[string]string `json:"fields"`
+}
+
+// ExportContext serializes the triage context carried by ctx (the fields
+// Propagator carries) and the origin of the work (see Origin), so a producer
+// can attach them to a queue message (SQS, Pub/Sub, ...) and the worker can
+// restore them with ImportContext:
+//
+//	data, err := triage.ExportContext(ctx)
+//	msg.MessageAttributes["triage"] = data
+//
+// The result is small JSON that holds no prompt content.
+func ExportContext(ctx context.Context) ([]byte, error) {
+	carrier := propagation.MapCarrier{}
+	Propagator{}.Inject(ctx, carrier)
+	if origin := Origin(ctx); origin != "" {
+		carrier.Set(traceparentHeader, origin)
+	}
+	return json.Marshal(exportedContext{Version: exportedContextVersion, Fields: carrier})
+}
+
+// ImportContext returns ctx carrying the context serialized by
+// ExportContext. As with WithOrigin, the worker's spans run in their own
+// trace, linked back to the producer's span, and they carry the producer's
+// user, tenant and session. Values already set on ctx take precedence.
+//
+//	ctx, err := triage.ImportContext(ctx, msg.MessageAttributes["triage"])
+//
+// On error, ctx is returned unchanged. Import only data from producers you
+// trust: it determines who the work is attributed to.
+func ImportContext(ctx context.Context, data []byte) (context.Context, error) {
+	var ec exportedContext
+	if err := json.Unmarshal(data, &ec); err != nil {
+		return ctx, fmt.Errorf("triage: invalid exported context: %w", err)
+	}
+	if ec.Version != exportedContextVersion {
+		return ctx, fmt.Errorf("triage: unsupported exported context version %d", ec.Version)
+	}
+	carrier := propagation.MapCarrier(ec.Fields)
+	ctx = WithOrigin(ctx, carrier.Get(traceparentHeader))
+	return Propagator{}.Extract(ctx, carrier), nil
+}
@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestExportContext_RoundTrip(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx := WithTenant(WithUser(context.Background(), "u_1"), "org_1")
+	ctx = WithInput(ctx, "secret prompt")
+	wf, ctx := StartWorkflow(ctx, "enqueue")
+	data, err := ExportContext(ctx)
+	wf.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret prompt") {
+		t.Errorf("exported context should not carry input content: %s", data)
+	}
+
+	worker, err := ImportContext(context.Background(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task, _ := StartTask(worker, "process")
+	task.End()
+
+	spans := exporter.GetSpans()
+	producer, consumer := spans[0], spans[1]
+	if consumer.SpanContext.TraceID() == producer.SpanContext.TraceID() {
+		t.Error("worker span should run in its own trace")
+	}
+	if len(consumer.Links) != 1 || consumer.Links[0].SpanContext.SpanID() != producer.SpanContext.SpanID() {
+		t.Errorf("worker span should link to the producer's span, got %+v", consumer.Links)
+	}
+	attrs := attrMap(consumer.Attributes)
+	if attrs[AttrUserID] != "u_1" || attrs[AttrTenantID] != "org_1" {
+		t.Errorf("worker span attribution: got %v", attrs)
+	}
+}
+
+func TestImportContext_KeepsExistingValues(t *testing.T) {
+	data, err := ExportContext(WithSession(WithUser(context.Background(), "u_1"), "sess_1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, err := ImportContext(WithUser(context.Background(), "u_local"), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc := getFromContext(ctx); tc.userID != "u_local" || tc.sessionID != "sess_1" {
+		t.Errorf("got user %q session %q", tc.userID, tc.sessionID)
+	}
+}
+
+func TestImportContext_Invalid(t *testing.T) {
+	ctx := WithUser(context.Background(), "u_1")
+	for _, data := range []string{"not json", `{"v":99,"fields":{}}`} {
+		got, err := ImportContext(ctx, []byte(data))
+		if err == nil {
+			t.Errorf("%s: expected an error", data)
+		}
+		if got != ctx {
+			t.Errorf("%s: ctx should be returned unchanged", data)
+		}
+	}
+}
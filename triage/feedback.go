@@ -0,0 +1,97 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Feedback ratings for thumbs up/down reactions.
+const (
+	ThumbsDown = -1
+	ThumbsUp   = 1
+)
+
+// Feedback is an end-user reaction to an LLM output.
+type Feedback struct {
+	Rating  int    // ThumbsUp, ThumbsDown, or any app-defined scale
+	Comment string // Free-text comment; only captured when trace content is enabled
+
+	// TargetTraceID and TargetSpanID identify the LLM span the feedback is
+	// about, as hex strings (see LLMSpan.SpanContext). When both are empty
+	// the feedback applies to the current span in ctx.
+	TargetTraceID string
+	TargetSpanID  string
+}
+
+// LogFeedback records user feedback against an LLM span so user-reported bad
+// outputs join the trace.
+//
+// If the target is the span currently active in ctx and it is still
+// recording, the feedback is added to it as a "triage.feedback" event.
+// Otherwise — typically because the feedback arrives in a later request,
+// after the LLM span was exported — a short "triage.feedback" span is emitted
+// with a span link to the target, which the backend uses to join the two.
+//
+// Returns an error if the target IDs are malformed.
+func LogFeedback(ctx context.Context, fb Feedback) error {
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrFeedbackRating, fb.Rating),
+	}
+	if fb.Comment != "" && isTraceContentEnabled() {
+		attrs = append(attrs, attribute.String(AttrFeedbackComment, fb.Comment))
+	}
+
+	current := trace.SpanFromContext(ctx)
+	if fb.TargetTraceID == "" && fb.TargetSpanID == "" {
+		if !current.IsRecording() {
+			return errors.New("triage: feedback has no target span and ctx carries no recording span")
+		}
+		current.AddEvent(feedbackName, trace.WithAttributes(attrs...))
+		return nil
+	}
+
+	target, err := remoteSpanContext(fb.TargetTraceID, fb.TargetSpanID)
+	if err != nil {
+		return err
+	}
+
+	if current.IsRecording() && current.SpanContext().SpanID() == target.SpanID() {
+		current.AddEvent(feedbackName, trace.WithAttributes(attrs...))
+		return nil
+	}
+
+	attrs = append(attrs,
+		attribute.String(AttrFeedbackTargetTrace, fb.TargetTraceID),
+		attribute.String(AttrFeedbackTargetSpan, fb.TargetSpanID),
+	)
+	tracer := sdkTracer()
+	_, span := tracer.Start(ctx, feedbackName,
+		trace.WithLinks(trace.Link{SpanContext: target}),
+		trace.WithAttributes(attrs...),
+	)
+	span.End()
+	return nil
+}
+
+// remoteSpanContext parses hex trace and span IDs into a remote span context
+// suitable for a span link.
+func remoteSpanContext(traceIDHex, spanIDHex string) (trace.SpanContext, error) {
+	traceID, err := trace.TraceIDFromHex(traceIDHex)
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("triage: invalid trace ID %q: %w", traceIDHex, err)
+	}
+	spanID, err := trace.SpanIDFromHex(spanIDHex)
+	if err != nil {
+		return trace.SpanContext{}, fmt.Errorf("triage: invalid span ID %q: %w", spanIDHex, err)
+	}
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}), nil
+}
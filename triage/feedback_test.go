@@ -0,0 +1,104 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Feedback on a live span
+// ---------------------------------------------------------------------------
+
+func TestLogFeedback_CurrentSpanGetsEvent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	if err := LogFeedback(ctx, Feedback{Rating: ThumbsDown, Comment: "wrong answer"}); err != nil {
+		t.Fatalf("LogFeedback failed: %v", err)
+	}
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	events := spans[0].Events
+	if len(events) != 1 || events[0].Name != feedbackName {
+		t.Fatalf("expected one %q event, got %+v", feedbackName, events)
+	}
+	attrs := attrMap(events[0].Attributes)
+	if attrs[AttrFeedbackRating] != int64(ThumbsDown) {
+		t.Errorf("rating: got %v, want %d", attrs[AttrFeedbackRating], ThumbsDown)
+	}
+	if attrs[AttrFeedbackComment] != "wrong answer" {
+		t.Errorf("comment: got %v, want %q", attrs[AttrFeedbackComment], "wrong answer")
+	}
+}
+
+func TestLogFeedback_NoTargetNoSpanReturnsError(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	if err := LogFeedback(context.Background(), Feedback{Rating: ThumbsUp}); err == nil {
+		t.Error("expected error when there is no target and no current span")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Feedback on an exported span
+// ---------------------------------------------------------------------------
+
+func TestLogFeedback_ExportedSpanGetsLinkedFeedbackSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	sc := llmSpan.SpanContext()
+
+	err := LogFeedback(context.Background(), Feedback{
+		Rating:        ThumbsUp,
+		TargetTraceID: sc.TraceID().String(),
+		TargetSpanID:  sc.SpanID().String(),
+	})
+	if err != nil {
+		t.Fatalf("LogFeedback failed: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	fbSpan := spans[1]
+	if fbSpan.Name != feedbackName {
+		t.Errorf("span name: got %q, want %q", fbSpan.Name, feedbackName)
+	}
+	if len(fbSpan.Links) != 1 || fbSpan.Links[0].SpanContext.SpanID() != sc.SpanID() {
+		t.Fatalf("expected a link to the LLM span, got %+v", fbSpan.Links)
+	}
+	attrs := attrMap(fbSpan.Attributes)
+	if attrs[AttrFeedbackTargetSpan] != sc.SpanID().String() {
+		t.Errorf("target span: got %v, want %q", attrs[AttrFeedbackTargetSpan], sc.SpanID().String())
+	}
+}
+
+func TestLogFeedback_CommentOmittedWithoutTraceContent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	llmSpan, ctx := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	_ = LogFeedback(ctx, Feedback{Rating: ThumbsDown, Comment: "my SSN is 123-45-6789"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Events[0].Attributes)
+	if _, ok := attrs[AttrFeedbackComment]; ok {
+		t.Error("comment should be omitted when trace content is disabled")
+	}
+}
+
+func TestLogFeedback_InvalidTargetReturnsError(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	err := LogFeedback(context.Background(), Feedback{TargetTraceID: "nothex", TargetSpanID: "nothex"})
+	if err == nil {
+		t.Error("expected error for malformed target IDs")
+	}
+}
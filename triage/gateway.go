@@ -0,0 +1,254 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxGatewayCapture bounds the request or non-streamed response body the
+// gateway buffers for instrumentation. Larger bodies are forwarded untouched
+// and their call is not recorded (request) or recorded without content
+// (response).
+const maxGatewayCapture = 8 << 20
+
+// GatewayOption configures NewGateway.
+type GatewayOption func(*gatewayConfig)
+
+// gatewayConfig holds the settings applied by GatewayOptions.
+type gatewayConfig struct {
+	vendor    string
+	transport http.RoundTripper
+}
+
+// GatewayVendor sets the vendor recorded on the gateway's LLM spans, for
+// OpenAI-compatible upstreams other than OpenAI (e.g. "vllm", "groq").
+// Defaults to "openai".
+func GatewayVendor(vendor string) GatewayOption {
+	return func(gc *gatewayConfig) { gc.vendor = vendor }
+}
+
+// GatewayTransport sets the transport used to reach the upstream. Defaults to
+// http.DefaultTransport.
+func GatewayTransport(rt http.RoundTripper) GatewayOption {
+	return func(gc *gatewayConfig) { gc.transport = rt }
+}
+
+// llmAPI describes the wire format of an instrumented LLM endpoint.
+type llmAPI struct {
+	parsePrompt     func(body []byte) (Prompt, error)
+	parseCompletion func(body []byte) (Completion, Usage, error)
+	newStream       func(onChunk func(token bool)) completionStream
+}
+
+// completionStream reassembles a streamed response from raw SSE bytes.
+type completionStream interface {
+	io.Writer
+	result() (Completion, Usage)
+}
+
+// openAIChatAPI is the OpenAI Chat Completions format, also spoken by most
+// OpenAI-compatible servers.
+var openAIChatAPI = &llmAPI{
+	parsePrompt:     ParsePromptFromJSON,
+	parseCompletion: ParseCompletionFromJSON,
+	newStream:       func(onChunk func(token bool)) completionStream { return &openAIStream{onChunk: onChunk} },
+}
+
+// openAIResponsesAPI is the OpenAI Responses API format.
+var openAIResponsesAPI = &llmAPI{
+	parsePrompt:     ParseResponsesPromptFromJSON,
+	parseCompletion: ParseResponsesCompletionFromJSON,
+	newStream:       func(onChunk func(token bool)) completionStream { return &responsesStream{onChunk: onChunk} },
+}
+
+// anthropicMessagesAPI is the Anthropic Messages format.
+var anthropicMessagesAPI = &llmAPI{
+	parsePrompt:     parseAnthropicPrompt,
+	parseCompletion: parseAnthropicCompletion,
+	newStream:       func(onChunk func(token bool)) completionStream { return &anthropicStream{onChunk: onChunk} },
+}
+
+// gatewaySpanKey is an unexported context key for the LLM span of the request
+// being proxied.
+type gatewaySpanKey struct{}
+
+// NewGateway returns a reverse proxy to an OpenAI-compatible upstream that
+// records every Chat Completions call passing through as an LLM span, so
+// platform teams get organization-wide coverage at the gateway instead of
+// per-service SDK adoption:
+//
+//	upstream, _ := url.Parse("https://api.openai.com")
+//	http.ListenAndServe(addr, triage.Middleware(triage.NewGateway(upstream)))
+//
+// Streamed responses are passed through unbuffered and reassembled on the
+// side, recording chunk timing as RecordChunk does. Requests other than
+// POSTs to a .../chat/completions path are proxied without instrumentation.
+func NewGateway(upstream *url.URL, opts ...GatewayOption) http.Handler {
+	gc := gatewayConfig{vendor: "openai"}
+	for _, o := range opts {
+		o(&gc)
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(upstream)
+			pr.SetXForwarded()
+		},
+		Transport:      gc.transport,
+		ModifyResponse: gatewayResponse,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if ls, ok := r.Context().Value(gatewaySpanKey{}).(*LLMSpan); ok {
+				ls.SetError(err)
+				ls.End()
+			}
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/chat/completions") {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxGatewayCapture+1))
+		if err != nil {
+			http.Error(w, "triage gateway: failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxGatewayCapture {
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		prompt, err := openAIChatAPI.parsePrompt(body)
+		if err != nil {
+			proxy.ServeHTTP(w, r)
+			return
+		}
+		prompt.Vendor = gc.vendor
+		prompt.Endpoint = upstream.String()
+
+		ls, ctx := LogPrompt(r.Context(), prompt)
+		defer ls.End() // no-op once the response body has been recorded
+		proxy.ServeHTTP(w, r.WithContext(context.WithValue(ctx, gatewaySpanKey{}, ls)))
+	})
+}
+
+// gatewayResponse attaches the upstream response to the request's LLM span.
+func gatewayResponse(resp *http.Response) error {
+	if ls, ok := resp.Request.Context().Value(gatewaySpanKey{}).(*LLMSpan); ok {
+		instrumentResponse(ls, resp, openAIChatAPI, "triage gateway")
+	}
+	return nil
+}
+
+// instrumentResponse attaches resp to ls: errors are recorded immediately,
+// successful bodies as they are read. who prefixes the recorded error for
+// error statuses.
+func instrumentResponse(ls *LLMSpan, resp *http.Response, api *llmAPI, who string) {
+	ls.RecordResponseHeaders(resp.Header)
+
+	if resp.StatusCode >= 400 {
+		ls.SetError(fmt.Errorf("%s: upstream returned %s", who, resp.Status))
+		ls.End()
+		return
+	}
+
+	gb := &gatewayBody{ReadCloser: resp.Body, ls: ls, parse: api.parseCompletion}
+	if mt, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mt == "text/event-stream" {
+		gb.stream = api.newStream(ls.recordStreamChunk)
+		ls.onStreamCancelled(gb.partial)
+	}
+	resp.Body = gb
+}
+
+// gatewayBody tees an upstream response body into the LLM span as the caller
+// reads it, and logs the completion at EOF or Close.
+type gatewayBody struct {
+	io.ReadCloser
+	ls     *LLMSpan
+	parse  func(body []byte) (Completion, Usage, error)
+	stream completionStream // nil for non-streamed responses
+
+	mu       sync.Mutex // guards stream, read by partial on cancellation
+	buf      bytes.Buffer
+	overflow bool
+	once     sync.Once
+}
+
+func (b *gatewayBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		switch {
+		case b.stream != nil:
+			b.ls.RecordStreamBytes(n)
+			// A parser bug must not panic in the goroutine reading the body.
+			b.mu.Lock()
+			guard("stream", func() { b.stream.Write(p[:n]) })
+			b.mu.Unlock()
+		case b.buf.Len()+n <= maxGatewayCapture:
+			b.buf.Write(p[:n])
+		default:
+			b.overflow = true
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		b.finish(nil)
+	} else if err != nil {
+		b.finish(err)
+	}
+	return n, err
+}
+
+func (b *gatewayBody) Close() error {
+	b.finish(nil)
+	return b.ReadCloser.Close()
+}
+
+// finish logs the completion once. A body closed before EOF (e.g. the client
+// disconnected mid-stream) records what was received so far; a stream cut
+// short by the caller's cancellation is recorded with LogCancelled.
+func (b *gatewayBody) finish(readErr error) {
+	b.once.Do(func() {
+		if b.stream != nil && isCancellation(b.ls.Context(), readErr) {
+			b.ls.LogCancelled(b.partial())
+			return
+		}
+		if readErr != nil {
+			b.ls.SetError(readErr)
+		}
+		var (
+			completion Completion
+			usage      Usage
+		)
+		switch {
+		case b.stream != nil:
+			completion, usage = b.partial()
+		case !b.overflow:
+			completion, usage, _ = b.parse(b.buf.Bytes())
+		}
+		b.ls.LogCompletion(completion, usage)
+	})
+}
+
+// partial returns the stream's response reassembled so far.
+func (b *gatewayBody) partial() (Completion, Usage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stream.result()
+}
@@ -0,0 +1,148 @@
+package triage
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func newTestGateway(t *testing.T, upstream http.HandlerFunc, opts ...GatewayOption) *httptest.Server {
+	t.Helper()
+	up := httptest.NewServer(upstream)
+	t.Cleanup(up.Close)
+	u, err := url.Parse(up.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := httptest.NewServer(NewGateway(u, opts...))
+	t.Cleanup(gw.Close)
+	return gw
+}
+
+func postChat(t *testing.T, gw *httptest.Server, body string) string {
+	t.Helper()
+	resp, err := http.Post(gw.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+const gatewayRequest = `{"model":"gpt-4o","messages":[{"role":"user","content":"Hi"}]}`
+
+func TestGateway_RecordsJSONCompletion(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	const respBody = `{"model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != gatewayRequest {
+			t.Errorf("upstream got body %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_1")
+		io.WriteString(w, respBody)
+	})
+
+	if got := postChat(t, gw, gatewayRequest); got != respBody {
+		t.Errorf("client got %q", got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAISystem] != "openai" || attrs[AttrGenAIRequestModel] != "gpt-4o" {
+		t.Errorf("system/model: got %v/%v", attrs[AttrGenAISystem], attrs[AttrGenAIRequestModel])
+	}
+	if attrs["gen_ai.prompt.0.content"] != "Hi" || attrs["gen_ai.completion.0.content"] != "Hello!" {
+		t.Errorf("content: got %v / %v", attrs["gen_ai.prompt.0.content"], attrs["gen_ai.completion.0.content"])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("total tokens: got %v", attrs["llm.usage.total_tokens"])
+	}
+	if got, _ := attrs[AttrResponseHeaderPrefix+"x-request-id"].([]string); len(got) != 1 || got[0] != "req_1" {
+		t.Errorf("request id header: got %v", attrs[AttrResponseHeaderPrefix+"x-request-id"])
+	}
+}
+
+func TestGateway_ReassemblesStream(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	events := []string{
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\""}}]}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`{"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":4,"total_tokens":7}}`,
+	}
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			io.WriteString(w, "data: "+e+"\n\n")
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, "data: [DONE]\n\n")
+	})
+
+	if got := postChat(t, gw, gatewayRequest); !strings.Contains(got, "[DONE]") {
+		t.Errorf("client should receive the full stream, got %q", got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hello" {
+		t.Errorf("content: got %v", attrs["gen_ai.completion.0.content"])
+	}
+	if attrs["gen_ai.completion.0.tool_calls.0.function.arguments"] != `{"q":1}` {
+		t.Errorf("tool call arguments: got %v", attrs["gen_ai.completion.0.tool_calls.0.function.arguments"])
+	}
+	if attrs[AttrGenAIResponseFinishReason] != "tool_calls" || attrs["llm.usage.total_tokens"] != int64(7) {
+		t.Errorf("finish/usage: got %v/%v", attrs[AttrGenAIResponseFinishReason], attrs["llm.usage.total_tokens"])
+	}
+	if _, ok := attrs[AttrStreamChunkGapHistogram]; !ok {
+		t.Error("stream chunk timing should be recorded")
+	}
+}
+
+func TestGateway_UpstreamErrorMarksSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"rate limited"}}`, http.StatusTooManyRequests)
+	})
+
+	postChat(t, gw, gatewayRequest)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
+
+func TestGateway_PassesThroughOtherRequests(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	gw := newTestGateway(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[]}`)
+	})
+
+	resp, err := http.Get(gw.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans for non-chat requests, got %d", n)
+	}
+}
@@ -0,0 +1,141 @@
+package triage
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Span attributes Genkit records on a flow, step or action span. Genkit sets
+// them just before the span ends, not when it starts.
+const (
+	genkitAttrType    = "genkit:type"
+	genkitAttrSubtype = "genkit:metadata:subtype"
+	genkitAttrName    = "genkit:name"
+	genkitAttrPath    = "genkit:path"
+)
+
+// WithGenkit maps the spans Firebase Genkit records onto triage spans at
+// export: flows become workflow spans, model actions LLM spans (with
+// gen_ai.system and gen_ai.request.model taken from the "provider/model"
+// action name), tool actions tool spans and everything else task spans.
+// Genkit records its spans on the global tracer provider, so initialize
+// Genkit after Init; triage context from the flow's ctx is added as it is
+// for any span:
+//
+//	shutdown, err := triage.Init(triage.WithGenkit())
+//	g := genkit.Init(ctx, genkit.WithPlugins(&googlegenai.GoogleAI{}))
+//
+// Spans from other instrumentation are left untouched. The SDK doesn't
+// depend on Genkit; it reads the genkit:* span attributes.
+func WithGenkit() Option {
+	return func(c *config) { c.genkit = true }
+}
+
+// Compile-time check that genkitExporter implements SpanExporter.
+var _ sdktrace.SpanExporter = (*genkitExporter)(nil)
+
+// genkitExporter adds the triage attributes of Genkit spans on the way to the
+// exporter. Genkit only sets its attributes as a span ends, so they can't be
+// mapped by a span processor's OnStart.
+type genkitExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e *genkitExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	mapped := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		mapped[i] = s
+		if attrs := genkitAttributes(s.Attributes()); attrs != nil {
+			mapped[i] = genkitSpan{ReadOnlySpan: s, attrs: attrs}
+		}
+	}
+	return e.SpanExporter.ExportSpans(ctx, mapped)
+}
+
+// genkitSpan is a Genkit ReadOnlySpan with its triage attributes added.
+type genkitSpan struct {
+	sdktrace.ReadOnlySpan
+	attrs []attribute.KeyValue
+}
+
+func (s genkitSpan) Attributes() []attribute.KeyValue {
+	return append(s.ReadOnlySpan.Attributes(), s.attrs...)
+}
+
+// genkitAttributes returns the triage attributes for a span with the Genkit
+// attributes in attrs, or nil if it isn't a Genkit span.
+func genkitAttributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	var typ, subtype, name, path string
+	for _, kv := range attrs {
+		switch kv.Key {
+		case genkitAttrType:
+			typ = kv.Value.AsString()
+		case genkitAttrSubtype:
+			subtype = kv.Value.AsString()
+		case genkitAttrName:
+			name = kv.Value.AsString()
+		case genkitAttrPath:
+			path = kv.Value.AsString()
+		}
+	}
+	if typ == "" {
+		return nil
+	}
+
+	var out []attribute.KeyValue
+	switch {
+	case typ == "flow" || subtype == "flow":
+		out = append(out,
+			attribute.String("traceloop.span.kind", SpanKindWorkflow),
+			attribute.String("traceloop.entity.name", name),
+			attribute.String("traceloop.workflow.name", name),
+		)
+		return out
+	case subtype == "model":
+		vendor, model, ok := strings.Cut(name, "/")
+		if !ok {
+			vendor, model = "genkit", name
+		}
+		vendor = normalizeVendor(vendor)
+		out = append(out,
+			attribute.String(AttrGenAISystem, vendor),
+			attribute.String(AttrGenAIOperationName, OperationChat),
+			attribute.String(AttrGenAIRequestModel, model),
+			attribute.String("llm.vendor", vendor),
+			attribute.String("llm.request.model", model),
+			attribute.String("llm.request.type", OperationChat),
+		)
+	case subtype == "tool":
+		out = append(out,
+			attribute.String("traceloop.span.kind", SpanKindTool),
+			attribute.String("traceloop.entity.name", name),
+		)
+	default:
+		out = append(out,
+			attribute.String("traceloop.span.kind", SpanKindTask),
+			attribute.String("traceloop.entity.name", name),
+		)
+	}
+	if wf := genkitFlowName(path); wf != "" {
+		out = append(out, attribute.String("traceloop.workflow.name", wf))
+	}
+	return out
+}
+
+// genkitFlowName returns the name of the outermost flow in a Genkit span
+// path such as "/{support,t:flow}/{lookup,t:flowStep}" or
+// "/{support,t:action,s:flow}/…", or "".
+func genkitFlowName(path string) string {
+	for _, seg := range strings.Split(strings.TrimPrefix(path, "/"), "/{") {
+		parts := strings.Split(strings.Trim(seg, "{}"), ",")
+		for _, tag := range parts[1:] {
+			if tag == "t:flow" || tag == "s:flow" {
+				return parts[0]
+			}
+		}
+	}
+	return ""
+}
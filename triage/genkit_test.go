@@ -0,0 +1,89 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startGenkitSpan starts and ends a span the way Genkit does: with only
+// genkit:type set at start, and its other genkit:* attributes set just
+// before the span ends.
+func startGenkitSpan(ctx context.Context, tracer trace.Tracer, name string, attrs ...attribute.KeyValue) context.Context {
+	var start []attribute.KeyValue
+	for _, kv := range attrs {
+		if kv.Key == genkitAttrType {
+			start = append(start, kv)
+		}
+	}
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(start...))
+	span.SetAttributes(append(attrs, attribute.String(genkitAttrName, name))...)
+	span.End()
+	return ctx
+}
+
+func TestWithGenkit_MapsSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSyncer(&genkitExporter{SpanExporter: exporter}),
+	)
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	tracer := tp.Tracer("genkit-tracer")
+
+	ctx := WithUser(context.Background(), "u_1")
+	ctx = startGenkitSpan(ctx, tracer, "support",
+		attribute.String(genkitAttrType, "action"), attribute.String(genkitAttrSubtype, "flow"),
+		attribute.String(genkitAttrPath, "/{support,t:action,s:flow}"))
+	startGenkitSpan(ctx, tracer, "googleai/gemini-2.0-flash",
+		attribute.String(genkitAttrType, "action"), attribute.String(genkitAttrSubtype, "model"),
+		attribute.String(genkitAttrPath, "/{support,t:action,s:flow}/{googleai/gemini-2.0-flash,t:action,s:model}"))
+	startGenkitSpan(ctx, tracer, "lookupOrder",
+		attribute.String(genkitAttrType, "action"), attribute.String(genkitAttrSubtype, "tool"),
+		attribute.String(genkitAttrPath, "/{support,t:action,s:flow}/{lookupOrder,t:action,s:tool}"))
+	startGenkitSpan(ctx, tracer, "rerank",
+		attribute.String(genkitAttrType, "flowStep"),
+		attribute.String(genkitAttrPath, "/{support,t:action,s:flow}/{rerank,t:flowStep}"))
+	startGenkitSpan(ctx, tracer, "http.request")
+
+	spans := exporter.GetSpans()
+	if len(spans) != 5 {
+		t.Fatalf("expected 5 spans, got %d", len(spans))
+	}
+	for _, tc := range []struct {
+		index int
+		want  map[string]any
+	}{
+		{0, map[string]any{"traceloop.span.kind": SpanKindWorkflow, "traceloop.workflow.name": "support", AttrUserID: "u_1"}},
+		{1, map[string]any{AttrGenAISystem: VendorGemini, AttrGenAIRequestModel: "gemini-2.0-flash", "traceloop.workflow.name": "support"}},
+		{2, map[string]any{"traceloop.span.kind": SpanKindTool, "traceloop.entity.name": "lookupOrder", "traceloop.workflow.name": "support"}},
+		{3, map[string]any{"traceloop.span.kind": SpanKindTask, "traceloop.workflow.name": "support", AttrUserID: "u_1"}},
+	} {
+		attrs := attrMap(spans[tc.index].Attributes)
+		for k, v := range tc.want {
+			if attrs[k] != v {
+				t.Errorf("%s: %s got %v, want %v", spans[tc.index].Name, k, attrs[k], v)
+			}
+		}
+	}
+	if _, ok := attrMap(spans[4].Attributes)["traceloop.span.kind"]; ok {
+		t.Error("spans not recorded by Genkit should be left untouched")
+	}
+}
+
+func TestGenkitFlowName(t *testing.T) {
+	for path, want := range map[string]string{
+		"/{support,t:flow}/{lookup,t:flowStep}":            "support",
+		"/{outer,t:action,s:flow}/{inner,t:action,s:flow}": "outer",
+		"/{googleai/gemini-2.0-flash,t:action,s:model}":    "",
+		"": "",
+	} {
+		if got := genkitFlowName(path); got != want {
+			t.Errorf("genkitFlowName(%q): got %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package triage
+
+import (
+	"context"
+	"sync"
+)
+
+// background tracks goroutines started with Go so Shutdown can let them
+// finish before flushing. Unlike a sync.WaitGroup, it can be waited on with
+// a timeout without leaking a goroutine, and reused while a timed-out wait
+// left goroutines running.
+var background struct {
+	sync.Mutex
+	running int
+	idle    chan struct{} // closed when running drops to zero; nil while idle
+}
+
+// backgroundStarted records that a goroutine started with Go is running.
+func backgroundStarted() {
+	background.Lock()
+	defer background.Unlock()
+	if background.running == 0 {
+		background.idle = make(chan struct{})
+	}
+	background.running++
+}
+
+// backgroundDone records that a goroutine started with Go has returned.
+func backgroundDone() {
+	background.Lock()
+	defer background.Unlock()
+	if background.running--; background.running == 0 {
+		close(background.idle)
+		background.idle = nil
+	}
+}
+
+// Detach returns a context that carries everything ctx does — the active
+// span, the workflow, the triage annotations — but is never cancelled and
+// has no deadline. Use it for work that must outlive the request that
+// started it, e.g. when submitting to a worker pool or errgroup, instead of
+// context.Background(), which silently drops the trace:
+//
+//	pool.Submit(func() { summarize(triage.Detach(ctx), doc) })
+func Detach(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// Go runs fn in a new goroutine with Detach(ctx), so spans it creates stay
+// in the caller's trace even after the request completes. Shutdown waits for
+// goroutines started with Go, bounded by its context and the shutdown
+// timeout, before flushing:
+//
+//	triage.Go(ctx, func(ctx context.Context) {
+//		llmSpan, _ := triage.LogPrompt(ctx, prompt)
+//		...
+//	})
+func Go(ctx context.Context, fn func(ctx context.Context)) {
+	ctx = Detach(ctx)
+	backgroundStarted()
+	go func() {
+		defer backgroundDone()
+		fn(ctx)
+	}()
+}
+
+// waitBackground waits for goroutines started with Go (or tracked with
+// backgroundStarted) to finish, for ctx to be done, or for the shutdown
+// timeout (see WithShutdownTimeout) to elapse, whichever comes first.
+func waitBackground(ctx context.Context) {
+	timeout := defaultShutdownTimeout
+	if cfg := globalCfg; cfg != nil && cfg.shutdownTimeout > 0 {
+		timeout = cfg.shutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	background.Lock()
+	idle := background.idle
+	background.Unlock()
+	if idle == nil {
+		return
+	}
+	select {
+	case <-idle:
+	case <-ctx.Done():
+	}
+}
@@ -0,0 +1,105 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGo_KeepsTraceAfterCancellation(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(WithUser(context.Background(), "u_1"), "request")
+	ctx, cancel := context.WithCancel(ctx)
+
+	done := make(chan struct{})
+	Go(ctx, func(ctx context.Context) {
+		defer close(done)
+		if ctx.Err() != nil {
+			t.Error("detached context should not be cancelled")
+		}
+		task, _ := StartTask(ctx, "background-summary")
+		task.End()
+	})
+	cancel()
+	<-done
+	wf.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	task, root := spans[0], spans[1]
+	if task.Parent.SpanID() != root.SpanContext.SpanID() {
+		t.Error("background task should be a child of the workflow")
+	}
+	if attrMap(task.Attributes)[AttrUserID] != "u_1" {
+		t.Error("background task should inherit triage annotations")
+	}
+}
+
+func TestDetach_DropsDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(WithTenant(context.Background(), "org_1"), time.Millisecond)
+	defer cancel()
+
+	d := Detach(ctx)
+	if _, ok := d.Deadline(); ok {
+		t.Error("detached context should have no deadline")
+	}
+	if attrMap(getTriageAttrs(d))[AttrTenantID] != "org_1" {
+		t.Error("detached context should keep triage annotations")
+	}
+}
+
+func TestShutdown_WaitsForGoroutines(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	Go(context.Background(), func(ctx context.Context) {
+		time.Sleep(50 * time.Millisecond)
+		wf, _ := StartWorkflow(ctx, "late")
+		wf.End()
+	})
+
+	fs, err := ShutdownWithStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.SpansFlushed != 1 {
+		t.Errorf("expected the goroutine's span to be flushed, got %+v", fs)
+	}
+}
+
+func TestWaitBackground_TimesOutAndStaysUsable(t *testing.T) {
+	release := make(chan struct{})
+	Go(context.Background(), func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	waitBackground(ctx)
+
+	// Go after a timed-out wait, while the first goroutine still runs.
+	done := make(chan struct{})
+	Go(context.Background(), func(context.Context) { close(done) })
+	<-done
+	close(release)
+
+	finished := make(chan struct{})
+	go func() {
+		waitBackground(context.Background())
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("waitBackground should return once all goroutines finish")
+	}
+}
@@ -0,0 +1,77 @@
+package triage
+
+import (
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultResponseHeaders are the provider response headers captured when
+// WithResponseHeaders is not set: request IDs for support tickets, and
+// deprecation notices.
+var defaultResponseHeaders = []string{
+	"x-request-id", // OpenAI, most OpenAI-compatible servers
+	"request-id",   // Anthropic
+	"openai-processing-ms",
+	"deprecation",
+	"sunset",
+	"warning",
+}
+
+// neverCapturedHeaders are dropped even if allow-listed.
+var neverCapturedHeaders = map[string]bool{
+	"set-cookie": true,
+}
+
+// WithResponseHeaders sets the allow-list of provider response headers that
+// LLMSpan.RecordResponseHeaders captures (case-insensitive), replacing the
+// default list of request ID and deprecation headers. Call with no arguments
+// to capture none.
+func WithResponseHeaders(names ...string) Option {
+	return func(c *config) {
+		c.responseHeaders = make([]string, 0, len(names))
+		for _, n := range names {
+			c.responseHeaders = append(c.responseHeaders, strings.ToLower(n))
+		}
+	}
+}
+
+// RecordResponseHeaders records the allow-listed headers of a provider
+// response as http.response.header.<name> attributes, so request IDs, model
+// routing info and deprecation warnings join the trace. Integrations call it
+// with the raw HTTP response headers.
+//
+// Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) RecordResponseHeaders(h http.Header) {
+	if ls == nil || ls.span == nil {
+		return
+	}
+	if attrs := responseHeaderAttributes(h, responseHeaderAllowList()); len(attrs) > 0 {
+		ls.span.SetAttributes(attrs...)
+	}
+}
+
+// responseHeaderAllowList returns the configured allow-list, or the default
+// if the SDK hasn't been initialized yet.
+func responseHeaderAllowList() []string {
+	if globalCfg == nil || globalCfg.responseHeaders == nil {
+		return defaultResponseHeaders
+	}
+	return globalCfg.responseHeaders
+}
+
+// responseHeaderAttributes returns one string-slice attribute per
+// allow-listed header present in h.
+func responseHeaderAttributes(h http.Header, allow []string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, name := range allow {
+		if neverCapturedHeaders[name] {
+			continue
+		}
+		if vals := h.Values(name); len(vals) > 0 {
+			attrs = append(attrs, attribute.StringSlice(AttrResponseHeaderPrefix+name, vals))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,61 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestRecordResponseHeaders_DefaultAllowList(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	h := http.Header{}
+	h.Set("X-Request-Id", "req_abc")
+	h.Set("Deprecation", "true")
+	h.Set("Openai-Organization", "org-secret")
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.RecordResponseHeaders(h)
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if got := attrs[AttrResponseHeaderPrefix+"x-request-id"]; !reflect.DeepEqual(got, []string{"req_abc"}) {
+		t.Errorf("x-request-id: got %v", got)
+	}
+	if got := attrs[AttrResponseHeaderPrefix+"deprecation"]; !reflect.DeepEqual(got, []string{"true"}) {
+		t.Errorf("deprecation: got %v", got)
+	}
+	if _, ok := attrs[AttrResponseHeaderPrefix+"openai-organization"]; ok {
+		t.Error("headers outside the allow-list must not be captured")
+	}
+}
+
+func TestWithResponseHeaders(t *testing.T) {
+	c := &config{}
+	WithResponseHeaders("X-Model-Route", "Set-Cookie")(c)
+
+	h := http.Header{}
+	h.Set("X-Model-Route", "us-east/gpt-4o-2024-08-06")
+	h.Set("X-Request-Id", "req_abc")
+	h.Add("Set-Cookie", "session=1")
+
+	attrs := attrMap(responseHeaderAttributes(h, c.responseHeaders))
+	if len(attrs) != 1 {
+		t.Fatalf("expected only x-model-route, got %v", attrs)
+	}
+	if _, ok := attrs[AttrResponseHeaderPrefix+"x-model-route"]; !ok {
+		t.Errorf("x-model-route missing: %v", attrs)
+	}
+}
+
+func TestWithResponseHeaders_None(t *testing.T) {
+	c := &config{}
+	WithResponseHeaders()(c)
+
+	h := http.Header{}
+	h.Set("X-Request-Id", "req_abc")
+	if attrs := responseHeaderAttributes(h, c.responseHeaders); len(attrs) != 0 {
+		t.Errorf("expected no headers, got %v", attrs)
+	}
+}
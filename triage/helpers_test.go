@@ -4,7 +4,9 @@ import (
 	"context"
 	"testing"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
@@ -41,10 +43,23 @@ func resetSDK(t *testing.T) {
 	t.Helper()
 	mu.Lock()
 	defer mu.Unlock()
+	if datasets != nil {
+		_ = datasets.shutdown(context.Background())
+	}
 	if provider != nil {
 		_ = provider.Shutdown(context.Background())
 	}
+	if meters != nil {
+		_ = meters.Shutdown(context.Background())
+	}
 	initialized = false
 	provider = nil
+	meters = nil
 	globalCfg = nil
+	apiClient = nil
+	datasets = nil
+	sessions = nil
+	risks = nil
+	queueHigh.Store(false)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
 }
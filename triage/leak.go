@@ -0,0 +1,80 @@
+package triage
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WithOutputScanning controls whether completion content is scanned for PII
+// and secrets (API keys, private keys, tokens). When a completion contains
+// sensitive data, its span records triage.output.leak.* attributes naming
+// the detected types and the number of matches — never the matched values —
+// so exfiltration via the response channel is detectable even when trace
+// content is disabled.
+//
+// Off by default: the SDK leaves PII detection to the backend. Scanning runs
+// the PII and secret detectors synchronously inside LogCompletion, over up
+// to the first 64 KiB of each completion, which can add noticeable latency
+// to calls with large outputs.
+func WithOutputScanning(b bool) Option {
+	return func(c *config) { c.outputScanning = b }
+}
+
+// maxOutputScanBytes caps the completion content scanned per call.
+const maxOutputScanBytes = 64 << 10
+
+// isOutputScanningEnabled returns whether completions should be scanned.
+// Defaults to false if the SDK hasn't been initialized yet.
+func isOutputScanningEnabled() bool {
+	return globalCfg != nil && globalCfg.outputScanning
+}
+
+// outputLeakAttributes scans the completion's message content and tool call
+// arguments, up to maxOutputScanBytes in all, with the PII and secret
+// detectors and returns the leak attributes, or nil if nothing was found.
+func outputLeakAttributes(completion Completion) []attribute.KeyValue {
+	if !isOutputScanningEnabled() {
+		return nil
+	}
+	counts := map[string]int{}
+	budget := maxOutputScanBytes
+	scan := func(text string) {
+		if len(text) > budget {
+			text = text[:budget]
+		}
+		if text == "" {
+			return
+		}
+		budget -= len(text)
+		for _, detectors := range [][]piiDetector{defaultPIIDetectors, defaultSecretDetectors} {
+			for _, d := range detectors {
+				if n := len(d.find(text)); n > 0 {
+					counts[d.name] += n
+				}
+			}
+		}
+	}
+	for _, m := range completion.Messages {
+		scan(m.Content)
+		for _, tc := range m.ToolCalls {
+			scan(tc.Function.Arguments)
+		}
+	}
+	if len(counts) == 0 {
+		return nil
+	}
+
+	types := make([]string, 0, len(counts))
+	total := 0
+	for name, n := range counts {
+		types = append(types, name)
+		total += n
+	}
+	sort.Strings(types)
+	return []attribute.KeyValue{
+		attribute.Bool(AttrOutputLeakDetected, true),
+		attribute.StringSlice(AttrOutputLeakTypes, types),
+		attribute.Int(AttrOutputLeakCount, total),
+	}
+}
@@ -0,0 +1,73 @@
+package triage
+
+import (
+	"context"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Lib is the handle a shared library uses to instrument itself with Triage.
+// Create one with Library.
+type Lib struct {
+	name    string
+	version string
+	attrs   []attribute.KeyValue
+}
+
+// Library registers a reusable package as a source of Triage spans and
+// annotations. It is safe to call from package initialization, before the
+// host application has called Init (or whether it ever does):
+//
+//	var triageLib = triage.Library("github.com/acme/rag", "v1.4.0")
+//
+//	func Rerank(ctx context.Context, docs []Doc) []Doc {
+//	    ctx, span := triageLib.Start(ctx, "rerank")
+//	    defer span.End()
+//	    ...
+//	}
+//
+// Everything recorded through the handle is a no-op until Init runs, then
+// flows through the Triage pipeline with the library's name and version as
+// the instrumentation scope and as triage.library.* attributes.
+func Library(name, version string) *Lib {
+	l := &Lib{name: name, version: version}
+	l.attrs = append(l.attrs, attribute.String(AttrLibraryName, name))
+	if version != "" {
+		l.attrs = append(l.attrs, attribute.String(AttrLibraryVersion, version))
+	}
+	return l
+}
+
+// Enabled reports whether the host application has initialized the SDK, so
+// libraries can skip building annotations nobody will record.
+func (l *Lib) Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return provider != nil
+}
+
+// Tracer returns a tracer scoped to the library from TracerProvider. It is
+// resolved on every call, so a tracer obtained before Init is a no-op while
+// one obtained after records.
+func (l *Lib) Tracer() trace.Tracer {
+	return TracerProvider().Tracer(l.name, trace.WithInstrumentationVersion(l.version))
+}
+
+// Start starts a span in the library's scope, tagged with the library's name
+// and version. The span is a non-recording no-op until Init runs.
+func (l *Lib) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(l.attrs...))
+	return l.Tracer().Start(ctx, name, opts...)
+}
+
+// AddEvent records a timestamped event on the current span, tagged with the
+// library's name and version. No-op if the SDK is not initialized, ctx
+// carries no recording span, or name is empty.
+func (l *Lib) AddEvent(ctx context.Context, name string, attrs ...attribute.KeyValue) {
+	if !l.Enabled() {
+		return
+	}
+	AddEvent(ctx, name, slices.Concat(attrs, l.attrs)...)
+}
@@ -0,0 +1,72 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// installProvider stands in for Init, setting the SDK's tracer provider to
+// one exporting to an in-memory exporter.
+func installProvider(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	mu.Lock()
+	provider = sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	mu.Unlock()
+	t.Cleanup(func() { resetSDK(t) })
+	return exporter
+}
+
+// ---------------------------------------------------------------------------
+// Library
+// ---------------------------------------------------------------------------
+
+func TestLibrary_NoopBeforeInit(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	lib := Library("github.com/acme/rag", "v1.4.0")
+
+	if lib.Enabled() {
+		t.Error("Enabled should be false before Init")
+	}
+	ctx, span := lib.Start(context.Background(), "rerank")
+	lib.AddEvent(ctx, "reranked")
+	span.End()
+	if span.SpanContext().IsValid() || span.IsRecording() {
+		t.Error("spans should be no-ops before Init")
+	}
+}
+
+func TestLibrary_RecordsAfterInit(t *testing.T) {
+	// Registered before Init, as a package-level var would be.
+	lib := Library("github.com/acme/rag", "v1.4.0")
+	exporter := installProvider(t)
+
+	if !lib.Enabled() {
+		t.Fatal("Enabled should be true after Init")
+	}
+	ctx, span := lib.Start(context.Background(), "rerank")
+	lib.AddEvent(ctx, "reranked", attribute.Int("docs", 3))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	got := spans[0]
+	if got.InstrumentationScope.Name != "github.com/acme/rag" || got.InstrumentationScope.Version != "v1.4.0" {
+		t.Errorf("scope: got %+v", got.InstrumentationScope)
+	}
+	if attrs := attrMap(got.Attributes); attrs[AttrLibraryName] != "github.com/acme/rag" || attrs[AttrLibraryVersion] != "v1.4.0" {
+		t.Errorf("span attributes: got %v", attrs)
+	}
+	if len(got.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(got.Events))
+	}
+	if ev := attrMap(got.Events[0].Attributes); ev["docs"] != int64(3) || ev[AttrLibraryName] != "github.com/acme/rag" {
+		t.Errorf("event attributes: got %v", ev)
+	}
+}
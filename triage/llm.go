@@ -2,11 +2,13 @@ package triage
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -18,10 +20,14 @@ const llmTracerName = "triage.llm"
 
 // Prompt represents an LLM request with messages and optional parameters.
 type Prompt struct {
-	Vendor   string    // LLM provider: "openai", "anthropic", etc.
+	Vendor   string    // LLM provider: VendorOpenAI, VendorAnthropic, VendorGroq, etc.
 	Model    string    // Model name: "gpt-4o", "claude-sonnet-4-5-20250929", etc.
 	Messages []Message // Conversation messages
-	Tools    []ToolDef // Available tool/function definitions
+	Tools    []ToolDef // Available tool/function definitions, including built-in tools such as "web_search"
+
+	// Operation is the API operation, recorded as gen_ai.operation.name and
+	// in the span name: OperationChat (the default) or OperationResponses.
+	Operation string
 
 	// Optional request parameters.
 	MaxTokens        int
@@ -30,33 +36,58 @@ type Prompt struct {
 	FrequencyPenalty *float64
 	PresencePenalty  *float64
 	Stop             []string
+	ServiceTier      string // Requested processing tier, e.g. OpenAI's "auto", "default", "flex", "priority"
+
+	// Azure OpenAI deployment name and API version (e.g. "2024-10-21").
+	// Deployment names need not match the model they serve, so set Model to
+	// the underlying model when known; otherwise spans are named after the
+	// deployment.
+	Deployment string
+	APIVersion string
+
+	// Provider endpoint that served the call (URL or host[:port], e.g.
+	// "https://eu.api.openai.com/v1") and its region (e.g. a Bedrock or Azure
+	// region), for data residency audits. Region is inferred from Bedrock and
+	// Vertex AI hosts when empty.
+	Endpoint string
+	Region   string
+
+	// Serving stack for self-hosted models, merged over WithServing.
+	Serving Serving
+
+	// Provider prompt caching. CacheKey is a routing key such as OpenAI's
+	// prompt_cache_key; CacheBreakpoints mark cache blocks such as Anthropic
+	// cache_control markers.
+	CacheKey         string
+	CacheBreakpoints []CacheBreakpoint
 }
 
 // Message represents a single message in an LLM conversation.
 type Message struct {
-	Role       string     // "system", "user", "assistant", "tool"
-	Content    string     // Message text content
-	ToolCalls  []ToolCall // Tool calls in assistant messages
-	ToolCallID string     // Tool call ID in tool-result messages
+	Role       string     `json:"role"`                   // "system", "user", "assistant", "tool"
+	Content    string     `json:"content,omitempty"`      // Message text content
+	Reasoning  string     `json:"reasoning,omitempty"`    // Model reasoning ("thinking") text, if the provider returns it
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // Tool calls in assistant messages
+	ToolCallID string     `json:"tool_call_id,omitempty"` // Tool call ID in tool-result messages
 }
 
 // ToolCall represents a tool/function call made by the model.
 type ToolCall struct {
-	ID       string           // Unique call ID
-	Type     string           // "function"
-	Function ToolCallFunction // Function name and arguments
+	ID       string           `json:"id"`       // Unique call ID
+	Type     string           `json:"type"`     // "function"
+	Function ToolCallFunction `json:"function"` // Function name and arguments
 }
 
 // ToolCallFunction holds the function name and JSON-encoded arguments.
 type ToolCallFunction struct {
-	Name      string // Function name
-	Arguments string // JSON-encoded arguments
+	Name      string `json:"name"`      // Function name
+	Arguments string `json:"arguments"` // JSON-encoded arguments
 }
 
 // ToolDef defines a tool available to the model.
 type ToolDef struct {
-	Type     string       // "function"
-	Function ToolFunction // Function definition
+	Type     string       // "function", or a built-in tool type such as "web_search" or "file_search"
+	Function ToolFunction // Function definition; empty for built-in tools
 }
 
 // ToolFunction describes a callable function for tool use.
@@ -68,8 +99,35 @@ type ToolFunction struct {
 
 // Completion represents an LLM response.
 type Completion struct {
-	Model    string    // Model that generated the response
-	Messages []Message // Response messages
+	Model       string    // Model that generated the response
+	Messages    []Message // Response messages
+	ServiceTier string    // Tier that actually processed the request, if reported
+
+	// FinishReason is the provider's stop reason, e.g. "stop", "tool_calls",
+	// "length" or "max_tokens". Token-limit truncations are reported via a
+	// triage.truncated span event, a counter metric and WithTruncationHandler.
+	FinishReason string
+
+	// BuiltinToolCalls are the calls the model made to tools the provider
+	// runs itself, such as the OpenAI Responses API's web_search and
+	// file_search.
+	BuiltinToolCalls []BuiltinToolCall
+
+	// ServerMetrics carries server-side timings and KV-cache statistics from
+	// self-hosted servers; see ServerMetricsFromHeaders.
+	ServerMetrics ServerMetrics
+}
+
+// BuiltinToolCall is a call to a tool the provider runs itself, reported as
+// an output item by the OpenAI Responses API. Unlike ToolCalls, it needs no
+// tool message in reply.
+type BuiltinToolCall struct {
+	ID      string   // Output item ID
+	Type    string   // Item type, e.g. "web_search_call", "file_search_call", "code_interpreter_call"
+	Status  string   // "completed", "failed", ...
+	Name    string   // Tool name, for remote MCP tool calls
+	Queries []string // Search queries, when reported; recorded only when trace content is enabled
+	Results int      // Number of search results, when reported
 }
 
 // Usage represents token counts for an LLM call.
@@ -77,13 +135,52 @@ type Usage struct {
 	PromptTokens     int
 	CompletionTokens int
 	TotalTokens      int
+
+	// Prompt cache usage. Zero values are not recorded.
+	CacheReadTokens  int
+	CacheWriteTokens int
+
+	// Reasoning ("thinking") tokens, included in CompletionTokens. Zero
+	// values are not recorded.
+	ReasoningTokens int
+
+	// Multimodal token classes, billed separately by most providers. Zero
+	// values are not recorded.
+	AudioInputTokens  int
+	AudioOutputTokens int
+	ImageTokens       int
 }
 
 // LLMSpan wraps an in-flight LLM call span. Call LogCompletion to record the
 // response and end the span.
 type LLMSpan struct {
-	span trace.Span
-	ctx  context.Context
+	span  trace.Span
+	ctx   context.Context
+	start time.Time
+	ended atomic.Bool // set by the first LogCompletion or End
+
+	budget *traceBudget // set if this span opened the trace budget
+	rollup *usageRollup // enclosing workflow's usage rollup, if any
+
+	stream streamState // chunk timing, fed by RecordChunk
+	deltas deltaState  // streamed response, fed by AppendDelta
+	rounds roundState  // intermediate model rounds, fed by LogRound
+
+	// Enclosing workflow and prompt template, the cost attribution dimensions.
+	workflow   string
+	templateID string
+
+	// Requested vendor, operation, model and service tier, used for cost
+	// estimation, token usage metrics and truncation reporting.
+	vendor      string
+	operation   string
+	model       string
+	serviceTier string
+
+	// Retained only when the call is mirrored into a dataset or recorded by
+	// session tracking.
+	dataset  *datasetCapture
+	messages []Message
 }
 
 // Context returns the context carrying this LLM span, suitable for creating
@@ -95,6 +192,15 @@ func (ls *LLMSpan) Context() context.Context {
 	return ls.ctx
 }
 
+// SpanContext returns the span context of the LLM call. Hand its trace and
+// span IDs to the client so later feedback can be linked back to this call.
+func (ls *LLMSpan) SpanContext() trace.SpanContext {
+	if ls == nil || ls.span == nil {
+		return trace.SpanContext{}
+	}
+	return ls.span.SpanContext()
+}
+
 // ---------------------------------------------------------------------------
 // Public API
 // ---------------------------------------------------------------------------
@@ -114,20 +220,191 @@ func (ls *LLMSpan) Context() context.Context {
 //	// ... make your LLM API call using ctx ...
 //	llmSpan.LogCompletion(triage.Completion{...}, triage.Usage{...})
 func LogPrompt(ctx context.Context, prompt Prompt) (*LLMSpan, context.Context) {
-	tracer := otel.GetTracerProvider().Tracer(llmTracerName)
+	tracer := sdkTracer()
 
-	spanName := prompt.Vendor + ".chat"
-	if prompt.Model != "" {
-		spanName = prompt.Vendor + ".chat " + prompt.Model
+	prompt.Vendor = normalizeVendor(prompt.Vendor)
+	prompt.Model = requestModel(prompt)
+	if prompt.Operation == "" {
+		prompt.Operation = OperationChat
 	}
+	spanName := llmSpanName(prompt.Vendor, prompt.Operation, prompt.Model)
 
+	ctx, budget, ok := admitSpan(ctx)
+	if !ok {
+		return &LLMSpan{ctx: ctx}, ctx
+	}
+
+	parent := trace.SpanContextFromContext(ctx)
 	ctx, span := tracer.Start(ctx, spanName, trace.WithSpanKind(trace.SpanKindClient))
+	if parent.IsValid() && !parent.IsRemote() {
+		// Local root spans are linked to the origin by the span processor.
+		linkOrigin(ctx, span)
+	}
+
+	// Attribute building runs user-supplied values (e.g. tool parameter
+	// MarshalJSON methods); a panic there must not escape into the caller.
+	var attrs []attribute.KeyValue
+	hashContent := isTraceContentEnabled() &&
+		!traceBudgetFrom(ctx).admitContent(messagesContentBytes(prompt.Messages), span)
+	guard("LogPrompt", func() { attrs = promptAttributes(prompt, hashContent) })
+	attrs = append(attrs, retrievedDocumentsFromContext(ctx)...)
+	attrs = append(attrs, getFromContext(ctx).templateValues...)
+	workflow := workflowNameFromContext(ctx)
+	if workflow != "" {
+		attrs = append(attrs, attribute.String("traceloop.workflow.name", workflow))
+	}
+	span.SetAttributes(attrs...)
+
+	ls := &LLMSpan{
+		span:        span,
+		ctx:         ctx,
+		start:       time.Now(),
+		budget:      budget,
+		rollup:      usageRollupFrom(ctx),
+		workflow:    workflow,
+		templateID:  getFromContext(ctx).templateID,
+		vendor:      prompt.Vendor,
+		operation:   prompt.Operation,
+		model:       prompt.Model,
+		serviceTier: prompt.ServiceTier,
+	}
+	ls.dataset = datasetCaptureFor(ctx)
+	if ls.dataset != nil || sessionTrackingFor(ctx) {
+		ls.messages = prompt.Messages
+	}
+	return ls, ctx
+}
 
+// LogCompletion records the LLM response and token usage, then ends the span.
+// For a streamed response recorded with AppendDelta, the messages, model,
+// finish reason and usage left empty are taken from the deltas. It never
+// reads the caller's context, so the span is recorded and exported even if
+// that context was cancelled. Calls after the span has ended (by
+// LogCompletion or End) are no-ops. Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) LogCompletion(completion Completion, usage Usage) {
+	if ls == nil || ls.span == nil || !ls.ended.CompareAndSwap(false, true) {
+		return
+	}
+
+	completion, usage = ls.deltas.merge(completion, usage)
+	usage, rounds := ls.rounds.total(usage)
+	hashContent := isTraceContentEnabled() &&
+		!traceBudgetFrom(ls.ctx).admitContent(messagesContentBytes(completion.Messages), ls.span)
+	var attrs []attribute.KeyValue
+	guard("LogCompletion", func() { attrs = ls.completionAttributes(completion, usage, hashContent) })
+	if rounds > 0 {
+		attrs = append(attrs, attribute.Int(AttrLLMRounds, rounds))
+	}
+	var record *DatasetRecord
+	if ls.dataset != nil && datasets != nil {
+		guard("LogCompletion", func() {
+			rec, redacted := newDatasetRecord(ls, completion)
+			record = &rec
+			attrs = append(attrs, redactionAttributes(datasetRedactionField, redacted)...)
+		})
+	}
+	ls.span.SetAttributes(attrs...)
+	ls.stopWatchingCancellation()
+	ls.budget.report(ls.span)
+	ls.span.End()
+	ls.notifyTruncation(completion, usage)
+
+	guard("LogCompletion", func() {
+		if record != nil {
+			if sink := datasets; sink != nil {
+				sink.enqueue(ls.dataset.name, *record)
+			}
+		}
+		if sm := sessions; sm != nil {
+			sm.recordTurn(ls.ctx, ls, completion)
+		}
+	})
+}
+
+// SetError records a failed provider call on the span and sets its status to
+// Error. Follow it with LogCompletion (with any partial response) or End.
+// Context cancellation and deadline errors are tagged error.type "cancelled"
+// and "timeout", so caller timeouts are distinguishable from provider
+// failures.
+//
+// Provider errors sometimes echo fragments of the prompt, so the message is
+// scrubbed (see scrubErrorMessage) before it becomes the status description
+// and exception event. Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) SetError(err error) {
+	if ls == nil || ls.span == nil || err == nil {
+		return
+	}
+	recordSpanError(ls.span, err)
+}
+
+// recordSpanError records err on span as a scrubbed exception event and
+// Error status, tagged with error.type and an audit of any redactions.
+func recordSpanError(span trace.Span, err error) {
+	msg, redacted := scrubErrorMessageMatches(err.Error())
+	span.AddEvent(exceptionEventName, trace.WithAttributes(
+		attribute.String(AttrExceptionType, fmt.Sprintf("%T", err)),
+		attribute.String(AttrExceptionMessage, msg),
+	))
+	span.SetStatus(codes.Error, msg)
+	span.SetAttributes(attribute.String(AttrErrorType, errorType(err)))
+	span.SetAttributes(redactionAttributes(AttrExceptionMessage, redacted)...)
+}
+
+// End ends the span without recording a completion, unless LogCompletion or
+// End already ended it. If the response was streamed with AppendDelta, End
+// records the completion accumulated so far, as LogCompletion would. Defer it
+// right after LogPrompt so the span is never lost when the call returns
+// early, e.g. on a cancelled context:
+//
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//	defer llmSpan.End()
+//	resp, err := client.CreateChatCompletion(ctx, req)
+//	if err != nil {
+//	    llmSpan.SetError(err)
+//	    return err
+//	}
+//	llmSpan.LogCompletion(completion, usage)
+//
+// Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) End() {
+	if ls == nil || ls.span == nil {
+		return
+	}
+	if ls.deltas.hasDeltas() {
+		ls.LogCompletion(Completion{}, Usage{})
+		return
+	}
+	if !ls.ended.CompareAndSwap(false, true) {
+		return
+	}
+	ls.stopWatchingCancellation()
+	ls.budget.report(ls.span)
+	ls.span.End()
+}
+
+// errorType classifies err for the error.type attribute.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "cancelled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrBlocked):
+		return "blocked"
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+// promptAttributes returns the request attributes recorded by LogPrompt.
+// hashContent records message content as digests (see
+// WithMaxContentBytesPerTrace).
+func promptAttributes(prompt Prompt, hashContent bool) []attribute.KeyValue {
 	var attrs []attribute.KeyValue
 
 	// gen_ai.* — OpenTelemetry GenAI semantic conventions (primary).
 	attrs = append(attrs,
 		attribute.String("gen_ai.system", prompt.Vendor),
+		attribute.String(AttrGenAIOperationName, prompt.Operation),
 		attribute.String("gen_ai.request.model", prompt.Model),
 	)
 
@@ -135,7 +412,7 @@ func LogPrompt(ctx context.Context, prompt Prompt) (*LLMSpan, context.Context) {
 	attrs = append(attrs,
 		attribute.String("llm.vendor", prompt.Vendor),
 		attribute.String("llm.request.model", prompt.Model),
-		attribute.String("llm.request.type", "chat"),
+		attribute.String("llm.request.type", prompt.Operation),
 	)
 
 	// Optional request parameters.
@@ -157,14 +434,25 @@ func LogPrompt(ctx context.Context, prompt Prompt) (*LLMSpan, context.Context) {
 	if len(prompt.Stop) > 0 {
 		attrs = append(attrs, attribute.StringSlice("gen_ai.request.stop_sequences", prompt.Stop))
 	}
+	if prompt.ServiceTier != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIRequestServiceTier, prompt.ServiceTier))
+	}
+	attrs = append(attrs, cacheAttributes(prompt)...)
+	attrs = append(attrs, providerEndpointAttributes(prompt.Endpoint, prompt.Region)...)
+	attrs = append(attrs, azureAttributes(prompt)...)
+	attrs = append(attrs, servingAttributes(prompt)...)
+	attrs = append(attrs, modelValidationAttributes(prompt)...)
 
 	// Prompt messages — only when trace content is enabled.
 	if isTraceContentEnabled() {
+		if hashContent {
+			attrs = append(attrs, attribute.Bool(AttrContentHashed, true))
+		}
 		for i, msg := range prompt.Messages {
 			prefix := fmt.Sprintf("gen_ai.prompt.%d", i)
 			attrs = append(attrs, attribute.String(prefix+".role", msg.Role))
 			if msg.Content != "" {
-				attrs = append(attrs, attribute.String(prefix+".content", msg.Content))
+				attrs = append(attrs, attribute.String(prefix+".content", capturedContent(msg.Content, hashContent)))
 			}
 			for j, tc := range msg.ToolCalls {
 				tcPrefix := fmt.Sprintf("%s.tool_calls.%d", prefix, j)
@@ -172,7 +460,7 @@ func LogPrompt(ctx context.Context, prompt Prompt) (*LLMSpan, context.Context) {
 					attribute.String(tcPrefix+".id", tc.ID),
 					attribute.String(tcPrefix+".type", tc.Type),
 					attribute.String(tcPrefix+".function.name", tc.Function.Name),
-					attribute.String(tcPrefix+".function.arguments", tc.Function.Arguments),
+					attribute.String(tcPrefix+".function.arguments", capturedContent(tc.Function.Arguments, hashContent)),
 				)
 			}
 			if msg.ToolCallID != "" {
@@ -185,28 +473,26 @@ func LogPrompt(ctx context.Context, prompt Prompt) (*LLMSpan, context.Context) {
 	for i, tool := range prompt.Tools {
 		prefix := fmt.Sprintf("gen_ai.request.tool.%d", i)
 		attrs = append(attrs, attribute.String(prefix+".type", tool.Type))
-		attrs = append(attrs, attribute.String(prefix+".function.name", tool.Function.Name))
+		if tool.Function.Name != "" {
+			attrs = append(attrs, attribute.String(prefix+".function.name", tool.Function.Name))
+		}
 		if tool.Function.Description != "" {
 			attrs = append(attrs, attribute.String(prefix+".function.description", tool.Function.Description))
 		}
 		if tool.Function.Parameters != nil {
-			if paramJSON, err := json.Marshal(tool.Function.Parameters); err == nil {
+			if paramJSON, err := safeMarshal("LogPrompt", tool.Function.Parameters); err == nil {
 				attrs = append(attrs, attribute.String(prefix+".function.parameters", string(paramJSON)))
 			}
 		}
 	}
 
-	span.SetAttributes(attrs...)
-	return &LLMSpan{span: span, ctx: ctx}, ctx
+	return attrs
 }
 
-// LogCompletion records the LLM response and token usage, then ends the span.
-// Safe to call on a nil LLMSpan (no-op).
-func (ls *LLMSpan) LogCompletion(completion Completion, usage Usage) {
-	if ls == nil || ls.span == nil {
-		return
-	}
-
+// completionAttributes returns the response attributes recorded by
+// LogCompletion. Truncated responses are also reported here. hashContent
+// records message content as digests (see WithMaxContentBytesPerTrace).
+func (ls *LLMSpan) completionAttributes(completion Completion, usage Usage, hashContent bool) []attribute.KeyValue {
 	var attrs []attribute.KeyValue
 
 	// Response model.
@@ -216,13 +502,41 @@ func (ls *LLMSpan) LogCompletion(completion Completion, usage Usage) {
 			attribute.String("llm.response.model", completion.Model),
 		)
 	}
+	if completion.ServiceTier != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIResponseServiceTier, completion.ServiceTier))
+	}
+	if completion.FinishReason != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIResponseFinishReason, completion.FinishReason))
+		if category := finishCategory(completion.FinishReason); category != "" {
+			attrs = append(attrs, attribute.String(AttrResponseFinishCategory, category))
+		}
+	}
 
 	// Token usage — gen_ai.* conventions.
 	attrs = append(attrs,
-		attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
-		attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+		attribute.Int(AttrGenAIUsageInputTokens, usage.PromptTokens),
+		attribute.Int(AttrGenAIUsageOutputTokens, usage.CompletionTokens),
 	)
 
+	if usage.CacheReadTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageCacheReadTokens, usage.CacheReadTokens))
+	}
+	if usage.CacheWriteTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageCacheWriteTokens, usage.CacheWriteTokens))
+	}
+	if usage.ReasoningTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageReasoningTokens, usage.ReasoningTokens))
+	}
+	if usage.AudioInputTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageAudioInputTokens, usage.AudioInputTokens))
+	}
+	if usage.AudioOutputTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageAudioOutputTokens, usage.AudioOutputTokens))
+	}
+	if usage.ImageTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrGenAIUsageImageTokens, usage.ImageTokens))
+	}
+
 	// Token usage — llm.* conventions (backward compat).
 	attrs = append(attrs,
 		attribute.Int("llm.usage.prompt_tokens", usage.PromptTokens),
@@ -230,13 +544,56 @@ func (ls *LLMSpan) LogCompletion(completion Completion, usage Usage) {
 		attribute.Int("llm.usage.total_tokens", usage.TotalTokens),
 	)
 
+	// Estimated cost, priced by the model and tier that actually answered.
+	model := completion.Model
+	if model == "" {
+		model = ls.model
+	}
+	tier := completion.ServiceTier
+	if tier == "" {
+		tier = ls.serviceTier
+	}
+	cost, priced := estimateCost(model, tier, usage)
+	if priced {
+		attrs = append(attrs,
+			attribute.Float64(AttrCostInput, cost.Input),
+			attribute.Float64(AttrCostOutput, cost.Output),
+			attribute.Float64(AttrCostTotal, cost.Total),
+		)
+		ls.recordCost(model, cost)
+	}
+	ls.rollup.add(usage, cost, priced)
+	ls.recordTokenUsage(completion.Model, usage)
+	attrs = append(attrs, contextUtilizationAttributes(model, usage)...)
+
+	attrs = append(attrs, ls.stream.attributes(ls.start)...)
+	attrs = append(attrs, ls.stream.latencyAttributes(ls.start, usage.CompletionTokens)...)
+	attrs = append(attrs, completion.ServerMetrics.attributes(time.Since(ls.start), usage.PromptTokens)...)
+
+	if isTruncated(completion.FinishReason) {
+		attrs = append(attrs, attribute.Bool(AttrResponseTruncated, true))
+		ls.recordTruncation(model, completion, usage)
+	}
+
+	attrs = append(attrs, builtinToolCallAttributes(completion.BuiltinToolCalls, hashContent)...)
+
+	// Leak scanning reports detector names and counts only, so it runs
+	// regardless of the trace content setting.
+	attrs = append(attrs, outputLeakAttributes(completion)...)
+
 	// Completion messages — only when trace content is enabled.
 	if isTraceContentEnabled() {
+		if hashContent {
+			attrs = append(attrs, attribute.Bool(AttrContentHashed, true))
+		}
 		for i, msg := range completion.Messages {
 			prefix := fmt.Sprintf("gen_ai.completion.%d", i)
 			attrs = append(attrs, attribute.String(prefix+".role", msg.Role))
 			if msg.Content != "" {
-				attrs = append(attrs, attribute.String(prefix+".content", msg.Content))
+				attrs = append(attrs, attribute.String(prefix+".content", capturedContent(msg.Content, hashContent)))
+			}
+			if msg.Reasoning != "" {
+				attrs = append(attrs, attribute.String(prefix+".reasoning", capturedContent(msg.Reasoning, hashContent)))
 			}
 			for j, tc := range msg.ToolCalls {
 				tcPrefix := fmt.Sprintf("%s.tool_calls.%d", prefix, j)
@@ -244,21 +601,62 @@ func (ls *LLMSpan) LogCompletion(completion Completion, usage Usage) {
 					attribute.String(tcPrefix+".id", tc.ID),
 					attribute.String(tcPrefix+".type", tc.Type),
 					attribute.String(tcPrefix+".function.name", tc.Function.Name),
-					attribute.String(tcPrefix+".function.arguments", tc.Function.Arguments),
+					attribute.String(tcPrefix+".function.arguments", capturedContent(tc.Function.Arguments, hashContent)),
 				)
 			}
 		}
 	}
 
-	ls.span.SetAttributes(attrs...)
-	ls.span.End()
+	return attrs
+}
+
+// builtinToolCallAttributes returns the attributes recording built-in tool
+// calls. Search queries are content, recorded only when trace content is
+// enabled.
+func builtinToolCallAttributes(calls []BuiltinToolCall, hashContent bool) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for i, call := range calls {
+		prefix := fmt.Sprintf("%s.%d", AttrGenAIResponseBuiltinToolCall, i)
+		attrs = append(attrs, attribute.String(prefix+".type", call.Type))
+		if call.ID != "" {
+			attrs = append(attrs, attribute.String(prefix+".id", call.ID))
+		}
+		if call.Status != "" {
+			attrs = append(attrs, attribute.String(prefix+".status", call.Status))
+		}
+		if call.Name != "" {
+			attrs = append(attrs, attribute.String(prefix+".name", call.Name))
+		}
+		if call.Results > 0 {
+			attrs = append(attrs, attribute.Int(prefix+".results", call.Results))
+		}
+		if len(call.Queries) > 0 && isTraceContentEnabled() {
+			queries := make([]string, len(call.Queries))
+			for j, q := range call.Queries {
+				queries[j] = capturedContent(q, hashContent)
+			}
+			attrs = append(attrs, attribute.StringSlice(prefix+".queries", queries))
+		}
+	}
+	return attrs
+}
+
+// capturedContent returns s as recorded on a span: as-is, or as a digest
+// when hashed.
+func capturedContent(s string, hashed bool) string {
+	if hashed {
+		return contentDigest(s)
+	}
+	return s
 }
 
 // isTraceContentEnabled returns whether prompt/completion content should be
-// captured. Defaults to true if the SDK hasn't been initialized yet.
+// captured. Defaults to true if the SDK hasn't been initialized yet, unless
+// spans are being buffered for Init (see BufferEarlySpans): they may be
+// exported under a configuration that disables content.
 func isTraceContentEnabled() bool {
 	if globalCfg == nil {
-		return true
+		return earlyTracerProvider() == nil
 	}
 	return globalCfg.traceContent
 }
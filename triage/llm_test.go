@@ -377,6 +377,64 @@ func TestLogPrompt_RecordsOptionalParams(t *testing.T) {
 	}
 }
 
+func TestLogPrompt_RecordsServiceTier(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o", ServiceTier: "auto"})
+	llmSpan.LogCompletion(Completion{ServiceTier: "default"}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrGenAIRequestServiceTier] != "auto" {
+		t.Errorf("request tier: got %v, want %q", attrs[AttrGenAIRequestServiceTier], "auto")
+	}
+	if attrs[AttrGenAIResponseServiceTier] != "default" {
+		t.Errorf("response tier: got %v, want %q", attrs[AttrGenAIResponseServiceTier], "default")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Usage
+// ---------------------------------------------------------------------------
+
+func TestLogCompletion_RecordsMultimodalUsage(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o-audio-preview"})
+	llmSpan.LogCompletion(Completion{}, Usage{
+		PromptTokens:      100,
+		CompletionTokens:  50,
+		AudioInputTokens:  40,
+		AudioOutputTokens: 30,
+		ImageTokens:       20,
+	})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	want := map[string]int64{
+		AttrGenAIUsageAudioInputTokens:  40,
+		AttrGenAIUsageAudioOutputTokens: 30,
+		AttrGenAIUsageImageTokens:       20,
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %d", k, attrs[k], v)
+		}
+	}
+}
+
+func TestLogCompletion_OmitsZeroMultimodalUsage(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	for _, k := range []string{AttrGenAIUsageAudioInputTokens, AttrGenAIUsageAudioOutputTokens, AttrGenAIUsageImageTokens} {
+		if _, ok := attrs[k]; ok {
+			t.Errorf("%s should be omitted when zero", k)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Integration with triage context
 // ---------------------------------------------------------------------------
@@ -0,0 +1,76 @@
+package triage
+
+import (
+	"context"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+// MCP method and GenAI operation recorded on MCP tool spans.
+const (
+	mcpToolsCall         = "tools/call"
+	executeToolOperation = "execute_tool"
+	mcpToolErrorType     = "tool_error"
+)
+
+// CallMCPTool runs call, an MCP client's tool invocation, in a tool span
+// named after the tool, recording the server name, the JSON arguments and
+// the result (both only when trace content is enabled), so tools reached
+// through the Model Context Protocol are traced like local ones. It works
+// with any MCP client library:
+//
+//	res, err := triage.CallMCPTool(ctx, "github", "create_issue", args,
+//	    func(ctx context.Context) (*mcp.CallToolResult, error) {
+//	        return session.CallTool(ctx, &mcp.CallToolParams{Name: "create_issue", Arguments: args})
+//	    })
+//
+// The span fails if call returns an error or if the result reports a
+// tool-level error (an IsError field set to true, as in the MCP
+// CallToolResult); the latter records error.type "tool_error".
+func CallMCPTool[R any](ctx context.Context, server, tool string, args any, call func(ctx context.Context) (R, error)) (R, error) {
+	var opts []ToolOption
+	if args != nil {
+		opts = append(opts, ToolInput(args))
+	}
+	ts, ctx := StartTool(ctx, tool, opts...)
+	defer ts.End()
+	if ts.span != nil {
+		ts.span.SetAttributes(
+			attribute.String(AttrMCPServer, server),
+			attribute.String(AttrMCPMethodName, mcpToolsCall),
+			attribute.String(AttrGenAIToolName, tool),
+			attribute.String(AttrGenAIOperationName, executeToolOperation),
+		)
+	}
+
+	res, err := call(ctx)
+	if err != nil {
+		ts.SetResult(nil, err)
+		return res, err
+	}
+	ts.SetResult(res, nil)
+	if ts.span != nil && isToolError(res) {
+		ts.span.SetStatus(codes.Error, "MCP tool returned an error")
+		ts.span.SetAttributes(attribute.String(AttrErrorType, mcpToolErrorType))
+	}
+	return res, nil
+}
+
+// isToolError reports whether result is a struct, or pointer to one, with a
+// true IsError field: an MCP CallToolResult describing a failed tool call.
+func isToolError(result any) bool {
+	v := reflect.ValueOf(result)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	f := v.FieldByName("IsError")
+	return f.IsValid() && f.Kind() == reflect.Bool && f.Bool()
+}
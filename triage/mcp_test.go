@@ -0,0 +1,120 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// mcpResult mirrors the shape of an MCP client's CallToolResult.
+type mcpResult struct {
+	Content []mcpContent `json:"content"`
+	IsError bool         `json:"isError,omitempty"`
+}
+
+type mcpContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func TestCallMCPTool_RecordsToolSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	var inner trace.SpanContext
+	res, err := CallMCPTool(context.Background(), "github", "create_issue", map[string]string{"title": "bug"},
+		func(ctx context.Context) (*mcpResult, error) {
+			inner = trace.SpanContextFromContext(ctx)
+			return &mcpResult{Content: []mcpContent{{Type: "text", Text: "created #12"}}}, nil
+		})
+	if err != nil || res.Content[0].Text != "created #12" {
+		t.Fatalf("result: got %v, %v", res, err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "create_issue" {
+		t.Errorf("span name: got %q", span.Name)
+	}
+	if inner.SpanID() != span.SpanContext.SpanID() {
+		t.Error("call should run under the tool span")
+	}
+	attrs := attrMap(span.Attributes)
+	for k, want := range map[string]string{
+		"traceloop.span.kind":  SpanKindTool,
+		AttrMCPServer:          "github",
+		AttrMCPMethodName:      "tools/call",
+		AttrGenAIToolName:      "create_issue",
+		AttrGenAIOperationName: "execute_tool",
+		AttrEntityInput:        `{"title":"bug"}`,
+		AttrEntityOutput:       `{"content":[{"type":"text","text":"created #12"}]}`,
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s: got %v, want %q", k, attrs[k], want)
+		}
+	}
+	if span.Status.Code == codes.Error {
+		t.Error("successful tool call should not have Error status")
+	}
+}
+
+func TestCallMCPTool_ToolError(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	_, err := CallMCPTool(context.Background(), "github", "create_issue", nil,
+		func(ctx context.Context) (*mcpResult, error) {
+			return &mcpResult{Content: []mcpContent{{Type: "text", Text: "repository not found"}}, IsError: true}, nil
+		})
+	if err != nil {
+		t.Fatalf("tool-level errors are returned in the result: %v", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("status: got %v", span.Status.Code)
+	}
+	if got := attrMap(span.Attributes)[AttrErrorType]; got != "tool_error" {
+		t.Errorf("error.type: got %v", got)
+	}
+}
+
+func TestCallMCPTool_CallError(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	callErr := errors.New("connection closed")
+	_, err := CallMCPTool(context.Background(), "github", "create_issue", nil,
+		func(ctx context.Context) (*mcpResult, error) { return nil, callErr })
+	if !errors.Is(err, callErr) {
+		t.Fatalf("error: got %v", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("status: got %v", span.Status.Code)
+	}
+	if _, ok := attrMap(span.Attributes)[AttrEntityOutput]; ok {
+		t.Error("failed call should not record an output")
+	}
+}
+
+func TestCallMCPTool_NoContentWhenTraceContentDisabled(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	_, _ = CallMCPTool(context.Background(), "files", "read_file", map[string]string{"path": "secret.txt"},
+		func(ctx context.Context) (*mcpResult, error) {
+			return &mcpResult{Content: []mcpContent{{Type: "text", Text: "secret"}}}, nil
+		})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if _, ok := attrs[AttrEntityInput]; ok {
+		t.Error("arguments should not be recorded when traceContent is false")
+	}
+	if _, ok := attrs[AttrEntityOutput]; ok {
+		t.Error("result should not be recorded when traceContent is false")
+	}
+	if attrs[AttrMCPServer] != "files" {
+		t.Errorf("server should still be recorded: got %v", attrs[AttrMCPServer])
+	}
+}
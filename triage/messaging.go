@@ -0,0 +1,166 @@
+package triage
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// messagePropagator writes trace context, baggage and triage context into
+// message headers, independent of the global propagator.
+var messagePropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+	Propagator{},
+)
+
+// InjectMessage writes the trace context and triage context carried by ctx
+// into the headers of an outgoing message, wrapped by KafkaCarrier,
+// NATSCarrier or any other propagation.TextMapCarrier:
+//
+//	record := &kgo.Record{Topic: "jobs", Value: payload}
+//	triage.InjectMessage(ctx, triage.KafkaCarrier(&record.Headers))
+func InjectMessage(ctx context.Context, carrier propagation.TextMapCarrier) {
+	messagePropagator.Inject(ctx, carrier)
+}
+
+// StartConsumerSpan starts the span that processes one received message,
+// named "process <destination>". Like work restored with WithOrigin, it runs
+// in its own trace and is linked to the producer's span; it carries the
+// triage context (user, tenant, session, ...) written by InjectMessage.
+// Values already set on ctx take precedence. End the returned workflow when
+// the message is handled:
+//
+//	wf, ctx := triage.StartConsumerSpan(ctx, "kafka", record.Topic, triage.KafkaCarrier(&record.Headers))
+//	defer wf.End()
+func StartConsumerSpan(ctx context.Context, system, destination string, carrier propagation.TextMapCarrier) (*Workflow, context.Context) {
+	remote := messagePropagator.Extract(context.Background(), carrier)
+	if sc := trace.SpanContextFromContext(remote); sc.IsValid() {
+		ctx = context.WithValue(ctx, originKey{}, sc)
+	}
+	if baggage.FromContext(ctx).Len() == 0 {
+		ctx = baggage.ContextWithBaggage(ctx, baggage.FromContext(remote))
+	}
+	ctx = Propagator{}.Extract(ctx, carrier)
+
+	wf, ctx := StartWorkflow(ctx, "process "+destination)
+	wf.span.SetAttributes(
+		attribute.String(AttrMessagingSystem, system),
+		attribute.String(AttrMessagingDestinationName, destination),
+		attribute.String(AttrMessagingOperationType, "process"),
+	)
+	return wf, ctx
+}
+
+// kafkaHeader is the shape shared by Kafka client record headers: franz-go's
+// kgo.RecordHeader (string keys) and sarama's RecordHeader (byte keys).
+type kafkaHeader[K ~string | ~[]byte] struct {
+	Key   K
+	Value []byte
+}
+
+// KafkaCarrier adapts the headers of a Kafka record as a
+// propagation.TextMapCarrier, for franz-go (&record.Headers, a
+// []kgo.RecordHeader) and sarama producer messages (&msg.Headers, a
+// []sarama.RecordHeader). Set replaces a header with the same key. For
+// sarama consumer messages, whose headers are pointers, use
+// KafkaConsumerCarrier.
+func KafkaCarrier[H ~struct {
+	Key   K
+	Value []byte
+}, K ~string | ~[]byte](headers *[]H) propagation.TextMapCarrier {
+	return kafkaCarrier[H, K]{headers: headers}
+}
+
+type kafkaCarrier[H ~struct {
+	Key   K
+	Value []byte
+}, K ~string | ~[]byte] struct {
+	headers *[]H
+}
+
+func (c kafkaCarrier[H, K]) Get(key string) string {
+	for _, h := range *c.headers {
+		if kh := kafkaHeader[K](h); string(kh.Key) == key {
+			return string(kh.Value)
+		}
+	}
+	return ""
+}
+
+func (c kafkaCarrier[H, K]) Set(key, value string) {
+	headers := (*c.headers)[:0:0]
+	for _, h := range *c.headers {
+		if string(kafkaHeader[K](h).Key) != key {
+			headers = append(headers, h)
+		}
+	}
+	*c.headers = append(headers, H(kafkaHeader[K]{Key: K(key), Value: []byte(value)}))
+}
+
+func (c kafkaCarrier[H, K]) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, h := range *c.headers {
+		keys = append(keys, string(kafkaHeader[K](h).Key))
+	}
+	return keys
+}
+
+// KafkaConsumerCarrier adapts the headers of a sarama ConsumerMessage
+// (msg.Headers, a []*sarama.RecordHeader) as a read-only
+// propagation.TextMapCarrier for StartConsumerSpan.
+func KafkaConsumerCarrier[H ~struct {
+	Key   []byte
+	Value []byte
+}](headers []*H) propagation.TextMapCarrier {
+	values := make([]kafkaHeader[[]byte], 0, len(headers))
+	for _, h := range headers {
+		if h != nil {
+			values = append(values, kafkaHeader[[]byte](*h))
+		}
+	}
+	return kafkaCarrier[kafkaHeader[[]byte], []byte]{headers: &values}
+}
+
+// NATSCarrier adapts the headers of a NATS message (&msg.Header, a
+// nats.Header) as a propagation.TextMapCarrier, creating them if nil. Keys
+// are matched exactly, as NATS does, falling back to a case-insensitive
+// match.
+func NATSCarrier[H ~map[string][]string](header *H) propagation.TextMapCarrier {
+	return natsCarrier[H]{header: header}
+}
+
+type natsCarrier[H ~map[string][]string] struct {
+	header *H
+}
+
+func (c natsCarrier[H]) Get(key string) string {
+	if vs := (*c.header)[key]; len(vs) > 0 {
+		return vs[0]
+	}
+	for k, vs := range *c.header {
+		if len(vs) > 0 && strings.EqualFold(k, key) {
+			return vs[0]
+		}
+	}
+	return ""
+}
+
+func (c natsCarrier[H]) Set(key, value string) {
+	if *c.header == nil {
+		*c.header = make(H)
+	}
+	(*c.header)[key] = []string{value}
+}
+
+func (c natsCarrier[H]) Keys() []string {
+	keys := make([]string, 0, len(*c.header))
+	for k := range *c.header {
+		keys = append(keys, k)
+	}
+	return keys
+}
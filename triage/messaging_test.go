@@ -0,0 +1,102 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// Header types shaped like the Kafka and NATS client libraries'.
+type (
+	franzHeader struct {
+		Key   string
+		Value []byte
+	}
+	saramaHeader struct {
+		Key   []byte
+		Value []byte
+	}
+	natsHeader map[string][]string
+)
+
+func TestKafkaCarrier_FranzAndSarama(t *testing.T) {
+	var franz []franzHeader
+	c := KafkaCarrier(&franz)
+	c.Set("traceparent", "a")
+	c.Set("traceparent", "b")
+	if len(franz) != 1 || c.Get("traceparent") != "b" {
+		t.Errorf("Set should replace an existing header, got %+v", franz)
+	}
+
+	sarama := []saramaHeader{{Key: []byte("x-other"), Value: []byte("1")}}
+	c = KafkaCarrier(&sarama)
+	c.Set(HeaderUser, "u_1")
+	if got := c.Keys(); len(got) != 2 || got[1] != HeaderUser {
+		t.Errorf("keys: got %v", got)
+	}
+
+	consumer := []*saramaHeader{&sarama[0], nil, &sarama[1]}
+	if got := KafkaConsumerCarrier(consumer).Get(HeaderUser); got != "u_1" {
+		t.Errorf("consumer carrier: got %q", got)
+	}
+}
+
+func TestNATSCarrier(t *testing.T) {
+	var h natsHeader
+	c := NATSCarrier(&h)
+	c.Set("traceparent", "v")
+	if h["traceparent"][0] != "v" {
+		t.Errorf("NATS keys should be kept as set, got %v", h)
+	}
+	h["X-Triage-User"] = []string{"u_1"}
+	if got := c.Get("x-triage-user"); got != "u_1" {
+		t.Errorf("case-insensitive fallback: got %q", got)
+	}
+}
+
+func TestStartConsumerSpan_LinksProducer(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	var headers []franzHeader
+	producer, pctx := StartWorkflow(WithUser(context.Background(), "u_1"), "publish")
+	InjectMessage(WithSession(pctx, "sess_1"), KafkaCarrier(&headers))
+	producer.End()
+
+	wf, ctx := StartConsumerSpan(context.Background(), "kafka", "jobs", KafkaCarrier(&headers))
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	wf.End()
+
+	spans := exporter.GetSpans()
+	pub, consumer := spans[0], spans[2]
+	if consumer.Name != "process jobs" {
+		t.Errorf("consumer span name: got %q", consumer.Name)
+	}
+	if consumer.SpanContext.TraceID() == pub.SpanContext.TraceID() || consumer.Parent.IsValid() {
+		t.Error("the consumer span should start its own trace")
+	}
+	if len(consumer.Links) != 1 || consumer.Links[0].SpanContext.SpanID() != pub.SpanContext.SpanID() {
+		t.Errorf("the consumer span should link to the producer, got %+v", consumer.Links)
+	}
+	attrs := attrMap(consumer.Attributes)
+	if attrs[AttrMessagingSystem] != "kafka" || attrs[AttrMessagingDestinationName] != "jobs" {
+		t.Errorf("messaging attributes: got %v", attrs)
+	}
+	if attrs[AttrUserID] != "u_1" || attrs[AttrSessionID] != "sess_1" {
+		t.Errorf("triage context: got %v", attrs)
+	}
+	if attrMap(spans[1].Attributes)[AttrUserID] != "u_1" {
+		t.Error("spans under the consumer span should carry the triage context")
+	}
+}
+
+func TestStartConsumerSpan_WithoutHeaders(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, _ := StartConsumerSpan(context.Background(), "nats", "events", NATSCarrier(new(natsHeader)))
+	wf.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || len(spans[0].Links) != 0 {
+		t.Errorf("expected one unlinked span, got %+v", spans)
+	}
+}
@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// instrumentSet holds the SDK's metric instruments, created on provider.
+// Instruments that fail to be created are no-ops.
+type instrumentSet struct {
+	provider      metric.MeterProvider
+	cost          metric.Float64Counter
+	tokenUsage    metric.Int64Histogram
+	truncations   metric.Int64Counter
+	droppedTraces metric.Int64Counter
+}
+
+// instruments caches the instrumentSet of the current global meter provider.
+var instruments atomic.Pointer[instrumentSet]
+
+// sdkInstruments returns the metric instruments on the global meter
+// provider, creating them only when the provider has changed (e.g. by Init).
+func sdkInstruments() *instrumentSet {
+	mp := otel.GetMeterProvider()
+	if s := instruments.Load(); s != nil && s.provider == mp {
+		return s
+	}
+	s := newInstrumentSet(mp)
+	instruments.Store(s)
+	return s
+}
+
+// newInstrumentSet creates the SDK's metric instruments on mp.
+func newInstrumentSet(mp metric.MeterProvider) *instrumentSet {
+	meter := mp.Meter(llmTracerName)
+	var fallback noop.Meter
+	s := &instrumentSet{provider: mp}
+
+	var err error
+	if s.cost, err = meter.Float64Counter(
+		costMetricName,
+		metric.WithDescription("Estimated cost of LLM calls"),
+		metric.WithUnit("USD"),
+	); err != nil {
+		s.cost, _ = fallback.Float64Counter(costMetricName)
+	}
+	if s.tokenUsage, err = meter.Int64Histogram(
+		tokenUsageMetricName,
+		metric.WithDescription("Number of input and output tokens used"),
+		metric.WithUnit("{token}"),
+		metric.WithExplicitBucketBoundaries(tokenUsageBuckets...),
+	); err != nil {
+		s.tokenUsage, _ = fallback.Int64Histogram(tokenUsageMetricName)
+	}
+	if s.truncations, err = meter.Int64Counter(
+		truncationMetricName,
+		metric.WithDescription("LLM responses truncated by the output token limit"),
+	); err != nil {
+		s.truncations, _ = fallback.Int64Counter(truncationMetricName)
+	}
+	if s.droppedTraces, err = meter.Int64Counter(
+		droppedTracesMetricName,
+		metric.WithDescription("Traces not sampled, by the sampling rule that dropped them"),
+	); err != nil {
+		s.droppedTraces, _ = fallback.Int64Counter(droppedTracesMetricName)
+	}
+	return s
+}
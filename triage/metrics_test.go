@@ -0,0 +1,29 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+func TestSDKInstruments_CachedPerMeterProvider(t *testing.T) {
+	prev := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+
+	first := sdkmetric.NewMeterProvider()
+	t.Cleanup(func() { _ = first.Shutdown(context.Background()) })
+	otel.SetMeterProvider(first)
+	s := sdkInstruments()
+	if sdkInstruments() != s {
+		t.Error("instruments should be created once per meter provider")
+	}
+
+	second := sdkmetric.NewMeterProvider()
+	t.Cleanup(func() { _ = second.Shutdown(context.Background()) })
+	otel.SetMeterProvider(second)
+	if got := sdkInstruments(); got == s || got.provider != second {
+		t.Error("instruments should be recreated on a new meter provider")
+	}
+}
@@ -0,0 +1,274 @@
+package triage
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// RouteNamer returns the route pattern matched by r, such as
+// "/users/{id}", or "" if it cannot tell. Route patterns keep workflow names
+// low-cardinality, unlike raw URL paths.
+type RouteNamer func(r *http.Request) string
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+// middlewareConfig holds the settings applied by MiddlewareOptions.
+type middlewareConfig struct {
+	routeNamers    []RouteNamer
+	contextHeaders []string // headers to read per headerContextFields entry; nil disables
+	skip           []func(r *http.Request, route string) bool
+	anonSessions   bool
+}
+
+// newMiddlewareConfig applies opts.
+func newMiddlewareConfig(opts []MiddlewareOption) *middlewareConfig {
+	var mc middlewareConfig
+	for _, o := range opts {
+		o(&mc)
+	}
+	return &mc
+}
+
+// WithRouteNamer adds route-name extractors, tried in order until one returns
+// a non-empty pattern. Use ServeMuxRoute for net/http, or a framework's own
+// router lookup (e.g. chi's RouteContext(ctx).RoutePattern()).
+func WithRouteNamer(namers ...RouteNamer) MiddlewareOption {
+	return func(mc *middlewareConfig) { mc.routeNamers = append(mc.routeNamers, namers...) }
+}
+
+// WithContextHeaders makes Middleware read the user, tenant and session from
+// the Triage context headers (HeaderUser, HeaderTenant, HeaderSession) set by
+// upstream services with SetContextHeaders. Enable it only for services
+// behind a trusted boundary: the headers are client-controlled, and a
+// spoofed user ID would be recorded as-is.
+func WithContextHeaders() MiddlewareOption {
+	return WithContextHeaderNames(HeaderNames{})
+}
+
+// WithContextHeaderNames is WithContextHeaders reading the Triage context
+// from the given headers instead of the defaults:
+//
+//	triage.Middleware(mux, triage.WithContextHeaderNames(triage.HeaderNames{
+//	    User: "X-Auth-User",
+//	}))
+//
+// The same trust caveat applies.
+func WithContextHeaderNames(names HeaderNames) MiddlewareOption {
+	return func(mc *middlewareConfig) { mc.contextHeaders = names.resolve() }
+}
+
+// WithSkip serves requests for which skip returns true without a workflow
+// span, e.g. to leave out health checks or static assets.
+func WithSkip(skip func(r *http.Request) bool) MiddlewareOption {
+	return func(mc *middlewareConfig) {
+		mc.skip = append(mc.skip, func(r *http.Request, _ string) bool { return skip(r) })
+	}
+}
+
+// WithSkipRoutes serves requests whose route pattern, as reported by the
+// route namers before dispatch, is one of routes without a workflow span. A
+// pattern without a method ("/healthz") matches every method; one with a
+// method ("GET /healthz") matches only that method.
+func WithSkipRoutes(routes ...string) MiddlewareOption {
+	return func(mc *middlewareConfig) {
+		mc.skip = append(mc.skip, func(r *http.Request, route string) bool {
+			if route == "" {
+				return false
+			}
+			for _, skip := range routes {
+				if route == skip || routeWorkflowName(r.Method, route) == routeWorkflowName(r.Method, skip) {
+					return true
+				}
+			}
+			return false
+		})
+	}
+}
+
+// WithAnonymousSessions gives requests that carry neither a user nor a
+// session a generated session ID, kept in the SessionCookie cookie, so the
+// turns of an anonymous visitor's conversation are grouped like a signed-in
+// user's. Cookie values the SDK did not generate are replaced.
+func WithAnonymousSessions() MiddlewareOption {
+	return func(mc *middlewareConfig) { mc.anonSessions = true }
+}
+
+// SessionCookie is the cookie holding the session ID generated for anonymous
+// users (see WithAnonymousSessions).
+const SessionCookie = "triage_session"
+
+// anonSessionPrefix marks session IDs generated by WithAnonymousSessions.
+const anonSessionPrefix = "anon_"
+
+// anonymousSession returns ctx carrying the anonymous session of r, creating
+// the session and its cookie on w if r has none. Requests whose context
+// already has a user or session are left alone.
+func anonymousSession(ctx context.Context, w http.ResponseWriter, r *http.Request) context.Context {
+	if tc := getFromContext(ctx); tc.userID != "" || tc.sessionID != "" {
+		return ctx
+	}
+	if c, err := r.Cookie(SessionCookie); err == nil && isAnonymousSessionID(c.Value) {
+		return WithSession(ctx, c.Value)
+	}
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ctx
+	}
+	id := anonSessionPrefix + hex.EncodeToString(b[:])
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return WithSession(ctx, id)
+}
+
+// isAnonymousSessionID reports whether id has the form of a session ID
+// generated by anonymousSession.
+func isAnonymousSessionID(id string) bool {
+	hexID, ok := strings.CutPrefix(id, anonSessionPrefix)
+	if !ok || len(hexID) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(hexID)
+	return err == nil
+}
+
+// ServeMuxRoute returns a RouteNamer that resolves the pattern registered on
+// mux for a request, e.g. "GET /users/{id}".
+func ServeMuxRoute(mux *http.ServeMux) RouteNamer {
+	return func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+}
+
+// Middleware wraps an HTTP handler so each request runs in a workflow span
+// named after the matched route ("GET /users/{id}"), with LLM calls made by
+// the handler nested beneath it. The workflow continues the caller's trace
+// from the W3C traceparent header (via the global propagator, which Init
+// registers) and otherwise starts a new trace:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("POST /chat/{session}", chatHandler)
+//	http.ListenAndServe(addr, triage.Middleware(mux, triage.WithRouteNamer(triage.ServeMuxRoute(mux))))
+//
+// Route namers are consulted once, before the request is dispatched, so that
+// spans started by the handler are nested under the final workflow name;
+// namers for routers that only resolve the pattern while routing must look
+// it up themselves, as triagechi.Route does. Requests with no known route
+// are named "HTTP <method>". The workflow records the response status code
+// the handler writes, and 5xx responses mark it as failed.
+func Middleware(next http.Handler, opts ...MiddlewareOption) http.Handler {
+	mc := newMiddlewareConfig(opts)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mc.route(r)
+		if mc.skipped(r, route) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		if mc.contextHeaders != nil {
+			ctx = contextFromHeaderLookup(ctx, mc.contextHeaders, r.Header.Get)
+		}
+		if mc.anonSessions {
+			ctx = anonymousSession(ctx, w, r)
+		}
+		wf, ctx := StartWorkflow(ctx, routeWorkflowName(r.Method, route))
+		defer wf.End()
+		wf.span.SetAttributes(attribute.String(AttrHTTPRequestMethod, r.Method))
+		if route != "" {
+			wf.span.SetAttributes(attribute.String(AttrHTTPRoute, route))
+		}
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, r.WithContext(ctx))
+		if sw.status != 0 {
+			wf.span.SetAttributes(attribute.Int(AttrHTTPResponseStatusCode, sw.status))
+			if sw.status >= http.StatusInternalServerError {
+				wf.span.SetStatus(codes.Error, http.StatusText(sw.status))
+			}
+		}
+	})
+}
+
+// statusWriter records the status code of the response written through it.
+// It forwards Flush and Hijack, and unwraps for http.ResponseController.
+type statusWriter struct {
+	http.ResponseWriter
+	status int // 0 until the header is written
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *statusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// skipped reports whether a skip rule excludes r, whose route is route.
+func (mc *middlewareConfig) skipped(r *http.Request, route string) bool {
+	for _, skip := range mc.skip {
+		if skip(r, route) {
+			return true
+		}
+	}
+	return false
+}
+
+// route returns the first non-empty pattern reported by the route namers.
+func (mc *middlewareConfig) route(r *http.Request) string {
+	for _, namer := range mc.routeNamers {
+		if route := namer(r); route != "" {
+			return route
+		}
+	}
+	return ""
+}
+
+// routeWorkflowName builds the workflow name for a request, prefixing the
+// method unless the pattern already carries one (Go 1.22 mux patterns).
+func routeWorkflowName(method, route string) string {
+	if route == "" {
+		return "HTTP " + method
+	}
+	if strings.HasPrefix(route, method+" ") {
+		return route
+	}
+	return method + " " + route
+}
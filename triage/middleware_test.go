@@ -0,0 +1,292 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useTraceContextPropagator installs the W3C trace context propagator for
+// the test.
+func useTraceContextPropagator(t *testing.T) {
+	t.Helper()
+	prev := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(prev) })
+}
+
+func TestMiddleware_NamesWorkflowFromServeMuxPattern(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		llmSpan, _ := LogPrompt(r.Context(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(Completion{}, Usage{})
+	})
+	h := Middleware(mux, WithRouteNamer(ServeMuxRoute(mux)))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/43", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	for i := 0; i < len(spans); i += 2 {
+		llm, wf := spans[i], spans[i+1]
+		if wf.Name != "GET /users/{id}" {
+			t.Errorf("workflow span name: got %q", wf.Name)
+		}
+		attrs := attrMap(wf.Attributes)
+		if attrs[AttrHTTPRoute] != "GET /users/{id}" || attrs["traceloop.workflow.name"] != "GET /users/{id}" {
+			t.Errorf("workflow attributes: %v", attrs)
+		}
+		if llm.Parent.SpanID() != wf.SpanContext.SpanID() {
+			t.Error("LLM span should be nested under the request workflow")
+		}
+	}
+}
+
+func TestMiddleware_UnmatchedRoute(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	mux := http.NewServeMux()
+	h := Middleware(mux, WithRouteNamer(ServeMuxRoute(mux)))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/nope/123", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "HTTP POST" {
+		t.Fatalf("expected one %q span, got %v", "HTTP POST", spans)
+	}
+	if _, ok := attrMap(spans[0].Attributes)[AttrHTTPRoute]; ok {
+		t.Error("http.route should be omitted for unmatched requests")
+	}
+}
+
+func TestMiddleware_RouteResolvedDuringDispatchIsIgnored(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	// A router that records the matched pattern while routing. Renaming the
+	// workflow afterwards would leave child spans under the old name.
+	var matched string
+	router := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		matched = "/docs/{slug}"
+	})
+	namer := func(r *http.Request) string { return matched }
+
+	Middleware(router, WithRouteNamer(namer)).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/docs/intro", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "HTTP GET" {
+		t.Fatalf("expected the span named before dispatch, got %v", spans)
+	}
+	if _, ok := attrMap(spans[0].Attributes)[AttrHTTPRoute]; ok {
+		t.Error("http.route should be omitted when the route is unknown before dispatch")
+	}
+}
+
+func TestMiddleware_PropagatesTriageContext(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	var got string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = workflowNameFromContext(r.Context())
+	}))
+	req := httptest.NewRequest("GET", "/", nil).WithContext(WithUser(context.Background(), "u_1"))
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "HTTP GET" {
+		t.Errorf("workflow name in handler context: got %q", got)
+	}
+}
+
+func TestMiddleware_ContextHeaders(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	var user string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = getFromContext(r.Context()).userID
+	}), WithContextHeaders())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderUser, "u_1")
+	req.Header.Set(HeaderSession, "s_1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if user != "u_1" {
+		t.Errorf("user in handler context: got %q", user)
+	}
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrUserID] != "u_1" || attrs[AttrSessionID] != "s_1" {
+		t.Errorf("workflow span context attributes: got %v", attrs)
+	}
+}
+
+func TestMiddleware_IgnoresContextHeadersByDefault(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	var user string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user = getFromContext(r.Context()).userID
+	}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderUser, "spoofed")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if user != "" {
+		t.Errorf("headers should be ignored without WithContextHeaders, got user %q", user)
+	}
+}
+
+func TestMiddleware_ContextHeaderNames(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	h := Middleware(http.NotFoundHandler(), WithContextHeaderNames(HeaderNames{User: "X-Auth-User"}))
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Auth-User", "u_1")
+	req.Header.Set(HeaderUser, "ignored")
+	req.Header.Set(HeaderTenant, "org_1")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrUserID] != "u_1" || attrs[AttrTenantID] != "org_1" {
+		t.Errorf("renamed header should replace the default, others keep theirs: got %v", attrs)
+	}
+}
+
+func TestMiddleware_ContinuesW3CTrace(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	useTraceContextPropagator(t)
+
+	traceID := trace.TraceID{0xab, 2}
+	h := Middleware(http.NotFoundHandler())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("traceparent", "00-"+traceID.String()+"-0102030405060708-01")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	wf := exporter.GetSpans()[0]
+	if wf.SpanContext.TraceID() != traceID || !wf.Parent.IsRemote() {
+		t.Errorf("workflow should continue the caller's trace, got trace %s parent %v", wf.SpanContext.TraceID(), wf.Parent)
+	}
+}
+
+func TestMiddleware_SkipRoutes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /chat", func(w http.ResponseWriter, r *http.Request) {})
+	h := Middleware(mux, WithRouteNamer(ServeMuxRoute(mux)), WithSkipRoutes("/healthz"))
+
+	served := 0
+	skipAll := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ }),
+		WithSkip(func(r *http.Request) bool { return r.URL.Path == "/static/app.js" }))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/chat", nil))
+	skipAll.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/static/app.js", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "GET /chat" {
+		t.Fatalf("expected only the chat workflow, got %v", spans)
+	}
+	if served != 1 {
+		t.Error("skipped requests should still be served")
+	}
+}
+
+func TestMiddleware_AnonymousSessions(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	h := Middleware(http.NotFoundHandler(), WithAnonymousSessions())
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != SessionCookie || !isAnonymousSessionID(cookies[0].Value) {
+		t.Fatalf("expected a generated session cookie, got %v", cookies)
+	}
+
+	again := httptest.NewRequest("GET", "/", nil)
+	again.AddCookie(cookies[0])
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, again)
+	if len(rec.Result().Cookies()) != 0 {
+		t.Error("a returning visitor should keep their session cookie")
+	}
+
+	signedIn := httptest.NewRequest("GET", "/", nil)
+	signedIn = signedIn.WithContext(WithUser(signedIn.Context(), "u_1"))
+	h.ServeHTTP(httptest.NewRecorder(), signedIn)
+
+	spans := exporter.GetSpans()
+	for i, want := range []any{cookies[0].Value, cookies[0].Value, nil} {
+		if got := attrMap(spans[i].Attributes)[AttrSessionID]; got != want {
+			t.Errorf("request %d: session got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestMiddleware_AnonymousSessionsReplaceForeignCookie(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	var session string
+	h := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session = getFromContext(r.Context()).sessionID
+	}), WithAnonymousSessions())
+	req := httptest.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: SessionCookie, Value: "victim-session"})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !isAnonymousSessionID(session) {
+		t.Errorf("a cookie the SDK did not generate should be replaced, got %q", session)
+	}
+}
+
+func TestMiddleware_RecordsResponseStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		handler http.HandlerFunc
+		status  any
+		failed  bool
+	}{
+		{"implicit ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }, int64(http.StatusOK), false},
+		{"client error", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) }, int64(http.StatusNotFound), false},
+		{"server error", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusBadGateway) }, int64(http.StatusBadGateway), true},
+		{"nothing written", func(w http.ResponseWriter, r *http.Request) {}, nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter := newGlobalTestProvider(t)
+			Middleware(tc.handler).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+			wf := exporter.GetSpans()[0]
+			if got := attrMap(wf.Attributes)[AttrHTTPResponseStatusCode]; got != tc.status {
+				t.Errorf("status: got %v, want %v", got, tc.status)
+			}
+			if failed := wf.Status.Code == codes.Error; failed != tc.failed {
+				t.Errorf("failed: got %v, want %v", failed, tc.failed)
+			}
+		})
+	}
+}
+
+func TestMiddleware_KeepsFlusher(t *testing.T) {
+	newGlobalTestProvider(t)
+	rec := httptest.NewRecorder()
+	Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("the response writer should still be an http.Flusher")
+		}
+		f.Flush()
+	})).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if !rec.Flushed {
+		t.Error("Flush should reach the underlying writer")
+	}
+}
@@ -0,0 +1,133 @@
+package triage
+
+import (
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ModelCapabilities describes what a model supports. Model matches the exact
+// model name or, failing that, the longest matching prefix (so "gpt-4o" also
+// describes "gpt-4o-2024-08-06"). Zero values mean unknown.
+type ModelCapabilities struct {
+	Model           string    `json:"model"`
+	ContextWindow   int       `json:"context_window"`    // max input + output tokens
+	MaxOutputTokens int       `json:"max_output_tokens"` // max completion tokens per call
+	Vision          bool      `json:"vision"`            // accepts image input
+	Audio           bool      `json:"audio"`             // accepts audio input
+	ToolCalling     bool      `json:"tool_calling"`      // supports function/tool calls
+	KnowledgeCutoff time.Time `json:"knowledge_cutoff"`
+}
+
+// Model validation warnings, recorded in triage.model.warnings.
+const (
+	warnToolsUnsupported = "tools_unsupported"
+	warnMaxTokensAbove   = "max_tokens_above_limit"
+)
+
+// defaultModels is the built-in capability table. Entries supplied via
+// WithModels take precedence.
+var defaultModels = []ModelCapabilities{
+	{Model: "gpt-4o", ContextWindow: 128000, MaxOutputTokens: 16384, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2023, time.October)},
+	{Model: "gpt-4o-mini", ContextWindow: 128000, MaxOutputTokens: 16384, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2023, time.October)},
+	{Model: "gpt-4o-audio", ContextWindow: 128000, MaxOutputTokens: 16384, Audio: true, ToolCalling: true, KnowledgeCutoff: cutoff(2023, time.October)},
+	{Model: "gpt-4.1", ContextWindow: 1047576, MaxOutputTokens: 32768, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2024, time.June)},
+	{Model: "gpt-4.1-mini", ContextWindow: 1047576, MaxOutputTokens: 32768, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2024, time.June)},
+	{Model: "o3-mini", ContextWindow: 200000, MaxOutputTokens: 100000, ToolCalling: true, KnowledgeCutoff: cutoff(2023, time.October)},
+	{Model: "claude-sonnet-4", ContextWindow: 200000, MaxOutputTokens: 64000, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2025, time.March)},
+	{Model: "claude-3-5-haiku", ContextWindow: 200000, MaxOutputTokens: 8192, ToolCalling: true, KnowledgeCutoff: cutoff(2024, time.July)},
+	{Model: "claude-opus-4", ContextWindow: 200000, MaxOutputTokens: 32000, Vision: true, ToolCalling: true, KnowledgeCutoff: cutoff(2025, time.March)},
+}
+
+// cutoff returns the first day of the given month in UTC.
+func cutoff(year int, month time.Month) time.Time {
+	return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// WithModels adds or overrides entries in the model capability registry,
+// e.g. for fine-tuned or self-hosted models missing from the built-in table:
+//
+//	triage.WithModels(triage.ModelCapabilities{Model: "llama-3.1-70b", ContextWindow: 131072, ToolCalling: true})
+func WithModels(models ...ModelCapabilities) Option {
+	return func(c *config) { c.models = append(c.models, models...) }
+}
+
+// ModelInfo returns the known capabilities of model, so apps don't have to
+// hardcode context windows and feature support themselves:
+//
+//	if info, ok := triage.ModelInfo("gpt-4o"); ok && tokens > info.ContextWindow {
+//	    // trim history
+//	}
+//
+// Entries from WithModels take precedence over the built-in table. Returns
+// false if the model is unknown.
+func ModelInfo(model string) (ModelCapabilities, bool) {
+	var overrides []ModelCapabilities
+	if globalCfg != nil {
+		overrides = globalCfg.models
+	}
+	return lookupModel(model, overrides, defaultModels)
+}
+
+// lookupModel finds model in each table in order and returns the first
+// match. Within a table, the longest model match wins.
+func lookupModel(model string, tables ...[]ModelCapabilities) (ModelCapabilities, bool) {
+	if model == "" {
+		return ModelCapabilities{}, false
+	}
+	for _, table := range tables {
+		var best ModelCapabilities
+		found := false
+		for _, m := range table {
+			if m.Model == "" || !strings.HasPrefix(model, m.Model) {
+				continue
+			}
+			if !found || len(m.Model) > len(best.Model) {
+				best, found = m, true
+			}
+		}
+		if found {
+			return best, true
+		}
+	}
+	return ModelCapabilities{}, false
+}
+
+// modelValidationAttributes checks a prompt against the requested model's
+// capabilities and records any mismatches the provider would reject or
+// silently clamp. Unknown models are not checked.
+func modelValidationAttributes(prompt Prompt) []attribute.KeyValue {
+	info, ok := ModelInfo(prompt.Model)
+	if !ok {
+		return nil
+	}
+	var warnings []string
+	if len(prompt.Tools) > 0 && !info.ToolCalling {
+		warnings = append(warnings, warnToolsUnsupported)
+	}
+	if info.MaxOutputTokens > 0 && prompt.MaxTokens > info.MaxOutputTokens {
+		warnings = append(warnings, warnMaxTokensAbove)
+	}
+	if len(warnings) == 0 {
+		return nil
+	}
+	return []attribute.KeyValue{attribute.StringSlice(AttrModelWarnings, warnings)}
+}
+
+// contextUtilizationAttributes records how much of the model's context window
+// the call's prompt and completion used.
+func contextUtilizationAttributes(model string, usage Usage) []attribute.KeyValue {
+	info, ok := ModelInfo(model)
+	if !ok || info.ContextWindow <= 0 {
+		return nil
+	}
+	used := usage.PromptTokens + usage.CompletionTokens
+	if used <= 0 {
+		return nil
+	}
+	return []attribute.KeyValue{
+		attribute.Int(AttrContextWindow, info.ContextWindow),
+		attribute.Float64(AttrContextUtilization, float64(used)/float64(info.ContextWindow)),
+	}
+}
@@ -0,0 +1,111 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Model lookup
+// ---------------------------------------------------------------------------
+
+func TestModelInfo_BuiltIn(t *testing.T) {
+	info, ok := ModelInfo("gpt-4o-2024-08-06")
+	if !ok {
+		t.Fatal("expected gpt-4o to be known")
+	}
+	if info.Model != "gpt-4o" || info.ContextWindow != 128000 || !info.ToolCalling || !info.Vision {
+		t.Errorf("got %+v", info)
+	}
+	if info.KnowledgeCutoff.IsZero() {
+		t.Error("expected a knowledge cutoff")
+	}
+	if mini, _ := ModelInfo("gpt-4o-mini-2024-07-18"); mini.Model != "gpt-4o-mini" {
+		t.Errorf("longest prefix: got %q, want gpt-4o-mini", mini.Model)
+	}
+	if _, ok := ModelInfo("my-finetune"); ok {
+		t.Error("expected unknown model")
+	}
+	if _, ok := ModelInfo(""); ok {
+		t.Error("expected no match for empty model")
+	}
+}
+
+func TestModelInfo_OverridesWin(t *testing.T) {
+	cfg, err := resolveConfig(WithAPIKey("tsk_test"), WithModels(
+		ModelCapabilities{Model: "gpt-4o", ContextWindow: 64000},
+		ModelCapabilities{Model: "llama-3.1-70b", ContextWindow: 131072, ToolCalling: true},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalCfg = cfg
+	t.Cleanup(func() { globalCfg = nil })
+
+	if info, _ := ModelInfo("gpt-4o"); info.ContextWindow != 64000 {
+		t.Errorf("override: got %d, want 64000", info.ContextWindow)
+	}
+	if info, ok := ModelInfo("llama-3.1-70b-instruct"); !ok || !info.ToolCalling {
+		t.Errorf("custom model: got %+v (ok=%v)", info, ok)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Span attributes
+// ---------------------------------------------------------------------------
+
+func TestLogCompletion_RecordsContextUtilization(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, models: []ModelCapabilities{{Model: "acme-llm", ContextWindow: 1000}}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "acme", Model: "acme-llm"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 600, CompletionTokens: 150})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if got := attrs[AttrContextWindow]; got != int64(1000) {
+		t.Errorf("context window: got %v, want 1000", got)
+	}
+	if got := attrs[AttrContextUtilization]; !approxEqual(got.(float64), 0.75) {
+		t.Errorf("utilization: got %v, want 0.75", got)
+	}
+}
+
+func TestLogCompletion_NoUtilizationForUnknownModel(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "local", Model: "my-finetune"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrContextUtilization]; ok {
+		t.Error("expected no utilization for an unknown model")
+	}
+}
+
+func TestLogPrompt_ModelWarnings(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, models: []ModelCapabilities{{Model: "acme-llm", MaxOutputTokens: 100}}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor:    "acme",
+		Model:     "acme-llm",
+		MaxTokens: 500,
+		Tools:     []ToolDef{{Type: "function", Function: ToolFunction{Name: "lookup"}}},
+	})
+	llmSpan.End()
+
+	got, _ := attrMap(exporter.GetSpans()[0].Attributes)[AttrModelWarnings].([]string)
+	if len(got) != 2 || got[0] != warnToolsUnsupported || got[1] != warnMaxTokensAbove {
+		t.Errorf("warnings: got %v", got)
+	}
+}
+
+func TestLogPrompt_NoWarningsWithinLimits(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o", MaxTokens: 1000})
+	llmSpan.End()
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrModelWarnings]; ok {
+		t.Error("expected no model warnings")
+	}
+}
@@ -0,0 +1,127 @@
+package triage
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultNamespace is the prefix of the SDK's own attribute keys.
+const defaultNamespace = "triage"
+
+// validNamespace matches dotted attribute namespaces such as "acme.triage".
+var validNamespace = regexp.MustCompile(`^[A-Za-z0-9_]+(\.[A-Za-z0-9_]+)*$`)
+
+// WithAttributeNamespace renames the SDK's triage.* attributes to ns.* at
+// export (e.g. "acme.triage" exports triage.user.id as acme.triage.user.id),
+// for organizations with attribute-naming governance in shared collectors.
+// It applies to span, event, link and resource attributes alike, including
+// triage.* attributes set by the application. The Attr* constants keep their
+// default names; use NamespacedKey to get the exported name.
+func WithAttributeNamespace(ns string) Option {
+	return func(c *config) { c.attrNamespace = ns }
+}
+
+// NamespacedKey returns the name key is exported under, given the namespace
+// set by WithAttributeNamespace. Keys outside the triage.* namespace are
+// returned unchanged.
+func NamespacedKey(key string) string {
+	if globalCfg == nil {
+		return key
+	}
+	return renameKey(globalCfg.attrNamespace, key)
+}
+
+// renameKey moves key from the triage namespace to ns.
+func renameKey(ns, key string) string {
+	if ns == "" || ns == defaultNamespace {
+		return key
+	}
+	if rest, ok := strings.CutPrefix(key, defaultNamespace+"."); ok {
+		return ns + "." + rest
+	}
+	return key
+}
+
+// renameAttributes returns attrs with triage.* keys moved to ns, or attrs
+// itself if none needed renaming.
+func renameAttributes(ns string, attrs []attribute.KeyValue) []attribute.KeyValue {
+	var out []attribute.KeyValue
+	for i, kv := range attrs {
+		key := renameKey(ns, string(kv.Key))
+		if key == string(kv.Key) {
+			if out != nil {
+				out = append(out, kv)
+			}
+			continue
+		}
+		if out == nil {
+			out = make([]attribute.KeyValue, i, len(attrs))
+			copy(out, attrs[:i])
+		}
+		out = append(out, attribute.KeyValue{Key: attribute.Key(key), Value: kv.Value})
+	}
+	if out == nil {
+		return attrs
+	}
+	return out
+}
+
+// Compile-time check that namespaceExporter implements SpanExporter.
+var _ sdktrace.SpanExporter = (*namespaceExporter)(nil)
+
+// withNamespace wraps exp in a namespaceExporter unless ns keeps the default
+// triage.* namespace.
+func withNamespace(exp sdktrace.SpanExporter, ns string) sdktrace.SpanExporter {
+	if ns == "" || ns == defaultNamespace {
+		return exp
+	}
+	return &namespaceExporter{SpanExporter: exp, ns: ns}
+}
+
+// namespaceExporter renames triage.* attributes on the way to the exporter.
+type namespaceExporter struct {
+	sdktrace.SpanExporter
+	ns string
+}
+
+func (e *namespaceExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	renamed := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		renamed[i] = namespacedSpan{ReadOnlySpan: s, ns: e.ns}
+	}
+	return e.SpanExporter.ExportSpans(ctx, renamed)
+}
+
+// namespacedSpan is a ReadOnlySpan whose triage.* attributes are renamed.
+type namespacedSpan struct {
+	sdktrace.ReadOnlySpan
+	ns string
+}
+
+func (s namespacedSpan) Attributes() []attribute.KeyValue {
+	return renameAttributes(s.ns, s.ReadOnlySpan.Attributes())
+}
+
+func (s namespacedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	out := make([]sdktrace.Event, len(events))
+	for i, ev := range events {
+		ev.Attributes = renameAttributes(s.ns, ev.Attributes)
+		out[i] = ev
+	}
+	return out
+}
+
+func (s namespacedSpan) Links() []sdktrace.Link {
+	links := s.ReadOnlySpan.Links()
+	out := make([]sdktrace.Link, len(links))
+	for i, l := range links {
+		l.Attributes = renameAttributes(s.ns, l.Attributes)
+		out[i] = l
+	}
+	return out
+}
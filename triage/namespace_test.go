@@ -0,0 +1,90 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNamespaceExporter_RenamesTriageAttributes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSyncer(&namespaceExporter{SpanExporter: exporter, ns: "acme.triage"}),
+	)
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	ctx := WithUser(context.Background(), "u_1")
+	wf, ctx := StartWorkflow(ctx, "pipeline")
+	LogScore(ctx, "faithfulness", 0.9)
+	wf.End()
+
+	span := exporter.GetSpans()[0]
+	attrs := attrMap(span.Attributes)
+	if attrs["acme.triage.user.id"] != "u_1" {
+		t.Errorf("renamed user id: got %v", attrs["acme.triage.user.id"])
+	}
+	if _, ok := attrs[AttrUserID]; ok {
+		t.Error("original triage.user.id should not be exported")
+	}
+	if attrs["traceloop.span.kind"] != "workflow" {
+		t.Error("attributes outside the triage namespace should be kept as-is")
+	}
+	if ev := attrMap(span.Events[0].Attributes); ev["acme.triage.score.name"] != "faithfulness" {
+		t.Errorf("event attributes should be renamed, got %v", ev)
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	tests := []struct{ ns, key, want string }{
+		{"", "triage.user.id", "triage.user.id"},
+		{"triage", "triage.user.id", "triage.user.id"},
+		{"acme.triage", "triage.user.id", "acme.triage.user.id"},
+		{"acme", "triagex.user", "triagex.user"},
+		{"acme", "gen_ai.system", "gen_ai.system"},
+	}
+	for _, tt := range tests {
+		if got := renameKey(tt.ns, tt.key); got != tt.want {
+			t.Errorf("renameKey(%q, %q) = %q, want %q", tt.ns, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestWithAttributeNamespace_Validation(t *testing.T) {
+	t.Setenv(EnvAttributeNamespace, "")
+	if _, errs := buildConfig(WithAPIKey("k"), WithAttributeNamespace("acme.triage")); len(errs) != 0 {
+		t.Errorf("valid namespace rejected: %v", errs)
+	}
+	_, errs := buildConfig(WithAPIKey("k"), WithAttributeNamespace("acme..triage."))
+	if len(errs) != 1 || !errors.Is(errs[0], ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", errs)
+	}
+}
+
+func TestWithAttributeNamespace_FromEnv(t *testing.T) {
+	t.Setenv(EnvAttributeNamespace, "acme.triage")
+	cfg, _ := buildConfig(WithAPIKey("k"))
+	if cfg.attrNamespace != "acme.triage" {
+		t.Errorf("got %q", cfg.attrNamespace)
+	}
+}
+
+func TestNamespacedKey(t *testing.T) {
+	t.Cleanup(func() { globalCfg = nil })
+	if got := NamespacedKey(AttrUserID); got != AttrUserID {
+		t.Errorf("before Init: got %q", got)
+	}
+	globalCfg = &config{attrNamespace: "acme.triage"}
+	if got := NamespacedKey(AttrUserID); got != "acme.triage.user.id" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,320 @@
+package triage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// openAIRequest is the subset of an OpenAI Chat Completions request body
+// mapped onto a Prompt.
+type openAIRequest struct {
+	Model               string          `json:"model"`
+	Messages            []openAIMessage `json:"messages"`
+	Tools               []openAITool    `json:"tools"`
+	MaxTokens           int             `json:"max_tokens"`
+	MaxCompletionTokens int             `json:"max_completion_tokens"`
+	Temperature         *float64        `json:"temperature"`
+	TopP                *float64        `json:"top_p"`
+	FrequencyPenalty    *float64        `json:"frequency_penalty"`
+	PresencePenalty     *float64        `json:"presence_penalty"`
+	Stop                json.RawMessage `json:"stop"`
+	ServiceTier         string          `json:"service_tier"`
+	PromptCacheKey      string          `json:"prompt_cache_key"`
+}
+
+type openAIMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []ToolCall      `json:"tool_calls"`
+	ToolCallID string          `json:"tool_call_id"`
+}
+
+type openAITool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+// openAIResponse is the subset of an OpenAI Chat Completions response body
+// mapped onto a Completion and Usage.
+type openAIResponse struct {
+	Model       string `json:"model"`
+	ServiceTier string `json:"service_tier"`
+	Choices     []struct {
+		Message      openAIMessage `json:"message"`
+		FinishReason string        `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
+}
+
+// ParsePromptFromJSON maps a raw OpenAI-format Chat Completions request body
+// onto a Prompt with Vendor "openai", so proxies and gateways can instrument
+// traffic they forward without re-implementing the mapping:
+//
+//	prompt, err := triage.ParsePromptFromJSON(body)
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//
+// Message content given as an array of parts is flattened to its text parts.
+func ParsePromptFromJSON(body []byte) (Prompt, error) {
+	var req openAIRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return Prompt{}, fmt.Errorf("triage: invalid OpenAI request body: %w", err)
+	}
+
+	p := Prompt{
+		Vendor:           "openai",
+		Model:            req.Model,
+		MaxTokens:        req.MaxTokens,
+		Temperature:      req.Temperature,
+		TopP:             req.TopP,
+		FrequencyPenalty: req.FrequencyPenalty,
+		PresencePenalty:  req.PresencePenalty,
+		ServiceTier:      req.ServiceTier,
+		CacheKey:         req.PromptCacheKey,
+	}
+	if p.MaxTokens == 0 {
+		p.MaxTokens = req.MaxCompletionTokens
+	}
+	for _, m := range req.Messages {
+		p.Messages = append(p.Messages, m.message())
+	}
+	for _, t := range req.Tools {
+		def := ToolDef{Type: t.Type, Function: ToolFunction{Name: t.Function.Name, Description: t.Function.Description}}
+		if len(t.Function.Parameters) > 0 {
+			def.Function.Parameters = t.Function.Parameters
+		}
+		p.Tools = append(p.Tools, def)
+	}
+	if stop, ok := stringOrList(req.Stop); ok {
+		p.Stop = stop
+	}
+	return p, nil
+}
+
+// ParseCompletionFromJSON maps a raw OpenAI-format Chat Completions response
+// body onto a Completion and Usage, for use with LogCompletion. The finish
+// reason is taken from the first choice.
+func ParseCompletionFromJSON(body []byte) (Completion, Usage, error) {
+	var resp openAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Completion{}, Usage{}, fmt.Errorf("triage: invalid OpenAI response body: %w", err)
+	}
+
+	c := Completion{Model: resp.Model, ServiceTier: resp.ServiceTier}
+	for i, choice := range resp.Choices {
+		c.Messages = append(c.Messages, choice.Message.message())
+		if i == 0 {
+			c.FinishReason = choice.FinishReason
+		}
+	}
+	var u Usage
+	if resp.Usage != nil {
+		u = FromOpenAIUsage(*resp.Usage)
+	}
+	return c, u, nil
+}
+
+func (m openAIMessage) message() Message {
+	return Message{
+		Role:       m.Role,
+		Content:    contentText(m.Content),
+		ToolCalls:  m.ToolCalls,
+		ToolCallID: m.ToolCallID,
+	}
+}
+
+// contentText returns message content given either as a string or as an
+// array of typed parts, joining the text parts with newlines.
+func contentText(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &parts) != nil {
+		return ""
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// stringOrList decodes a JSON value that may be a single string or an array
+// of strings.
+func stringOrList(raw json.RawMessage) ([]string, bool) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, false
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []string{s}, true
+	}
+	var list []string
+	if json.Unmarshal(raw, &list) == nil {
+		return list, true
+	}
+	return nil, false
+}
+
+// sseBuffer splits raw server-sent event bytes into data payloads, tolerating
+// events split across writes.
+type sseBuffer struct {
+	partial []byte // incomplete trailing line
+}
+
+// feed consumes p and calls fn with the payload of each complete, non-empty
+// data line.
+func (b *sseBuffer) feed(p []byte, fn func(data []byte)) {
+	b.partial = append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(b.partial, '\n')
+		if i < 0 {
+			return
+		}
+		line := bytes.TrimSpace(b.partial[:i])
+		b.partial = b.partial[i+1:]
+		if data, ok := bytes.CutPrefix(line, []byte("data:")); ok {
+			if data = bytes.TrimSpace(data); len(data) > 0 {
+				fn(data)
+			}
+		}
+	}
+}
+
+// openAIChunk is one server-sent event of a streamed Chat Completions
+// response.
+type openAIChunk struct {
+	Model       string `json:"model"`
+	ServiceTier string `json:"service_tier"`
+	Choices     []struct {
+		Index int `json:"index"`
+		Delta struct {
+			Role      string `json:"role"`
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Index    int    `json:"index"`
+				ID       string `json:"id"`
+				Type     string `json:"type"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *OpenAIUsage `json:"usage"`
+}
+
+// hasOutput reports whether the chunk's first choice carries content or a
+// tool call.
+func (c openAIChunk) hasOutput() bool {
+	for _, choice := range c.Choices {
+		if choice.Index == 0 && (choice.Delta.Content != "" || len(choice.Delta.ToolCalls) > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAIStream reassembles a streamed Chat Completions response from its
+// server-sent events. Only the first choice is kept. It is fed raw bytes as
+// they pass through and tolerates events split across writes.
+type openAIStream struct {
+	sse     sseBuffer
+	onChunk func(token bool) // called once per data event; token if it carries output
+
+	completion Completion
+	message    Message
+	content    strings.Builder
+	usage      Usage
+}
+
+// Write consumes raw SSE bytes. It never fails.
+func (s *openAIStream) Write(p []byte) (int, error) {
+	s.sse.feed(p, s.event)
+	return len(p), nil
+}
+
+func (s *openAIStream) event(data []byte) {
+	if bytes.Equal(data, []byte("[DONE]")) {
+		return
+	}
+	var chunk openAIChunk
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	if s.onChunk != nil {
+		s.onChunk(chunk.hasOutput())
+	}
+	if chunk.Model != "" {
+		s.completion.Model = chunk.Model
+	}
+	if chunk.ServiceTier != "" {
+		s.completion.ServiceTier = chunk.ServiceTier
+	}
+	if chunk.Usage != nil {
+		s.usage = FromOpenAIUsage(*chunk.Usage)
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		if choice.Delta.Role != "" {
+			s.message.Role = choice.Delta.Role
+		}
+		s.content.WriteString(choice.Delta.Content)
+		for _, tc := range choice.Delta.ToolCalls {
+			if !validStreamIndex(tc.Index) {
+				continue
+			}
+			for len(s.message.ToolCalls) <= tc.Index {
+				s.message.ToolCalls = append(s.message.ToolCalls, ToolCall{})
+			}
+			call := &s.message.ToolCalls[tc.Index]
+			if tc.ID != "" {
+				call.ID = tc.ID
+			}
+			if tc.Type != "" {
+				call.Type = tc.Type
+			}
+			call.Function.Name += tc.Function.Name
+			call.Function.Arguments += tc.Function.Arguments
+		}
+		if choice.FinishReason != "" {
+			s.completion.FinishReason = choice.FinishReason
+		}
+	}
+}
+
+// result returns the reassembled completion and the usage from the final
+// event, if the upstream sent one (stream_options.include_usage).
+func (s *openAIStream) result() (Completion, Usage) {
+	c := s.completion
+	m := s.message
+	m.Content = s.content.String()
+	if m.Role == "" {
+		m.Role = "assistant"
+	}
+	if m.Content != "" || len(m.ToolCalls) > 0 {
+		c.Messages = []Message{m}
+	}
+	return c, s.usage
+}
@@ -0,0 +1,98 @@
+package triage
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParsePromptFromJSON_MapsRequest(t *testing.T) {
+	body := []byte(`{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "system", "content": "You are helpful."},
+			{"role": "user", "content": [{"type": "text", "text": "Describe"}, {"type": "image_url", "image_url": {"url": "https://x"}}, {"type": "text", "text": "this image"}]},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":1}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "42"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "description": "Look up", "parameters": {"type": "object"}}}],
+		"max_completion_tokens": 256,
+		"temperature": 0.2,
+		"stop": "END",
+		"service_tier": "flex",
+		"prompt_cache_key": "tenant-1"
+	}`)
+
+	p, err := ParsePromptFromJSON(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Vendor != "openai" || p.Model != "gpt-4o" {
+		t.Errorf("vendor/model: got %q/%q", p.Vendor, p.Model)
+	}
+	if len(p.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(p.Messages))
+	}
+	if p.Messages[1].Content != "Describe\nthis image" {
+		t.Errorf("content parts: got %q", p.Messages[1].Content)
+	}
+	if tc := p.Messages[2].ToolCalls; len(tc) != 1 || tc[0].Function.Name != "lookup" || tc[0].Function.Arguments != `{"q":1}` {
+		t.Errorf("tool calls: got %+v", tc)
+	}
+	if p.Messages[3].ToolCallID != "call_1" || p.Messages[3].Content != "42" {
+		t.Errorf("tool result: got %+v", p.Messages[3])
+	}
+	if len(p.Tools) != 1 || p.Tools[0].Function.Name != "lookup" {
+		t.Fatalf("tools: got %+v", p.Tools)
+	}
+	if params, _ := json.Marshal(p.Tools[0].Function.Parameters); string(params) != `{"type":"object"}` {
+		t.Errorf("tool parameters: got %s", params)
+	}
+	if p.MaxTokens != 256 || p.Temperature == nil || *p.Temperature != 0.2 {
+		t.Errorf("params: max_tokens=%d temperature=%v", p.MaxTokens, p.Temperature)
+	}
+	if !reflect.DeepEqual(p.Stop, []string{"END"}) {
+		t.Errorf("stop: got %v", p.Stop)
+	}
+	if p.ServiceTier != "flex" || p.CacheKey != "tenant-1" {
+		t.Errorf("tier/cache key: got %q/%q", p.ServiceTier, p.CacheKey)
+	}
+}
+
+func TestParseCompletionFromJSON_MapsResponse(t *testing.T) {
+	body := []byte(`{
+		"id": "chatcmpl-1",
+		"model": "gpt-4o-2024-08-06",
+		"service_tier": "default",
+		"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hi!"}, "finish_reason": "length"}],
+		"usage": {
+			"prompt_tokens": 100, "completion_tokens": 20, "total_tokens": 120,
+			"prompt_tokens_details": {"cached_tokens": 64, "audio_tokens": 5},
+			"completion_tokens_details": {"audio_tokens": 3}
+		}
+	}`)
+
+	c, u, err := ParseCompletionFromJSON(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != "gpt-4o-2024-08-06" || c.ServiceTier != "default" || c.FinishReason != "length" {
+		t.Errorf("completion: got %+v", c)
+	}
+	if len(c.Messages) != 1 || c.Messages[0].Content != "Hi!" {
+		t.Errorf("messages: got %+v", c.Messages)
+	}
+	want := Usage{PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120, CacheReadTokens: 64, AudioInputTokens: 5, AudioOutputTokens: 3}
+	if u != want {
+		t.Errorf("usage: got %+v, want %+v", u, want)
+	}
+}
+
+func TestParseFromJSON_InvalidBody(t *testing.T) {
+	if _, err := ParsePromptFromJSON([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid request body")
+	}
+	if _, _, err := ParseCompletionFromJSON([]byte(`{"choices": 1}`)); err == nil {
+		t.Error("expected an error for an invalid response body")
+	}
+}
@@ -0,0 +1,70 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceparentHeader is the W3C Trace Context header name used as the origin
+// token format.
+const traceparentHeader = "traceparent"
+
+// originKey is an unexported context key for the span context of the request
+// that caused background work.
+type originKey struct{}
+
+// Origin returns a token identifying the span active in ctx (a W3C
+// traceparent string), to be carried through a queue alongside background
+// work. Returns "" if ctx carries no valid span.
+//
+//	job := Job{Payload: p, TriageOrigin: triage.Origin(ctx)}
+func Origin(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceparentHeader)
+}
+
+// WithOrigin restores an origin token produced by Origin in a worker. LLM
+// spans and local root spans started from the returned context carry a span
+// link (and triage.origin.* attributes) back to the originating request's
+// span, so "which user request caused this background completion" is
+// answerable. Invalid tokens are ignored.
+//
+//	ctx = triage.WithOrigin(ctx, job.TriageOrigin)
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+func WithOrigin(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	carrier := propagation.MapCarrier{traceparentHeader: token}
+	sc := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), carrier))
+	if !sc.IsValid() {
+		return ctx
+	}
+	return context.WithValue(ctx, originKey{}, sc)
+}
+
+// originFromContext returns the origin span context stored by WithOrigin.
+func originFromContext(ctx context.Context) (trace.SpanContext, bool) {
+	sc, ok := ctx.Value(originKey{}).(trace.SpanContext)
+	return sc, ok
+}
+
+// linkOrigin links span to the origin stored in ctx, if any.
+func linkOrigin(ctx context.Context, span trace.Span) {
+	origin, ok := originFromContext(ctx)
+	if !ok || origin.Equal(span.SpanContext()) {
+		return
+	}
+	span.AddLink(trace.Link{
+		SpanContext: origin,
+		Attributes:  []attribute.KeyValue{attribute.String(AttrLinkType, linkTypeOrigin)},
+	})
+	span.SetAttributes(
+		attribute.String(AttrOriginTraceID, origin.TraceID().String()),
+		attribute.String(AttrOriginSpanID, origin.SpanID().String()),
+	)
+}
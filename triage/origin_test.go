@@ -0,0 +1,125 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// originLink returns the origin link on span, if any.
+func originLink(span tracetest.SpanStub) (sdktrace.Link, bool) {
+	for _, l := range span.Links {
+		if attrMap(l.Attributes)[AttrLinkType] == linkTypeOrigin {
+			return l, true
+		}
+	}
+	return sdktrace.Link{}, false
+}
+
+// ---------------------------------------------------------------------------
+// Origin tokens
+// ---------------------------------------------------------------------------
+
+func TestOrigin_EmptyWithoutSpan(t *testing.T) {
+	if got := Origin(context.Background()); got != "" {
+		t.Errorf("expected empty origin, got %q", got)
+	}
+}
+
+func TestOrigin_RoundTrip(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "request")
+	defer span.End()
+
+	restored := WithOrigin(context.Background(), Origin(ctx))
+	sc, ok := originFromContext(restored)
+	if !ok {
+		t.Fatal("expected origin in context")
+	}
+	if sc.TraceID() != span.SpanContext().TraceID() || sc.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("origin: got %s/%s, want %s/%s", sc.TraceID(), sc.SpanID(),
+			span.SpanContext().TraceID(), span.SpanContext().SpanID())
+	}
+}
+
+func TestWithOrigin_InvalidTokenIgnored(t *testing.T) {
+	ctx := WithOrigin(context.Background(), "not-a-traceparent")
+	if _, ok := originFromContext(ctx); ok {
+		t.Error("invalid token should not set an origin")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Linking
+// ---------------------------------------------------------------------------
+
+func TestWithOrigin_LinksWorkerLLMSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	reqCtx, reqSpan := otel.Tracer("test").Start(context.Background(), "POST /chat")
+	token := Origin(reqCtx)
+	reqSpan.End()
+
+	// Worker: fresh context, origin carried through the queue.
+	ctx := WithOrigin(context.Background(), token)
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	spans := exporter.GetSpans()
+	worker := spans[len(spans)-1]
+	if worker.SpanContext.TraceID() == reqSpan.SpanContext().TraceID() {
+		t.Error("worker span should start its own trace")
+	}
+	link, ok := originLink(worker)
+	if !ok {
+		t.Fatalf("expected an origin link, got %+v", worker.Links)
+	}
+	if link.SpanContext.SpanID() != reqSpan.SpanContext().SpanID() {
+		t.Errorf("link span: got %s, want %s", link.SpanContext.SpanID(), reqSpan.SpanContext().SpanID())
+	}
+	attrs := attrMap(worker.Attributes)
+	if attrs[AttrOriginTraceID] != reqSpan.SpanContext().TraceID().String() {
+		t.Errorf("origin trace attr: got %v", attrs[AttrOriginTraceID])
+	}
+}
+
+func TestWithOrigin_LinksNestedLLMSpanAndRootOnce(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	reqCtx, reqSpan := otel.Tracer("test").Start(context.Background(), "POST /chat")
+	token := Origin(reqCtx)
+	reqSpan.End()
+
+	ctx := WithOrigin(context.Background(), token)
+	wf, ctx := StartWorkflow(ctx, "summarize")
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+	wf.End()
+
+	for _, s := range exporter.GetSpans()[1:] {
+		n := 0
+		for _, l := range s.Links {
+			if attrMap(l.Attributes)[AttrLinkType] == linkTypeOrigin {
+				n++
+			}
+		}
+		if n != 1 {
+			t.Errorf("span %q: expected 1 origin link, got %d", s.Name, n)
+		}
+	}
+}
+
+func TestWithOrigin_NoLinkWithoutOrigin(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if _, ok := originLink(exporter.GetSpans()[0]); ok {
+		t.Error("expected no origin link")
+	}
+}
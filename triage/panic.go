@@ -0,0 +1,58 @@
+package triage
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel"
+)
+
+// WithErrorHandler sets a callback for errors the SDK cannot return to the
+// caller: failed exports, and panics recovered inside SDK code (wrapping
+// ErrInternalPanic). It is installed as the global OpenTelemetry error
+// handler and takes precedence over the WithLogger default of logging them.
+func WithErrorHandler(fn func(error)) Option {
+	return func(c *config) { c.errorHandler = fn }
+}
+
+// guard runs fn, recovering any panic so that an SDK bug never crashes the
+// host application. Recovered panics are reported via reportPanic.
+func guard(where string, fn func()) {
+	defer recoverPanic(where)
+	fn()
+}
+
+// recoverPanic recovers a panic and reports it. It must be deferred directly
+// (defer recoverPanic("...")) for recover to take effect.
+func recoverPanic(where string) {
+	if r := recover(); r != nil {
+		reportPanic(where, r)
+	}
+}
+
+// reportPanic hands a recovered panic, with its stack, to the OpenTelemetry
+// error handler (see WithErrorHandler).
+func reportPanic(where string, r any) {
+	stats.panics.Add(1)
+	otel.Handle(panicError(where, r))
+}
+
+// panicError converts a recovered panic value into an error wrapping
+// ErrInternalPanic.
+func panicError(where string, r any) error {
+	return fmt.Errorf("%w in %s: %v\n%s", ErrInternalPanic, where, r, debug.Stack())
+}
+
+// safeMarshal is json.Marshal for user-supplied values, whose MarshalJSON
+// methods may panic. A recovered panic is reported and returned as an error.
+func safeMarshal(where string, v any) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stats.panics.Add(1)
+			err = panicError(where, r)
+			otel.Handle(err)
+		}
+	}()
+	return json.Marshal(v)
+}
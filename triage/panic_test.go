@@ -0,0 +1,174 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// panickyJSON panics when marshaled, standing in for a buggy user type.
+type panickyJSON struct{}
+
+func (panickyJSON) MarshalJSON() ([]byte, error) { panic("boom") }
+
+// captureErrors routes OpenTelemetry errors to the returned slice for the
+// duration of the test.
+func captureErrors(t *testing.T) func() []error {
+	t.Helper()
+	prev := otel.GetErrorHandler()
+	t.Cleanup(func() { otel.SetErrorHandler(prev) })
+
+	var mu sync.Mutex
+	var errs []error
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}))
+	return func() []error {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]error(nil), errs...)
+	}
+}
+
+func TestLogPrompt_RecoversMarshalPanic(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	errs := captureErrors(t)
+	before := stats.panics.Load()
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor: "openai",
+		Model:  "gpt-4o",
+		Tools: []ToolDef{{
+			Type:     "function",
+			Function: ToolFunction{Name: "search", Parameters: map[string]any{"bad": panickyJSON{}}},
+		}},
+	})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected the span to still be exported, got %d", len(spans))
+	}
+	if attrMap(spans[0].Attributes)["gen_ai.request.tool.0.function.name"] != "search" {
+		t.Error("attributes before the failing parameter should be kept")
+	}
+	if got := errs(); len(got) != 1 || !errors.Is(got[0], ErrInternalPanic) {
+		t.Fatalf("expected one ErrInternalPanic, got %v", got)
+	}
+	if stats.panics.Load() != before+1 {
+		t.Errorf("panic counter: got %d, want %d", stats.panics.Load(), before+1)
+	}
+}
+
+func TestLogCompletion_RecoversPanic(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	errs := captureErrors(t)
+	globalCfg = &config{traceContent: true, truncationHandler: func(context.Context, Truncation) {
+		panic("handler bug")
+	}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{FinishReason: "length"}, Usage{})
+
+	if len(exporter.GetSpans()) != 1 {
+		t.Fatal("expected the span to be ended and exported despite the panic")
+	}
+	if got := errs(); len(got) != 1 || !errors.Is(got[0], ErrInternalPanic) {
+		t.Fatalf("expected one ErrInternalPanic, got %v", got)
+	}
+}
+
+func TestWithChunkACLs_RecoversMarshalPanic(t *testing.T) {
+	errs := captureErrors(t)
+
+	ctx := WithChunkACLs(context.Background(), []map[string]any{{"acl": panickyJSON{}}})
+	if getFromContext(ctx).chunkACLs != "" {
+		t.Error("expected no ACLs recorded")
+	}
+	if len(errs()) != 1 {
+		t.Error("expected the panic to be reported")
+	}
+}
+
+// panickyExporter panics on every export.
+type panickyExporter struct{}
+
+func (panickyExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	panic("exporter bug")
+}
+func (panickyExporter) Shutdown(context.Context) error { return nil }
+
+func TestCountingExporter_RecoversPanic(t *testing.T) {
+	before := stats.dropped.Load()
+	e := &countingExporter{SpanExporter: panickyExporter{}}
+
+	err := e.ExportSpans(context.Background(), make([]sdktrace.ReadOnlySpan, 3))
+	if !errors.Is(err, ErrInternalPanic) {
+		t.Fatalf("expected ErrInternalPanic, got %v", err)
+	}
+	if stats.dropped.Load() != before+3 {
+		t.Errorf("dropped: got %d, want %d", stats.dropped.Load(), before+3)
+	}
+}
+
+func TestInit_WithErrorHandler(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	prev := otel.GetErrorHandler()
+	t.Cleanup(func() { otel.SetErrorHandler(prev) })
+
+	var got error
+	if _, err := Init(WithAPIKey("tsk_test"), WithErrorHandler(func(err error) { got = err })); err != nil {
+		t.Fatal(err)
+	}
+	otel.Handle(errors.New("export failed"))
+	if got == nil || got.Error() != "export failed" {
+		t.Errorf("expected the handler to receive OpenTelemetry errors, got %v", got)
+	}
+}
+
+// FuzzLogPrompt checks that arbitrary prompt and completion content never
+// panics the caller.
+func FuzzLogPrompt(f *testing.F) {
+	f.Add("openai", "gpt-4o", "user", "hello", "{}", "length", "https://api.openai.com:443/v1")
+	f.Add("", "", "", "", "", "", "")
+	f.Add("\x00", "model\n", "💥", "%s%d", "{\"a\":", "MAX_TOKENS", "http://[::1")
+
+	prev := otel.GetTracerProvider()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(&triageSpanProcessor{}))
+	otel.SetTracerProvider(tp)
+	f.Cleanup(func() {
+		_ = tp.Shutdown(context.Background())
+		otel.SetTracerProvider(prev)
+	})
+	tracer := tp.Tracer(llmTracerName)
+
+	f.Fuzz(func(t *testing.T, vendor, model, role, content, args, finish, endpoint string) {
+		ctx, parent := tracer.Start(context.Background(), "root")
+		defer parent.End()
+		ctx = WithSession(WithUser(ctx, content), role)
+
+		llmSpan, ctx := LogPrompt(ctx, Prompt{
+			Vendor:   vendor,
+			Model:    model,
+			Endpoint: endpoint,
+			Messages: []Message{{Role: role, Content: content, ToolCalls: []ToolCall{{
+				Function: ToolCallFunction{Name: content, Arguments: args},
+			}}}},
+			CacheKey:         content,
+			CacheBreakpoints: []CacheBreakpoint{{MessageIndex: len(content) - 3}},
+		})
+		llmSpan.RecordChunk()
+		llmSpan.LogCompletion(Completion{
+			Model:        model,
+			FinishReason: finish,
+			Messages:     []Message{{Role: "assistant", Content: args}},
+		}, Usage{PromptTokens: len(content), CompletionTokens: -len(args)})
+		_ = ctx
+	})
+}
@@ -12,20 +12,41 @@ var _ sdktrace.SpanProcessor = (*triageSpanProcessor)(nil)
 // triageSpanProcessor injects triage context attributes into every span on
 // start. It reads the triageContext stored in context.Context (set by the
 // WithUser/WithTenant/etc. helpers) and writes non-zero values as span
-// attributes.
+// attributes. With WithContextBaggage, fields the triageContext lacks are
+// read from the context's baggage, so spans continuing a remote trace keep
+// the caller's user, tenant and session.
 //
 // In Go, OTel passes context.Context directly to OnStart, so the processor
 // reads triage data from the same ctx the user passed to their LLM call.
 type triageSpanProcessor struct{}
 
 func (p *triageSpanProcessor) OnStart(ctx context.Context, span sdktrace.ReadWriteSpan) {
+	defer recoverPanic("span processor OnStart")
+	stats.started.Add(1)
+	ctx = contextFromBaggage(ctx)
 	attrs := getTriageAttrs(ctx)
 	if len(attrs) > 0 {
 		span.SetAttributes(attrs...)
 	}
+	stampSessionRisk(ctx, span)
+	linkPreviousTurn(ctx, span, span.Parent())
+	if parent := span.Parent(); !parent.IsValid() || parent.IsRemote() {
+		linkOrigin(ctx, span)
+	}
 }
 
-func (p *triageSpanProcessor) OnEnd(_ sdktrace.ReadOnlySpan) {}
+func (p *triageSpanProcessor) OnEnd(span sdktrace.ReadOnlySpan) {
+	defer recoverPanic("span processor OnEnd")
+	// Only sampled spans reach the exporter; counting the rest would make
+	// them look permanently queued.
+	if span.SpanContext().IsSampled() {
+		stats.ended.Add(1)
+		checkQueuePressure()
+	}
+	if parent := span.Parent(); !parent.IsValid() || parent.IsRemote() {
+		releaseTraceBudget(span.SpanContext().TraceID())
+	}
+}
 
 func (p *triageSpanProcessor) Shutdown(_ context.Context) error {
 	return nil
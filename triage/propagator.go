@@ -0,0 +1,70 @@
+package triage
+
+import (
+	"context"
+	"strconv"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Propagator is a propagation.TextMapPropagator that carries the triage
+// context (user, tenant, session, conversation, traffic class, template and
+// eval run) in X-Triage-* carrier fields, for teams that prefer a dedicated
+// propagator to WithContextBaggage. Compose it with the W3C propagators:
+//
+//	triage.Init(triage.WithPropagators(
+//	    propagation.TraceContext{}, propagation.Baggage{}, triage.Propagator{},
+//	))
+//
+// Instrumentation that propagates through the global propagator (HTTP and
+// gRPC clients, message queues) then carries the triage context along with
+// the trace. Extract keeps values already set on ctx. The fields are
+// client-controlled: register the propagator only in services whose
+// incoming requests come from trusted callers.
+type Propagator struct{}
+
+var _ propagation.TextMapPropagator = Propagator{}
+
+// Inject sets the triage context carried by ctx on carrier.
+func (Propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	tc := getFromContext(ctx)
+	for _, f := range propagatorFields {
+		if v := *f.field(&tc); v != "" {
+			carrier.Set(f.header, v)
+		}
+	}
+	if tc.sessionTurnNumber != nil {
+		carrier.Set(HeaderSessionTurn, strconv.Itoa(*tc.sessionTurnNumber))
+	}
+}
+
+// Extract returns ctx carrying the triage context read from carrier.
+func (Propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	tc := getFromContext(ctx).clone()
+	changed := false
+	for _, f := range propagatorFields {
+		if p := f.field(&tc); *p == "" {
+			if v := carrier.Get(f.header); v != "" {
+				*p, changed = v, true
+			}
+		}
+	}
+	if tc.sessionTurnNumber == nil {
+		if n, err := strconv.Atoi(carrier.Get(HeaderSessionTurn)); err == nil {
+			tc.sessionTurnNumber, changed = &n, true
+		}
+	}
+	if !changed {
+		return ctx
+	}
+	return setInContext(ctx, tc)
+}
+
+// Fields returns the carrier keys Propagator sets.
+func (Propagator) Fields() []string {
+	fields := make([]string, 0, len(propagatorFields)+1)
+	for _, f := range propagatorFields {
+		fields = append(fields, f.header)
+	}
+	return append(fields, HeaderSessionTurn)
+}
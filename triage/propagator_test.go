@@ -0,0 +1,99 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestPropagator_RoundTrip(t *testing.T) {
+	ctx := WithUser(context.Background(), "u_1", UserRole("admin"))
+	ctx = WithTenant(ctx, "org_1", TenantName("Acme"), Workspace("ws_1"), Project("p_1"))
+	ctx = WithSession(ctx, "sess_1", TurnNumber(3), HistoryHash("h"))
+	ctx = WithConversation(ctx, "conv_1")
+	ctx = WithTrafficClass(ctx, TrafficShadow)
+	ctx = WithTemplate(ctx, "tmpl", TemplateVersion("v2"))
+	ctx = WithInput(ctx, "secret prompt")
+
+	carrier := propagation.MapCarrier{}
+	Propagator{}.Inject(ctx, carrier)
+	if _, ok := carrier["X-Triage-Input"]; ok || len(carrier) != len(Propagator{}.Fields())-2 {
+		t.Errorf("unexpected carrier fields: %v", carrier)
+	}
+
+	got := getFromContext(Propagator{}.Extract(context.Background(), carrier))
+	want := getFromContext(ctx)
+	want.inputRaw = ""
+	if got.sessionTurnNumber == nil || *got.sessionTurnNumber != 3 {
+		t.Fatalf("turn number: got %v", got.sessionTurnNumber)
+	}
+	got.sessionTurnNumber, want.sessionTurnNumber = nil, nil
+	if got.userID != want.userID || got.userRole != want.userRole || got.tenantID != want.tenantID ||
+		got.tenantName != want.tenantName || got.tenantWorkspace != want.tenantWorkspace ||
+		got.tenantProject != want.tenantProject || got.sessionID != want.sessionID ||
+		got.sessionHistoryHash != want.sessionHistoryHash || got.conversationID != want.conversationID ||
+		got.trafficClass != want.trafficClass || got.templateID != want.templateID ||
+		got.templateVersion != want.templateVersion || got.inputRaw != "" {
+		t.Errorf("extracted context: got %+v, want %+v", got, want)
+	}
+}
+
+func TestPropagator_InteroperatesWithContextHeaders(t *testing.T) {
+	h := http.Header{}
+	SetContextHeaders(WithUser(context.Background(), "u_1"), h)
+
+	ctx := Propagator{}.Extract(context.Background(), propagation.HeaderCarrier(h))
+	if got := getFromContext(ctx).userID; got != "u_1" {
+		t.Errorf("user: got %q", got)
+	}
+}
+
+func TestPropagator_KeepsExistingValues(t *testing.T) {
+	carrier := propagation.MapCarrier{HeaderUser: "remote", HeaderTenant: "org_1"}
+
+	ctx := Propagator{}.Extract(WithUser(context.Background(), "local"), carrier)
+	if tc := getFromContext(ctx); tc.userID != "local" || tc.tenantID != "org_1" {
+		t.Errorf("got user %q tenant %q", tc.userID, tc.tenantID)
+	}
+}
+
+func TestPropagator_ComposesWithTraceContext(t *testing.T) {
+	p := propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, Propagator{})
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := WithSession(trace.ContextWithSpanContext(context.Background(), sc), "sess_1")
+
+	h := http.Header{}
+	p.Inject(ctx, propagation.HeaderCarrier(h))
+	out := p.Extract(context.Background(), propagation.HeaderCarrier(h))
+
+	if trace.SpanContextFromContext(out).TraceID() != sc.TraceID() {
+		t.Error("trace context should be propagated")
+	}
+	if getFromContext(out).sessionID != "sess_1" {
+		t.Error("triage context should be propagated")
+	}
+}
+
+func TestPropagator_FieldsAreExportedHeaders(t *testing.T) {
+	want := []string{
+		HeaderUser, HeaderUserRole, HeaderTenant, HeaderTenantName, HeaderTenantWorkspace, HeaderTenantProject,
+		HeaderSession, HeaderSessionHash, HeaderConversation, HeaderTrafficClass, HeaderTemplate,
+		HeaderTemplateVersion, HeaderEvalRun, HeaderEvalDataset, HeaderSessionTurn,
+	}
+	got := Propagator{}.Fields()
+	if len(got) != len(want) {
+		t.Fatalf("fields: got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("field %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+}
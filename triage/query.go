@@ -0,0 +1,90 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// TimeRange bounds a trace search by root span start time. Zero values leave
+// the corresponding side open.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Query filters traces returned by Client.SearchTraces. Empty fields are not
+// filtered on.
+type Query struct {
+	UserID    string
+	SessionID string
+	TimeRange TimeRange
+	Flagged   bool // only traces flagged for human review
+
+	Limit  int    // maximum traces per page; 0 uses the backend default
+	Cursor string // TraceSearchResult.NextCursor of the previous page
+}
+
+// TraceSummary describes one trace returned by SearchTraces.
+type TraceSummary struct {
+	TraceID   string    `json:"trace_id"`
+	Name      string    `json:"name,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	TenantID  string    `json:"tenant_id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Flagged   bool      `json:"flagged,omitempty"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// TraceSearchResult is one page of SearchTraces results.
+type TraceSearchResult struct {
+	Traces     []TraceSummary `json:"traces"`
+	NextCursor string         `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// SearchTraces queries the backend for traces matching q — e.g. to show a
+// user the audit history of their own conversations:
+//
+//	res, err := client.SearchTraces(ctx, triage.Query{UserID: "user_123", Limit: 50})
+//	for res.NextCursor != "" { ... }
+func (c *Client) SearchTraces(ctx context.Context, q Query) (*TraceSearchResult, error) {
+	var res TraceSearchResult
+	if err := c.do(ctx, http.MethodGet, traceSearchPath+q.encode(), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// encode returns q as a URL query string (including the leading "?"), or ""
+// if q has no filters.
+func (q Query) encode() string {
+	v := url.Values{}
+	if q.UserID != "" {
+		v.Set("user_id", q.UserID)
+	}
+	if q.SessionID != "" {
+		v.Set("session_id", q.SessionID)
+	}
+	if !q.TimeRange.Start.IsZero() {
+		v.Set("start", q.TimeRange.Start.UTC().Format(time.RFC3339Nano))
+	}
+	if !q.TimeRange.End.IsZero() {
+		v.Set("end", q.TimeRange.End.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Flagged {
+		v.Set("flagged", "true")
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Cursor != "" {
+		v.Set("cursor", q.Cursor)
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return "?" + v.Encode()
+}
@@ -0,0 +1,72 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchTraces_EncodesQuery(t *testing.T) {
+	client, req, _ := newTestBackend(t, http.StatusOK, map[string]any{
+		"traces":      []TraceSummary{{TraceID: "abc", UserID: "user_123"}},
+		"next_cursor": "page2",
+	})
+
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	res, err := client.SearchTraces(context.Background(), Query{
+		UserID:    "user_123",
+		SessionID: "sess_1",
+		TimeRange: TimeRange{Start: start},
+		Flagged:   true,
+		Limit:     10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Traces) != 1 || res.Traces[0].TraceID != "abc" || res.NextCursor != "page2" {
+		t.Errorf("unexpected result: %+v", res)
+	}
+
+	if req.Method != http.MethodGet || req.URL.Path != traceSearchPath {
+		t.Errorf("request: got %s %s", req.Method, req.URL.Path)
+	}
+	q := req.URL.Query()
+	want := map[string]string{
+		"user_id":    "user_123",
+		"session_id": "sess_1",
+		"start":      "2025-01-02T03:04:05Z",
+		"flagged":    "true",
+		"limit":      "10",
+	}
+	for k, v := range want {
+		if q.Get(k) != v {
+			t.Errorf("%s: got %q, want %q", k, q.Get(k), v)
+		}
+	}
+	if q.Has("end") || q.Has("cursor") {
+		t.Errorf("unset filters should be omitted, got %v", q)
+	}
+}
+
+func TestSearchTraces_NoFilters(t *testing.T) {
+	client, req, _ := newTestBackend(t, http.StatusOK, map[string]any{"traces": []any{}})
+
+	if _, err := client.SearchTraces(context.Background(), Query{}); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.RawQuery != "" {
+		t.Errorf("expected no query string, got %q", req.URL.RawQuery)
+	}
+}
+
+func TestSearchTraces_APIError(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusForbidden, nil)
+
+	_, err := client.SearchTraces(context.Background(), Query{UserID: "u"})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 APIError, got %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+package triage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// quotedFragment matches quoted text in error messages, where providers echo
+// back parts of the offending request.
+var quotedFragment = regexp.MustCompile("\"[^\"]*\"|'[^']*'|`[^`]*`")
+
+// piiDetector matches one class of sensitive data in captured content.
+type piiDetector struct {
+	name        string
+	pattern     *regexp.Regexp
+	valid       func(match string) bool // optional check that rejects false positives
+	replacement string
+}
+
+// find returns the locations of d's matches in s, as
+// regexp.FindAllStringIndex does.
+func (d piiDetector) find(s string) [][]int {
+	locs := d.pattern.FindAllStringIndex(s, -1)
+	if d.valid == nil {
+		return locs
+	}
+	valid := locs[:0]
+	for _, loc := range locs {
+		if d.valid(s[loc[0]:loc[1]]) {
+			valid = append(valid, loc)
+		}
+	}
+	return valid
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by
+// payment card numbers, ignoring separators.
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// defaultPIIDetectors are applied, in order, wherever the SDK redacts content
+// before it leaves the process outside the trace pipeline (e.g. dataset
+// capture), and to error messages recorded on spans. Patterns favor precision
+// over recall: a missed match is caught by backend PII detection, while a
+// false positive silently corrupts a dataset.
+var defaultPIIDetectors = []piiDetector{
+	{
+		name:        "email",
+		pattern:     regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		replacement: "[REDACTED_EMAIL]",
+	},
+	{
+		name:        "credit_card",
+		pattern:     regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		valid:       luhnValid,
+		replacement: "[REDACTED_CARD]",
+	},
+	{
+		name:        "ssn",
+		pattern:     regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+		replacement: "[REDACTED_SSN]",
+	},
+	{
+		name:        "phone",
+		pattern:     regexp.MustCompile(`(?:\+\d{1,3}[ .\-]?)?\(?\b\d{3}\)?[ .\-]\d{3}[ .\-]\d{4}\b`),
+		replacement: "[REDACTED_PHONE]",
+	},
+}
+
+// defaultSecretDetectors match credentials that must never appear in model
+// output. Like the PII detectors they favor precision: each matches a
+// provider's documented key format. They only count matches (see
+// outputLeakAttributes), so they have no replacement.
+var defaultSecretDetectors = []piiDetector{
+	{
+		name:    "aws_access_key",
+		pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	{
+		name:    "private_key",
+		pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`),
+	},
+	{
+		name:    "github_token",
+		pattern: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	},
+	{
+		name:    "openai_api_key",
+		pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{32,}`),
+	},
+	{
+		name:    "slack_token",
+		pattern: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9\-]{10,}`),
+	},
+	{
+		name:    "jwt",
+		pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{8,}\.eyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`),
+	},
+}
+
+// quotedFragmentDetector removes quoted text from error messages when trace
+// content is disabled.
+var quotedFragmentDetector = piiDetector{
+	name:        "quoted_fragment",
+	pattern:     quotedFragment,
+	replacement: "[REDACTED]",
+}
+
+// redactionMatch is one stretch of captured content a redactor replaced, as
+// byte offsets into the original value. start is -1 when the redactor can't
+// report positions (e.g. a WithErrorScrubber function).
+type redactionMatch struct {
+	detector   string
+	start, end int
+}
+
+// redactPII replaces every match of the default PII detectors in s.
+func redactPII(s string) string {
+	s, _ = redact(s, defaultPIIDetectors)
+	return s
+}
+
+// redactPIIInto is redactPII, appending what it replaced to *matches without
+// positions: the values of a dataset record or transcript are redacted
+// together but have no common offsets.
+func redactPIIInto(s string, matches *[]redactionMatch) string {
+	s, found := redact(s, defaultPIIDetectors)
+	for _, m := range found {
+		*matches = append(*matches, redactionMatch{detector: m.detector, start: -1})
+	}
+	return s
+}
+
+// redactionCounts returns the number of matches per detector, or nil if
+// nothing was redacted.
+func redactionCounts(matches []redactionMatch) map[string]int {
+	if len(matches) == 0 {
+		return nil
+	}
+	counts := make(map[string]int)
+	for _, m := range matches {
+		counts[m.detector]++
+	}
+	return counts
+}
+
+// redact replaces every match of detectors in s and reports what it
+// replaced. All detectors match against the original value; where matches
+// overlap, the earlier detector wins.
+func redact(s string, detectors []piiDetector) (string, []redactionMatch) {
+	if s == "" {
+		return s, nil
+	}
+	type hit struct {
+		redactionMatch
+		replacement string
+	}
+	var hits []hit
+	for _, d := range detectors {
+	next:
+		for _, loc := range d.find(s) {
+			for _, h := range hits {
+				if loc[0] < h.end && h.start < loc[1] {
+					continue next
+				}
+			}
+			hits = append(hits, hit{redactionMatch{d.name, loc[0], loc[1]}, d.replacement})
+		}
+	}
+	if len(hits) == 0 {
+		return s, nil
+	}
+	sort.Slice(hits, func(i, j int) bool { return hits[i].start < hits[j].start })
+
+	out := make([]byte, 0, len(s))
+	matches := make([]redactionMatch, len(hits))
+	prev := 0
+	for i, h := range hits {
+		out = append(out, s[prev:h.start]...)
+		out = append(out, h.replacement...)
+		prev = h.end
+		matches[i] = h.redactionMatch
+	}
+	return string(append(out, s[prev:]...)), matches
+}
+
+// redactionAttributes records that the captured value under field, an
+// attribute key, was redacted: triage.redaction.applied, and under
+// triage.redaction.<field> (minus any "triage." prefix) the detectors that
+// matched, the number of matches and each match as "detector:start-end" byte
+// offsets into the original value. The redacted values themselves are never
+// recorded. Returns nil if nothing was redacted.
+func redactionAttributes(field string, matches []redactionMatch) []attribute.KeyValue {
+	if len(matches) == 0 {
+		return nil
+	}
+	var detectors, positions []string
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if !seen[m.detector] {
+			seen[m.detector] = true
+			detectors = append(detectors, m.detector)
+		}
+		if m.start >= 0 {
+			positions = append(positions, fmt.Sprintf("%s:%d-%d", m.detector, m.start, m.end))
+		}
+	}
+	prefix := AttrRedactionPrefix + strings.TrimPrefix(field, "triage.")
+	attrs := []attribute.KeyValue{
+		attribute.Bool(AttrRedactionApplied, true),
+		attribute.StringSlice(prefix+".detectors", detectors),
+		attribute.Int(prefix+".count", len(matches)),
+	}
+	if len(positions) > 0 {
+		attrs = append(attrs, attribute.StringSlice(prefix+".positions", positions))
+	}
+	return attrs
+}
+
+// WithErrorScrubber adds a function applied to error messages recorded by
+// LLMSpan.SetError, after the built-in PII redaction, e.g. to strip
+// account-specific identifiers.
+func WithErrorScrubber(fn func(string) string) Option {
+	return func(c *config) { c.errorScrubber = fn }
+}
+
+// scrubErrorMessage redacts PII from an error message. When trace content is
+// disabled, quoted fragments (which providers use to echo request content)
+// are removed as well, since the span must not carry prompt text.
+func scrubErrorMessage(msg string) string {
+	msg, _ = scrubErrorMessageMatches(msg)
+	return msg
+}
+
+// scrubErrorMessageMatches is scrubErrorMessage, also reporting what was
+// redacted. Quoted fragments are matched first so a quote is removed whole
+// even if it contains PII.
+func scrubErrorMessageMatches(msg string) (string, []redactionMatch) {
+	detectors := defaultPIIDetectors
+	if !isTraceContentEnabled() {
+		detectors = append([]piiDetector{quotedFragmentDetector}, defaultPIIDetectors...)
+	}
+	msg, matches := redact(msg, detectors)
+	if cfg := globalCfg; cfg != nil && cfg.errorScrubber != nil {
+		if scrubbed := cfg.errorScrubber(msg); scrubbed != msg {
+			msg = scrubbed
+			matches = append(matches, redactionMatch{detector: "custom", start: -1})
+		}
+	}
+	return msg, matches
+}
@@ -0,0 +1,152 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "mail me at jane.doe@example.com please", "mail me at [REDACTED_EMAIL] please"},
+		{"credit card", "card 4111 1111 1111 1111 exp 12/29", "card [REDACTED_CARD] exp 12/29"},
+		{"non-luhn card-like number", "order 1234 5678 9012 3456", "order 1234 5678 9012 3456"},
+		{"ssn", "ssn 123-45-6789", "ssn [REDACTED_SSN]"},
+		{"phone", "call (555) 123-4567", "call [REDACTED_PHONE]"},
+		{"phone international", "call +1 555-123-4567", "call [REDACTED_PHONE]"},
+		{"no pii", "what is prompt injection?", "what is prompt injection?"},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redactPII(tt.in); got != tt.want {
+				t.Errorf("redactPII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrubErrorMessage(t *testing.T) {
+	t.Cleanup(func() { globalCfg = nil })
+	msg := `invalid request: message "email bob@example.com my SSN" too long`
+
+	globalCfg = &config{traceContent: true}
+	if got, want := scrubErrorMessage(msg), `invalid request: message "email [REDACTED_EMAIL] my SSN" too long`; got != want {
+		t.Errorf("with content: got %q, want %q", got, want)
+	}
+
+	globalCfg = &config{traceContent: false}
+	if got, want := scrubErrorMessage(msg), `invalid request: message [REDACTED] too long`; got != want {
+		t.Errorf("without content: got %q, want %q", got, want)
+	}
+
+	globalCfg = &config{traceContent: true, errorScrubber: func(s string) string {
+		return strings.ReplaceAll(s, "invalid request", "bad request")
+	}}
+	if got := scrubErrorMessage("invalid request"); got != "bad request" {
+		t.Errorf("custom scrubber: got %q", got)
+	}
+}
+
+func TestSetError_ScrubsStatusAndEvent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.SetError(errors.New("content filter triggered on: call me at 555-123-4567"))
+	llmSpan.End()
+
+	span := exporter.GetSpans()[0]
+	want := "content filter triggered on: call me at [REDACTED_PHONE]"
+	if span.Status.Description != want {
+		t.Errorf("status: got %q, want %q", span.Status.Description, want)
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != exceptionEventName {
+		t.Fatalf("expected one exception event, got %+v", span.Events)
+	}
+	if got := attrMap(span.Events[0].Attributes)[AttrExceptionMessage]; got != want {
+		t.Errorf("exception.message: got %v", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Redaction audit
+// ---------------------------------------------------------------------------
+
+func TestRedact_ReportsMatchesInOriginalOffsets(t *testing.T) {
+	in := "mail bob@example.com or call (555) 123-4567"
+	got, matches := redact(in, defaultPIIDetectors)
+	if got != "mail [REDACTED_EMAIL] or call [REDACTED_PHONE]" {
+		t.Errorf("redacted: got %q", got)
+	}
+	want := []redactionMatch{{"email", 5, 20}, {"phone", 29, 43}}
+	if len(matches) != len(want) {
+		t.Fatalf("matches: got %+v, want %+v", matches, want)
+	}
+	for i, m := range want {
+		if matches[i] != m {
+			t.Errorf("match %d: got %+v, want %+v", i, matches[i], m)
+		}
+		if in[m.start:m.end] == "" {
+			t.Errorf("match %d: empty range", i)
+		}
+	}
+}
+
+func TestRedactionAttributes(t *testing.T) {
+	if attrs := redactionAttributes(AttrExceptionMessage, nil); attrs != nil {
+		t.Errorf("no matches should record nothing, got %v", attrs)
+	}
+
+	attrs := attrMap(redactionAttributes(AttrTemplateVariablePrefix+"Question", []redactionMatch{
+		{"email", 0, 15}, {"email", 20, 35}, {"custom", -1, 0},
+	}))
+	prefix := AttrRedactionPrefix + "template.var.Question"
+	if attrs[AttrRedactionApplied] != true || attrs[prefix+".count"] != int64(3) {
+		t.Errorf("applied/count: got %v", attrs)
+	}
+	if d, _ := attrs[prefix+".detectors"].([]string); strings.Join(d, ",") != "email,custom" {
+		t.Errorf("detectors: got %v", attrs[prefix+".detectors"])
+	}
+	if p, _ := attrs[prefix+".positions"].([]string); strings.Join(p, ",") != "email:0-15,email:20-35" {
+		t.Errorf("positions: got %v", attrs[prefix+".positions"])
+	}
+}
+
+func TestSetError_RecordsRedactionAudit(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.SetError(errors.New("rejected: call me at 555-123-4567"))
+	llmSpan.End()
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	prefix := AttrRedactionPrefix + AttrExceptionMessage
+	if attrs[AttrRedactionApplied] != true || attrs[prefix+".count"] != int64(1) {
+		t.Errorf("audit: got %v", attrs)
+	}
+	if p, _ := attrs[prefix+".positions"].([]string); len(p) != 1 || p[0] != "phone:21-33" {
+		t.Errorf("positions: got %v", attrs[prefix+".positions"])
+	}
+	for _, v := range attrs {
+		if s, ok := v.(string); ok && strings.Contains(s, "555-123-4567") {
+			t.Errorf("redacted value leaked into attributes: %q", s)
+		}
+	}
+}
+
+func TestSetError_NoAuditWithoutRedaction(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.SetError(errors.New("rate limited"))
+	llmSpan.End()
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrRedactionApplied]; ok {
+		t.Error("unredacted errors should not record a redaction audit")
+	}
+}
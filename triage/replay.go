@@ -0,0 +1,189 @@
+package triage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ModelFunc executes a prompt against a model. Replay calls it once per
+// recorded LLM call; RunToolLoop calls it once per loop iteration.
+type ModelFunc func(ctx context.Context, prompt Prompt) (Completion, Usage, error)
+
+// CompareFunc scores a replayed completion against the recorded one. Each
+// returned entry is recorded with LogScore on the replay span.
+type CompareFunc func(original, replayed Completion) map[string]float64
+
+// ReplayOption configures optional behavior for Replay.
+type ReplayOption func(*replayConfig)
+
+type replayConfig struct {
+	compare CompareFunc
+	vendor  string
+	model   string
+}
+
+// ReplayCompare sets the function used to score replayed completions. The
+// default records a single "exact_match" score (1 or 0) comparing the final
+// message content.
+func ReplayCompare(fn CompareFunc) ReplayOption {
+	return func(rc *replayConfig) { rc.compare = fn }
+}
+
+// ReplayModel overrides the vendor and model recorded on the replayed
+// prompts, e.g. when replaying gpt-4o traffic against a candidate model.
+func ReplayModel(vendor, model string) ReplayOption {
+	return func(rc *replayConfig) {
+		rc.vendor = vendor
+		rc.model = model
+	}
+}
+
+// ReplayResult is the outcome of replaying one recorded call.
+type ReplayResult struct {
+	Record     DatasetRecord
+	Completion Completion
+	Usage      Usage
+	Scores     map[string]float64
+	Err        error
+}
+
+// Replay re-executes previously recorded LLM calls against model and emits a
+// "triage.replay" span per record, linked to the original span, with the new
+// LLM call nested beneath it and comparison scores attached — enabling
+// regression testing of new prompts/models against real historical traffic.
+//
+// Records typically come from ReadDatasetRecords or Client.ListDatasetRecords.
+// Replay runs sequentially and stops early only if ctx is cancelled.
+func Replay(ctx context.Context, records []DatasetRecord, model ModelFunc, opts ...ReplayOption) []ReplayResult {
+	rc := replayConfig{compare: exactMatch}
+	for _, o := range opts {
+		o(&rc)
+	}
+
+	results := make([]ReplayResult, 0, len(records))
+	for _, rec := range records {
+		if ctx.Err() != nil {
+			break
+		}
+		results = append(results, replayOne(ctx, rec, model, rc))
+	}
+	return results
+}
+
+func replayOne(ctx context.Context, rec DatasetRecord, model ModelFunc, rc replayConfig) ReplayResult {
+	startOpts := []trace.SpanStartOption{
+		trace.WithAttributes(
+			attribute.String("traceloop.span.kind", SpanKindWorkflow),
+			attribute.String("traceloop.entity.name", replaySpanName),
+			attribute.String(AttrReplayOriginalTrace, rec.TraceID),
+			attribute.String(AttrReplayOriginalSpan, rec.SpanID),
+			attribute.String(AttrReplayOriginalModel, rec.Model),
+		),
+	}
+	if link, err := remoteSpanContext(rec.TraceID, rec.SpanID); err == nil {
+		startOpts = append(startOpts, trace.WithLinks(trace.Link{SpanContext: link}))
+	}
+
+	tracer := sdkTracer()
+	ctx, span := tracer.Start(ctx, replaySpanName, startOpts...)
+	defer span.End()
+
+	prompt := Prompt{Vendor: rec.Vendor, Model: rec.Model}
+	if rc.vendor != "" {
+		prompt.Vendor = rc.vendor
+	}
+	if rc.model != "" {
+		prompt.Model = rc.model
+	}
+	for _, m := range rec.Input {
+		prompt.Messages = append(prompt.Messages, Message{Role: m.Role, Content: m.Content})
+	}
+
+	result := ReplayResult{Record: rec}
+	llmSpan, llmCtx := LogPrompt(ctx, prompt)
+	result.Completion, result.Usage, result.Err = model(llmCtx, prompt)
+	if result.Err != nil {
+		llmSpan.SetError(result.Err)
+		span.SetStatus(codes.Error, "replay model call failed")
+	}
+	llmSpan.LogCompletion(result.Completion, result.Usage)
+	if result.Err != nil {
+		return result
+	}
+
+	original := Completion{Model: rec.Model}
+	for _, m := range rec.Output {
+		original.Messages = append(original.Messages, Message{Role: m.Role, Content: m.Content})
+	}
+	result.Scores = rc.compare(original, result.Completion)
+	for name, v := range result.Scores {
+		LogScore(ctx, name, v)
+	}
+	return result
+}
+
+// exactMatch is the default CompareFunc: 1 if the final message content of
+// both completions is identical, 0 otherwise.
+func exactMatch(original, replayed Completion) map[string]float64 {
+	score := 0.0
+	if lastContent(original) == lastContent(replayed) {
+		score = 1
+	}
+	return map[string]float64{"exact_match": score}
+}
+
+func lastContent(c Completion) string {
+	if len(c.Messages) == 0 {
+		return ""
+	}
+	return c.Messages[len(c.Messages)-1].Content
+}
+
+// ReadDatasetRecords reads JSON Lines of DatasetRecord — the format produced
+// by dataset capture and by the backend's dataset export — from r.
+func ReadDatasetRecords(r io.Reader) ([]DatasetRecord, error) {
+	var records []DatasetRecord
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for sc.Scan() {
+		line++
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec DatasetRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("triage: invalid dataset record on line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("triage: failed to read dataset records: %w", err)
+	}
+	return records, nil
+}
+
+// ListDatasetRecords fetches all records of the named dataset from the
+// backend.
+func (c *Client) ListDatasetRecords(ctx context.Context, dataset string) ([]DatasetRecord, error) {
+	if dataset == "" {
+		return nil, errors.New("triage: ListDatasetRecords requires a dataset name")
+	}
+	var resp struct {
+		Records []DatasetRecord `json:"records"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/datasets/"+url.PathEscape(dataset)+"/records", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Records, nil
+}
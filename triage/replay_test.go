@@ -0,0 +1,160 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+var replayRecords = []DatasetRecord{
+	{
+		TraceID: "0af7651916cd43dd8448eb211c80319c",
+		SpanID:  "b7ad6b7169203331",
+		Vendor:  "openai",
+		Model:   "gpt-4o",
+		Input:   []DatasetMessage{{Role: "user", Content: "2+2?"}},
+		Output:  []DatasetMessage{{Role: "assistant", Content: "4"}},
+	},
+	{
+		Vendor: "openai",
+		Model:  "gpt-4o",
+		Input:  []DatasetMessage{{Role: "user", Content: "capital of France?"}},
+		Output: []DatasetMessage{{Role: "assistant", Content: "Paris"}},
+	},
+}
+
+// echoModel answers "4" to everything.
+func echoModel(_ context.Context, p Prompt) (Completion, Usage, error) {
+	return Completion{Model: p.Model, Messages: []Message{{Role: "assistant", Content: "4"}}}, Usage{TotalTokens: 3}, nil
+}
+
+// ---------------------------------------------------------------------------
+// Replay
+// ---------------------------------------------------------------------------
+
+func TestReplay_EmitsLinkedComparisonSpans(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	results := Replay(context.Background(), replayRecords, echoModel, ReplayModel("openai", "gpt-4o-mini"))
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if results[0].Scores["exact_match"] != 1 || results[1].Scores["exact_match"] != 0 {
+		t.Errorf("scores: got %v / %v", results[0].Scores, results[1].Scores)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans (2 replay + 2 llm), got %d", len(spans))
+	}
+
+	// Spans end child-first: llm, replay, llm, replay.
+	llm, replay := spans[0], spans[1]
+	if replay.Name != replaySpanName {
+		t.Errorf("span name: got %q, want %q", replay.Name, replaySpanName)
+	}
+	if llm.Parent.SpanID() != replay.SpanContext.SpanID() {
+		t.Error("LLM span should be nested under the replay span")
+	}
+	if len(replay.Links) != 1 || replay.Links[0].SpanContext.SpanID().String() != "b7ad6b7169203331" {
+		t.Errorf("expected link to original span, got %+v", replay.Links)
+	}
+	if attrMap(llm.Attributes)["gen_ai.request.model"] != "gpt-4o-mini" {
+		t.Errorf("replayed model: got %v", attrMap(llm.Attributes)["gen_ai.request.model"])
+	}
+	attrs := attrMap(replay.Attributes)
+	if attrs[AttrReplayOriginalModel] != "gpt-4o" {
+		t.Errorf("original model: got %v", attrs[AttrReplayOriginalModel])
+	}
+	if attrs["triage.score.exact_match"] != 1.0 {
+		t.Errorf("exact_match score: got %v", attrs["triage.score.exact_match"])
+	}
+
+	// Second record has no original IDs, so no link.
+	if len(spans[3].Links) != 0 {
+		t.Errorf("expected no link without original IDs, got %+v", spans[3].Links)
+	}
+}
+
+func TestReplay_ModelErrorRecorded(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	failing := func(context.Context, Prompt) (Completion, Usage, error) {
+		return Completion{}, Usage{}, errors.New("rate limited")
+	}
+	results := Replay(context.Background(), replayRecords[:1], failing)
+
+	if results[0].Err == nil || results[0].Scores != nil {
+		t.Errorf("expected error and no scores, got %+v", results[0])
+	}
+	if len(exporter.GetSpans()[0].Events) == 0 {
+		t.Error("expected an exception event on the LLM span")
+	}
+}
+
+func TestReplay_CustomCompare(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	lengthRatio := func(o, r Completion) map[string]float64 {
+		return map[string]float64{"length_ratio": float64(len(lastContent(r))) / float64(len(lastContent(o)))}
+	}
+	results := Replay(context.Background(), replayRecords[1:], echoModel, ReplayCompare(lengthRatio))
+
+	if got := results[0].Scores["length_ratio"]; got != 0.2 {
+		t.Errorf("length_ratio: got %v, want 0.2", got)
+	}
+}
+
+func TestReplay_StopsOnCancelledContext(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if results := Replay(ctx, replayRecords, echoModel); len(results) != 0 {
+		t.Errorf("expected no results for cancelled context, got %d", len(results))
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Record sources
+// ---------------------------------------------------------------------------
+
+func TestReadDatasetRecords_JSONLines(t *testing.T) {
+	input := `{"model":"gpt-4o","input":[{"role":"user","content":"hi"}],"output":[{"role":"assistant","content":"hello"}]}
+
+{"model":"gpt-4o","input":[{"role":"user","content":"bye"}],"output":[]}
+`
+	records, err := ReadDatasetRecords(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[1].Input[0].Content != "bye" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+}
+
+func TestReadDatasetRecords_InvalidLine(t *testing.T) {
+	_, err := ReadDatasetRecords(strings.NewReader("{\"model\":\"x\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected line 2 error, got %v", err)
+	}
+}
+
+func TestListDatasetRecords(t *testing.T) {
+	client, req, _ := newTestBackend(t, http.StatusOK, map[string]any{
+		"records": []DatasetRecord{{Model: "gpt-4o"}},
+	})
+
+	records, err := client.ListDatasetRecords(context.Background(), "prod chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Model != "gpt-4o" {
+		t.Errorf("unexpected records: %+v", records)
+	}
+	if req.Method != http.MethodGet || req.URL.EscapedPath() != "/v1/datasets/prod%20chat/records" {
+		t.Errorf("request: got %s %s", req.Method, req.URL.EscapedPath())
+	}
+}
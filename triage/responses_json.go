@@ -0,0 +1,285 @@
+package triage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// responsesRequest is the subset of an OpenAI Responses API request body
+// mapped onto a Prompt.
+type responsesRequest struct {
+	Model           string          `json:"model"`
+	Instructions    string          `json:"instructions"`
+	Input           json.RawMessage `json:"input"`
+	Tools           []responsesTool `json:"tools"`
+	MaxOutputTokens int             `json:"max_output_tokens"`
+	Temperature     *float64        `json:"temperature"`
+	TopP            *float64        `json:"top_p"`
+	ServiceTier     string          `json:"service_tier"`
+	PromptCacheKey  string          `json:"prompt_cache_key"`
+}
+
+// responsesTool is a tool definition. Function tools are flat, unlike Chat
+// Completions; built-in tools (web_search, file_search, ...) carry only
+// their type and configuration.
+type responsesTool struct {
+	Type        string          `json:"type"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// responsesItem is one input or output item: a message, a function call or
+// its output, a reasoning summary, or a built-in tool call.
+type responsesItem struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	Status    string          `json:"status"`
+	Role      string          `json:"role"`
+	Content   json.RawMessage `json:"content"`
+	CallID    string          `json:"call_id"`
+	Name      string          `json:"name"`
+	Arguments string          `json:"arguments"`
+	Output    json.RawMessage `json:"output"`
+	Summary   []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+
+	// Built-in tool call details: web_search_call reports its query in
+	// action, file_search_call its queries and results.
+	Action struct {
+		Query string `json:"query"`
+	} `json:"action"`
+	Queries []string          `json:"queries"`
+	Results []json.RawMessage `json:"results"`
+}
+
+// responsesResponse is the subset of an OpenAI Responses API response body
+// mapped onto a Completion and Usage.
+type responsesResponse struct {
+	Model             string          `json:"model"`
+	Status            string          `json:"status"`
+	ServiceTier       string          `json:"service_tier"`
+	Output            []responsesItem `json:"output"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Usage *OpenAIResponsesUsage `json:"usage"`
+}
+
+// ParseResponsesPromptFromJSON maps a raw OpenAI Responses API request body
+// onto a Prompt with Vendor "openai" and Operation OperationResponses, the
+// Responses counterpart of ParsePromptFromJSON. Instructions become a
+// leading system message, and input items map onto the Chat Completions
+// shape the rest of the SDK records: function_call items become assistant
+// tool calls and function_call_output items become tool messages. Built-in
+// tools (web_search, file_search, ...) are recorded by type. Other input
+// items, such as replayed reasoning or built-in tool calls, were recorded on
+// the span of the response that produced them and are skipped.
+func ParseResponsesPromptFromJSON(body []byte) (Prompt, error) {
+	var req responsesRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return Prompt{}, fmt.Errorf("triage: invalid OpenAI Responses request body: %w", err)
+	}
+
+	p := Prompt{
+		Vendor:      "openai",
+		Operation:   OperationResponses,
+		Model:       req.Model,
+		MaxTokens:   req.MaxOutputTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		ServiceTier: req.ServiceTier,
+		CacheKey:    req.PromptCacheKey,
+	}
+	if req.Instructions != "" {
+		p.Messages = append(p.Messages, Message{Role: "system", Content: req.Instructions})
+	}
+	p.Messages = append(p.Messages, responsesInputMessages(req.Input)...)
+	for _, t := range req.Tools {
+		def := ToolDef{Type: t.Type, Function: ToolFunction{Name: t.Name, Description: t.Description}}
+		if len(t.Parameters) > 0 {
+			def.Function.Parameters = t.Parameters
+		}
+		p.Tools = append(p.Tools, def)
+	}
+	return p, nil
+}
+
+// ParseResponsesCompletionFromJSON maps a raw OpenAI Responses API response
+// body onto a Completion and Usage, for use with LogCompletion. Message,
+// reasoning and function_call output items are joined into one assistant
+// message; built-in tool calls become BuiltinToolCalls. The finish reason is
+// derived from the response status: "stop" or "tool_calls" for a completed
+// response, or the incomplete_details reason (e.g. "max_output_tokens").
+func ParseResponsesCompletionFromJSON(body []byte) (Completion, Usage, error) {
+	var resp responsesResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return Completion{}, Usage{}, fmt.Errorf("triage: invalid OpenAI Responses response body: %w", err)
+	}
+	c, u := resp.completion()
+	return c, u, nil
+}
+
+func (r responsesResponse) completion() (Completion, Usage) {
+	c := Completion{Model: r.Model, ServiceTier: r.ServiceTier}
+	m := Message{Role: "assistant"}
+	var texts, reasoning []string
+	for _, item := range r.Output {
+		switch item.Type {
+		case "message":
+			if text := contentText(item.Content); text != "" {
+				texts = append(texts, text)
+			}
+		case "reasoning":
+			for _, s := range item.Summary {
+				reasoning = append(reasoning, s.Text)
+			}
+		case "function_call":
+			m.ToolCalls = append(m.ToolCalls, item.toolCall())
+		default:
+			c.BuiltinToolCalls = append(c.BuiltinToolCalls, item.builtinToolCall())
+		}
+	}
+	m.Content = strings.Join(texts, "\n")
+	m.Reasoning = strings.Join(reasoning, "\n")
+	if !m.empty() {
+		c.Messages = []Message{m}
+	}
+
+	switch {
+	case r.Status == "incomplete" && r.IncompleteDetails != nil:
+		c.FinishReason = r.IncompleteDetails.Reason
+	case r.Status == "completed" && len(m.ToolCalls) > 0:
+		c.FinishReason = "tool_calls"
+	case r.Status == "completed":
+		c.FinishReason = "stop"
+	}
+
+	var u Usage
+	if r.Usage != nil {
+		u = FromOpenAIResponsesUsage(*r.Usage)
+	}
+	return c, u
+}
+
+// responsesInputMessages converts a request's input, a string or an array of
+// items, into SDK messages. Consecutive function_call items are joined into
+// one assistant message, as Chat Completions would send them.
+func responsesInputMessages(raw json.RawMessage) []Message {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return []Message{{Role: "user", Content: s}}
+	}
+	var items []responsesItem
+	if json.Unmarshal(raw, &items) != nil {
+		return nil
+	}
+	var msgs []Message
+	for _, item := range items {
+		switch item.Type {
+		case "", "message":
+			msgs = append(msgs, Message{Role: item.Role, Content: contentText(item.Content)})
+		case "function_call":
+			if n := len(msgs); n > 0 && msgs[n-1].Role == "assistant" && len(msgs[n-1].ToolCalls) > 0 {
+				msgs[n-1].ToolCalls = append(msgs[n-1].ToolCalls, item.toolCall())
+				continue
+			}
+			msgs = append(msgs, Message{Role: "assistant", ToolCalls: []ToolCall{item.toolCall()}})
+		case "function_call_output":
+			msgs = append(msgs, Message{Role: "tool", Content: contentText(item.Output), ToolCallID: item.CallID})
+		}
+	}
+	return msgs
+}
+
+func (item responsesItem) toolCall() ToolCall {
+	return ToolCall{
+		ID:       item.CallID,
+		Type:     "function",
+		Function: ToolCallFunction{Name: item.Name, Arguments: item.Arguments},
+	}
+}
+
+func (item responsesItem) builtinToolCall() BuiltinToolCall {
+	call := BuiltinToolCall{
+		ID:      item.ID,
+		Type:    item.Type,
+		Status:  item.Status,
+		Name:    item.Name,
+		Queries: item.Queries,
+		Results: len(item.Results),
+	}
+	if item.Action.Query != "" {
+		call.Queries = append(call.Queries, item.Action.Query)
+	}
+	return call
+}
+
+// responsesEvent is one server-sent event of a streamed Responses API
+// response.
+type responsesEvent struct {
+	Type     string            `json:"type"`
+	Delta    json.RawMessage   `json:"delta"`
+	Response responsesResponse `json:"response"`
+}
+
+// responsesStream reassembles a streamed Responses API response from its
+// server-sent events. The final response.completed (or .incomplete, .failed)
+// event carries the whole response; if the stream ends before it, the
+// output text received so far is recorded. Like openAIStream it is fed raw
+// bytes as they pass through.
+type responsesStream struct {
+	sse     sseBuffer
+	onChunk func(token bool) // called once per delta event, always with token
+
+	model string
+	text  strings.Builder
+	final *responsesResponse
+}
+
+// Write consumes raw SSE bytes. It never fails.
+func (s *responsesStream) Write(p []byte) (int, error) {
+	s.sse.feed(p, s.event)
+	return len(p), nil
+}
+
+func (s *responsesStream) event(data []byte) {
+	var ev responsesEvent
+	if json.Unmarshal(data, &ev) != nil {
+		return
+	}
+	if strings.HasSuffix(ev.Type, ".delta") && s.onChunk != nil {
+		s.onChunk(true)
+	}
+	switch ev.Type {
+	case "response.created":
+		s.model = ev.Response.Model
+	case "response.output_text.delta":
+		var delta string
+		if json.Unmarshal(ev.Delta, &delta) == nil {
+			s.text.WriteString(delta)
+		}
+	case "response.completed", "response.incomplete", "response.failed":
+		s.final = &ev.Response
+	}
+}
+
+// result returns the final response, or the partial output text if the
+// stream ended before it.
+func (s *responsesStream) result() (Completion, Usage) {
+	if s.final != nil {
+		return s.final.completion()
+	}
+	c := Completion{Model: s.model}
+	if s.text.Len() > 0 {
+		c.Messages = []Message{{Role: "assistant", Content: s.text.String()}}
+	}
+	return c, Usage{}
+}
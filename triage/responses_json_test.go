@@ -0,0 +1,212 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const responsesRequestBody = `{
+	"model": "gpt-4.1",
+	"instructions": "Be brief.",
+	"input": [
+		{"role": "user", "content": [{"type": "input_text", "text": "Weather in Paris?"}]},
+		{"type": "function_call", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"},
+		{"type": "function_call_output", "call_id": "call_1", "output": "18C"}
+	],
+	"tools": [
+		{"type": "function", "name": "get_weather", "description": "Current weather", "parameters": {"type": "object"}},
+		{"type": "web_search_preview"}
+	],
+	"max_output_tokens": 256
+}`
+
+const responsesResponseBody = `{
+	"id": "resp_1",
+	"model": "gpt-4.1-2025-04-14",
+	"status": "completed",
+	"output": [
+		{"type": "web_search_call", "id": "ws_1", "status": "completed", "action": {"type": "search", "query": "paris weather"}},
+		{"type": "file_search_call", "id": "fs_1", "status": "completed", "queries": ["paris"], "results": [{"file_id": "f1"}, {"file_id": "f2"}]},
+		{"type": "message", "id": "msg_1", "role": "assistant", "content": [{"type": "output_text", "text": "It is 18C."}]}
+	],
+	"usage": {"input_tokens": 40, "input_tokens_details": {"cached_tokens": 8}, "output_tokens": 12, "output_tokens_details": {"reasoning_tokens": 4}, "total_tokens": 52}
+}`
+
+func TestParseResponsesPromptFromJSON(t *testing.T) {
+	p, err := ParseResponsesPromptFromJSON([]byte(responsesRequestBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Vendor != "openai" || p.Operation != OperationResponses || p.Model != "gpt-4.1" || p.MaxTokens != 256 {
+		t.Errorf("prompt: got %+v", p)
+	}
+	want := []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "Weather in Paris?"},
+		{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Type: "function", Function: ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`}}}},
+		{Role: "tool", Content: "18C", ToolCallID: "call_1"},
+	}
+	if !reflect.DeepEqual(p.Messages, want) {
+		t.Errorf("messages:\n got %+v\nwant %+v", p.Messages, want)
+	}
+	if len(p.Tools) != 2 || p.Tools[0].Function.Name != "get_weather" || p.Tools[1].Type != "web_search_preview" {
+		t.Errorf("tools: got %+v", p.Tools)
+	}
+}
+
+func TestParseResponsesPromptFromJSON_StringInput(t *testing.T) {
+	p, err := ParseResponsesPromptFromJSON([]byte(`{"model":"gpt-4.1","input":"Hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(p.Messages, []Message{{Role: "user", Content: "Hi"}}) {
+		t.Errorf("messages: got %+v", p.Messages)
+	}
+}
+
+func TestParseResponsesCompletionFromJSON(t *testing.T) {
+	c, u, err := ParseResponsesCompletionFromJSON([]byte(responsesResponseBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Model != "gpt-4.1-2025-04-14" || c.FinishReason != "stop" {
+		t.Errorf("model/finish: got %q/%q", c.Model, c.FinishReason)
+	}
+	if len(c.Messages) != 1 || c.Messages[0].Content != "It is 18C." {
+		t.Errorf("messages: got %+v", c.Messages)
+	}
+	want := []BuiltinToolCall{
+		{ID: "ws_1", Type: "web_search_call", Status: "completed", Queries: []string{"paris weather"}},
+		{ID: "fs_1", Type: "file_search_call", Status: "completed", Queries: []string{"paris"}, Results: 2},
+	}
+	if !reflect.DeepEqual(c.BuiltinToolCalls, want) {
+		t.Errorf("builtin tool calls:\n got %+v\nwant %+v", c.BuiltinToolCalls, want)
+	}
+	if u != (Usage{PromptTokens: 40, CompletionTokens: 12, TotalTokens: 52, CacheReadTokens: 8, ReasoningTokens: 4}) {
+		t.Errorf("usage: got %+v", u)
+	}
+}
+
+func TestParseResponsesCompletionFromJSON_FinishReason(t *testing.T) {
+	for body, want := range map[string]string{
+		`{"status":"completed","output":[{"type":"function_call","call_id":"c","name":"f","arguments":"{}"}]}`: "tool_calls",
+		`{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}`:                          "max_output_tokens",
+		`{"status":"in_progress"}`: "",
+	} {
+		c, _, err := ParseResponsesCompletionFromJSON([]byte(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.FinishReason != want {
+			t.Errorf("%s: got %q, want %q", body, c.FinishReason, want)
+		}
+	}
+}
+
+func TestParseResponsesCompletionFromJSON_Invalid(t *testing.T) {
+	if _, _, err := ParseResponsesCompletionFromJSON([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid body")
+	}
+}
+
+func TestLogCompletion_RecordsResponsesItems(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	prompt, _ := ParseResponsesPromptFromJSON([]byte(responsesRequestBody))
+	completion, usage, _ := ParseResponsesCompletionFromJSON([]byte(responsesResponseBody))
+	ls, _ := LogPrompt(context.Background(), prompt)
+	ls.LogCompletion(completion, usage)
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "openai.responses gpt-4.1" {
+		t.Errorf("span name: got %q", span.Name)
+	}
+	attrs := attrMap(span.Attributes)
+	for k, want := range map[string]any{
+		AttrGenAIOperationName:                        "responses",
+		"llm.request.type":                            "responses",
+		"gen_ai.request.tool.1.type":                  "web_search_preview",
+		"gen_ai.response.builtin_tool_call.0.type":    "web_search_call",
+		"gen_ai.response.builtin_tool_call.0.id":      "ws_1",
+		"gen_ai.response.builtin_tool_call.0.status":  "completed",
+		"gen_ai.response.builtin_tool_call.1.results": int64(2),
+		"gen_ai.completion.0.content":                 "It is 18C.",
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], want)
+		}
+	}
+	if got := attrs["gen_ai.response.builtin_tool_call.0.queries"]; !reflect.DeepEqual(got, []string{"paris weather"}) {
+		t.Errorf("queries: got %v", got)
+	}
+	if _, ok := attrs["gen_ai.request.tool.1.function.name"]; ok {
+		t.Error("built-in tools have no function name")
+	}
+}
+
+func TestLogCompletion_BuiltinToolQueriesRequireTraceContent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+
+	completion, usage, _ := ParseResponsesCompletionFromJSON([]byte(responsesResponseBody))
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Operation: OperationResponses, Model: "gpt-4.1"})
+	ls.LogCompletion(completion, usage)
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if _, ok := attrs["gen_ai.response.builtin_tool_call.0.queries"]; ok {
+		t.Error("search queries should not be recorded when traceContent is false")
+	}
+	if attrs["gen_ai.response.builtin_tool_call.0.type"] != "web_search_call" {
+		t.Errorf("builtin tool call type should still be recorded: got %v", attrs["gen_ai.response.builtin_tool_call.0.type"])
+	}
+}
+
+func TestOpenAIMiddleware_RecordsResponsesStream(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	callMiddleware(t, OpenAIMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: response.created\n"+`data: {"type":"response.created","response":{"model":"gpt-4.1","status":"in_progress"}}`+"\n\n")
+		io.WriteString(w, "event: response.output_text.delta\n"+`data: {"type":"response.output_text.delta","delta":"It is "}`+"\n\n")
+		io.WriteString(w, "event: response.output_text.delta\n"+`data: {"type":"response.output_text.delta","delta":"18C."}`+"\n\n")
+		io.WriteString(w, "event: response.completed\n"+`data: {"type":"response.completed","response":`+compactJSON(t, responsesResponseBody)+`}`+"\n\n")
+	}, http.MethodPost, "/v1/responses", `{"model":"gpt-4.1","input":"Weather in Paris?","stream":true}`)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAIOperationName] != "responses" || attrs["gen_ai.completion.0.content"] != "It is 18C." {
+		t.Errorf("operation/content: got %v/%v", attrs[AttrGenAIOperationName], attrs["gen_ai.completion.0.content"])
+	}
+	if attrs["gen_ai.response.builtin_tool_call.0.type"] != "web_search_call" || attrs["llm.usage.total_tokens"] != int64(52) {
+		t.Errorf("builtin/usage: got %v/%v", attrs["gen_ai.response.builtin_tool_call.0.type"], attrs["llm.usage.total_tokens"])
+	}
+}
+
+func TestResponsesStream_PartialText(t *testing.T) {
+	s := &responsesStream{}
+	s.Write([]byte(`data: {"type":"response.created","response":{"model":"gpt-4.1"}}` + "\n\n"))
+	s.Write([]byte(`data: {"type":"response.output_text.delta","delta":"It is"}` + "\n\n"))
+
+	c, _ := s.result()
+	if c.Model != "gpt-4.1" || len(c.Messages) != 1 || c.Messages[0].Content != "It is" {
+		t.Errorf("partial completion: got %+v", c)
+	}
+}
+
+// compactJSON returns s, a JSON document, on one line for an SSE data field.
+func compactJSON(t *testing.T, s string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,233 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Retry defaults used by DoWithRetry.
+const (
+	defaultRetryAttempts  = 4
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// StatusFunc extracts the HTTP status of a failed provider call from err and
+// the delay the provider asked for (Retry-After), or 0 for either if unknown.
+type StatusFunc func(err error) (status int, retryAfter time.Duration)
+
+// RetryOption configures optional behavior for DoWithRetry.
+type RetryOption func(*retryConfig)
+
+type retryConfig struct {
+	attempts  int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	status    StatusFunc
+	sleep     func(ctx context.Context, d time.Duration) error // replaced in tests
+}
+
+// RetryAttempts sets the maximum number of calls, including the first.
+// Defaults to 4.
+func RetryAttempts(n int) RetryOption {
+	return func(rc *retryConfig) { rc.attempts = n }
+}
+
+// RetryBackoff sets the delay before the first retry and the cap on any
+// delay, including one requested by Retry-After. The delay doubles with each
+// retry, with jitter. Defaults to 500ms and 30s.
+func RetryBackoff(base, max time.Duration) RetryOption {
+	return func(rc *retryConfig) {
+		rc.baseDelay = base
+		rc.maxDelay = max
+	}
+}
+
+// RetryStatus sets the function that reads the HTTP status and Retry-After
+// from an error, for provider SDKs whose errors the default doesn't
+// recognize. The default looks through the error chain for a StatusCode or
+// HTTPStatusCode field (openai-go, anthropic-sdk-go, go-openai, APIError)
+// and reads Retry-After from a Response *http.Response field.
+func RetryStatus(fn StatusFunc) RetryOption {
+	return func(rc *retryConfig) { rc.status = fn }
+}
+
+// DoWithRetry calls call until it succeeds, returns an error that isn't
+// retryable, or the attempts run out. Throttling (429) and server errors
+// (5xx) are retried with exponential backoff; a Retry-After the provider
+// sends is honored instead of the backoff delay:
+//
+//	completion, err := triage.DoWithRetry(ctx, func(ctx context.Context) (*openai.ChatCompletion, error) {
+//	    return client.Chat.Completions.New(ctx, params)
+//	})
+//
+// Each retry is recorded as a triage.retry event on the span in ctx with the
+// attempt number, status, error type and delay, so throttling shows up in
+// the trace. Returns the last error, or ctx.Err() if ctx is cancelled while
+// waiting.
+func DoWithRetry[T any](ctx context.Context, call func(ctx context.Context) (T, error), opts ...RetryOption) (T, error) {
+	rc := retryConfig{
+		attempts:  defaultRetryAttempts,
+		baseDelay: defaultRetryBaseDelay,
+		maxDelay:  defaultRetryMaxDelay,
+		status:    providerStatus,
+		sleep:     sleepContext,
+	}
+	for _, o := range opts {
+		o(&rc)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	for attempt := 1; ; attempt++ {
+		v, err := call(ctx)
+		if err == nil || attempt >= rc.attempts || ctx.Err() != nil {
+			return v, err
+		}
+		var status int
+		var retryAfter time.Duration
+		guard("DoWithRetry", func() { status, retryAfter = rc.status(err) })
+		if !retryableStatus(status) {
+			return v, err
+		}
+
+		delay := rc.backoff(attempt)
+		if retryAfter > 0 {
+			delay = min(retryAfter, rc.maxDelay)
+		}
+		span.AddEvent(retryEventName, trace.WithAttributes(
+			attribute.Int(AttrRetryAttempt, attempt),
+			attribute.Int(AttrRetryStatusCode, status),
+			attribute.String(AttrErrorType, errorType(err)),
+			attribute.Int64(AttrRetryDelayMs, delay.Milliseconds()),
+			attribute.Bool(AttrRetryAfterHonored, retryAfter > 0),
+		))
+		if err := rc.sleep(ctx, delay); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// backoff returns the delay before retry number attempt: the base delay
+// doubled per previous retry, capped at the max delay, with up to 50% of it
+// randomized so concurrent callers spread out.
+func (rc *retryConfig) backoff(attempt int) time.Duration {
+	d := rc.baseDelay
+	for i := 1; i < attempt && d < rc.maxDelay; i++ {
+		d *= 2
+	}
+	d = min(d, rc.maxDelay)
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// retryableStatus reports whether a call that failed with status should be
+// retried: throttling and server errors.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// providerStatus is the default StatusFunc. It finds the first error in err's
+// chain with an int StatusCode or HTTPStatusCode field, the shape used by the
+// common provider SDKs, and reads Retry-After from its Response field if it
+// has one.
+func providerStatus(err error) (int, time.Duration) {
+	for _, e := range errorChain(err) {
+		v := reflect.ValueOf(e)
+		for v.Kind() == reflect.Pointer && !v.IsNil() {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		var status int
+		for _, name := range []string{"StatusCode", "HTTPStatusCode"} {
+			if f := v.FieldByName(name); f.IsValid() && f.CanInt() {
+				status = int(f.Int())
+				break
+			}
+		}
+		if status == 0 {
+			continue
+		}
+		var retryAfter time.Duration
+		if f := v.FieldByName("Response"); f.IsValid() && f.CanInterface() {
+			if resp, ok := f.Interface().(*http.Response); ok && resp != nil {
+				retryAfter = parseRetryAfter(resp.Header, time.Now())
+			}
+		}
+		return status, retryAfter
+	}
+	return 0, 0
+}
+
+// errorChain returns err and every error it wraps, depth first.
+func errorChain(err error) []error {
+	var chain []error
+	var walk func(error)
+	walk = func(e error) {
+		for e != nil {
+			chain = append(chain, e)
+			switch u := e.(type) {
+			case interface{ Unwrap() []error }:
+				for _, inner := range u.Unwrap() {
+					walk(inner)
+				}
+				return
+			default:
+				e = errors.Unwrap(e)
+			}
+		}
+	}
+	walk(err)
+	return chain
+}
+
+// parseRetryAfter returns the delay requested by h: retry-after-ms (sent by
+// OpenAI), then Retry-After in seconds or as an HTTP date. Returns 0 if
+// absent or invalid.
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d or until ctx is done, returning ctx.Err() in the
+// latter case.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
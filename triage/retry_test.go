@@ -0,0 +1,193 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// providerError mimics the error types of the Stainless-generated SDKs.
+type providerError struct {
+	StatusCode int
+	Response   *http.Response
+}
+
+func (e *providerError) Error() string { return fmt.Sprintf("provider returned %d", e.StatusCode) }
+
+// legacyError mimics go-openai's APIError.
+type legacyError struct {
+	HTTPStatusCode int
+}
+
+func (e legacyError) Error() string { return "legacy" }
+
+// recordSleeps replaces the retry wait with one that records the delays.
+func recordSleeps(delays *[]time.Duration) RetryOption {
+	return func(rc *retryConfig) {
+		rc.sleep = func(_ context.Context, d time.Duration) error {
+			*delays = append(*delays, d)
+			return nil
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// DoWithRetry
+// ---------------------------------------------------------------------------
+
+func TestDoWithRetry_RetriesThrottlingAndRecordsEvents(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	wf, ctx := StartWorkflow(context.Background(), "chat")
+
+	calls := 0
+	var delays []time.Duration
+	got, err := DoWithRetry(ctx, func(ctx context.Context) (string, error) {
+		calls++
+		switch calls {
+		case 1:
+			return "", &providerError{StatusCode: 429}
+		case 2:
+			return "", fmt.Errorf("call: %w", &providerError{StatusCode: 503})
+		}
+		return "ok", nil
+	}, RetryBackoff(100*time.Millisecond, time.Second), recordSleeps(&delays))
+	wf.End()
+
+	if err != nil || got != "ok" || calls != 3 {
+		t.Fatalf("got %q, %v after %d calls", got, err, calls)
+	}
+	if len(delays) != 2 || delays[0] < 50*time.Millisecond || delays[0] > 100*time.Millisecond ||
+		delays[1] < 100*time.Millisecond || delays[1] > 200*time.Millisecond {
+		t.Errorf("expected exponential backoff with jitter, got %v", delays)
+	}
+
+	events := exporter.GetSpans()[0].Events
+	if len(events) != 2 {
+		t.Fatalf("expected 2 retry events, got %d", len(events))
+	}
+	for i, want := range []int64{429, 503} {
+		attrs := attrMap(events[i].Attributes)
+		if events[i].Name != retryEventName || attrs[AttrRetryAttempt] != int64(i+1) || attrs[AttrRetryStatusCode] != want {
+			t.Errorf("event %d: got %s %v", i, events[i].Name, attrs)
+		}
+		if attrs[AttrRetryAfterHonored] != false {
+			t.Errorf("event %d: no Retry-After was sent", i)
+		}
+	}
+}
+
+func TestDoWithRetry_HonorsRetryAfter(t *testing.T) {
+	var delays []time.Duration
+	calls := 0
+	_, err := DoWithRetry(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			resp := &http.Response{Header: http.Header{"Retry-After": {"2"}}}
+			return 0, &providerError{StatusCode: 429, Response: resp}
+		}
+		return 1, nil
+	}, recordSleeps(&delays))
+
+	if err != nil || len(delays) != 1 || delays[0] != 2*time.Second {
+		t.Errorf("expected a 2s wait, got %v (err %v)", delays, err)
+	}
+}
+
+func TestDoWithRetry_RetryAfterCappedAtMaxDelay(t *testing.T) {
+	var delays []time.Duration
+	calls := 0
+	DoWithRetry(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			resp := &http.Response{Header: http.Header{"Retry-After": {"3600"}}}
+			return 0, &providerError{StatusCode: 429, Response: resp}
+		}
+		return 1, nil
+	}, RetryBackoff(time.Millisecond, 5*time.Second), recordSleeps(&delays))
+
+	if len(delays) != 1 || delays[0] != 5*time.Second {
+		t.Errorf("expected the wait capped at 5s, got %v", delays)
+	}
+}
+
+func TestDoWithRetry_DoesNotRetryClientErrors(t *testing.T) {
+	calls := 0
+	wantErr := legacyError{HTTPStatusCode: 400}
+	_, err := DoWithRetry(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		return 0, wantErr
+	})
+	if calls != 1 || !errors.Is(err, wantErr) {
+		t.Errorf("expected a single call returning the error, got %d calls, %v", calls, err)
+	}
+}
+
+func TestDoWithRetry_GivesUpAfterAttempts(t *testing.T) {
+	var delays []time.Duration
+	calls := 0
+	_, err := DoWithRetry(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		return 0, legacyError{HTTPStatusCode: 500}
+	}, RetryAttempts(3), recordSleeps(&delays))
+
+	var le legacyError
+	if calls != 3 || len(delays) != 2 || !errors.As(err, &le) {
+		t.Errorf("expected 3 calls and the last error, got %d calls, %v", calls, err)
+	}
+}
+
+func TestDoWithRetry_StopsWhenContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := DoWithRetry(ctx, func(ctx context.Context) (int, error) {
+		calls++
+		cancel()
+		return 0, &providerError{StatusCode: 503}
+	})
+	if calls != 1 || err == nil {
+		t.Errorf("expected one call and its error, got %d calls, %v", calls, err)
+	}
+}
+
+func TestDoWithRetry_CustomStatus(t *testing.T) {
+	errBusy := errors.New("busy")
+	var delays []time.Duration
+	calls := 0
+	_, err := DoWithRetry(context.Background(), func(ctx context.Context) (int, error) {
+		calls++
+		if calls == 1 {
+			return 0, errBusy
+		}
+		return 1, nil
+	}, RetryStatus(func(err error) (int, time.Duration) {
+		if errors.Is(err, errBusy) {
+			return 503, 10 * time.Millisecond
+		}
+		return 0, 0
+	}), recordSleeps(&delays))
+
+	if err != nil || calls != 2 || delays[0] != 10*time.Millisecond {
+		t.Errorf("got %d calls, delays %v, err %v", calls, delays, err)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		header http.Header
+		want   time.Duration
+	}{
+		{http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{http.Header{"Retry-After-Ms": {"250"}, "Retry-After": {"3"}}, 250 * time.Millisecond},
+		{http.Header{"Retry-After": {now.Add(time.Minute).Format(http.TimeFormat)}}, time.Minute},
+		{http.Header{"Retry-After": {"soon"}}, 0},
+		{http.Header{}, 0},
+	} {
+		if got := parseRetryAfter(tc.header, now); got != tc.want {
+			t.Errorf("parseRetryAfter(%v): got %v, want %v", tc.header, got, tc.want)
+		}
+	}
+}
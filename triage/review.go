@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reviewNotifyTimeout bounds the background backend call made by
+// FlagForReview when NotifyBackend is set.
+const reviewNotifyTimeout = 10 * time.Second
+
+// ReviewOption configures optional behavior for FlagForReview.
+type ReviewOption func(*reviewConfig)
+
+type reviewConfig struct {
+	notify bool
+}
+
+// NotifyBackend makes FlagForReview also enqueue the trace in the backend's
+// human review queue immediately, instead of waiting for the flagged span to
+// be exported and ingested. Requires Init; ignored otherwise.
+func NotifyBackend() ReviewOption {
+	return func(rc *reviewConfig) { rc.notify = true }
+}
+
+// FlagForReview marks the current span as requiring human review, setting
+// triage.review.required and triage.review.reason, so suspicious
+// conversations are routed to a review queue directly from application code:
+//
+//	if detector.Suspicious(input) {
+//	    triage.FlagForReview(ctx, "possible jailbreak", triage.NotifyBackend())
+//	}
+//
+// No-op if ctx carries no recording span. The backend notification runs in
+// the background and never blocks the caller, though Shutdown waits for it;
+// failures are logged.
+func FlagForReview(ctx context.Context, reason string, opts ...ReviewOption) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	var rc reviewConfig
+	for _, o := range opts {
+		o(&rc)
+	}
+
+	span.SetAttributes(
+		attribute.Bool(AttrReviewRequired, true),
+		attribute.String(AttrReviewReason, reason),
+	)
+
+	client := apiClient
+	if !rc.notify || client == nil {
+		return
+	}
+	sc := span.SpanContext()
+	backgroundStarted()
+	go func() {
+		defer backgroundDone()
+		defer recoverPanic("review notification")
+		ctx, cancel := context.WithTimeout(context.Background(), reviewNotifyTimeout)
+		defer cancel()
+		if err := client.RequestReview(ctx, sc.TraceID().String(), sc.SpanID().String(), reason); err != nil {
+			client.logger.Warn("triage: review queue notification failed", "error", err)
+		}
+	}()
+}
+
+// reviewRequest is the wire format for POST /v1/reviews.
+type reviewRequest struct {
+	TraceID string `json:"trace_id"`
+	SpanID  string `json:"span_id,omitempty"`
+	Reason  string `json:"reason"`
+}
+
+// RequestReview enqueues a trace (and optionally a specific span) in the
+// backend's human review queue.
+func (c *Client) RequestReview(ctx context.Context, traceID, spanID, reason string) error {
+	if traceID == "" {
+		return errors.New("triage: RequestReview requires a trace ID")
+	}
+	return c.do(ctx, http.MethodPost, reviewsPath, reviewRequest{
+		TraceID: traceID,
+		SpanID:  spanID,
+		Reason:  reason,
+	}, nil)
+}
@@ -0,0 +1,86 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// FlagForReview
+// ---------------------------------------------------------------------------
+
+func TestFlagForReview_SetsAttributes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "chat")
+	FlagForReview(ctx, "possible jailbreak")
+	wf.End()
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrReviewRequired] != true {
+		t.Errorf("review.required: got %v, want true", attrs[AttrReviewRequired])
+	}
+	if attrs[AttrReviewReason] != "possible jailbreak" {
+		t.Errorf("review.reason: got %v, want %q", attrs[AttrReviewReason], "possible jailbreak")
+	}
+}
+
+func TestFlagForReview_NoSpanIsNoop(t *testing.T) {
+	// Should not panic.
+	FlagForReview(context.Background(), "reason", NotifyBackend())
+}
+
+func TestFlagForReview_NotifyBackend(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	got := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got <- r.URL.Path
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+	apiClient = newClientFromConfig(&config{apiKey: "k", endpoint: srv.URL})
+	t.Cleanup(func() { apiClient = nil })
+
+	wf, ctx := StartWorkflow(context.Background(), "chat")
+	FlagForReview(ctx, "possible jailbreak", NotifyBackend())
+	wf.End()
+	waitBackground(context.Background())
+
+	select {
+	case path := <-got:
+		if path != reviewsPath {
+			t.Errorf("path: got %q, want %q", path, reviewsPath)
+		}
+	default:
+		t.Fatal("backend should be notified before waitBackground returns")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Client.RequestReview
+// ---------------------------------------------------------------------------
+
+func TestRequestReview_SendsRequest(t *testing.T) {
+	client, req, body := newTestBackend(t, http.StatusAccepted, nil)
+
+	if err := client.RequestReview(context.Background(), "trace123", "", "pii leak"); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.Path != reviewsPath {
+		t.Errorf("path: got %q, want %q", req.URL.Path, reviewsPath)
+	}
+	if (*body)["reason"] != "pii leak" || (*body)["trace_id"] != "trace123" {
+		t.Errorf("body: got %v", *body)
+	}
+}
+
+func TestRequestReview_RequiresTraceID(t *testing.T) {
+	client, _, _ := newTestBackend(t, http.StatusAccepted, nil)
+
+	if err := client.RequestReview(context.Background(), "", "", "x"); err == nil {
+		t.Error("expected error for empty trace ID")
+	}
+}
@@ -0,0 +1,182 @@
+package triage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RiskEvent describes a session's accumulated risk crossing the threshold set
+// with WithRiskThreshold.
+type RiskEvent struct {
+	SessionID string
+	Source    string  // detector whose score crossed the threshold
+	Score     float64 // that detector's contribution
+	Total     float64 // the session's accumulated risk
+	Threshold float64
+	TraceID   string
+	SpanID    string
+}
+
+// RiskHandler is called synchronously from AddRiskScore, at most once per
+// session, when the session's accumulated risk first reaches the threshold.
+// It should return quickly.
+type RiskHandler func(ctx context.Context, e RiskEvent)
+
+// WithRiskThreshold registers a callback invoked when a session's
+// accumulated risk (see AddRiskScore) first reaches threshold, enabling
+// progressive responses such as forcing re-authentication mid-conversation.
+func WithRiskThreshold(threshold float64, h RiskHandler) Option {
+	return func(c *config) {
+		c.riskThreshold = threshold
+		c.riskHandler = h
+	}
+}
+
+// riskTracker accumulates risk scores per session ID in memory, bounded like
+// SessionManager.
+type riskTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionRisk
+	now      func() time.Time
+}
+
+// sessionRisk is the accumulated risk of one session.
+type sessionRisk struct {
+	total    float64
+	tripped  bool // threshold handler already called
+	lastSeen time.Time
+}
+
+func newRiskTracker() *riskTracker {
+	return &riskTracker{sessions: make(map[string]*sessionRisk), now: time.Now}
+}
+
+// add adds score to the session's total and reports the new total and
+// whether this contribution crossed threshold (<= 0 disables it).
+func (rt *riskTracker) add(sessionID string, score, threshold float64) (total float64, crossed bool) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	now := rt.now()
+	sr, ok := rt.sessions[sessionID]
+	if !ok {
+		if len(rt.sessions) >= sessionMaxSessions {
+			rt.evictLocked(now)
+		}
+		sr = &sessionRisk{}
+		rt.sessions[sessionID] = sr
+	}
+	sr.total += score
+	sr.lastSeen = now
+	if threshold > 0 && !sr.tripped && sr.total >= threshold {
+		sr.tripped = true
+		crossed = true
+	}
+	return sr.total, crossed
+}
+
+// total returns the session's accumulated risk, or 0 if unknown.
+func (rt *riskTracker) total(sessionID string) float64 {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if sr, ok := rt.sessions[sessionID]; ok {
+		return sr.total
+	}
+	return 0
+}
+
+// evictLocked drops idle sessions, and if none were idle, the least recently
+// active one. rt.mu must be held.
+func (rt *riskTracker) evictLocked(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	evicted := false
+	for id, sr := range rt.sessions {
+		if now.Sub(sr.lastSeen) > sessionIdleTTL {
+			delete(rt.sessions, id)
+			evicted = true
+			continue
+		}
+		if oldestID == "" || sr.lastSeen.Before(oldest) {
+			oldestID, oldest = id, sr.lastSeen
+		}
+	}
+	if !evicted && oldestID != "" {
+		delete(rt.sessions, oldestID)
+	}
+}
+
+// AddRiskScore lets a detector or guardrail contribute a risk score to the
+// session in ctx (see WithSession) and returns the session's accumulated
+// risk. The contribution is recorded on the current span as a "triage.risk"
+// event, and every span subsequently started in the session carries the
+// running total as triage.session.risk_score:
+//
+//	if total := triage.AddRiskScore(ctx, "prompt-injection", 0.4); total > 1 {
+//		return errReauthRequired
+//	}
+//
+// Without a session in ctx (or before Init) the score is only recorded on
+// the current span and returned as-is.
+func AddRiskScore(ctx context.Context, source string, score float64) float64 {
+	total := score
+	sid := getFromContext(ctx).sessionID
+	rt, cfg := risks, globalCfg
+	crossed := false
+	if rt != nil && cfg != nil && sid != "" {
+		total, crossed = rt.add(sid, score, cfg.riskThreshold)
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if span.IsRecording() {
+		span.AddEvent(riskEventName, trace.WithAttributes(
+			attribute.String(AttrRiskSource, source),
+			attribute.Float64(AttrRiskScore, score),
+			attribute.Float64(AttrSessionRiskScore, total),
+		))
+		span.SetAttributes(attribute.Float64(AttrSessionRiskScore, total))
+	}
+
+	if crossed && cfg.riskHandler != nil {
+		sc := span.SpanContext()
+		e := RiskEvent{
+			SessionID: sid,
+			Source:    source,
+			Score:     score,
+			Total:     total,
+			Threshold: cfg.riskThreshold,
+		}
+		if sc.IsValid() {
+			e.TraceID, e.SpanID = sc.TraceID().String(), sc.SpanID().String()
+		}
+		guard("risk handler", func() { cfg.riskHandler(ctx, e) })
+	}
+	return total
+}
+
+// SessionRiskScore returns the accumulated risk of a session, or 0 if the
+// session has no recorded risk (or the SDK is not initialized).
+func SessionRiskScore(sessionID string) float64 {
+	if rt := risks; rt != nil {
+		return rt.total(sessionID)
+	}
+	return 0
+}
+
+// stampSessionRisk records the session's accumulated risk on a starting span.
+func stampSessionRisk(ctx context.Context, span trace.Span) {
+	rt := risks
+	if rt == nil {
+		return
+	}
+	sid := getFromContext(ctx).sessionID
+	if sid == "" {
+		return
+	}
+	if total := rt.total(sid); total != 0 {
+		span.SetAttributes(attribute.Float64(AttrSessionRiskScore, total))
+	}
+}
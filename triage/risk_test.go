@@ -0,0 +1,92 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+func newTestRiskTracker(t *testing.T) {
+	t.Helper()
+	risks = newRiskTracker()
+	t.Cleanup(func() { risks = nil })
+}
+
+func TestAddRiskScore_AccumulatesPerSession(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{}
+	newTestRiskTracker(t)
+
+	ctx := WithSession(context.Background(), "s1")
+	other := WithSession(context.Background(), "s2")
+	AddRiskScore(ctx, "pii-detector", 0.25)
+	if got := AddRiskScore(ctx, "prompt-injection", 0.5); got != 0.75 {
+		t.Errorf("total: got %v, want 0.75", got)
+	}
+	AddRiskScore(other, "pii-detector", 0.1)
+	if got := SessionRiskScore("s1"); got != 0.75 {
+		t.Errorf("SessionRiskScore: got %v, want 0.75", got)
+	}
+
+	wf, _ := StartWorkflow(ctx, "next-turn")
+	wf.End()
+	if got := attrMap(exporter.GetSpans()[0].Attributes)[AttrSessionRiskScore]; got != 0.75 {
+		t.Errorf("subsequent span risk: got %v, want 0.75", got)
+	}
+}
+
+func TestAddRiskScore_RecordsEventOnCurrentSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{}
+	newTestRiskTracker(t)
+
+	wf, ctx := StartWorkflow(WithSession(context.Background(), "s1"), "turn")
+	AddRiskScore(ctx, "jailbreak-classifier", 0.6)
+	wf.End()
+
+	span := exporter.GetSpans()[0]
+	if len(span.Events) != 1 || span.Events[0].Name != riskEventName {
+		t.Fatalf("expected a %s event, got %+v", riskEventName, span.Events)
+	}
+	ev := attrMap(span.Events[0].Attributes)
+	if ev[AttrRiskSource] != "jailbreak-classifier" || ev[AttrRiskScore] != 0.6 {
+		t.Errorf("event attrs: got %v", ev)
+	}
+	if attrMap(span.Attributes)[AttrSessionRiskScore] != 0.6 {
+		t.Errorf("span risk: got %v", attrMap(span.Attributes)[AttrSessionRiskScore])
+	}
+}
+
+func TestAddRiskScore_ThresholdHandlerCalledOnce(t *testing.T) {
+	newGlobalTestProvider(t)
+	var events []RiskEvent
+	globalCfg = &config{riskThreshold: 1, riskHandler: func(_ context.Context, e RiskEvent) {
+		events = append(events, e)
+	}}
+	newTestRiskTracker(t)
+
+	ctx := WithSession(context.Background(), "s1")
+	AddRiskScore(ctx, "a", 0.5)
+	AddRiskScore(ctx, "b", 0.6)
+	AddRiskScore(ctx, "c", 0.3)
+
+	if len(events) != 1 {
+		t.Fatalf("expected one threshold event, got %d", len(events))
+	}
+	e := events[0]
+	if e.SessionID != "s1" || e.Source != "b" || e.Total != 1.1 || e.Threshold != 1 {
+		t.Errorf("event: got %+v", e)
+	}
+}
+
+func TestAddRiskScore_WithoutSession(t *testing.T) {
+	newGlobalTestProvider(t)
+	globalCfg = &config{}
+	newTestRiskTracker(t)
+
+	if got := AddRiskScore(context.Background(), "a", 0.4); got != 0.4 {
+		t.Errorf("got %v, want the score itself", got)
+	}
+	if got := SessionRiskScore(""); got != 0 {
+		t.Errorf("no session should be tracked, got %v", got)
+	}
+}
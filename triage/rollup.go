@@ -0,0 +1,83 @@
+package triage
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// usageRollup accumulates the token usage and estimated cost of every LLM
+// call made beneath a workflow. Nested workflows keep their own rollup and
+// forward each call to the enclosing one, so every workflow reports the
+// totals of its whole subtree.
+type usageRollup struct {
+	parent *usageRollup
+
+	mu       sync.Mutex
+	calls    int
+	usage    Usage
+	cost     float64
+	unpriced int // calls whose model had no known price
+}
+
+// usageRollupKey is an unexported context key for the innermost workflow's
+// usage rollup.
+type usageRollupKey struct{}
+
+// withUsageRollup returns ctx carrying a new rollup chained to the one
+// already in ctx, if any.
+func withUsageRollup(ctx context.Context) (context.Context, *usageRollup) {
+	parent, _ := ctx.Value(usageRollupKey{}).(*usageRollup)
+	r := &usageRollup{parent: parent}
+	return context.WithValue(ctx, usageRollupKey{}, r), r
+}
+
+// usageRollupFrom returns the innermost workflow rollup in ctx, or nil.
+func usageRollupFrom(ctx context.Context) *usageRollup {
+	r, _ := ctx.Value(usageRollupKey{}).(*usageRollup)
+	return r
+}
+
+// add records one LLM call on r and all enclosing rollups. Safe to call on a
+// nil usageRollup (no-op).
+func (r *usageRollup) add(usage Usage, cost Cost, priced bool) {
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		r.calls++
+		r.usage = addUsage(r.usage, usage)
+		if priced {
+			r.cost += cost.Total
+		} else {
+			r.unpriced++
+		}
+		r.mu.Unlock()
+	}
+}
+
+// report records the accumulated totals on span, the workflow span, just
+// before it ends. Nothing is recorded if no LLM call completed beneath it.
+// Safe to call on a nil usageRollup (no-op).
+func (r *usageRollup) report(span trace.Span) {
+	if r == nil || span == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.calls == 0 {
+		return
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrWorkflowLLMCalls, r.calls),
+		attribute.Int(AttrWorkflowInputTokens, r.usage.PromptTokens),
+		attribute.Int(AttrWorkflowOutputTokens, r.usage.CompletionTokens),
+		attribute.Int(AttrWorkflowTotalTokens, r.usage.TotalTokens),
+	}
+	// A partial sum would understate the workflow's cost; only report it
+	// when every call was priced.
+	if r.unpriced == 0 {
+		attrs = append(attrs, attribute.Float64(AttrWorkflowCost, r.cost))
+	}
+	span.SetAttributes(attrs...)
+}
@@ -0,0 +1,98 @@
+package triage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func spanNamed(t *testing.T, exporter *tracetest.InMemoryExporter, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range exporter.GetSpans() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("no span named %q", name)
+	return tracetest.SpanStub{}
+}
+
+func TestWorkflow_RollsUpUsageAndCost(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "pipeline")
+	agent, agentCtx := StartAgent(ctx, "researcher")
+	for i := 0; i < 2; i++ {
+		llmSpan, _ := LogPrompt(agentCtx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500})
+	}
+	agent.End()
+	wf.End()
+
+	attrs := attrMap(spanNamed(t, exporter, "pipeline").Attributes)
+	if attrs[AttrWorkflowLLMCalls] != int64(2) {
+		t.Errorf("llm_calls: got %v", attrs[AttrWorkflowLLMCalls])
+	}
+	if attrs[AttrWorkflowInputTokens] != int64(2000) || attrs[AttrWorkflowOutputTokens] != int64(1000) {
+		t.Errorf("tokens: got %v in, %v out", attrs[AttrWorkflowInputTokens], attrs[AttrWorkflowOutputTokens])
+	}
+	if attrs[AttrWorkflowTotalTokens] != int64(3000) {
+		t.Errorf("total_tokens: got %v", attrs[AttrWorkflowTotalTokens])
+	}
+	// 2 × (1000 × 0.0025/1K + 500 × 0.01/1K) = 0.015
+	if cost, _ := attrs[AttrWorkflowCost].(float64); math.Abs(cost-0.015) > 1e-9 {
+		t.Errorf("cost: got %v, want 0.015", attrs[AttrWorkflowCost])
+	}
+}
+
+func TestWorkflow_NestedRollupsForwardToParent(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	outer, ctx := StartWorkflow(context.Background(), "outer")
+	inner, innerCtx := StartWorkflow(ctx, "inner")
+	llmSpan, _ := LogPrompt(innerCtx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15})
+	inner.End()
+	llmSpan, _ = LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30})
+	outer.End()
+
+	if got := attrMap(spanNamed(t, exporter, "inner").Attributes)[AttrWorkflowTotalTokens]; got != int64(15) {
+		t.Errorf("inner total_tokens: got %v, want 15", got)
+	}
+	if got := attrMap(spanNamed(t, exporter, "outer").Attributes)[AttrWorkflowTotalTokens]; got != int64(45) {
+		t.Errorf("outer total_tokens: got %v, want 45", got)
+	}
+}
+
+func TestWorkflow_RollupOmitsCostForUnpricedModels(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, ctx := StartWorkflow(context.Background(), "pipeline")
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10, TotalTokens: 10})
+	llmSpan, _ = LogPrompt(ctx, Prompt{Vendor: "acme", Model: "in-house-7b"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 10, TotalTokens: 10})
+	wf.End()
+
+	attrs := attrMap(spanNamed(t, exporter, "pipeline").Attributes)
+	if attrs[AttrWorkflowTotalTokens] != int64(20) {
+		t.Errorf("total_tokens: got %v", attrs[AttrWorkflowTotalTokens])
+	}
+	if _, ok := attrs[AttrWorkflowCost]; ok {
+		t.Error("cost should be omitted when a call could not be priced")
+	}
+}
+
+func TestWorkflow_NoRollupWithoutLLMCalls(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	wf, _ := StartWorkflow(context.Background(), "pipeline")
+	wf.End()
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrWorkflowLLMCalls]; ok {
+		t.Error("workflow without LLM calls should not record rollup attributes")
+	}
+}
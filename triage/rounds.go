@@ -0,0 +1,91 @@
+package triage
+
+import (
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// roundState accumulates the intermediate model rounds recorded by LogRound.
+type roundState struct {
+	mu    sync.Mutex
+	count int
+	usage Usage
+}
+
+// LogRound records one intermediate model invocation inside this LLM span,
+// for calls where a single API request hides several model rounds — e.g. a
+// provider's server-side tool-execution loop:
+//
+//	for _, step := range resp.Steps[:len(resp.Steps)-1] {
+//	    llmSpan.LogRound(stepCompletion(step), stepUsage(step))
+//	}
+//	llmSpan.LogCompletion(finalCompletion, finalUsage)
+//
+// Each round becomes a gen_ai.round span event carrying its index, finish
+// reason, tool calls and token usage (and its messages, when trace content is
+// enabled). Round usage is added to the usage passed to LogCompletion, so pass
+// LogCompletion only the final round's usage; the span then reports the
+// call's total and triage.llm.rounds. Calls after the span has ended are
+// no-ops. Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) LogRound(completion Completion, usage Usage) {
+	if ls == nil || ls.span == nil || ls.ended.Load() {
+		return
+	}
+	ls.rounds.mu.Lock()
+	index := ls.rounds.count
+	ls.rounds.count++
+	ls.rounds.usage = addUsage(ls.rounds.usage, usage)
+	ls.rounds.mu.Unlock()
+
+	hashContent := isTraceContentEnabled() &&
+		!traceBudgetFrom(ls.ctx).admitContent(messagesContentBytes(completion.Messages), ls.span)
+	var attrs []attribute.KeyValue
+	guard("LogRound", func() { attrs = roundAttributes(index, completion, usage, hashContent) })
+	ls.span.AddEvent(roundEventName, trace.WithAttributes(attrs...))
+}
+
+// total returns usage plus the usage of all recorded rounds, and the number
+// of rounds including the final one, or 0 if no rounds were recorded.
+func (r *roundState) total(usage Usage) (Usage, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.count == 0 {
+		return usage, 0
+	}
+	return addUsage(r.usage, usage), r.count + 1
+}
+
+// roundAttributes returns the attributes of one gen_ai.round event.
+func roundAttributes(index int, completion Completion, usage Usage, hashContent bool) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrRoundIndex, index),
+		attribute.Int(AttrGenAIUsageInputTokens, usage.PromptTokens),
+		attribute.Int(AttrGenAIUsageOutputTokens, usage.CompletionTokens),
+	}
+	if completion.Model != "" {
+		attrs = append(attrs, attribute.String("gen_ai.response.model", completion.Model))
+	}
+	if completion.FinishReason != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIResponseFinishReason, completion.FinishReason))
+	}
+	var tools []string
+	for _, msg := range completion.Messages {
+		for _, tc := range msg.ToolCalls {
+			tools = append(tools, tc.Function.Name)
+		}
+	}
+	if len(tools) > 0 {
+		attrs = append(attrs, attribute.StringSlice(AttrRoundToolCalls, tools))
+	}
+	if isTraceContentEnabled() {
+		for i, msg := range completion.Messages {
+			if msg.Content != "" {
+				attrs = append(attrs, attribute.String(fmt.Sprintf("gen_ai.completion.%d.content", i), capturedContent(msg.Content, hashContent)))
+			}
+		}
+	}
+	return attrs
+}
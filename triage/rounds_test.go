@@ -0,0 +1,78 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// LogRound
+// ---------------------------------------------------------------------------
+
+func TestLogRound_RecordsEventsAndTotals(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogRound(Completion{
+		FinishReason: "tool_calls",
+		Messages: []Message{{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "ws_1", Type: "function", Function: ToolCallFunction{Name: "web_search"}},
+		}}},
+	}, Usage{PromptTokens: 100, CompletionTokens: 10, TotalTokens: 110})
+	llmSpan.LogCompletion(
+		Completion{FinishReason: "stop", Messages: []Message{{Role: "assistant", Content: "Sunny."}}},
+		Usage{PromptTokens: 150, CompletionTokens: 5, TotalTokens: 155},
+	)
+
+	span := exporter.GetSpans()[0]
+	attrs := attrMap(span.Attributes)
+	if attrs[AttrLLMRounds] != int64(2) {
+		t.Errorf("rounds: got %v, want 2", attrs[AttrLLMRounds])
+	}
+	if attrs[AttrGenAIUsageInputTokens] != int64(250) || attrs[AttrGenAIUsageOutputTokens] != int64(15) {
+		t.Errorf("usage should sum rounds: got %v / %v", attrs[AttrGenAIUsageInputTokens], attrs[AttrGenAIUsageOutputTokens])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(265) {
+		t.Errorf("total tokens: got %v, want 265", attrs["llm.usage.total_tokens"])
+	}
+	if attrs[AttrGenAIResponseFinishReason] != "stop" {
+		t.Errorf("finish reason should be the final round's, got %v", attrs[AttrGenAIResponseFinishReason])
+	}
+
+	if len(span.Events) != 1 || span.Events[0].Name != roundEventName {
+		t.Fatalf("expected 1 %s event, got %+v", roundEventName, span.Events)
+	}
+	ev := attrMap(span.Events[0].Attributes)
+	if ev[AttrRoundIndex] != int64(0) || ev[AttrGenAIResponseFinishReason] != "tool_calls" {
+		t.Errorf("round event: got %v", ev)
+	}
+	if tools, _ := ev[AttrRoundToolCalls].([]string); len(tools) != 1 || tools[0] != "web_search" {
+		t.Errorf("round tool calls: got %v", ev[AttrRoundToolCalls])
+	}
+}
+
+func TestLogRound_NoRoundsLeavesSpanUnchanged(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 1, CompletionTokens: 1, TotalTokens: 2})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrLLMRounds]; ok {
+		t.Error("single-round calls should not record a round count")
+	}
+}
+
+func TestLogRound_AfterEndIsNoop(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.End()
+	llmSpan.LogRound(Completion{}, Usage{PromptTokens: 1})
+
+	if n := len(exporter.GetSpans()[0].Events); n != 0 {
+		t.Errorf("expected no events after End, got %d", n)
+	}
+
+	var nilSpan *LLMSpan
+	nilSpan.LogRound(Completion{}, Usage{}) // must not panic
+}
@@ -0,0 +1,79 @@
+package triage
+
+import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Sampling rules recorded as triage.sampling.rule and used to label the
+// triage.sampling.dropped_traces counter.
+const (
+	SamplingRuleRatio  = "ratio"  // a new trace, sampled by WithSampleRatio
+	SamplingRuleParent = "parent" // the decision of a remote parent (e.g. an upstream service)
+)
+
+// Compile-time check that triageSampler implements Sampler.
+var _ sdktrace.Sampler = (*triageSampler)(nil)
+
+// triageSampler samples new traces by ratio and follows the parent's
+// decision otherwise, like ParentBased(TraceIDRatioBased(ratio)). It also
+// records which rule decided on each local root span of a sampled trace, and
+// counts the traces each rule dropped.
+type triageSampler struct {
+	ratio float64
+	root  sdktrace.Sampler
+}
+
+// newSampler returns the sampler Init installs for ratio.
+func newSampler(ratio float64) *triageSampler {
+	return &triageSampler{ratio: ratio, root: sdktrace.TraceIDRatioBased(ratio)}
+}
+
+func (s *triageSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	psc := trace.SpanContextFromContext(p.ParentContext)
+	if psc.IsValid() && !psc.IsRemote() {
+		// A local child: the decision was made and recorded at the root.
+		return sdktrace.SamplingResult{Decision: decisionFor(psc.IsSampled()), Tracestate: psc.TraceState()}
+	}
+
+	var res sdktrace.SamplingResult
+	rule := SamplingRuleRatio
+	if psc.IsValid() {
+		rule = SamplingRuleParent
+		res = sdktrace.SamplingResult{Decision: decisionFor(psc.IsSampled()), Tracestate: psc.TraceState()}
+	} else {
+		res = s.root.ShouldSample(p)
+	}
+
+	if res.Decision == sdktrace.Drop {
+		guard("sampler", func() { countDroppedTrace(p, rule) })
+		return res
+	}
+	res.Attributes = append(res.Attributes, attribute.String(AttrSamplingRule, rule))
+	if rule == SamplingRuleRatio {
+		res.Attributes = append(res.Attributes, attribute.Float64(AttrSamplingRatio, s.ratio))
+	}
+	return res
+}
+
+func (s *triageSampler) Description() string {
+	return fmt.Sprintf("TriageSampler{ratio:%g}", s.ratio)
+}
+
+// decisionFor maps a parent's sampled flag onto a sampling decision.
+func decisionFor(sampled bool) sdktrace.SamplingDecision {
+	if sampled {
+		return sdktrace.RecordAndSample
+	}
+	return sdktrace.Drop
+}
+
+// countDroppedTrace adds a trace dropped by rule to the
+// triage.sampling.dropped_traces counter on the global meter provider.
+func countDroppedTrace(p sdktrace.SamplingParameters, rule string) {
+	sdkInstruments().droppedTraces.Add(p.ParentContext, 1, metric.WithAttributes(attribute.String(AttrSamplingRule, rule)))
+}
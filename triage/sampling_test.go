@@ -0,0 +1,122 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newSampledTracer returns a tracer sampling with the SDK's sampler at ratio,
+// and the reader for the global meter provider.
+func newSampledTracer(t *testing.T, ratio float64) (trace.Tracer, *tracetest.InMemoryExporter, *sdkmetric.ManualReader) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(newSampler(ratio)), sdktrace.WithSyncer(exporter))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	return tp.Tracer("test"), exporter, reader
+}
+
+// droppedTraces returns the dropped trace counts by rule.
+func droppedTraces(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != droppedTracesMetricName {
+				continue
+			}
+			sum, _ := m.Data.(metricdata.Sum[int64])
+			for _, dp := range sum.DataPoints {
+				rule, _ := dp.Attributes.Value(AttrSamplingRule)
+				counts[rule.AsString()] += dp.Value
+			}
+		}
+	}
+	return counts
+}
+
+// ---------------------------------------------------------------------------
+// Sampler
+// ---------------------------------------------------------------------------
+
+func TestSampler_RecordsRuleOnSampledRoot(t *testing.T) {
+	tracer, exporter, _ := newSampledTracer(t, 1)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	root.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	rootAttrs := attrMap(spans[1].Attributes)
+	if rootAttrs[AttrSamplingRule] != SamplingRuleRatio || rootAttrs[AttrSamplingRatio] != 1.0 {
+		t.Errorf("root: got rule %v, ratio %v", rootAttrs[AttrSamplingRule], rootAttrs[AttrSamplingRatio])
+	}
+	if _, ok := attrMap(spans[0].Attributes)[AttrSamplingRule]; ok {
+		t.Error("child spans should not repeat the sampling rule")
+	}
+}
+
+func TestSampler_CountsDroppedTracesByRule(t *testing.T) {
+	tracer, exporter, reader := newSampledTracer(t, 0)
+
+	for range 3 {
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, child := tracer.Start(ctx, "child")
+		child.End()
+		root.End()
+	}
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1}, SpanID: trace.SpanID{1}, Remote: true,
+	})
+	_, span := tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), remote), "from-upstream")
+	span.End()
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Fatalf("expected no spans at ratio 0, got %d", n)
+	}
+	got := droppedTraces(t, reader)
+	if got[SamplingRuleRatio] != 3 || got[SamplingRuleParent] != 1 {
+		t.Errorf("dropped traces: got %v, want 3 by ratio and 1 by parent", got)
+	}
+}
+
+func TestSampler_FollowsSampledRemoteParent(t *testing.T) {
+	tracer, exporter, _ := newSampledTracer(t, 0)
+
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{2}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled, Remote: true,
+	})
+	_, span := tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), remote), "from-upstream")
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected the remote parent's decision to be honored, got %d spans", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrSamplingRule] != SamplingRuleParent {
+		t.Errorf("rule: got %v, want %s", attrs[AttrSamplingRule], SamplingRuleParent)
+	}
+	if _, ok := attrs[AttrSamplingRatio]; ok {
+		t.Error("the ratio doesn't apply to parent decisions")
+	}
+}
@@ -2,33 +2,52 @@ package triage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+// defaultShutdownTimeout bounds the flush performed by the shutdown function
+// returned by Init unless WithShutdownTimeout is set.
+const defaultShutdownTimeout = 5 * time.Second
+
 var (
 	mu          sync.Mutex
 	initialized bool
 	provider    *sdktrace.TracerProvider
 	globalCfg   *config // stored for runtime checks (e.g. traceContent)
+	apiClient   *Client // backend API client built from the Init config
+	datasets    *datasetSink
+	transcripts *transcriptSink
+	sessions    *SessionManager          // nil unless session tracking is enabled
+	meters      *sdkmetric.MeterProvider // nil unless metrics are enabled
+	risks       *riskTracker
 )
 
 // Init initializes the Triage SDK. It configures OpenTelemetry with a
 // TriageSpanProcessor (injects triage.* context attributes) and a
 // BatchSpanProcessor backed by an OTLP/HTTP exporter pointed at the Triage
-// backend.
+// backend. With WithMetrics, it also registers a MeterProvider exporting to
+// the same backend.
 //
-// Returns a shutdown function that flushes pending spans and releases
-// resources. The caller should defer it:
+// Returns a shutdown function that flushes pending spans and metrics and
+// releases resources. The caller should defer it:
 //
 //	shutdown, err := triage.Init(triage.WithAPIKey("tsk_..."))
 //	if err != nil {
@@ -41,36 +60,44 @@ func Init(opts ...Option) (func(), error) {
 	mu.Lock()
 	defer mu.Unlock()
 
-	noop := func() {}
+	nop := func() {}
 
 	if initialized {
-		slog.Warn("triage: Init() called more than once — ignoring")
-		return noop, nil
+		sdkLogger().Warn("triage: Init() called more than once — ignoring")
+		return nop, nil
 	}
 
 	cfg, err := resolveConfig(opts...)
 	if err != nil {
-		return noop, err
+		return nop, err
 	}
 
+	log := cfg.log()
 	if !cfg.enabled {
-		slog.Info("triage: SDK disabled via config — skipping initialization")
-		return noop, nil
+		log.Info("triage: SDK disabled via config — skipping initialization")
+		discardEarlySpans()
+		return nop, nil
 	}
 
 	ctx := context.Background()
 
 	// Create OTLP/HTTP exporter pointed at the Triage backend.
-	exporterOpts := []otlptracehttp.Option{
-		otlptracehttp.WithEndpointURL(cfg.endpoint + defaultOTLPTracesPath),
-		otlptracehttp.WithHeaders(map[string]string{
-			"Authorization": "Bearer " + cfg.apiKey,
-		}),
+	var exporter sdktrace.SpanExporter
+	apiKey := cfg.apiKey
+	if cfg.collectorMode {
+		apiKey = ""
 	}
-
-	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	exporter, err = newOTLPExporter(ctx, cfg, cfg.endpoint, apiKey)
 	if err != nil {
-		return noop, fmt.Errorf("triage: failed to create OTLP exporter: %w", err)
+		return nop, err
+	}
+	// Route by tenant before renaming: the router reads triage.tenant.id.
+	exporter = withNamespace(exporter, cfg.attrNamespace)
+	if cfg.tenantResolver != nil {
+		exporter = newTenantRouter(exporter, cfg)
+	}
+	if cfg.genkit {
+		exporter = &genkitExporter{SpanExporter: exporter}
 	}
 
 	// Build the resource with SDK metadata.
@@ -78,66 +105,313 @@ func Init(opts ...Option) (func(), error) {
 		resource.Default(),
 		resource.NewWithAttributes(
 			semconv.SchemaURL,
-			attribute.String(AttrSDKName, sdkName),
-			attribute.String(AttrSDKVersion, Version),
-			attribute.String("triage.environment", cfg.environment),
-			semconv.ServiceName(cfg.appName),
+			renameAttributes(cfg.attrNamespace, []attribute.KeyValue{
+				attribute.String(AttrSDKName, sdkName),
+				attribute.String(AttrSDKVersion, Version),
+				attribute.String("triage.environment", cfg.environment),
+				semconv.ServiceName(cfg.appName),
+			})...,
 		),
 	)
 	if err != nil {
-		return noop, fmt.Errorf("triage: failed to create resource: %w", err)
+		return nop, fmt.Errorf("triage: failed to create resource: %w", err)
+	}
+
+	var batchOpts []sdktrace.BatchSpanProcessorOption
+	if cfg.exportTimeout > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithExportTimeout(cfg.exportTimeout))
+	}
+	if cfg.maxQueueSize > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithMaxQueueSize(cfg.maxQueueSize))
+	}
+	if cfg.batchTimeout > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithBatchTimeout(cfg.batchTimeout))
 	}
 
 	// Create TracerProvider with:
 	// 1. triageSpanProcessor — injects triage.* context attributes on span start
-	// 2. BatchSpanProcessor — batches and exports spans via OTLP
+	// 2. BatchSpanProcessor — batches and exports spans via OTLP, behind a
+	//    queueLimiter that counts the spans dropped when its queue is full
+	batcher := &queueLimiter{
+		SpanProcessor: sdktrace.NewBatchSpanProcessor(&countingExporter{SpanExporter: exporter}, batchOpts...),
+		capacity:      cfg.queueCapacity(),
+	}
 	tp := sdktrace.NewTracerProvider(
 		sdktrace.WithResource(res),
+		sdktrace.WithSampler(newSampler(cfg.sampleRatio)),
 		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
-		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSpanProcessor(batcher),
 	)
 
+	var mp *sdkmetric.MeterProvider
+	if cfg.metrics {
+		metricExporter, err := newOTLPMetricExporter(ctx, cfg, apiKey)
+		if err != nil {
+			_ = tp.Shutdown(ctx)
+			return nop, err
+		}
+		mp = sdkmetric.NewMeterProvider(
+			sdkmetric.WithResource(res),
+			sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		)
+		otel.SetMeterProvider(mp)
+	}
+
 	// Register as the global TracerProvider so any OTel-instrumented library
 	// (HTTP middleware, gRPC interceptors, LLM wrappers) picks it up.
 	otel.SetTracerProvider(tp)
+	if cfg.propagator != nil {
+		otel.SetTextMapPropagator(cfg.propagator)
+	}
+	if cfg.errorHandler != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(cfg.errorHandler))
+	} else if cfg.logger != nil {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+			log.Warn("triage: OpenTelemetry error", "error", err)
+		}))
+	}
 
 	provider = tp
+	meters = mp
 	globalCfg = cfg
+	apiClient = newClientFromConfig(cfg)
+	datasets = newDatasetSink(apiClient)
+	if cfg.sessionTracking || cfg.transcriptWebhook != "" {
+		sessions = newSessionManager()
+	}
+	if cfg.transcriptWebhook != "" {
+		transcripts = newTranscriptSink(cfg)
+		sessions.onEnd = transcripts.enqueue
+	}
+	risks = newRiskTracker()
 	initialized = true
 
-	slog.Info("triage: SDK initialized",
+	if flushed, dropped := flushEarlySpans(batcher, res); flushed+dropped > 0 {
+		log.Info("triage: exporting spans recorded before Init",
+			"spans_buffered", flushed,
+			"spans_dropped", dropped,
+		)
+	}
+
+	log.Info("triage: SDK initialized",
 		"app", cfg.appName,
 		"env", cfg.environment,
 		"endpoint", cfg.endpoint,
+		"metrics", cfg.metrics,
 	)
 
+	timeout := cfg.shutdownTimeout
 	shutdown := func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
 		defer cancel()
-		if err := Shutdown(shutdownCtx); err != nil {
-			slog.Error("triage: shutdown error", "error", err)
+		fs, err := ShutdownWithStats(shutdownCtx)
+		attrs := []any{"spans_flushed", fs.SpansFlushed, "spans_dropped", fs.SpansDropped, "duration", fs.Duration}
+		switch {
+		case err != nil:
+			log.Error("triage: shutdown error", append(attrs, "error", err)...)
+		case fs.SpansDropped > 0:
+			log.Warn("triage: SDK shut down with dropped spans", attrs...)
+		default:
+			log.Info("triage: SDK shut down", attrs...)
 		}
 	}
 
 	return shutdown, nil
 }
 
-// Shutdown flushes pending spans and releases resources. Pass a context with
+// sdkLogger returns the logger configured via WithLogger, or slog.Default().
+func sdkLogger() *slog.Logger {
+	if globalCfg == nil {
+		return slog.Default()
+	}
+	return globalCfg.log()
+}
+
+// log returns the configured logger, or slog.Default().
+func (c *config) log() *slog.Logger {
+	if c.logger == nil {
+		return slog.Default()
+	}
+	return c.logger
+}
+
+// newOTLPExporter creates an OTLP/HTTP exporter that sends spans to endpoint
+// authenticated with apiKey, or unauthenticated if apiKey is empty.
+func newOTLPExporter(ctx context.Context, cfg *config, endpoint, apiKey string) (sdktrace.SpanExporter, error) {
+	exporterOpts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpointURL(endpoint + cfg.otlpPath),
+	}
+	if apiKey != "" {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithHeaders(map[string]string{
+			"Authorization": "Bearer " + apiKey,
+		}))
+	}
+	if cfg.exportTimeout > 0 {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithTimeout(cfg.exportTimeout))
+	}
+	if cfg.insecure {
+		exporterOpts = append(exporterOpts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExporterInit, err)
+	}
+	return exporter, nil
+}
+
+// newOTLPMetricExporter creates an OTLP/HTTP metric exporter that sends to
+// the Triage endpoint, next to the trace path, authenticated like
+// newOTLPExporter.
+func newOTLPMetricExporter(ctx context.Context, cfg *config, apiKey string) (sdkmetric.Exporter, error) {
+	exporterOpts := []otlpmetrichttp.Option{
+		otlpmetrichttp.WithEndpointURL(cfg.endpoint + metricsPath(cfg.otlpPath)),
+	}
+	if apiKey != "" {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithHeaders(map[string]string{
+			"Authorization": "Bearer " + apiKey,
+		}))
+	}
+	if cfg.exportTimeout > 0 {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithTimeout(cfg.exportTimeout))
+	}
+	if cfg.insecure {
+		exporterOpts = append(exporterOpts, otlpmetrichttp.WithInsecure())
+	}
+
+	exporter, err := otlpmetrichttp.New(ctx, exporterOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrExporterInit, err)
+	}
+	return exporter, nil
+}
+
+// metricsPath returns the metric export path for the trace export path
+// tracesPath: a path ending in "/v1/traces" (e.g. "/otlp/v1/traces" behind a
+// collector) keeps its prefix, anything else exports to "/v1/metrics".
+func metricsPath(tracesPath string) string {
+	if prefix, ok := strings.CutSuffix(tracesPath, defaultOTLPTracesPath); ok {
+		return prefix + otlpMetricsPath
+	}
+	return otlpMetricsPath
+}
+
+// FlushStats reports what happened to spans pending at shutdown.
+type FlushStats struct {
+	SpansFlushed int64         `json:"spans_flushed"` // exported during shutdown
+	SpansDropped int64         `json:"spans_dropped"` // failed to export or still queued at the deadline
+	Duration     time.Duration `json:"duration_ns"`   // time spent flushing
+}
+
+// Shutdown flushes pending spans (and metrics, with WithMetrics) and releases
+// resources. Pass a context with
 // a deadline to control how long the flush waits.
 //
 // Safe to call multiple times — subsequent calls after the first are no-ops.
 // This is also available as the function returned by Init() for use with defer.
 func Shutdown(ctx context.Context) error {
+	_, err := ShutdownWithStats(ctx)
+	return err
+}
+
+// ShutdownWithStats is Shutdown, additionally reporting how many spans were
+// flushed and dropped, so graceful termination (e.g. in a Kubernetes preStop
+// hook) can be verified.
+//
+// If ctx is already cancelled or past its deadline, pending spans are still
+// flushed, bounded by the shutdown timeout (see WithShutdownTimeout), so a
+// request-scoped context that timed out doesn't lose the request's spans.
+// Goroutines started with Go are first given until ctx is done (at most the
+// shutdown timeout) to finish.
+func ShutdownWithStats(ctx context.Context) (FlushStats, error) {
+	if ctx.Err() == nil {
+		waitBackground(ctx)
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
 
 	if !initialized || provider == nil {
-		return nil
+		return FlushStats{}, nil
+	}
+	if ctx.Err() != nil {
+		timeout := globalCfg.shutdownTimeout
+		if timeout <= 0 {
+			timeout = defaultShutdownTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
 	}
+	start := time.Now()
+	exported, dropped := stats.exported.Load(), stats.dropped.Load()
 
-	err := provider.Shutdown(ctx)
+	var errs []error
+	if datasets != nil {
+		errs = append(errs, datasets.shutdown(ctx))
+	}
+	if transcripts != nil {
+		for _, t := range sessions.endAll() {
+			transcripts.enqueueWait(ctx, t)
+		}
+		errs = append(errs, transcripts.shutdown(ctx))
+	}
+	errs = append(errs, provider.Shutdown(ctx))
+	if meters != nil {
+		errs = append(errs, meters.Shutdown(ctx))
+	}
+	stats.settle()
+	fs := FlushStats{
+		SpansFlushed: stats.exported.Load() - exported,
+		SpansDropped: stats.dropped.Load() - dropped,
+		Duration:     time.Since(start),
+	}
+	stats.recordShutdown(fs)
 	initialized = false
 	provider = nil
+	meters = nil
 	globalCfg = nil
-	return err
+	apiClient = nil
+	datasets = nil
+	transcripts = nil
+	sessions = nil
+	risks = nil
+	queueHigh.Store(false)
+	return fs, errors.Join(errs...)
+}
+
+// TracerProvider returns the tracer provider created by Init, or a no-op
+// provider if the SDK is not initialized or disabled. Libraries can use it to
+// create custom spans that flow through the Triage pipeline (span processor,
+// exporter, sampling) without relying on the global OpenTelemetry provider
+// being the one Init registered.
+func TracerProvider() trace.TracerProvider {
+	mu.Lock()
+	defer mu.Unlock()
+	if provider == nil {
+		return noop.NewTracerProvider()
+	}
+	return provider
+}
+
+// MeterProvider returns the meter provider created by Init with WithMetrics,
+// or a no-op provider if metrics are not enabled or the SDK is not
+// initialized. Like TracerProvider, it keeps working if another library
+// replaces the global meter provider.
+func MeterProvider() metric.MeterProvider {
+	mu.Lock()
+	defer mu.Unlock()
+	if meters == nil {
+		return metricnoop.NewMeterProvider()
+	}
+	return meters
+}
+
+// Tracer returns a tracer with the given instrumentation name from
+// TracerProvider:
+//
+//	ctx, span := triage.Tracer("github.com/acme/rag").Start(ctx, "rerank")
+//	defer span.End()
+func Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return TracerProvider().Tracer(name, opts...)
 }
@@ -0,0 +1,57 @@
+package triage
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ClientMiddleware is the request middleware signature of the official
+// vendor SDKs generated by Stainless (openai-go, anthropic-sdk-go). It is
+// identical to their option.Middleware, so values are passed to
+// option.WithMiddleware directly.
+type ClientMiddleware = func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error)
+
+// OpenAIMiddleware returns middleware for the official openai-go SDK that
+// records every Chat Completions and Responses API call as an LLM span —
+// messages, tools, usage and streamed responses — without logging calls in
+// application code:
+//
+//	client := openai.NewClient(option.WithMiddleware(triage.OpenAIMiddleware()))
+//
+// The span is a child of the request's context, so pass ctx carrying the
+// triage context to the SDK call. Other requests pass through untouched.
+func OpenAIMiddleware() ClientMiddleware {
+	return func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		var api *llmAPI
+		switch path := strings.TrimSuffix(req.URL.Path, "/"); {
+		case strings.HasSuffix(path, "/chat/completions"):
+			api = openAIChatAPI
+		case strings.HasSuffix(path, "/responses"):
+			api = openAIResponsesAPI
+		}
+		if req.Method != http.MethodPost || req.Body == nil || api == nil {
+			return next(req)
+		}
+		return roundTripLLM(req, api, next, "triage openai middleware")
+	}
+}
+
+// AnthropicMiddleware returns middleware for the official anthropic-sdk-go
+// SDK that records every Messages call as an LLM span, streamed or not:
+//
+//	client := anthropic.NewClient(option.WithMiddleware(triage.AnthropicMiddleware()))
+//
+// Anthropic-specific fields map onto the usual attributes: the system prompt
+// becomes a leading system message, cache_control markers become cache
+// breakpoints, thinking blocks are recorded as completion reasoning, and
+// stop_reason is recorded as the finish reason (with a normalized
+// triage.response.finish_category). Other requests pass through untouched.
+func AnthropicMiddleware() ClientMiddleware {
+	return func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+		if req.Method != http.MethodPost || req.Body == nil ||
+			!strings.HasSuffix(strings.TrimSuffix(req.URL.Path, "/"), "/v1/messages") {
+			return next(req)
+		}
+		return roundTripLLM(req, anthropicMessagesAPI, next, "triage anthropic middleware")
+	}
+}
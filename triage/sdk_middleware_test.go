@@ -0,0 +1,135 @@
+package triage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// callMiddleware sends a request through mw to an httptest server running
+// upstream, the way the vendor SDK's request pipeline would, and returns the
+// fully read response body.
+func callMiddleware(t *testing.T, mw ClientMiddleware, upstream http.HandlerFunc, method, path, body string) string {
+	t.Helper()
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequestWithContext(WithUser(context.Background(), "user_1"), method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := mw(req, http.DefaultTransport.RoundTrip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestOpenAIMiddleware_RecordsChatCompletion(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	const respBody = `{"model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`
+
+	got := callMiddleware(t, OpenAIMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != gatewayRequest {
+			t.Errorf("upstream got body %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, respBody)
+	}, http.MethodPost, "/v1/chat/completions", gatewayRequest)
+	if got != respBody {
+		t.Errorf("SDK got %q", got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hello!" || attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("completion/usage: got %v/%v", attrs["gen_ai.completion.0.content"], attrs["llm.usage.total_tokens"])
+	}
+	if attrs[AttrUserID] != "user_1" {
+		t.Errorf("triage context: got %v", attrs[AttrUserID])
+	}
+}
+
+func TestOpenAIMiddleware_RecordsStream(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	callMiddleware(t, OpenAIMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}, http.MethodPost, "/v1/chat/completions", gatewayRequest)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := attrMap(spans[0].Attributes)["gen_ai.completion.0.content"]; got != "Hi" {
+		t.Errorf("content: got %v", got)
+	}
+}
+
+func TestOpenAIMiddleware_PassesThroughOtherRequests(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	callMiddleware(t, OpenAIMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[]}`)
+	}, http.MethodPost, "/v1/embeddings", `{"model":"text-embedding-3-small","input":"hi"}`)
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans, got %d", n)
+	}
+}
+
+func TestAnthropicMiddleware_RecordsMessages(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	const reqBody = `{"model":"claude-sonnet-4","max_tokens":2048,"system":[{"type":"text","text":"Be brief.","cache_control":{"type":"ephemeral","ttl":"1h"}}],"messages":[{"role":"user","content":"Hi"}]}`
+
+	callMiddleware(t, AnthropicMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"model":"claude-sonnet-4","content":[{"type":"thinking","thinking":"Greet back.","signature":"sig"},{"type":"text","text":"Hello!"}],"stop_reason":"end_turn","usage":{"input_tokens":4,"output_tokens":6}}`)
+	}, http.MethodPost, "/v1/messages", reqBody)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	want := map[string]any{
+		"gen_ai.system":                                    "anthropic",
+		"gen_ai.prompt.0.role":                             "system",
+		AttrPromptCacheBreakpoints:                         int64(1),
+		AttrPromptCacheBreakpointPrefix + ".0.ttl_seconds": int64(3600),
+		"gen_ai.completion.0.content":                      "Hello!",
+		"gen_ai.completion.0.reasoning":                    "Greet back.",
+		AttrGenAIResponseFinishReason:                      "end_turn",
+		AttrResponseFinishCategory:                         "stop",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], v)
+		}
+	}
+}
+
+func TestAnthropicMiddleware_PassesThroughOtherRequests(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	callMiddleware(t, AnthropicMiddleware(), func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"input_tokens":3}`)
+	}, http.MethodPost, "/v1/messages/count_tokens", `{"model":"claude-sonnet-4","messages":[]}`)
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans, got %d", n)
+	}
+}
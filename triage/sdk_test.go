@@ -1,8 +1,20 @@
 package triage
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // ---------------------------------------------------------------------------
@@ -59,6 +71,232 @@ func TestInit_CustomEndpoint(t *testing.T) {
 	}
 }
 
+// newCollectorServer returns a test OTLP endpoint that records the path and
+// Authorization header of each export request.
+func newCollectorServer(t *testing.T) (url string, requests chan *http.Request) {
+	t.Helper()
+	requests = make(chan *http.Request, 8)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, requests
+}
+
+func TestInit_CollectorModeSendsNoCredentials(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	url, requests := newCollectorServer(t)
+
+	if _, err := Init(WithCollectorMode(), WithEndpoint(url), WithOTLPPath("otlp/v1/traces")); err != nil {
+		t.Fatalf("Init without API key in collector mode failed: %v", err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "op")
+	wf.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-requests
+	if r.URL.Path != "/otlp/v1/traces" {
+		t.Errorf("path: got %q, want %q", r.URL.Path, "/otlp/v1/traces")
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		t.Errorf("expected no Authorization header, got %q", auth)
+	}
+}
+
+func TestInit_DefaultSendsBearerToTracesPath(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	url, requests := newCollectorServer(t)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(url)); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "op")
+	wf.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	r := <-requests
+	if r.URL.Path != defaultOTLPTracesPath {
+		t.Errorf("path: got %q, want %q", r.URL.Path, defaultOTLPTracesPath)
+	}
+	if auth := r.Header.Get("Authorization"); auth != "Bearer tsk_test" {
+		t.Errorf("Authorization: got %q, want %q", auth, "Bearer tsk_test")
+	}
+}
+
+func TestInit_MetricsExportedAtShutdown(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	prev := otel.GetMeterProvider()
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	url, requests := newCollectorServer(t)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(url), WithMetrics(true)); err != nil {
+		t.Fatal(err)
+	}
+	counter, err := otel.GetMeterProvider().Meter("test").Int64Counter("test.calls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter.Add(context.Background(), 1)
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-requests:
+		if r.URL.Path != otlpMetricsPath {
+			t.Errorf("path: got %q, want %q", r.URL.Path, otlpMetricsPath)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer tsk_test" {
+			t.Errorf("Authorization: got %q, want %q", auth, "Bearer tsk_test")
+		}
+	default:
+		t.Fatal("expected metrics to be flushed at shutdown")
+	}
+}
+
+func TestInit_MetricsOffByDefault(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	prev := otel.GetMeterProvider()
+	url, _ := newCollectorServer(t)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(url)); err != nil {
+		t.Fatal(err)
+	}
+	if otel.GetMeterProvider() != prev {
+		t.Error("Init without WithMetrics should leave the global meter provider untouched")
+	}
+	if _, ok := MeterProvider().(*sdkmetric.MeterProvider); ok {
+		t.Error("MeterProvider should be a no-op without WithMetrics")
+	}
+}
+
+func TestMetricsPath(t *testing.T) {
+	for tracesPath, want := range map[string]string{
+		"/v1/traces":      "/v1/metrics",
+		"/otlp/v1/traces": "/otlp/v1/metrics",
+		"/ingest":         "/v1/metrics",
+	} {
+		if got := metricsPath(tracesPath); got != want {
+			t.Errorf("metricsPath(%q) = %q, want %q", tracesPath, got, want)
+		}
+	}
+}
+
+func TestInit_InsecureExportsToSchemelessEndpoint(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	url, requests := newCollectorServer(t)
+
+	hostPort := strings.TrimPrefix(url, "http://")
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(hostPort), WithInsecure()); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "op")
+	wf.End()
+	if err := Shutdown(context.Background()); err != nil {
+		t.Fatalf("plaintext export failed: %v", err)
+	}
+	if r := <-requests; r.URL.Path != defaultOTLPTracesPath {
+		t.Errorf("path: got %q, want %q", r.URL.Path, defaultOTLPTracesPath)
+	}
+}
+
+func TestInit_RegistersDefaultPropagators(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	if _, err := Init(WithAPIKey("tsk_test")); err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Join(otel.GetTextMapPropagator().Fields(), ",")
+	if !strings.Contains(fields, "traceparent") || !strings.Contains(fields, "baggage") {
+		t.Errorf("expected tracecontext and baggage propagators, got fields %q", fields)
+	}
+}
+
+func TestInit_WithPropagatorsCustom(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithPropagators(propagation.Baggage{})); err != nil {
+		t.Fatal(err)
+	}
+	fields := otel.GetTextMapPropagator().Fields()
+	if len(fields) != 1 || fields[0] != "baggage" {
+		t.Errorf("expected only the baggage propagator, got fields %v", fields)
+	}
+}
+
+func TestInit_WithPropagatorsNoneLeavesGlobal(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithPropagators()); err != nil {
+		t.Fatal(err)
+	}
+	if fields := otel.GetTextMapPropagator().Fields(); len(fields) != 0 {
+		t.Errorf("expected the global propagator untouched, got fields %v", fields)
+	}
+}
+
+func TestInit_WithLoggerReceivesSDKLogs(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	errHandler := otel.GetErrorHandler()
+	t.Cleanup(func() { otel.SetErrorHandler(errHandler) })
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	if _, err := Init(WithAPIKey("tsk_test"), WithLogger(logger)); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "SDK initialized") {
+		t.Errorf("expected init log in custom logger, got %q", buf.String())
+	}
+
+	buf.Reset()
+	otel.Handle(errors.New("export failed"))
+	if !strings.Contains(buf.String(), "export failed") {
+		t.Errorf("expected OpenTelemetry errors in custom logger, got %q", buf.String())
+	}
+}
+
+func TestInit_ShutdownWarnsOnDroppedSpans(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	shutdown, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "lost")
+	wf.End()
+	shutdown()
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, "spans_dropped=1") || !strings.Contains(out, "duration=") {
+		t.Errorf("expected a warning reporting the dropped span and flush duration, got %q", out)
+	}
+}
+
+func TestInit_SampleRatioZeroDropsNewTraces(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithSampleRatio(0)); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "unsampled")
+	defer wf.End()
+	if wf.span.SpanContext().IsSampled() {
+		t.Error("expected new trace to be unsampled")
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Double init
 // ---------------------------------------------------------------------------
@@ -186,3 +424,124 @@ func TestShutdown_CanBeCalledMultipleTimes(t *testing.T) {
 		t.Fatalf("second Shutdown failed: %v", err)
 	}
 }
+
+func TestShutdownWithStats_ReportsFlushedSpans(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "pending")
+	wf.End()
+
+	fs, err := ShutdownWithStats(context.Background())
+	if err != nil {
+		t.Fatalf("ShutdownWithStats failed: %v", err)
+	}
+	if fs.SpansFlushed != 1 || fs.SpansDropped != 0 {
+		t.Errorf("got %+v, want 1 flushed, 0 dropped", fs)
+	}
+	if fs.Duration <= 0 {
+		t.Errorf("expected a positive flush duration, got %v", fs.Duration)
+	}
+	if last := CurrentStats().LastShutdown; last == nil || *last != fs {
+		t.Errorf("CurrentStats().LastShutdown: got %+v, want %+v", last, fs)
+	}
+}
+
+func TestShutdownWithStats_ReportsDroppedSpans(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "pending")
+	wf.End()
+
+	fs, _ := ShutdownWithStats(context.Background())
+	if fs.SpansFlushed != 0 || fs.SpansDropped != 1 {
+		t.Errorf("got %+v, want 0 flushed, 1 dropped", fs)
+	}
+}
+
+func TestInit_ExportTimeoutBoundsExport(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL), WithExportTimeout(50*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	wf, _ := StartWorkflow(context.Background(), "slow")
+	wf.End()
+
+	start := time.Now()
+	fs, _ := ShutdownWithStats(context.Background())
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("export was not bounded by the export timeout: took %v", elapsed)
+	}
+	if fs.SpansDropped != 1 {
+		t.Errorf("expected the timed-out span to be dropped, got %+v", fs)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tracer accessors
+// ---------------------------------------------------------------------------
+
+func TestTracerProvider_NoopBeforeInit(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	_, span := Tracer("test").Start(context.Background(), "custom")
+	defer span.End()
+	if span.SpanContext().IsValid() {
+		t.Error("spans should be no-ops before Init")
+	}
+}
+
+func TestTracerProvider_ReturnsSDKProvider(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := Init(WithAPIKey("tsk_test"), WithEndpoint(srv.URL)); err != nil {
+		t.Fatal(err)
+	}
+	// Another library taking over the global provider must not detach
+	// spans created through the SDK accessor.
+	otel.SetTracerProvider(noop.NewTracerProvider())
+
+	ctx := WithUser(context.Background(), "u_1")
+	_, span := Tracer("github.com/acme/rag").Start(ctx, "rerank")
+	span.End()
+	if !span.SpanContext().IsValid() {
+		t.Fatal("expected a recording span from the SDK provider")
+	}
+
+	fs, err := ShutdownWithStats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.SpansFlushed != 1 {
+		t.Errorf("custom span should flow through the Triage pipeline, got %+v", fs)
+	}
+}
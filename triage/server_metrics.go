@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ServerMetrics are server-side timings and KV-cache statistics reported by
+// self-hosted inference servers (vLLM, TGI). Recorded alongside the span's
+// own duration, they decompose client-observed latency into queueing,
+// prefill, decode and network/client overhead. Zero fields are not recorded.
+type ServerMetrics struct {
+	QueueTime   time.Duration // Time waiting in the scheduler queue
+	PrefillTime time.Duration // Prompt processing, up to the first token
+	DecodeTime  time.Duration // Token generation after the first token
+
+	// InferenceTime is total server processing time, for servers that don't
+	// split prefill and decode (TGI's x-inference-time). Ignored when
+	// PrefillTime or DecodeTime is set.
+	InferenceTime time.Duration
+
+	// CachedPromptTokens is the number of prompt tokens served from the
+	// prefix/KV cache (vLLM's usage.prompt_tokens_details.cached_tokens).
+	CachedPromptTokens int
+}
+
+// ServerMetricsFromHeaders reads the timing headers returned by Hugging Face
+// TGI (x-queue-time, x-inference-time, in milliseconds). Servers fronted by a
+// proxy that emits x-prefill-time and x-decode-time are read the same way.
+func ServerMetricsFromHeaders(h http.Header) ServerMetrics {
+	return ServerMetrics{
+		QueueTime:     headerMillis(h, "X-Queue-Time"),
+		PrefillTime:   headerMillis(h, "X-Prefill-Time"),
+		DecodeTime:    headerMillis(h, "X-Decode-Time"),
+		InferenceTime: headerMillis(h, "X-Inference-Time"),
+	}
+}
+
+// headerMillis parses a header holding a (possibly fractional) number of
+// milliseconds, returning 0 if absent or malformed.
+func headerMillis(h http.Header, name string) time.Duration {
+	v := strings.TrimSpace(h.Get(name))
+	if v == "" {
+		return 0
+	}
+	ms, err := strconv.ParseFloat(v, 64)
+	if err != nil || ms < 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// serverTime returns the total server-side time accounted for by m.
+func (m ServerMetrics) serverTime() time.Duration {
+	if m.PrefillTime > 0 || m.DecodeTime > 0 {
+		return m.QueueTime + m.PrefillTime + m.DecodeTime
+	}
+	return m.QueueTime + m.InferenceTime
+}
+
+// attributes returns triage.server.* attributes for m. elapsed is the
+// client-observed call duration, used to derive client overhead; promptTokens
+// gives the KV-cache hit ratio.
+func (m ServerMetrics) attributes(elapsed time.Duration, promptTokens int) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	addMs := func(key string, d time.Duration) {
+		if d > 0 {
+			attrs = append(attrs, attribute.Float64(key, float64(d)/float64(time.Millisecond)))
+		}
+	}
+	addMs(AttrServerQueueMs, m.QueueTime)
+	addMs(AttrServerPrefillMs, m.PrefillTime)
+	addMs(AttrServerDecodeMs, m.DecodeTime)
+	if m.PrefillTime == 0 && m.DecodeTime == 0 {
+		addMs(AttrServerInferenceMs, m.InferenceTime)
+	}
+	if st := m.serverTime(); st > 0 && elapsed > st {
+		addMs(AttrServerClientOverheadMs, elapsed-st)
+	}
+
+	if m.CachedPromptTokens > 0 {
+		attrs = append(attrs, attribute.Int(AttrServerCachedPromptTokens, m.CachedPromptTokens))
+		if promptTokens > 0 {
+			attrs = append(attrs, attribute.Float64(AttrServerKVCacheHitRatio,
+				float64(m.CachedPromptTokens)/float64(promptTokens)))
+		}
+	}
+	return attrs
+}
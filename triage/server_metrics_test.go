@@ -0,0 +1,71 @@
+package triage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServerMetricsFromHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("X-Queue-Time", "12")
+	h.Set("X-Inference-Time", "340.5")
+	h.Set("X-Decode-Time", "bogus")
+
+	m := ServerMetricsFromHeaders(h)
+	if m.QueueTime != 12*time.Millisecond {
+		t.Errorf("QueueTime: got %v", m.QueueTime)
+	}
+	if m.InferenceTime != 340500*time.Microsecond {
+		t.Errorf("InferenceTime: got %v", m.InferenceTime)
+	}
+	if m.DecodeTime != 0 || m.PrefillTime != 0 {
+		t.Errorf("malformed or missing headers should be zero: %+v", m)
+	}
+}
+
+func TestServerMetrics_Attributes(t *testing.T) {
+	m := ServerMetrics{
+		QueueTime:          10 * time.Millisecond,
+		PrefillTime:        40 * time.Millisecond,
+		DecodeTime:         150 * time.Millisecond,
+		InferenceTime:      999 * time.Millisecond, // ignored: prefill/decode are split
+		CachedPromptTokens: 750,
+	}
+	attrs := attrMap(m.attributes(250*time.Millisecond, 1000))
+
+	want := map[string]any{
+		AttrServerQueueMs:            10.0,
+		AttrServerPrefillMs:          40.0,
+		AttrServerDecodeMs:           150.0,
+		AttrServerClientOverheadMs:   50.0,
+		AttrServerCachedPromptTokens: int64(750),
+		AttrServerKVCacheHitRatio:    0.75,
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v (%T), want %v", k, attrs[k], attrs[k], v)
+		}
+	}
+	if _, ok := attrs[AttrServerInferenceMs]; ok {
+		t.Error("inference_ms should be omitted when prefill/decode are set")
+	}
+}
+
+func TestLogCompletion_ServerMetrics(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "vllm", Model: "llama"})
+	llmSpan.LogCompletion(Completion{
+		ServerMetrics: ServerMetrics{QueueTime: 5 * time.Millisecond},
+	}, Usage{PromptTokens: 10})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs[AttrServerQueueMs] != 5.0 {
+		t.Errorf("queue_ms: got %v", attrs[AttrServerQueueMs])
+	}
+	if _, ok := attrs[AttrServerKVCacheHitRatio]; ok {
+		t.Error("kv cache ratio should be omitted without cached tokens")
+	}
+}
@@ -0,0 +1,61 @@
+package triage
+
+import "go.opentelemetry.io/otel/attribute"
+
+// Serving describes the stack serving a self-hosted model, so performance
+// and quality anomalies can be tied to a specific deployment.
+type Serving struct {
+	Engine        string // Inference server, e.g. "vllm", "tgi", "ollama"
+	EngineVersion string // e.g. "0.6.3"
+	GPU           string // Accelerator type, e.g. "H100-80GB"
+	Quantization  string // e.g. "awq", "fp8", "int4"
+	Deployment    string // Deployment or replica set name, e.g. "llama-70b-canary"
+}
+
+// WithServing sets process-wide serving metadata recorded on every LLM span.
+// Non-empty fields of Prompt.Serving override it per call.
+func WithServing(s Serving) Option {
+	return func(c *config) { c.serving = s }
+}
+
+// merge returns s with the non-empty fields of o applied on top.
+func (s Serving) merge(o Serving) Serving {
+	if o.Engine != "" {
+		s.Engine = o.Engine
+	}
+	if o.EngineVersion != "" {
+		s.EngineVersion = o.EngineVersion
+	}
+	if o.GPU != "" {
+		s.GPU = o.GPU
+	}
+	if o.Quantization != "" {
+		s.Quantization = o.Quantization
+	}
+	if o.Deployment != "" {
+		s.Deployment = o.Deployment
+	}
+	return s
+}
+
+// servingAttributes returns triage.serving.* attributes for the configured
+// defaults merged with the prompt's serving metadata.
+func servingAttributes(prompt Prompt) []attribute.KeyValue {
+	s := prompt.Serving
+	if globalCfg != nil {
+		s = globalCfg.serving.merge(prompt.Serving)
+	}
+	var attrs []attribute.KeyValue
+	for _, f := range []struct{ key, val string }{
+		{AttrServingEngine, s.Engine},
+		{AttrServingEngineVersion, s.EngineVersion},
+		{AttrServingGPU, s.GPU},
+		{AttrServingQuantization, s.Quantization},
+		{AttrServingDeployment, s.Deployment},
+	} {
+		if f.val != "" {
+			attrs = append(attrs, attribute.String(f.key, f.val))
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,48 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLogPrompt_RecordsServingMetadata(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, serving: Serving{
+		Engine:        "vllm",
+		EngineVersion: "0.6.3",
+		GPU:           "H100-80GB",
+		Deployment:    "llama-70b",
+	}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{
+		Vendor:  "vllm",
+		Model:   "meta-llama/Llama-3.1-70B-Instruct",
+		Serving: Serving{Quantization: "fp8", Deployment: "llama-70b-canary"},
+	})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	want := map[string]string{
+		AttrServingEngine:        "vllm",
+		AttrServingEngineVersion: "0.6.3",
+		AttrServingGPU:           "H100-80GB",
+		AttrServingQuantization:  "fp8",
+		AttrServingDeployment:    "llama-70b-canary",
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %q", k, attrs[k], v)
+		}
+	}
+}
+
+func TestLogPrompt_NoServingMetadata(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrServingEngine]; ok {
+		t.Error("serving attributes should be omitted when not configured")
+	}
+}
@@ -0,0 +1,374 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// In-memory bounds for session tracking. Sessions idle longer than
+// sessionIdleTTL are evicted; beyond sessionMaxSessions the least recently
+// active session is evicted; each session keeps at most sessionMaxTurns.
+const (
+	sessionMaxSessions = 10000
+	sessionMaxTurns    = 200
+	sessionIdleTTL     = 30 * time.Minute
+
+	// sessionSweepInterval is how often idle sessions are ended when their
+	// transcripts are delivered to a webhook (see WithTranscriptWebhook).
+	sessionSweepInterval = time.Minute
+)
+
+// SessionManager tracks recent conversation state per session ID in memory.
+// It is fed automatically by LogCompletion for calls whose context carries a
+// session (see WithSession) when session tracking is enabled via
+// WithSessionTracking. Safe for concurrent use.
+type SessionManager struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionState
+	now      func() time.Time
+	onEnd    func(*Transcript) // called, without sm.mu held, when a session ends; may be nil
+	ended    []*Transcript     // ended under sm.mu, waiting for deliverEnded
+
+	lastSweep time.Time // last idle sweep, when onEnd is set
+}
+
+// sessionState is the tracked state of one session.
+type sessionState struct {
+	userID   string
+	tenantID string
+	turns    []TranscriptTurn
+	history  []Message // running message log, used to find each turn's new input
+	lastRoot trace.SpanContext
+	lastSeen time.Time
+}
+
+// newSessionManager creates an empty SessionManager.
+func newSessionManager() *SessionManager {
+	return &SessionManager{
+		sessions: make(map[string]*sessionState),
+		now:      time.Now,
+	}
+}
+
+// Sessions returns the SDK's SessionManager, or nil if session tracking is
+// not enabled.
+func Sessions() *SessionManager {
+	return sessions
+}
+
+// sessionTrackingFor reports whether an LLM call made with ctx will be
+// recorded by session tracking. Content is never retained in memory when
+// trace content is disabled.
+func sessionTrackingFor(ctx context.Context) bool {
+	return sessions != nil && isTraceContentEnabled() && getFromContext(ctx).sessionID != ""
+}
+
+// recordTurn appends one prompt/completion exchange to its session. Only the
+// prompt messages not already in the session's running history are stored as
+// the turn's input, so transcripts don't repeat the conversation on every
+// turn.
+func (sm *SessionManager) recordTurn(ctx context.Context, ls *LLMSpan, completion Completion) {
+	if !sessionTrackingFor(ctx) {
+		return
+	}
+	tc := getFromContext(ctx)
+
+	defer sm.deliverEnded()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := sm.now()
+	st := sm.getOrCreateLocked(tc.sessionID, now)
+	st.lastSeen = now
+	if tc.userID != "" {
+		st.userID = tc.userID
+	}
+	if tc.tenantID != "" {
+		st.tenantID = tc.tenantID
+	}
+
+	input := ls.messages
+	if hasMessagePrefix(ls.messages, st.history) {
+		input = ls.messages[len(st.history):]
+	}
+
+	number := 1
+	if len(st.turns) > 0 {
+		number = st.turns[len(st.turns)-1].Number + 1
+	}
+	sc := ls.span.SpanContext()
+	turn := TranscriptTurn{
+		Number:    number,
+		TraceID:   sc.TraceID().String(),
+		SpanID:    sc.SpanID().String(),
+		Model:     ls.model,
+		Input:     append([]Message(nil), input...),
+		Output:    append([]Message(nil), completion.Messages...),
+		Timestamp: now.UTC(),
+	}
+	if completion.Model != "" {
+		turn.Model = completion.Model
+	}
+	st.turns = append(st.turns, turn)
+	if len(st.turns) > sessionMaxTurns {
+		st.turns = st.turns[len(st.turns)-sessionMaxTurns:]
+	}
+
+	st.history = append(append([]Message(nil), ls.messages...), completion.Messages...)
+}
+
+// swapRoot records sc as the session's latest turn root span and returns the
+// previous one (invalid if this is the session's first turn). Root spans are
+// tracked even when trace content is disabled, since they carry no content.
+func (sm *SessionManager) swapRoot(sessionID string, sc trace.SpanContext) trace.SpanContext {
+	defer sm.deliverEnded()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	now := sm.now()
+	st := sm.getOrCreateLocked(sessionID, now)
+	st.lastSeen = now
+	prev := st.lastRoot
+	st.lastRoot = sc
+	return prev
+}
+
+// linkPreviousTurn links a turn's root span to the root span of the
+// session's previous turn. Each HTTP request typically starts a new trace, so
+// these links let the backend walk a whole conversation across traces.
+func linkPreviousTurn(ctx context.Context, span trace.Span, parent trace.SpanContext) {
+	sm := sessions
+	if sm == nil || (parent.IsValid() && !parent.IsRemote()) {
+		return
+	}
+	sid := getFromContext(ctx).sessionID
+	if sid == "" {
+		return
+	}
+	prev := sm.swapRoot(sid, span.SpanContext())
+	if prev.IsValid() && prev.TraceID() != span.SpanContext().TraceID() {
+		span.AddLink(trace.Link{
+			SpanContext: prev,
+			Attributes:  []attribute.KeyValue{attribute.String(AttrLinkType, linkTypePreviousTurn)},
+		})
+	}
+}
+
+// getOrCreateLocked returns the state for sessionID, creating it (and
+// evicting stale sessions to make room) if needed. sm.mu must be held.
+func (sm *SessionManager) getOrCreateLocked(sessionID string, now time.Time) *sessionState {
+	if sm.onEnd != nil && now.Sub(sm.lastSweep) >= sessionSweepInterval {
+		sm.sweepLocked(now)
+	}
+	if st, ok := sm.sessions[sessionID]; ok {
+		return st
+	}
+	if len(sm.sessions) >= sessionMaxSessions {
+		sm.evictLocked(now)
+	}
+	st := &sessionState{lastSeen: now}
+	sm.sessions[sessionID] = st
+	return st
+}
+
+// evictLocked drops idle sessions, and if none were idle, the least recently
+// active one. sm.mu must be held.
+func (sm *SessionManager) evictLocked(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	evicted := false
+	for id, st := range sm.sessions {
+		if now.Sub(st.lastSeen) > sessionIdleTTL {
+			sm.endLocked(id, st)
+			evicted = true
+			continue
+		}
+		if oldestID == "" || st.lastSeen.Before(oldest) {
+			oldestID, oldest = id, st.lastSeen
+		}
+	}
+	if !evicted && oldestID != "" {
+		sm.endLocked(oldestID, sm.sessions[oldestID])
+	}
+}
+
+// sweepLocked ends every session idle longer than sessionIdleTTL, so their
+// transcripts are delivered without waiting for the session cap. sm.mu must
+// be held.
+func (sm *SessionManager) sweepLocked(now time.Time) {
+	sm.lastSweep = now
+	for id, st := range sm.sessions {
+		if now.Sub(st.lastSeen) > sessionIdleTTL {
+			sm.endLocked(id, st)
+		}
+	}
+}
+
+// endLocked stops tracking a session and queues its transcript for onEnd,
+// which deliverEnded calls once sm.mu is released. sm.mu must be held.
+func (sm *SessionManager) endLocked(sessionID string, st *sessionState) *Transcript {
+	delete(sm.sessions, sessionID)
+	t := st.transcript(sessionID)
+	if sm.onEnd != nil {
+		sm.ended = append(sm.ended, t)
+	}
+	return t
+}
+
+// deliverEnded hands the transcripts of sessions ended since the last call
+// to onEnd. Call it after releasing sm.mu, so a slow onEnd doesn't hold up
+// other sessions' LLM calls.
+func (sm *SessionManager) deliverEnded() {
+	sm.mu.Lock()
+	ended := sm.ended
+	sm.ended = nil
+	sm.mu.Unlock()
+	for _, t := range ended {
+		sm.onEnd(t)
+	}
+}
+
+// endAll ends every tracked session, e.g. at Shutdown. When onEnd is set, it
+// returns their transcripts, and any others not yet handed to onEnd, for the
+// caller to deliver instead.
+func (sm *SessionManager) endAll() []*Transcript {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	for id, st := range sm.sessions {
+		sm.endLocked(id, st)
+	}
+	ended := sm.ended
+	sm.ended = nil
+	return ended
+}
+
+// EndSession stops tracking a session and returns its final transcript, or
+// false if the session is unknown. The transcript is also delivered to the
+// WithTranscriptWebhook, if configured.
+func (sm *SessionManager) EndSession(sessionID string) (*Transcript, bool) {
+	if sm == nil {
+		return nil, false
+	}
+	defer sm.deliverEnded()
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	st, ok := sm.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	return sm.endLocked(sessionID, st), true
+}
+
+// Transcript returns an ordered transcript of the session's recorded turns,
+// or false if the session is unknown.
+func (sm *SessionManager) Transcript(sessionID string) (*Transcript, bool) {
+	if sm == nil {
+		return nil, false
+	}
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	st, ok := sm.sessions[sessionID]
+	if !ok {
+		return nil, false
+	}
+	return st.transcript(sessionID), true
+}
+
+// transcript copies the session's turns into a Transcript.
+func (st *sessionState) transcript(sessionID string) *Transcript {
+	return &Transcript{
+		SessionID: sessionID,
+		UserID:    st.userID,
+		TenantID:  st.tenantID,
+		Turns:     append([]TranscriptTurn(nil), st.turns...),
+	}
+}
+
+// hasMessagePrefix reports whether prefix is a prefix of msgs, comparing
+// role and content.
+func hasMessagePrefix(msgs, prefix []Message) bool {
+	if len(prefix) > len(msgs) {
+		return false
+	}
+	for i := range prefix {
+		if msgs[i].Role != prefix[i].Role || msgs[i].Content != prefix[i].Content {
+			return false
+		}
+	}
+	return true
+}
+
+// ---------------------------------------------------------------------------
+// Transcript
+// ---------------------------------------------------------------------------
+
+// Transcript is the ordered record of a session's LLM exchanges, suitable for
+// attaching to incident tickets and abuse reports.
+type Transcript struct {
+	SessionID string           `json:"session_id"`
+	UserID    string           `json:"user_id,omitempty"`
+	TenantID  string           `json:"tenant_id,omitempty"`
+	Turns     []TranscriptTurn `json:"turns"`
+
+	// Redactions counts the PII redacted by detector (e.g. "email") when the
+	// transcript was sent to a webhook (see WithTranscriptWebhook). The
+	// redacted values are never recorded.
+	Redactions map[string]int `json:"redactions,omitempty"`
+}
+
+// TranscriptTurn is one prompt/completion exchange. Input holds only the
+// messages that were new in this turn's prompt.
+type TranscriptTurn struct {
+	Number    int       `json:"number"`
+	TraceID   string    `json:"trace_id"`
+	SpanID    string    `json:"span_id"`
+	Model     string    `json:"model,omitempty"`
+	Input     []Message `json:"input"`
+	Output    []Message `json:"output"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// JSON returns the transcript as indented JSON.
+func (t *Transcript) JSON() ([]byte, error) {
+	return json.MarshalIndent(t, "", "  ")
+}
+
+// Markdown renders the transcript as a Markdown document.
+func (t *Transcript) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation %s\n\n", t.SessionID)
+	if t.UserID != "" {
+		fmt.Fprintf(&b, "- User: %s\n", t.UserID)
+	}
+	if t.TenantID != "" {
+		fmt.Fprintf(&b, "- Tenant: %s\n", t.TenantID)
+	}
+	for _, turn := range t.Turns {
+		fmt.Fprintf(&b, "\n## Turn %d — %s\n\n", turn.Number, turn.Timestamp.Format(time.RFC3339))
+		fmt.Fprintf(&b, "_model: %s · trace: %s_\n", turn.Model, turn.TraceID)
+		for _, m := range turn.Input {
+			writeMarkdownMessage(&b, m)
+		}
+		for _, m := range turn.Output {
+			writeMarkdownMessage(&b, m)
+		}
+	}
+	return b.String()
+}
+
+func writeMarkdownMessage(b *strings.Builder, m Message) {
+	fmt.Fprintf(b, "\n**%s:**", m.Role)
+	if m.Content != "" {
+		fmt.Fprintf(b, " %s", m.Content)
+	}
+	b.WriteString("\n")
+	for _, tc := range m.ToolCalls {
+		fmt.Fprintf(b, "\n> tool call `%s`: `%s`\n", tc.Function.Name, tc.Function.Arguments)
+	}
+}
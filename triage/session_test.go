@@ -0,0 +1,224 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSessionTestManager enables session tracking for the test.
+func newSessionTestManager(t *testing.T) *SessionManager {
+	t.Helper()
+	sessions = newSessionManager()
+	t.Cleanup(func() { sessions = nil })
+	return sessions
+}
+
+// chatTurn logs one LLM exchange with the full conversation as the prompt.
+func chatTurn(ctx context.Context, history []Message, reply string) []Message {
+	llmSpan, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o", Messages: history})
+	out := Message{Role: "assistant", Content: reply}
+	llmSpan.LogCompletion(Completion{Model: "gpt-4o", Messages: []Message{out}}, Usage{})
+	return append(history, out)
+}
+
+// ---------------------------------------------------------------------------
+// Recording
+// ---------------------------------------------------------------------------
+
+func TestSessionManager_RecordsOrderedTurnsWithoutRepetition(t *testing.T) {
+	newGlobalTestProvider(t)
+	sm := newSessionTestManager(t)
+
+	ctx := WithUser(context.Background(), "u_1")
+	ctx = WithSession(ctx, "sess_1")
+
+	history := []Message{{Role: "system", Content: "be brief"}, {Role: "user", Content: "hi"}}
+	history = chatTurn(ctx, history, "hello")
+	history = append(history, Message{Role: "user", Content: "bye"})
+	chatTurn(ctx, history, "goodbye")
+
+	tr, ok := sm.Transcript("sess_1")
+	if !ok {
+		t.Fatal("expected transcript for sess_1")
+	}
+	if tr.UserID != "u_1" {
+		t.Errorf("user: got %q, want %q", tr.UserID, "u_1")
+	}
+	if len(tr.Turns) != 2 {
+		t.Fatalf("expected 2 turns, got %d", len(tr.Turns))
+	}
+	if len(tr.Turns[0].Input) != 2 {
+		t.Errorf("turn 1 input: got %d messages, want 2", len(tr.Turns[0].Input))
+	}
+	if len(tr.Turns[1].Input) != 1 || tr.Turns[1].Input[0].Content != "bye" {
+		t.Errorf("turn 2 input should only hold the new message, got %+v", tr.Turns[1].Input)
+	}
+	if tr.Turns[1].Number != 2 || tr.Turns[1].Output[0].Content != "goodbye" {
+		t.Errorf("turn 2: got %+v", tr.Turns[1])
+	}
+	if tr.Turns[0].TraceID == "" {
+		t.Error("expected trace ID on turns")
+	}
+}
+
+func TestSessionManager_IgnoresCallsWithoutSession(t *testing.T) {
+	newGlobalTestProvider(t)
+	sm := newSessionTestManager(t)
+
+	chatTurn(context.Background(), []Message{{Role: "user", Content: "hi"}}, "hello")
+
+	if len(sm.sessions) != 0 {
+		t.Errorf("expected no sessions, got %d", len(sm.sessions))
+	}
+}
+
+func TestSessionManager_DisabledWithoutTraceContent(t *testing.T) {
+	newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: false}
+	sm := newSessionTestManager(t)
+
+	ctx := WithSession(context.Background(), "sess_1")
+	chatTurn(ctx, []Message{{Role: "user", Content: "hi"}}, "hello")
+
+	if tr, ok := sm.Transcript("sess_1"); ok && len(tr.Turns) > 0 {
+		t.Error("session content should not be retained when trace content is disabled")
+	}
+}
+
+func TestSessionManager_EndSessionRemovesState(t *testing.T) {
+	newGlobalTestProvider(t)
+	sm := newSessionTestManager(t)
+
+	ctx := WithSession(context.Background(), "sess_1")
+	chatTurn(ctx, []Message{{Role: "user", Content: "hi"}}, "hello")
+
+	tr, ok := sm.EndSession("sess_1")
+	if !ok || len(tr.Turns) != 1 {
+		t.Fatalf("EndSession: got %+v, %v", tr, ok)
+	}
+	if _, ok := sm.Transcript("sess_1"); ok {
+		t.Error("expected session to be gone after EndSession")
+	}
+}
+
+func TestSessionManager_EvictsIdleSessions(t *testing.T) {
+	sm := newSessionManager()
+	now := time.Now()
+	for i := 0; i < sessionMaxSessions; i++ {
+		sm.sessions[string(rune(i))] = &sessionState{lastSeen: now.Add(-time.Hour)}
+	}
+	sm.getOrCreateLocked("fresh", now)
+
+	if len(sm.sessions) != 1 {
+		t.Errorf("expected idle sessions to be evicted, %d remain", len(sm.sessions))
+	}
+}
+
+func TestSessionManager_NilIsSafe(t *testing.T) {
+	var sm *SessionManager
+	if _, ok := sm.Transcript("x"); ok {
+		t.Error("nil manager should report no transcript")
+	}
+	if _, ok := sm.EndSession("x"); ok {
+		t.Error("nil manager should report no session")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Export
+// ---------------------------------------------------------------------------
+
+func TestTranscript_JSONAndMarkdown(t *testing.T) {
+	tr := &Transcript{
+		SessionID: "sess_1",
+		UserID:    "u_1",
+		Turns: []TranscriptTurn{{
+			Number:  1,
+			TraceID: "abc",
+			Model:   "gpt-4o",
+			Input:   []Message{{Role: "user", Content: "weather?"}},
+			Output: []Message{{Role: "assistant", ToolCalls: []ToolCall{{
+				ID: "call_1", Type: "function",
+				Function: ToolCallFunction{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}}},
+		}},
+	}
+
+	data, err := tr.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["session_id"] != "sess_1" {
+		t.Errorf("json session_id: got %v", decoded["session_id"])
+	}
+	if !strings.Contains(string(data), `"role": "user"`) {
+		t.Errorf("expected snake_case message fields in JSON, got %s", data)
+	}
+
+	md := tr.Markdown()
+	for _, want := range []string{"# Conversation sess_1", "- User: u_1", "## Turn 1", "**user:** weather?", "`get_weather`"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Turn links
+// ---------------------------------------------------------------------------
+
+func TestSessionTurns_RootSpansLinkToPreviousTurn(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	newSessionTestManager(t)
+
+	ctx := WithSession(context.Background(), "sess_1")
+	wf1, wfCtx := StartWorkflow(ctx, "turn")
+	task, _ := StartTask(wfCtx, "step") // not a root: must not be linked or tracked
+	task.End()
+	wf1.End()
+	wf2, _ := StartWorkflow(ctx, "turn")
+	wf2.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+	taskSpan, first, second := spans[0], spans[1], spans[2]
+
+	if len(first.Links) != 0 || len(taskSpan.Links) != 0 {
+		t.Error("first turn and child spans should have no links")
+	}
+	if len(second.Links) != 1 {
+		t.Fatalf("expected 1 link on second turn, got %d", len(second.Links))
+	}
+	link := second.Links[0]
+	if link.SpanContext.SpanID() != first.SpanContext.SpanID() {
+		t.Error("second turn should link to the first turn's root span")
+	}
+	if attrMap(link.Attributes)[AttrLinkType] != linkTypePreviousTurn {
+		t.Errorf("link type: got %v", attrMap(link.Attributes)[AttrLinkType])
+	}
+}
+
+func TestSessionTurns_NoLinksAcrossSessions(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	newSessionTestManager(t)
+
+	wf1, _ := StartWorkflow(WithSession(context.Background(), "sess_a"), "turn")
+	wf1.End()
+	wf2, _ := StartWorkflow(WithSession(context.Background(), "sess_b"), "turn")
+	wf2.End()
+
+	for _, s := range exporter.GetSpans() {
+		if len(s.Links) != 0 {
+			t.Errorf("%s: expected no links across sessions", s.Name)
+		}
+	}
+}
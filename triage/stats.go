@@ -0,0 +1,202 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// Stats is a point-in-time snapshot of the SDK's internal counters, returned
+// by CurrentStats and served as JSON by StatsHandler.
+type Stats struct {
+	SpansStarted  int64 `json:"spans_started"`
+	SpansEnded    int64 `json:"spans_ended"`
+	SpansExported int64 `json:"spans_exported"`
+	SpansDropped  int64 `json:"spans_dropped"`
+
+	// QueueDepth is the number of spans that have ended but have not yet been
+	// handed to the exporter (successfully or not). Spans dropped because the
+	// queue was full are counted in SpansDropped, not here.
+	QueueDepth int64 `json:"queue_depth"`
+
+	// PanicsRecovered counts panics inside SDK code that were recovered
+	// instead of crashing the application. Any non-zero value is an SDK bug.
+	PanicsRecovered int64 `json:"panics_recovered"`
+
+	LastExportError     string     `json:"last_export_error,omitempty"`
+	LastExportErrorTime *time.Time `json:"last_export_error_time,omitempty"`
+
+	// LastShutdown reports the most recent Shutdown's flush result, or nil if
+	// the SDK has not been shut down in this process.
+	LastShutdown *FlushStats `json:"last_shutdown,omitempty"`
+
+	// Config is the active SDK configuration, or nil if the SDK is not
+	// initialized. The API key is never included.
+	Config *ActiveConfig `json:"config,omitempty"`
+}
+
+// ActiveConfig is the non-secret subset of the resolved SDK configuration.
+type ActiveConfig struct {
+	Endpoint     string `json:"endpoint"`
+	AppName      string `json:"app_name"`
+	Environment  string `json:"environment"`
+	TraceContent bool   `json:"trace_content"`
+}
+
+// sdkStats holds the live counters. Counters are cumulative for the lifetime
+// of the process and are not reset by Shutdown.
+type sdkStats struct {
+	started  atomic.Int64
+	ended    atomic.Int64
+	exported atomic.Int64
+	dropped  atomic.Int64
+	panics   atomic.Int64
+
+	errMu        sync.Mutex // guards the fields below
+	lastErr      string
+	lastErrAt    time.Time
+	lastShutdown *FlushStats
+}
+
+var stats sdkStats
+
+func (s *sdkStats) recordExportError(err error) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.lastErr = err.Error()
+	s.lastErrAt = time.Now()
+}
+
+func (s *sdkStats) recordShutdown(fs FlushStats) {
+	s.errMu.Lock()
+	defer s.errMu.Unlock()
+	s.lastShutdown = &fs
+}
+
+// settle counts every span still queued as dropped. Called after the provider
+// has shut down, when nothing left in the queue can be exported anymore.
+func (s *sdkStats) settle() {
+	if pending := s.ended.Load() - s.exported.Load() - s.dropped.Load(); pending > 0 {
+		s.dropped.Add(pending)
+	}
+}
+
+// queueDepth returns the number of ended spans not yet handed to the
+// exporter.
+func (s *sdkStats) queueDepth() int64 {
+	return max(s.ended.Load()-s.exported.Load()-s.dropped.Load(), 0)
+}
+
+// CurrentStats returns a snapshot of the SDK's span counters and active
+// configuration.
+func CurrentStats() Stats {
+	snap := Stats{
+		SpansStarted:  stats.started.Load(),
+		SpansEnded:    stats.ended.Load(),
+		SpansExported: stats.exported.Load(),
+		SpansDropped:  stats.dropped.Load(),
+
+		PanicsRecovered: stats.panics.Load(),
+	}
+	snap.QueueDepth = stats.queueDepth()
+
+	stats.errMu.Lock()
+	if stats.lastErr != "" {
+		at := stats.lastErrAt
+		snap.LastExportError = stats.lastErr
+		snap.LastExportErrorTime = &at
+	}
+	if fs := stats.lastShutdown; fs != nil {
+		last := *fs
+		snap.LastShutdown = &last
+	}
+	stats.errMu.Unlock()
+
+	mu.Lock()
+	if globalCfg != nil {
+		snap.Config = &ActiveConfig{
+			Endpoint:     globalCfg.endpoint,
+			AppName:      globalCfg.appName,
+			Environment:  globalCfg.environment,
+			TraceContent: globalCfg.traceContent,
+		}
+	}
+	mu.Unlock()
+
+	return snap
+}
+
+// StatsHandler returns an http.Handler that serves CurrentStats as JSON. It is
+// intended for mounting on an internal debug server:
+//
+//	debugMux.Handle("/debug/triage", triage.StatsHandler())
+func StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodHead {
+			return
+		}
+		_ = json.NewEncoder(w).Encode(CurrentStats())
+	})
+}
+
+// Compile-time check that countingExporter implements SpanExporter.
+var _ sdktrace.SpanExporter = (*countingExporter)(nil)
+
+// countingExporter wraps the OTLP exporter to count exported and dropped
+// spans and remember the most recent export error.
+type countingExporter struct {
+	sdktrace.SpanExporter
+}
+
+func (e *countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) (err error) {
+	// Deferred first so it runs last, once the spans have been counted.
+	defer checkQueuePressure()
+	// Export runs on the batch processor's goroutine, where a panic would
+	// crash the application; report it as a failed export instead.
+	defer func() {
+		if r := recover(); r != nil {
+			stats.panics.Add(1)
+			err = panicError("ExportSpans", r)
+			stats.dropped.Add(int64(len(spans)))
+			stats.recordExportError(err)
+		}
+	}()
+	err = e.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		dropped := len(spans)
+		var partial *partialExportError
+		if errors.As(err, &partial) {
+			dropped = partial.dropped
+		}
+		stats.dropped.Add(int64(dropped))
+		stats.exported.Add(int64(len(spans) - dropped))
+		stats.recordExportError(err)
+		return err
+	}
+	stats.exported.Add(int64(len(spans)))
+	return nil
+}
+
+// partialExportError is returned by an exporter that exported only part of a
+// batch (see tenantRouter), so countingExporter counts only the spans it
+// dropped.
+type partialExportError struct {
+	err     error
+	dropped int
+}
+
+func (e *partialExportError) Error() string { return e.err.Error() }
+
+func (e *partialExportError) Unwrap() error { return e.err }
@@ -0,0 +1,189 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// failingExporter is a SpanExporter that always returns err.
+type failingExporter struct {
+	err error
+}
+
+func (e *failingExporter) ExportSpans(context.Context, []sdktrace.ReadOnlySpan) error {
+	return e.err
+}
+
+func (e *failingExporter) Shutdown(context.Context) error { return nil }
+
+// ---------------------------------------------------------------------------
+// Counters
+// ---------------------------------------------------------------------------
+
+func TestStats_ProcessorCountsStartedAndEnded(t *testing.T) {
+	tp, _ := newTestProvider(t)
+	before := CurrentStats()
+
+	_, span := tp.Tracer("test").Start(context.Background(), "op")
+	mid := CurrentStats()
+	span.End()
+	after := CurrentStats()
+
+	if got := mid.SpansStarted - before.SpansStarted; got != 1 {
+		t.Errorf("spans started: got %d, want 1", got)
+	}
+	if got := after.SpansEnded - mid.SpansEnded; got != 1 {
+		t.Errorf("spans ended: got %d, want 1", got)
+	}
+}
+
+func TestStats_QueueDepthRecoversAfterOverflow(t *testing.T) {
+	zeroQueueDepth(t)
+	before := CurrentStats()
+
+	bsp := sdktrace.NewBatchSpanProcessor(
+		&countingExporter{SpanExporter: tracetest.NewInMemoryExporter()},
+		sdktrace.WithMaxQueueSize(2), sdktrace.WithBatchTimeout(time.Hour),
+	)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSpanProcessor(&queueLimiter{SpanProcessor: bsp, capacity: 2}),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	for range 10 {
+		_, span := tp.Tracer("test").Start(context.Background(), "op")
+		span.End()
+	}
+	if err := tp.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	after := CurrentStats()
+	if after.QueueDepth != 0 {
+		t.Errorf("queue depth after flush: got %d, want 0", after.QueueDepth)
+	}
+	if got := after.SpansExported - before.SpansExported; got != 2 {
+		t.Errorf("exported: got %d, want 2", got)
+	}
+	if got := after.SpansDropped - before.SpansDropped; got != 8 {
+		t.Errorf("dropped: got %d, want 8", got)
+	}
+}
+
+func TestStats_CountingExporterCountsExported(t *testing.T) {
+	exp := &countingExporter{SpanExporter: tracetest.NewInMemoryExporter()}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	before := CurrentStats()
+
+	_, span := tp.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	if got := CurrentStats().SpansExported - before.SpansExported; got != 1 {
+		t.Errorf("spans exported: got %d, want 1", got)
+	}
+}
+
+func TestStats_CountingExporterRecordsFailures(t *testing.T) {
+	exp := &countingExporter{SpanExporter: &failingExporter{err: errors.New("connection refused")}}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	before := CurrentStats()
+
+	_, span := tp.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	after := CurrentStats()
+	if got := after.SpansDropped - before.SpansDropped; got != 1 {
+		t.Errorf("spans dropped: got %d, want 1", got)
+	}
+	if after.LastExportError != "connection refused" {
+		t.Errorf("last export error: got %q, want %q", after.LastExportError, "connection refused")
+	}
+	if after.LastExportErrorTime == nil {
+		t.Error("expected last export error time to be set")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Active config
+// ---------------------------------------------------------------------------
+
+func TestStats_ConfigNilWhenNotInitialized(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	if CurrentStats().Config != nil {
+		t.Error("expected nil config before Init")
+	}
+}
+
+func TestStats_ConfigReflectsInit(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	shutdown, err := Init(WithAPIKey("tsk_secret"), WithAppName("stats-app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown()
+
+	cfg := CurrentStats().Config
+	if cfg == nil {
+		t.Fatal("expected config after Init")
+	}
+	if cfg.AppName != "stats-app" {
+		t.Errorf("app name: got %q, want %q", cfg.AppName, "stats-app")
+	}
+}
+
+// ---------------------------------------------------------------------------
+// HTTP handler
+// ---------------------------------------------------------------------------
+
+func TestStatsHandler_ServesJSON(t *testing.T) {
+	t.Cleanup(func() { resetSDK(t) })
+
+	shutdown, err := Init(WithAPIKey("tsk_secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown()
+
+	rec := httptest.NewRecorder()
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/triage", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: got %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("content type: got %q, want %q", ct, "application/json")
+	}
+	if strings.Contains(rec.Body.String(), "tsk_secret") {
+		t.Error("stats output must not contain the API key")
+	}
+
+	var got Stats
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got.Config == nil {
+		t.Error("expected config in stats output")
+	}
+}
+
+func TestStatsHandler_RejectsPost(t *testing.T) {
+	rec := httptest.NewRecorder()
+	StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/triage", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status: got %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,232 @@
+package triage
+
+import (
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// chunkGapBucketsMs are the upper bounds (inclusive, in milliseconds) of the
+// chunk-gap histogram buckets. The histogram attribute has one more entry
+// than this slice, counting gaps above the last bound.
+var chunkGapBucketsMs = []int64{10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// maxGapSamples bounds the chunk gaps kept for percentiles; longer streams
+// keep a uniform random sample of their gaps.
+const maxGapSamples = 1024
+
+// maxStreamIndex bounds the tool call and content block indexes accepted
+// from a streamed response, so a malformed stream can neither index out of
+// range nor make the SDK allocate without bound.
+const maxStreamIndex = 128
+
+// validStreamIndex reports whether i, an index sent by the provider, is in
+// [0, maxStreamIndex).
+func validStreamIndex(i int) bool { return i >= 0 && i < maxStreamIndex }
+
+// streamState accumulates chunk timing for a streamed LLM call.
+type streamState struct {
+	mu         sync.Mutex
+	chunks     int
+	last       time.Time
+	gapHist    []int64         // per chunkGapBucketsMs bucket, set on the first gap
+	maxGap     time.Duration   // longest gap between consecutive chunks
+	gaps       []time.Duration // sample of up to maxGapSamples gaps, for percentiles
+	bytes      int64           // reported by RecordStreamBytes
+	firstToken time.Time       // set by MarkFirstToken
+
+	// Cancellation watch, set on the first chunk (see LogCancelled), and the
+	// partial response of a stream the SDK reassembles.
+	stopWatch func() bool
+	partial   func() (Completion, Usage)
+}
+
+// RecordChunk marks the arrival of one streamed chunk. Call it for each
+// chunk read from the provider stream, before LogCompletion; the gaps between
+// chunks are summarized on the span as a histogram and maximum gap, so stalls
+// and mid-stream provider degradation show up in traces. With
+// WithStreamChunkEvents(true), each chunk is also recorded as a span event.
+// The first chunk starts watching the span's context, so a stream the caller
+// cancels is still ended (see LogCancelled).
+//
+// Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) RecordChunk() {
+	if ls == nil || ls.span == nil {
+		return
+	}
+	now := time.Now()
+
+	ls.stream.mu.Lock()
+	index := ls.stream.chunks
+	if index > 0 {
+		ls.stream.recordGap(now.Sub(ls.stream.last))
+	}
+	ls.stream.chunks++
+	ls.stream.last = now
+	ls.stream.mu.Unlock()
+
+	if index == 0 {
+		ls.watchCancellation()
+	}
+
+	if isChunkEventsEnabled() {
+		ls.span.AddEvent(streamChunkEventName,
+			trace.WithTimestamp(now),
+			trace.WithAttributes(
+				attribute.Int(AttrStreamChunkIndex, index),
+				attribute.Int64(AttrStreamChunkOffsetMs, now.Sub(ls.start).Milliseconds()),
+			),
+		)
+	}
+}
+
+// MarkFirstToken marks the arrival of the first output token of a streamed
+// response. The span then records gen_ai.server.time_to_first_token, the
+// time from LogPrompt to the first call, and, once LogCompletion reports more
+// than one output token, gen_ai.server.time_per_output_token, the average
+// time per token after the first. Both are in seconds. Calls after the first
+// are no-ops.
+//
+// AppendDelta calls it for the first delta carrying content, reasoning or a
+// tool call, and streams recorded by NewTransport, NewGateway and the client
+// middleware are marked automatically. Call it yourself when recording
+// chunks with RecordChunk. Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) MarkFirstToken() {
+	if ls == nil || ls.span == nil {
+		return
+	}
+	now := time.Now()
+	ls.stream.mu.Lock()
+	if ls.stream.firstToken.IsZero() {
+		ls.stream.firstToken = now
+	}
+	ls.stream.mu.Unlock()
+}
+
+// recordStreamChunk marks the arrival of one chunk of a stream reassembled
+// by the SDK; token reports whether it carries output.
+func (ls *LLMSpan) recordStreamChunk(token bool) {
+	if token {
+		ls.MarkFirstToken()
+	}
+	ls.RecordChunk()
+}
+
+// latencyAttributes returns the time to first token and, given the number of
+// output tokens, the time per output token after the first, measured to the
+// last chunk (or now, if no chunk came later). It returns nil if
+// MarkFirstToken was never called.
+func (s *streamState) latencyAttributes(start time.Time, outputTokens int) []attribute.KeyValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.firstToken.IsZero() {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Float64(AttrGenAIServerTimeToFirstToken, s.firstToken.Sub(start).Seconds()),
+	}
+	if outputTokens > 1 {
+		end := time.Now()
+		if s.last.After(s.firstToken) {
+			end = s.last
+		}
+		attrs = append(attrs, attribute.Float64(AttrGenAIServerTimePerOutputToken,
+			end.Sub(s.firstToken).Seconds()/float64(outputTokens-1)))
+	}
+	return attrs
+}
+
+// RecordStreamBytes adds n to the bytes streamed by the provider, recorded as
+// triage.stream.bytes. Streams recorded by NewStreamReader, NewTransport,
+// NewGateway and the client middleware count their bytes automatically.
+// Safe to call on a nil LLMSpan (no-op).
+func (ls *LLMSpan) RecordStreamBytes(n int) {
+	if ls == nil || ls.span == nil || n <= 0 {
+		return
+	}
+	ls.stream.mu.Lock()
+	ls.stream.bytes += int64(n)
+	ls.stream.mu.Unlock()
+}
+
+// recordGap adds gap to the histogram and maximum, and samples it for the
+// percentiles (reservoir sampling, so every gap is equally likely to be
+// kept). s.mu must be held, and s.chunks must not count the chunk yet.
+func (s *streamState) recordGap(gap time.Duration) {
+	if s.gapHist == nil {
+		s.gapHist = make([]int64, len(chunkGapBucketsMs)+1)
+	}
+	s.gapHist[gapBucket(gap)]++
+	s.maxGap = max(s.maxGap, gap)
+	if len(s.gaps) < maxGapSamples {
+		s.gaps = append(s.gaps, gap)
+	} else if i := rand.IntN(s.chunks); i < maxGapSamples {
+		s.gaps[i] = gap
+	}
+}
+
+// attributes summarizes the recorded chunks: their count, the time from
+// start to the last chunk and the bytes streamed, and, once two chunks were
+// recorded, the gap histogram, percentiles and maximum. It returns nil if no
+// chunk was recorded.
+func (s *streamState) attributes(start time.Time) []attribute.KeyValue {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.chunks == 0 {
+		return nil
+	}
+	attrs := []attribute.KeyValue{
+		attribute.Int(AttrStreamChunks, s.chunks),
+		attribute.Int64(AttrStreamDurationMs, s.last.Sub(start).Milliseconds()),
+	}
+	if s.bytes > 0 {
+		attrs = append(attrs, attribute.Int64(AttrStreamBytes, s.bytes))
+	}
+	if len(s.gaps) == 0 {
+		return attrs
+	}
+
+	sorted := slices.Clone(s.gaps)
+	slices.Sort(sorted)
+	return append(attrs,
+		attribute.Int64Slice(AttrStreamChunkGapHistogram, slices.Clone(s.gapHist)),
+		attribute.Int64(AttrStreamMaxChunkGapMs, s.maxGap.Milliseconds()),
+		attribute.Float64(AttrStreamChunkGapP50Ms, gapPercentileMs(sorted, 50)),
+		attribute.Float64(AttrStreamChunkGapP90Ms, gapPercentileMs(sorted, 90)),
+		attribute.Float64(AttrStreamChunkGapP99Ms, gapPercentileMs(sorted, 99)),
+	)
+}
+
+// gapPercentileMs returns the p-th percentile of sorted, a non-empty sorted
+// slice of gaps, in milliseconds, using the nearest-rank method.
+func gapPercentileMs(sorted []time.Duration, p int) float64 {
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return float64(sorted[rank-1]) / float64(time.Millisecond)
+}
+
+// gapBucket returns the histogram bucket index for gap.
+func gapBucket(gap time.Duration) int {
+	ms := gap.Milliseconds()
+	for i, bound := range chunkGapBucketsMs {
+		if ms <= bound {
+			return i
+		}
+	}
+	return len(chunkGapBucketsMs)
+}
+
+// isChunkEventsEnabled returns whether RecordChunk should add a span event per
+// chunk. Defaults to false if the SDK hasn't been initialized yet.
+func isChunkEventsEnabled() bool {
+	if globalCfg == nil {
+		return false
+	}
+	return globalCfg.chunkEvents
+}
@@ -0,0 +1,61 @@
+package triage
+
+import "io"
+
+// StreamFormat is the wire format of a provider server-sent event stream
+// read through NewStreamReader.
+type StreamFormat string
+
+// Stream formats understood by NewStreamReader.
+const (
+	StreamOpenAIChat      StreamFormat = "openai.chat"        // OpenAI Chat Completions and compatible servers
+	StreamOpenAIResponses StreamFormat = "openai.responses"   // OpenAI Responses API
+	StreamAnthropic       StreamFormat = "anthropic.messages" // Anthropic Messages
+)
+
+// api returns the wire format of f, or nil if f is unknown.
+func (f StreamFormat) api() *llmAPI {
+	switch f {
+	case StreamOpenAIChat:
+		return openAIChatAPI
+	case StreamOpenAIResponses:
+		return openAIResponsesAPI
+	case StreamAnthropic:
+		return anthropicMessagesAPI
+	}
+	return nil
+}
+
+// NewStreamReader wraps body, a provider SSE stream in format, so reading it
+// records the response on ls: chunks are parsed as they are read, with chunk
+// timing and the first token recorded, and the reassembled completion and
+// the usage from the final chunk are logged when the stream reaches EOF or
+// is closed. A read error is recorded on the span first. Read and close the
+// returned reader in place of body:
+//
+//	llmSpan, ctx := triage.LogPrompt(ctx, prompt)
+//	resp, err := httpClient.Do(req.WithContext(ctx))
+//	// ...
+//	stream := triage.NewStreamReader(llmSpan, resp.Body, triage.StreamOpenAIChat)
+//	defer stream.Close()
+//	// parse events from stream as you would from resp.Body
+//
+// A stream abandoned mid-way is still recorded with what was received: at
+// Close, or, if the context passed to LogPrompt is cancelled first, by
+// LogCancelled. Always Close the reader. Streams of an unknown format are
+// passed through, and an empty completion is logged at EOF or Close.
+func NewStreamReader(ls *LLMSpan, body io.ReadCloser, format StreamFormat) io.ReadCloser {
+	b := &gatewayBody{ReadCloser: body, ls: ls, stream: discardStream{}}
+	if api := format.api(); api != nil {
+		b.stream = api.newStream(ls.recordStreamChunk)
+	}
+	ls.onStreamCancelled(b.partial)
+	return b
+}
+
+// discardStream is the completionStream of an unknown format.
+type discardStream struct{}
+
+func (discardStream) Write(p []byte) (int, error) { return len(p), nil }
+
+func (discardStream) result() (Completion, Usage) { return Completion{}, Usage{} }
@@ -0,0 +1,155 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+const openAISSE = `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}
+
+data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: {"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}
+
+data: [DONE]
+
+`
+
+// closeRecorder is a body that records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestNewStreamReader_LogsCompletionAtEOF(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	r := NewStreamReader(ls, io.NopCloser(strings.NewReader(openAISSE)), StreamOpenAIChat)
+	data, err := io.ReadAll(r)
+	if err != nil || string(data) != openAISSE {
+		t.Fatalf("the stream should pass through unchanged: %q, %v", data, err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected the span to end at EOF, got %d spans", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hello" || attrs[AttrGenAIResponseFinishReason] != "stop" {
+		t.Errorf("content/finish: got %v/%v", attrs["gen_ai.completion.0.content"], attrs[AttrGenAIResponseFinishReason])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("usage from the final chunk: got %v", attrs["llm.usage.total_tokens"])
+	}
+	if _, ok := attrs[AttrGenAIServerTimeToFirstToken]; !ok {
+		t.Error("expected time to first token")
+	}
+	if attrs[AttrStreamChunks] != int64(3) || attrs[AttrStreamBytes] != int64(len(openAISSE)) {
+		t.Errorf("chunks/bytes: got %v/%v", attrs[AttrStreamChunks], attrs[AttrStreamBytes])
+	}
+}
+
+func TestNewStreamReader_CloseMidStream(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	first := strings.Index(openAISSE, "\n\n") + 2
+	body := &closeRecorder{Reader: strings.NewReader(openAISSE)}
+	r := NewStreamReader(ls, body, StreamOpenAIChat)
+	if _, err := io.ReadFull(r, make([]byte, first)); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+
+	if !body.closed {
+		t.Error("Close should close the underlying body")
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := attrMap(spans[0].Attributes)["gen_ai.completion.0.content"]; got != "Hel" {
+		t.Errorf("partial content: got %v", got)
+	}
+}
+
+func TestNewStreamReader_ReadErrorMarksSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	body := io.MultiReader(strings.NewReader(openAISSE[:40]), errReader{errors.New("connection reset")})
+	r := NewStreamReader(ls, io.NopCloser(body), StreamOpenAIChat)
+	if _, err := io.ReadAll(r); err == nil {
+		t.Fatal("expected the read error to be returned")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
+
+func TestNewStreamReader_Anthropic(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	const sse = `data: {"type":"message_start","message":{"model":"claude-sonnet-4","usage":{"input_tokens":4}}}
+
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}
+
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}
+
+`
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "anthropic", Model: "claude-sonnet-4"})
+	io.ReadAll(NewStreamReader(ls, io.NopCloser(strings.NewReader(sse)), StreamAnthropic))
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hi" || attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("content/usage: got %v/%v", attrs["gen_ai.completion.0.content"], attrs["llm.usage.total_tokens"])
+	}
+}
+
+func TestNewStreamReader_UnknownFormatPassesThrough(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ls, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	data, _ := io.ReadAll(NewStreamReader(ls, io.NopCloser(strings.NewReader(openAISSE)), StreamFormat("bogus")))
+	if string(data) != openAISSE {
+		t.Errorf("stream should pass through: %q", data)
+	}
+	if len(exporter.GetSpans()) != 1 {
+		t.Error("the span should still be ended at EOF")
+	}
+}
+
+// errReader fails every read with err.
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestNewStreamReader_MalformedStreamDoesNotPanic(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	body := `data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":-1,"function":{"name":"x"}}]}}]}` + "\n\n"
+	r := NewStreamReader(llmSpan, io.NopCloser(strings.NewReader(body)), StreamOpenAIChat)
+	if _, err := io.ReadAll(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if n := len(exporter.GetSpans()); n != 1 {
+		t.Errorf("expected 1 span, got %d", n)
+	}
+}
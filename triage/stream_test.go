@@ -0,0 +1,269 @@
+package triage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// Chunk timing
+// ---------------------------------------------------------------------------
+
+func TestGapBucket(t *testing.T) {
+	cases := map[time.Duration]int{
+		5 * time.Millisecond:    0,
+		10 * time.Millisecond:   0,
+		11 * time.Millisecond:   1,
+		300 * time.Millisecond:  5,
+		2500 * time.Millisecond: 7,
+		10 * time.Second:        8,
+	}
+	for gap, want := range cases {
+		if got := gapBucket(gap); got != want {
+			t.Errorf("gapBucket(%v): got %d, want %d", gap, got, want)
+		}
+	}
+}
+
+func TestStreamState_Attributes(t *testing.T) {
+	start := time.Now()
+	s := &streamState{last: start.Add(3100 * time.Millisecond), bytes: 512}
+	for _, gap := range []time.Duration{5 * time.Millisecond, 40 * time.Millisecond, 3 * time.Second} {
+		s.chunks++
+		s.recordGap(gap)
+	}
+	s.chunks++
+
+	attrs := attrMap(s.attributes(start))
+	hist, ok := attrs[AttrStreamChunkGapHistogram].([]int64)
+	if !ok || len(hist) != len(chunkGapBucketsMs)+1 {
+		t.Fatalf("histogram: got %v", attrs[AttrStreamChunkGapHistogram])
+	}
+	if hist[0] != 1 || hist[2] != 1 || hist[8] != 1 {
+		t.Errorf("histogram buckets: got %v", hist)
+	}
+	if attrs[AttrStreamMaxChunkGapMs] != int64(3000) {
+		t.Errorf("max gap: got %v, want 3000", attrs[AttrStreamMaxChunkGapMs])
+	}
+	for k, want := range map[string]any{
+		AttrStreamChunks:        int64(4),
+		AttrStreamDurationMs:    int64(3100),
+		AttrStreamBytes:         int64(512),
+		AttrStreamChunkGapP50Ms: 40.0,
+		AttrStreamChunkGapP90Ms: 3000.0,
+		AttrStreamChunkGapP99Ms: 3000.0,
+	} {
+		if attrs[k] != want {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], want)
+		}
+	}
+}
+
+func TestStreamState_BoundsGapSamples(t *testing.T) {
+	s := &streamState{}
+	const gaps = maxGapSamples * 3
+	for i := range gaps {
+		s.chunks = i + 1
+		s.recordGap(time.Duration(i%3000) * time.Millisecond)
+	}
+	s.chunks++
+
+	if len(s.gaps) != maxGapSamples {
+		t.Errorf("expected %d sampled gaps, got %d", maxGapSamples, len(s.gaps))
+	}
+	attrs := attrMap(s.attributes(time.Now()))
+	var total int64
+	for _, n := range attrs[AttrStreamChunkGapHistogram].([]int64) {
+		total += n
+	}
+	if total != gaps {
+		t.Errorf("the histogram should count every gap: got %d, want %d", total, gaps)
+	}
+	if attrs[AttrStreamMaxChunkGapMs] != int64(2999) {
+		t.Errorf("max gap: got %v, want 2999", attrs[AttrStreamMaxChunkGapMs])
+	}
+}
+
+func TestStreamState_SingleChunk(t *testing.T) {
+	start := time.Now()
+	s := &streamState{chunks: 1, last: start.Add(20 * time.Millisecond)}
+
+	attrs := attrMap(s.attributes(start))
+	if attrs[AttrStreamChunks] != int64(1) || attrs[AttrStreamDurationMs] != int64(20) {
+		t.Errorf("count/duration: got %v/%v", attrs[AttrStreamChunks], attrs[AttrStreamDurationMs])
+	}
+	if _, ok := attrs[AttrStreamChunkGapP50Ms]; ok {
+		t.Error("a single chunk has no gaps")
+	}
+	if _, ok := attrs[AttrStreamBytes]; ok {
+		t.Error("bytes should be omitted when not reported")
+	}
+}
+
+func TestGapPercentileMs(t *testing.T) {
+	var gaps []time.Duration
+	for i := 1; i <= 100; i++ {
+		gaps = append(gaps, time.Duration(i)*time.Millisecond)
+	}
+	for p, want := range map[int]float64{50: 50, 90: 90, 99: 99} {
+		if got := gapPercentileMs(gaps, p); got != want {
+			t.Errorf("p%d: got %v, want %v", p, got, want)
+		}
+	}
+	if got := gapPercentileMs(gaps[:1], 50); got != 1 {
+		t.Errorf("single gap: got %v", got)
+	}
+}
+
+func TestRecordChunk_SummarizedOnSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	for i := 0; i < 3; i++ {
+		llmSpan.RecordChunk()
+	}
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	span := exporter.GetSpans()[0]
+	hist, ok := attrMap(span.Attributes)[AttrStreamChunkGapHistogram].([]int64)
+	if !ok {
+		t.Fatal("expected chunk gap histogram")
+	}
+	var total int64
+	for _, n := range hist {
+		total += n
+	}
+	if total != 2 {
+		t.Errorf("expected 2 gaps for 3 chunks, got %d", total)
+	}
+	if len(span.Events) != 0 {
+		t.Errorf("expected no chunk events by default, got %d", len(span.Events))
+	}
+}
+
+func TestRecordChunk_EventsWhenEnabled(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, chunkEvents: true}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	llmSpan.RecordChunk()
+	llmSpan.RecordChunk()
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	events := exporter.GetSpans()[0].Events
+	if len(events) != 2 {
+		t.Fatalf("expected 2 chunk events, got %d", len(events))
+	}
+	if events[1].Name != streamChunkEventName || attrMap(events[1].Attributes)[AttrStreamChunkIndex] != int64(1) {
+		t.Errorf("unexpected second event: %+v", events[1])
+	}
+}
+
+func TestRecordChunk_NoStreamNoAttributes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if _, ok := attrMap(exporter.GetSpans()[0].Attributes)[AttrStreamChunkGapHistogram]; ok {
+		t.Error("expected no histogram for a non-streamed call")
+	}
+}
+
+func TestRecordChunk_NilSpanIsNoop(t *testing.T) {
+	var ls *LLMSpan
+	ls.RecordChunk()
+}
+
+// ---------------------------------------------------------------------------
+// Time to first token
+// ---------------------------------------------------------------------------
+
+func TestStreamState_LatencyAttributes(t *testing.T) {
+	start := time.Now()
+	s := &streamState{firstToken: start.Add(200 * time.Millisecond), last: start.Add(1200 * time.Millisecond)}
+
+	attrs := attrMap(s.latencyAttributes(start, 11))
+	if got := attrs[AttrGenAIServerTimeToFirstToken]; got != 0.2 {
+		t.Errorf("time to first token: got %v, want 0.2", got)
+	}
+	if got := attrs[AttrGenAIServerTimePerOutputToken]; got != 0.1 {
+		t.Errorf("time per output token: got %v, want 0.1", got)
+	}
+
+	if _, ok := attrMap(s.latencyAttributes(start, 1))[AttrGenAIServerTimePerOutputToken]; ok {
+		t.Error("time per output token needs more than one output token")
+	}
+	if attrs := (&streamState{}).latencyAttributes(start, 11); attrs != nil {
+		t.Errorf("expected no attributes without a first token, got %v", attrs)
+	}
+}
+
+func TestMarkFirstToken_RecordedOnSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	time.Sleep(5 * time.Millisecond)
+	llmSpan.MarkFirstToken()
+	first := llmSpan.stream.firstToken
+	llmSpan.MarkFirstToken()
+	if llmSpan.stream.firstToken != first {
+		t.Error("only the first call should count")
+	}
+	llmSpan.RecordChunk()
+	llmSpan.LogCompletion(Completion{}, Usage{CompletionTokens: 4})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if ttft, ok := attrs[AttrGenAIServerTimeToFirstToken].(float64); !ok || ttft < 0.005 {
+		t.Errorf("time to first token: got %v", attrs[AttrGenAIServerTimeToFirstToken])
+	}
+	if _, ok := attrs[AttrGenAIServerTimePerOutputToken].(float64); !ok {
+		t.Errorf("time per output token: got %v", attrs[AttrGenAIServerTimePerOutputToken])
+	}
+}
+
+func TestAppendDelta_MarksFirstOutputToken(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.AppendDelta(Delta{Role: "assistant"})
+	if !llmSpan.stream.firstToken.IsZero() {
+		t.Error("a role-only delta carries no token")
+	}
+	llmSpan.AppendDelta(Delta{Content: "Hi"})
+	if llmSpan.stream.firstToken.IsZero() {
+		t.Error("a content delta should mark the first token")
+	}
+	llmSpan.End()
+}
+
+func TestOpenAIStream_ReportsOutputChunks(t *testing.T) {
+	var tokens []bool
+	s := &openAIStream{onChunk: func(token bool) { tokens = append(tokens, token) }}
+	s.Write([]byte(`data: {"choices":[{"index":0,"delta":{"role":"assistant"}}]}` + "\n\n"))
+	s.Write([]byte(`data: {"choices":[{"index":0,"delta":{"content":"Hi"}}]}` + "\n\n"))
+	s.Write([]byte(`data: {"choices":[],"usage":{"prompt_tokens":1,"completion_tokens":1}}` + "\n\n"))
+
+	if len(tokens) != 3 || tokens[0] || !tokens[1] || tokens[2] {
+		t.Errorf("token flags: got %v, want [false true false]", tokens)
+	}
+}
+
+func TestMarkFirstToken_NilSpanIsNoop(t *testing.T) {
+	var ls *LLMSpan
+	ls.MarkFirstToken()
+}
+
+func TestOpenAIStream_IgnoresOutOfRangeToolCallIndex(t *testing.T) {
+	s := &openAIStream{}
+	for _, index := range []string{"-1", "1000000000"} {
+		s.Write([]byte(`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":` + index + `,"function":{"name":"x"}}]}}]}` + "\n\n"))
+	}
+	s.Write([]byte(`data: {"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"lookup"}}]}}]}` + "\n\n"))
+
+	c, _ := s.result()
+	if len(c.Messages) != 1 || len(c.Messages[0].ToolCalls) != 1 || c.Messages[0].ToolCalls[0].Function.Name != "lookup" {
+		t.Errorf("tool calls: got %+v", c.Messages)
+	}
+}
@@ -0,0 +1,93 @@
+package triage
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// envSessionTurn carries the session turn number.
+const envSessionTurn = "TRIAGE_CTX_SESSION_TURN"
+
+// envPropagator writes trace context and baggage as TRACEPARENT, TRACESTATE
+// and BAGGAGE, the OpenTelemetry convention for environment carriers, which
+// other OpenTelemetry and Triage SDKs read on startup.
+var envPropagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// SubprocessEnv returns environment entries ("KEY=value") carrying the trace
+// context and triage context (user, tenant, session, …) of ctx to an exec'd
+// subprocess, so its spans join the caller's trace:
+//
+//	cmd := exec.CommandContext(ctx, "python", "tool.py")
+//	cmd.Env = append(os.Environ(), triage.SubprocessEnv(ctx)...)
+//
+// A Go child restores the context with ContextFromEnv.
+func SubprocessEnv(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	envPropagator.Inject(ctx, carrier)
+	var env []string
+	for _, k := range envPropagator.Fields() {
+		if v := carrier.Get(k); v != "" {
+			env = append(env, strings.ToUpper(k)+"="+v)
+		}
+	}
+
+	tc := getFromContext(ctx)
+	for _, f := range envContextFields {
+		if v := *f.field(&tc); v != "" {
+			env = append(env, f.env+"="+v)
+		}
+	}
+	if tc.sessionTurnNumber != nil {
+		env = append(env, envSessionTurn+"="+strconv.Itoa(*tc.sessionTurnNumber))
+	}
+	return env
+}
+
+// ContextFromEnv returns ctx carrying the trace context and triage context a
+// parent process passed via SubprocessEnv (or another Triage SDK), read from
+// this process's environment. Spans started from the returned context are
+// children of the parent's span. Values already set on ctx take precedence.
+//
+//	shutdown, _ := triage.Init()
+//	defer shutdown()
+//	ctx := triage.ContextFromEnv(context.Background())
+func ContextFromEnv(ctx context.Context) context.Context {
+	return contextFromLookup(ctx, os.Getenv)
+}
+
+// contextFromLookup implements ContextFromEnv over an environment lookup.
+func contextFromLookup(ctx context.Context, getenv func(string) string) context.Context {
+	carrier := propagation.MapCarrier{}
+	for _, k := range envPropagator.Fields() {
+		if v := getenv(strings.ToUpper(k)); v != "" {
+			carrier.Set(k, v)
+		}
+	}
+	if len(carrier) > 0 && !trace.SpanContextFromContext(ctx).IsValid() {
+		ctx = envPropagator.Extract(ctx, carrier)
+	}
+
+	tc := getFromContext(ctx).clone()
+	changed := false
+	for _, f := range envContextFields {
+		if p := f.field(&tc); *p == "" {
+			if v := getenv(f.env); v != "" {
+				*p, changed = v, true
+			}
+		}
+	}
+	if tc.sessionTurnNumber == nil {
+		if n, err := strconv.Atoi(getenv(envSessionTurn)); err == nil {
+			tc.sessionTurnNumber, changed = &n, true
+		}
+	}
+	if !changed {
+		return ctx
+	}
+	return setInContext(ctx, tc)
+}
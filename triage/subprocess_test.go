@@ -0,0 +1,94 @@
+package triage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// envLookup turns SubprocessEnv output into a getenv function.
+func envLookup(env []string) func(string) string {
+	m := make(map[string]string, len(env))
+	for _, kv := range env {
+		k, v, _ := strings.Cut(kv, "=")
+		m[k] = v
+	}
+	return func(k string) string { return m[k] }
+}
+
+func TestSubprocessEnv_RoundTrip(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	ctx := WithUser(context.Background(), "u_1", UserRole("admin"))
+	ctx = WithTenant(ctx, "org_9")
+	ctx = WithSession(ctx, "sess_7", TurnNumber(3))
+	ctx = WithTrafficClass(ctx, TrafficShadow)
+	ctx = WithInput(ctx, "secret prompt")
+	wf, ctx := StartWorkflow(ctx, "parent")
+	env := SubprocessEnv(ctx)
+	wf.End()
+
+	for _, kv := range env {
+		if strings.Contains(kv, "secret prompt") {
+			t.Fatalf("raw input must not be bridged: %q", kv)
+		}
+	}
+
+	child := contextFromLookup(context.Background(), envLookup(env))
+	tc := getFromContext(child)
+	if tc.userID != "u_1" || tc.userRole != "admin" || tc.tenantID != "org_9" || tc.sessionID != "sess_7" {
+		t.Errorf("unexpected triage context: %+v", tc)
+	}
+	if tc.sessionTurnNumber == nil || *tc.sessionTurnNumber != 3 {
+		t.Errorf("turn number not bridged: %v", tc.sessionTurnNumber)
+	}
+	if tc.trafficClass != TrafficShadow {
+		t.Errorf("traffic class not bridged: %q", tc.trafficClass)
+	}
+
+	sc := trace.SpanContextFromContext(child)
+	parent := exporter.GetSpans()[0].SpanContext
+	if !sc.IsRemote() || sc.TraceID() != parent.TraceID() || sc.SpanID() != parent.SpanID() {
+		t.Errorf("child span context %v does not continue parent %v", sc, parent)
+	}
+}
+
+func TestContextFromEnv_ExistingValuesWin(t *testing.T) {
+	env := envLookup([]string{"TRIAGE_CTX_USER_ID=from-env", "TRIAGE_CTX_TENANT_ID=org_1"})
+
+	ctx := contextFromLookup(WithUser(context.Background(), "explicit"), env)
+	tc := getFromContext(ctx)
+	if tc.userID != "explicit" || tc.tenantID != "org_1" {
+		t.Errorf("got user %q tenant %q", tc.userID, tc.tenantID)
+	}
+}
+
+func TestContextFromEnv_Empty(t *testing.T) {
+	ctx := context.Background()
+	if got := contextFromLookup(ctx, func(string) string { return "" }); got != ctx {
+		t.Error("expected ctx unchanged with no bridged variables")
+	}
+}
+
+func TestContextFromEnv_ReadsProcessEnv(t *testing.T) {
+	t.Setenv("TRACEPARENT", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	t.Setenv("TRIAGE_CTX_SESSION_ID", "sess_py")
+
+	ctx := ContextFromEnv(context.Background())
+	if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID: got %s", got)
+	}
+	if getFromContext(ctx).sessionID != "sess_py" {
+		t.Error("session not read from environment")
+	}
+}
+
+func TestContextFields_CarriedByPropagatorAndEnv(t *testing.T) {
+	for _, f := range contextFields {
+		if f.header == "" || f.env == "" {
+			t.Errorf("context field (header %q, env %q) should be carried by both", f.header, f.env)
+		}
+	}
+}
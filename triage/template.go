@@ -0,0 +1,130 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"text/template"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// registeredTemplate is a parsed prompt template and its version.
+type registeredTemplate struct {
+	tmpl    *template.Template
+	version string
+}
+
+// templates is the process-wide prompt template registry used by
+// RenderTemplate.
+var templates = struct {
+	sync.RWMutex
+	byID map[string]registeredTemplate
+}{byID: map[string]registeredTemplate{}}
+
+// RegisterTemplate parses text as a text/template and registers it under
+// templateID for RenderTemplate. Registering an ID again replaces the
+// previous template. Executing the template fails on missing variables
+// rather than rendering "<no value>" into the prompt.
+func RegisterTemplate(templateID, text string, opts ...TemplateOption) error {
+	if templateID == "" {
+		return errors.New("triage: RegisterTemplate requires a template ID")
+	}
+	tmpl, err := template.New(templateID).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("triage: template %q: %w", templateID, err)
+	}
+	var tc triageContext
+	for _, o := range opts {
+		o(&tc)
+	}
+
+	templates.Lock()
+	templates.byID[templateID] = registeredTemplate{tmpl: tmpl, version: tc.templateVersion}
+	templates.Unlock()
+	return nil
+}
+
+// RenderTemplate renders the template registered under templateID with vars
+// and returns the prompt text with a context annotated as by WithTemplate.
+// All spans created from the returned context also record the variable names
+// (triage.template.variables). The current span and LLM spans started from
+// the context, which use the prompt, also record each variable's
+// PII-redacted value — or only a SHA-256 digest of it when trace content is
+// disabled — so a prompt can be traced back to the inputs that produced it:
+//
+//	text, ctx, err := triage.RenderTemplate(ctx, "support-answer", map[string]any{
+//		"Question": question,
+//		"Context":  docs,
+//	})
+func RenderTemplate(ctx context.Context, templateID string, vars map[string]any) (string, context.Context, error) {
+	templates.RLock()
+	rt, ok := templates.byID[templateID]
+	templates.RUnlock()
+	if !ok {
+		return "", ctx, fmt.Errorf("triage: unknown template %q", templateID)
+	}
+
+	var buf bytes.Buffer
+	if err := rt.tmpl.Execute(&buf, vars); err != nil {
+		return "", ctx, fmt.Errorf("triage: render template %q: %w", templateID, err)
+	}
+
+	var opts []TemplateOption
+	if rt.version != "" {
+		opts = append(opts, TemplateVersion(rt.version))
+	}
+	ctx = WithTemplate(ctx, templateID, opts...)
+
+	tc := getFromContext(ctx).clone()
+	tc.templateVars, tc.templateValues = templateVarAttributes(vars)
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(tc.templateVars...)
+		span.SetAttributes(tc.templateValues...)
+	}
+	return buf.String(), setInContext(ctx, tc), nil
+}
+
+// templateVarAttributes returns the sorted variable names and one attribute
+// per variable: the redacted value when trace content is enabled (with a
+// redaction audit of what was removed), otherwise a short SHA-256 digest
+// that still allows matching equal inputs.
+func templateVarAttributes(vars map[string]any) (names, values []attribute.KeyValue) {
+	keys := make([]string, 0, len(vars))
+	for name := range vars {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+
+	content := isTraceContentEnabled()
+	for _, name := range keys {
+		key := AttrTemplateVariablePrefix + name
+		value := templateVarString(vars[name])
+		if content {
+			var matches []redactionMatch
+			value, matches = redact(value, defaultPIIDetectors)
+			values = append(values, redactionAttributes(key, matches)...)
+		} else {
+			value = contentDigest(value)
+		}
+		values = append(values, attribute.String(key, value))
+	}
+	return []attribute.KeyValue{attribute.StringSlice(AttrTemplateVariables, keys)}, values
+}
+
+// templateVarString renders a variable value for capture: strings as-is,
+// other values JSON-encoded.
+func templateVarString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := safeMarshal("RenderTemplate", v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
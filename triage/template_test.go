@@ -0,0 +1,142 @@
+package triage
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestRenderTemplate_RendersAndAnnotates(t *testing.T) {
+	if err := RegisterTemplate("greet", "Hello {{.Name}}, reply to {{.Email}}.", TemplateVersion("v2")); err != nil {
+		t.Fatal(err)
+	}
+
+	text, ctx, err := RenderTemplate(context.Background(), "greet", map[string]any{
+		"Name":  "Ada",
+		"Email": "ada@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "Hello Ada, reply to ada@example.com." {
+		t.Errorf("rendered: got %q", text)
+	}
+
+	attrs := renderedTemplateAttrs(ctx)
+	if attrs[AttrTemplateID] != "greet" || attrs[AttrTemplateVersion] != "v2" {
+		t.Errorf("template id/version: got %v/%v", attrs[AttrTemplateID], attrs[AttrTemplateVersion])
+	}
+	if !reflect.DeepEqual(attrs[AttrTemplateVariables], []string{"Email", "Name"}) {
+		t.Errorf("variables: got %v", attrs[AttrTemplateVariables])
+	}
+	if attrs[AttrTemplateVariablePrefix+"Name"] != "Ada" {
+		t.Errorf("Name value: got %v", attrs[AttrTemplateVariablePrefix+"Name"])
+	}
+	if email, _ := attrs[AttrTemplateVariablePrefix+"Email"].(string); strings.Contains(email, "ada@example.com") {
+		t.Errorf("Email value should be redacted: %q", email)
+	}
+}
+
+// renderedTemplateAttrs returns the attributes an LLM span started from ctx
+// records for the rendered template.
+func renderedTemplateAttrs(ctx context.Context) map[string]any {
+	return attrMap(append(getTriageAttrs(ctx), getFromContext(ctx).templateValues...))
+}
+
+func TestRenderTemplate_ValuesOnlyOnSpansUsingThePrompt(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	if err := RegisterTemplate("greet", "Hello {{.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	wf, ctx := StartWorkflow(context.Background(), "chat")
+	_, ctx, err := RenderTemplate(ctx, "greet", map[string]any{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, tool := otel.Tracer("test").Start(ctx, "tool")
+	tool.End()
+	llm, _ := LogPrompt(ctx, Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llm.LogCompletion(Completion{}, Usage{})
+	wf.End()
+
+	key := AttrTemplateVariablePrefix + "Name"
+	for _, s := range exporter.GetSpans() {
+		attrs := attrMap(s.Attributes)
+		if !reflect.DeepEqual(attrs[AttrTemplateVariables], []string{"Name"}) {
+			t.Errorf("%s: variables: got %v", s.Name, attrs[AttrTemplateVariables])
+		}
+		_, hasValue := attrs[key]
+		if wantValue := s.Name != "tool"; hasValue != wantValue {
+			t.Errorf("%s: value recorded = %v, want %v", s.Name, hasValue, wantValue)
+		}
+	}
+}
+
+func TestRenderTemplate_RecordsRedactionAudit(t *testing.T) {
+	if err := RegisterTemplate("contact", "Reach {{.Who}}.", TemplateVersion("v1")); err != nil {
+		t.Fatal(err)
+	}
+	_, ctx, err := RenderTemplate(context.Background(), "contact", map[string]any{"Who": "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := renderedTemplateAttrs(ctx)
+	prefix := AttrRedactionPrefix + "template.var.Who"
+	if attrs[AttrRedactionApplied] != true || attrs[prefix+".count"] != int64(1) {
+		t.Errorf("audit: got %v", attrs)
+	}
+	if !reflect.DeepEqual(attrs[prefix+".positions"], []string{"email:0-15"}) {
+		t.Errorf("positions: got %v", attrs[prefix+".positions"])
+	}
+}
+
+func TestRenderTemplate_HashesValuesWithoutTraceContent(t *testing.T) {
+	t.Cleanup(func() { globalCfg = nil })
+	globalCfg = &config{traceContent: false}
+	if err := RegisterTemplate("ask", "{{.Question}}"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, ctx, err := RenderTemplate(context.Background(), "ask", map[string]any{"Question": "what is my balance?"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ := renderedTemplateAttrs(ctx)[AttrTemplateVariablePrefix+"Question"].(string)
+	if !strings.HasPrefix(v, "sha256:") || strings.Contains(v, "balance") {
+		t.Errorf("expected a digest, got %q", v)
+	}
+}
+
+func TestRenderTemplate_Errors(t *testing.T) {
+	if _, _, err := RenderTemplate(context.Background(), "no-such-template", nil); err == nil {
+		t.Error("expected an error for an unknown template")
+	}
+	if err := RegisterTemplate("strict", "{{.Missing}}"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := RenderTemplate(context.Background(), "strict", map[string]any{}); err == nil {
+		t.Error("expected an error for a missing variable")
+	}
+	if err := RegisterTemplate("broken", "{{.Unclosed"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestWithTemplate_ClearsRenderedVariables(t *testing.T) {
+	if err := RegisterTemplate("greet", "Hello {{.Name}}"); err != nil {
+		t.Fatal(err)
+	}
+	_, ctx, err := RenderTemplate(context.Background(), "greet", map[string]any{"Name": "Ada"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx = WithTemplate(ctx, "other")
+	if _, ok := attrMap(getTriageAttrs(ctx))[AttrTemplateVariables]; ok {
+		t.Error("WithTemplate should drop the previous template's variables")
+	}
+}
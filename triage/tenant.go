@@ -0,0 +1,222 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// In-memory bounds for per-tenant exporters, each of which holds its own
+// HTTP client. Beyond tenantMaxExporters, exporters idle longer than
+// tenantExporterIdleTTL are evicted, or if none are, the least recently used
+// one. Evicted exporters are shut down and their tenants re-resolved when
+// next seen.
+const (
+	tenantMaxExporters    = 256
+	tenantExporterIdleTTL = 30 * time.Minute
+)
+
+// TenantCredentials are the Triage credentials a tenant's traces are exported
+// with. An empty Endpoint uses the SDK's configured endpoint.
+type TenantCredentials struct {
+	APIKey   string
+	Endpoint string
+}
+
+// TenantKeyResolver maps a tenant ID (see WithTenant) to that tenant's
+// credentials. Returning false exports the tenant's spans with the SDK's own
+// credentials.
+type TenantKeyResolver func(tenantID string) (TenantCredentials, bool)
+
+// WithTenantKeyResolver routes each span to the credentials resolve returns
+// for its triage.tenant.id, so a multi-tenant platform can export each
+// tenant's traces under the tenant's own Triage account and data boundary.
+// The resolver is called once per tenant while the tenant's exporter is
+// cached; the least recently used exporters are shut down once many tenants
+// are active. Spans without a tenant use the SDK's own credentials.
+func WithTenantKeyResolver(resolve TenantKeyResolver) Option {
+	return func(c *config) { c.tenantResolver = resolve }
+}
+
+// tenantRouter is a SpanExporter that partitions each batch by tenant and
+// exports every partition through that tenant's exporter. It reads the
+// tenant from the default triage.tenant.id key, so it must see spans before
+// WithAttributeNamespace renames them: the fallback is passed in already
+// wrapped, and tenant exporters are wrapped as they are created.
+type tenantRouter struct {
+	fallback    sdktrace.SpanExporter
+	resolve     TenantKeyResolver
+	endpoint    string // default endpoint for credentials without one
+	insecure    bool
+	ns          string // attribute namespace applied after routing
+	newExporter func(ctx context.Context, endpoint, apiKey string) (sdktrace.SpanExporter, error)
+
+	mu        sync.Mutex
+	exporters map[string]*tenantExporter
+	retired   []sdktrace.SpanExporter // evicted, shut down after the current export
+	now       func() time.Time
+}
+
+// tenantExporter is the cached exporter of one tenant.
+type tenantExporter struct {
+	exp      sdktrace.SpanExporter // fallback if the tenant is unresolved
+	lastUsed time.Time
+}
+
+func newTenantRouter(fallback sdktrace.SpanExporter, cfg *config) *tenantRouter {
+	return &tenantRouter{
+		fallback: fallback,
+		resolve:  cfg.tenantResolver,
+		endpoint: cfg.endpoint,
+		insecure: cfg.insecure,
+		ns:       cfg.attrNamespace,
+		newExporter: func(ctx context.Context, endpoint, apiKey string) (sdktrace.SpanExporter, error) {
+			return newOTLPExporter(ctx, cfg, endpoint, apiKey)
+		},
+		exporters: make(map[string]*tenantExporter),
+		now:       time.Now,
+	}
+}
+
+// ExportSpans exports each tenant's spans through its exporter. If only some
+// tenants fail, the error reports how many spans were dropped, so the
+// others are still counted as exported.
+func (r *tenantRouter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	batches := make(map[sdktrace.SpanExporter][]sdktrace.ReadOnlySpan)
+	var order []sdktrace.SpanExporter
+	var errs []error
+	dropped := 0
+	for _, s := range spans {
+		exp, err := r.exporterFor(ctx, spanTenant(s))
+		if err != nil {
+			errs = append(errs, err)
+			dropped++
+			continue
+		}
+		if _, ok := batches[exp]; !ok {
+			order = append(order, exp)
+		}
+		batches[exp] = append(batches[exp], s)
+	}
+	for _, exp := range order {
+		if err := exp.ExportSpans(ctx, batches[exp]); err != nil {
+			errs = append(errs, err)
+			dropped += len(batches[exp])
+		}
+	}
+	r.shutdownRetired(ctx)
+
+	err := errors.Join(errs...)
+	if err != nil && dropped < len(spans) {
+		return &partialExportError{err: err, dropped: dropped}
+	}
+	return err
+}
+
+// shutdownRetired shuts down the exporters evicted since the last call, once
+// the spans already routed to them have been exported.
+func (r *tenantRouter) shutdownRetired(ctx context.Context) {
+	r.mu.Lock()
+	retired := r.retired
+	r.retired = nil
+	r.mu.Unlock()
+	for _, exp := range retired {
+		if err := exp.Shutdown(ctx); err != nil {
+			otel.Handle(err)
+		}
+	}
+}
+
+// exporterFor returns the exporter for tenantID, creating it on first use.
+func (r *tenantRouter) exporterFor(ctx context.Context, tenantID string) (sdktrace.SpanExporter, error) {
+	if tenantID == "" {
+		return r.fallback, nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if te, ok := r.exporters[tenantID]; ok {
+		te.lastUsed = now
+		return te.exp, nil
+	}
+
+	exp := r.fallback
+	if creds, ok := r.resolve(tenantID); ok && creds.APIKey != "" {
+		endpoint := creds.Endpoint
+		if endpoint == "" {
+			endpoint = r.endpoint
+		}
+		var err error
+		exp, err = r.newExporter(ctx, normalizeEndpoint(endpoint, r.insecure), creds.APIKey)
+		if err != nil {
+			return nil, err
+		}
+		exp = withNamespace(exp, r.ns)
+	}
+	if len(r.exporters) >= tenantMaxExporters {
+		r.evictLocked(now)
+	}
+	r.exporters[tenantID] = &tenantExporter{exp: exp, lastUsed: now}
+	return exp, nil
+}
+
+// evictLocked drops idle tenants' exporters, and if none were idle, the
+// least recently used one, retiring them for shutdown. r.mu must be held.
+func (r *tenantRouter) evictLocked(now time.Time) {
+	var oldestID string
+	var oldest time.Time
+	evicted := false
+	for id, te := range r.exporters {
+		if now.Sub(te.lastUsed) > tenantExporterIdleTTL {
+			r.retireLocked(id)
+			evicted = true
+			continue
+		}
+		if oldestID == "" || te.lastUsed.Before(oldest) {
+			oldestID, oldest = id, te.lastUsed
+		}
+	}
+	if !evicted && oldestID != "" {
+		r.retireLocked(oldestID)
+	}
+}
+
+// retireLocked removes the tenant's exporter, queueing it for shutdown
+// unless it is the fallback. r.mu must be held.
+func (r *tenantRouter) retireLocked(tenantID string) {
+	if exp := r.exporters[tenantID].exp; exp != r.fallback {
+		r.retired = append(r.retired, exp)
+	}
+	delete(r.exporters, tenantID)
+}
+
+func (r *tenantRouter) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errs := []error{r.fallback.Shutdown(ctx)}
+	for _, exp := range r.retired {
+		errs = append(errs, exp.Shutdown(ctx))
+	}
+	for _, te := range r.exporters {
+		if te.exp != r.fallback {
+			errs = append(errs, te.exp.Shutdown(ctx))
+		}
+	}
+	r.exporters = make(map[string]*tenantExporter)
+	r.retired = nil
+	return errors.Join(errs...)
+}
+
+// spanTenant returns the span's triage.tenant.id attribute, or "".
+func spanTenant(s sdktrace.ReadOnlySpan) string {
+	for _, kv := range s.Attributes() {
+		if string(kv.Key) == AttrTenantID {
+			return kv.Value.AsString()
+		}
+	}
+	return ""
+}
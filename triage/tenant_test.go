@@ -0,0 +1,245 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestTenantRouter returns a tenantRouter whose per-tenant exporters are
+// in-memory, keyed by "endpoint|apiKey".
+func newTestTenantRouter(t *testing.T, resolve TenantKeyResolver) (*tenantRouter, *tracetest.InMemoryExporter, map[string]*tracetest.InMemoryExporter) {
+	t.Helper()
+	fallback := tracetest.NewInMemoryExporter()
+	created := make(map[string]*tracetest.InMemoryExporter)
+	r := newTenantRouter(fallback, &config{endpoint: "https://default.example", tenantResolver: resolve})
+	r.newExporter = func(_ context.Context, endpoint, apiKey string) (sdktrace.SpanExporter, error) {
+		exp := tracetest.NewInMemoryExporter()
+		created[endpoint+"|"+apiKey] = exp
+		return exp, nil
+	}
+	return r, fallback, created
+}
+
+func TestTenantRouter_RoutesByTenant(t *testing.T) {
+	resolver := func(tenantID string) (TenantCredentials, bool) {
+		switch tenantID {
+		case "acme":
+			return TenantCredentials{APIKey: "tsk_acme"}, true
+		case "globex":
+			return TenantCredentials{APIKey: "tsk_globex", Endpoint: "https://eu.example/"}, true
+		}
+		return TenantCredentials{}, false
+	}
+	router, fallback, created := newTestTenantRouter(t, resolver)
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSyncer(router),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	tracer := tp.Tracer("test")
+
+	for _, tenant := range []string{"acme", "globex", "acme", "unknown", ""} {
+		ctx := context.Background()
+		if tenant != "" {
+			ctx = WithTenant(ctx, tenant)
+		}
+		_, span := tracer.Start(ctx, "op")
+		span.End()
+	}
+
+	if got := len(created["https://default.example|tsk_acme"].GetSpans()); got != 2 {
+		t.Errorf("acme spans: got %d, want 2", got)
+	}
+	if got := len(created["https://eu.example|tsk_globex"].GetSpans()); got != 1 {
+		t.Errorf("globex spans: got %d, want 1", got)
+	}
+	if got := len(fallback.GetSpans()); got != 2 {
+		t.Errorf("fallback spans: got %d, want 2", got)
+	}
+	if len(created) != 2 {
+		t.Errorf("expected one exporter per resolved tenant, got %d", len(created))
+	}
+}
+
+func TestTenantRouter_ResolverCalledOncePerTenant(t *testing.T) {
+	calls := 0
+	router, _, _ := newTestTenantRouter(t, func(string) (TenantCredentials, bool) {
+		calls++
+		return TenantCredentials{}, false
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := router.exporterFor(context.Background(), "acme"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("resolver calls: got %d, want 1", calls)
+	}
+}
+
+func TestTenantRouter_WithAttributeNamespace(t *testing.T) {
+	resolver := func(tenantID string) (TenantCredentials, bool) {
+		return TenantCredentials{APIKey: "tsk_" + tenantID}, tenantID == "acme"
+	}
+	fallback := tracetest.NewInMemoryExporter()
+	tenantExp := tracetest.NewInMemoryExporter()
+	cfg := &config{endpoint: "https://default.example", tenantResolver: resolver, attrNamespace: "acme.triage"}
+	router := newTenantRouter(withNamespace(fallback, cfg.attrNamespace), cfg)
+	router.newExporter = func(context.Context, string, string) (sdktrace.SpanExporter, error) {
+		return tenantExp, nil
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithSyncer(router),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	_, span := tp.Tracer("test").Start(WithTenant(context.Background(), "acme"), "op")
+	span.End()
+	_, span = tp.Tracer("test").Start(context.Background(), "op")
+	span.End()
+
+	spans := tenantExp.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("tenant spans: got %d, want 1 (fallback got %d)", len(spans), len(fallback.GetSpans()))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["acme.triage.tenant.id"] != "acme" {
+		t.Errorf("tenant spans should be renamed after routing, got %v", attrs)
+	}
+	if _, ok := attrs[AttrTenantID]; ok {
+		t.Errorf("unexpected %s under a custom namespace", AttrTenantID)
+	}
+	if got := len(fallback.GetSpans()); got != 1 {
+		t.Errorf("fallback spans: got %d, want 1", got)
+	}
+}
+
+// shutdownRecorder is an in-memory exporter that records whether it was shut
+// down.
+type shutdownRecorder struct {
+	*tracetest.InMemoryExporter
+	shutdown bool
+}
+
+func (e *shutdownRecorder) Shutdown(ctx context.Context) error {
+	e.shutdown = true
+	return e.InMemoryExporter.Shutdown(ctx)
+}
+
+func TestTenantRouter_EvictsLeastRecentlyUsed(t *testing.T) {
+	router, _, _ := newTestTenantRouter(t, func(tenantID string) (TenantCredentials, bool) {
+		return TenantCredentials{APIKey: "tsk_" + tenantID}, true
+	})
+	created := make(map[string]*shutdownRecorder)
+	router.newExporter = func(_ context.Context, _, apiKey string) (sdktrace.SpanExporter, error) {
+		exp := &shutdownRecorder{InMemoryExporter: tracetest.NewInMemoryExporter()}
+		created[apiKey] = exp
+		return exp, nil
+	}
+	now := time.Unix(0, 0)
+	router.now = func() time.Time { return now }
+
+	for i := range tenantMaxExporters {
+		now = now.Add(time.Second)
+		if _, err := router.exporterFor(context.Background(), fmt.Sprintf("t%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Touch t0 so that t1 is the least recently used.
+	now = now.Add(time.Second)
+	if _, err := router.exporterFor(context.Background(), "t0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := router.exporterFor(context.Background(), "new"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(router.exporters) != tenantMaxExporters {
+		t.Errorf("cached exporters: got %d, want %d", len(router.exporters), tenantMaxExporters)
+	}
+	if _, ok := router.exporters["t1"]; ok {
+		t.Error("expected the least recently used tenant to be evicted")
+	}
+	if _, ok := router.exporters["t0"]; !ok {
+		t.Error("recently used tenant should stay cached")
+	}
+	if created["tsk_t1"].shutdown {
+		t.Error("evicted exporter should not be shut down before the current export finishes")
+	}
+	router.shutdownRetired(context.Background())
+	if !created["tsk_t1"].shutdown {
+		t.Error("expected the evicted exporter to be shut down")
+	}
+	if created["tsk_t0"].shutdown {
+		t.Error("cached exporter should not be shut down")
+	}
+}
+
+func TestTenantRouter_EvictsIdleTenants(t *testing.T) {
+	router, _, _ := newTestTenantRouter(t, func(tenantID string) (TenantCredentials, bool) {
+		return TenantCredentials{APIKey: "tsk_" + tenantID}, true
+	})
+	now := time.Unix(0, 0)
+	router.now = func() time.Time { return now }
+
+	for i := range tenantMaxExporters {
+		if _, err := router.exporterFor(context.Background(), fmt.Sprintf("t%d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(tenantExporterIdleTTL + time.Second)
+	if _, err := router.exporterFor(context.Background(), "new"); err != nil {
+		t.Fatal(err)
+	}
+	if len(router.exporters) != 1 {
+		t.Errorf("cached exporters: got %d, want 1", len(router.exporters))
+	}
+	if len(router.retired) != tenantMaxExporters {
+		t.Errorf("retired exporters: got %d, want %d", len(router.retired), tenantMaxExporters)
+	}
+}
+
+func TestTenantRouter_CountsDropsPerTenant(t *testing.T) {
+	router, fallback, _ := newTestTenantRouter(t, func(tenantID string) (TenantCredentials, bool) {
+		return TenantCredentials{APIKey: "tsk_" + tenantID}, tenantID == "acme"
+	})
+	router.newExporter = func(context.Context, string, string) (sdktrace.SpanExporter, error) {
+		return &failingExporter{err: errors.New("connection refused")}, nil
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(&triageSpanProcessor{}),
+		sdktrace.WithBatcher(&countingExporter{SpanExporter: router}, sdktrace.WithBatchTimeout(time.Hour)),
+	)
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+	before := CurrentStats()
+
+	for _, tenant := range []string{"acme", "", "", "acme", ""} {
+		ctx := context.Background()
+		if tenant != "" {
+			ctx = WithTenant(ctx, tenant)
+		}
+		_, span := tp.Tracer("test").Start(ctx, "op")
+		span.End()
+	}
+	// The flush reports acme's failure; the counters are what matter here.
+	_ = tp.ForceFlush(context.Background())
+
+	after := CurrentStats()
+	if got := after.SpansDropped - before.SpansDropped; got != 2 {
+		t.Errorf("dropped: got %d, want 2", got)
+	}
+	if got := after.SpansExported - before.SpansExported; got != 3 {
+		t.Errorf("exported: got %d, want 3", got)
+	}
+	if got := len(fallback.GetSpans()); got != 3 {
+		t.Errorf("fallback spans: got %d, want 3", got)
+	}
+}
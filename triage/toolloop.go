@@ -0,0 +1,161 @@
+package triage
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Tool loop termination reasons, recorded as triage.loop.stop_reason.
+const (
+	LoopStopCompleted     = "completed"      // the model answered without requesting tools
+	LoopStopMaxIterations = "max_iterations" // MaxIterations model calls were made
+	LoopStopModelError    = "model_error"    // callModel returned an error
+	LoopStopCancelled     = "cancelled"      // ctx was cancelled between steps
+)
+
+// defaultMaxLoopIterations bounds RunToolLoop when MaxIterations is unset.
+const defaultMaxLoopIterations = 10
+
+// ToolFunc executes one tool call and returns the result passed back to the
+// model. A returned error is reported to the model as the tool result
+// ("error: ...") and the loop continues.
+type ToolFunc func(ctx context.Context, call ToolCall) (string, error)
+
+// ToolLoopOptions configures RunToolLoop.
+type ToolLoopOptions struct {
+	Name          string // Agent span name; defaults to "tool-loop"
+	Prompt        Prompt // Initial request: vendor, model, messages and tool definitions
+	MaxIterations int    // Maximum model calls; defaults to 10
+}
+
+// ToolLoopResult is the outcome of RunToolLoop.
+type ToolLoopResult struct {
+	Completion Completion // Final model response
+	Messages   []Message  // Full conversation, including tool calls and results
+	Usage      Usage      // Token usage summed over all model calls
+	Iterations int        // Number of model calls made
+	ToolCalls  int        // Number of tools executed
+	StopReason string     // One of the LoopStop* constants
+}
+
+// RunToolLoop drives the standard agent loop: call the model, execute the
+// tools it requests, append their results and call the model again, until it
+// answers without requesting tools or MaxIterations is reached. Tool calls
+// are read from the last message of each completion.
+//
+// The loop runs under an agent span with one child LLM span per model call
+// and one tool span per tool call, each tagged with its iteration. The agent
+// span records aggregated token usage, the number of iterations and tool
+// calls, and why the loop stopped:
+//
+//	res, err := triage.RunToolLoop(ctx, triage.ToolLoopOptions{
+//	    Name:   "support-agent",
+//	    Prompt: triage.Prompt{Vendor: "openai", Model: "gpt-4o", Messages: msgs, Tools: tools},
+//	}, callOpenAI, runTool)
+//
+// Returns the error from callModel, or ctx.Err() if ctx is cancelled; the
+// result then holds the conversation so far.
+func RunToolLoop(ctx context.Context, opts ToolLoopOptions, callModel ModelFunc, executeTool ToolFunc) (ToolLoopResult, error) {
+	name := opts.Name
+	if name == "" {
+		name = "tool-loop"
+	}
+	maxIter := opts.MaxIterations
+	if maxIter <= 0 {
+		maxIter = defaultMaxLoopIterations
+	}
+
+	agent, ctx := StartAgent(ctx, name)
+	defer agent.End()
+
+	res := ToolLoopResult{Messages: append([]Message(nil), opts.Prompt.Messages...)}
+	defer func() {
+		if agent.span != nil {
+			agent.span.SetAttributes(append(loopUsageAttributes(res.Usage),
+				attribute.Int(AttrLoopIterations, res.Iterations),
+				attribute.Int(AttrLoopToolCalls, res.ToolCalls),
+				attribute.String(AttrLoopStopReason, res.StopReason),
+			)...)
+		}
+	}()
+
+	for res.Iterations < maxIter {
+		if err := ctx.Err(); err != nil {
+			res.StopReason = LoopStopCancelled
+			return res, err
+		}
+		res.Iterations++
+		iterAttr := attribute.Int(AttrLoopIteration, res.Iterations)
+
+		prompt := opts.Prompt
+		prompt.Messages = res.Messages
+		llmSpan, llmCtx := LogPrompt(ctx, prompt)
+		if llmSpan.span != nil {
+			llmSpan.span.SetAttributes(iterAttr)
+		}
+		completion, usage, err := callModel(llmCtx, prompt)
+		if err != nil {
+			llmSpan.SetError(err)
+			llmSpan.End()
+			res.StopReason = LoopStopModelError
+			return res, err
+		}
+		llmSpan.LogCompletion(completion, usage)
+
+		res.Completion = completion
+		res.Usage = addUsage(res.Usage, usage)
+		res.Messages = append(res.Messages, completion.Messages...)
+
+		var calls []ToolCall
+		if n := len(completion.Messages); n > 0 {
+			calls = completion.Messages[n-1].ToolCalls
+		}
+		if len(calls) == 0 {
+			res.StopReason = LoopStopCompleted
+			return res, nil
+		}
+
+		for _, call := range calls {
+			tool, toolCtx := StartTool(ctx, call.Function.Name, ToolInput(call.Function.Arguments))
+			if tool.span != nil {
+				tool.span.SetAttributes(iterAttr)
+			}
+			out, err := executeTool(toolCtx, call)
+			tool.SetResult(out, err)
+			if err != nil {
+				out = "error: " + err.Error()
+			}
+			tool.End()
+			res.ToolCalls++
+			res.Messages = append(res.Messages, Message{Role: "tool", Content: out, ToolCallID: call.ID})
+		}
+	}
+	res.StopReason = LoopStopMaxIterations
+	return res, nil
+}
+
+// addUsage returns the field-wise sum of two usages.
+func addUsage(a, b Usage) Usage {
+	return Usage{
+		PromptTokens:      a.PromptTokens + b.PromptTokens,
+		CompletionTokens:  a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:       a.TotalTokens + b.TotalTokens,
+		CacheReadTokens:   a.CacheReadTokens + b.CacheReadTokens,
+		CacheWriteTokens:  a.CacheWriteTokens + b.CacheWriteTokens,
+		ReasoningTokens:   a.ReasoningTokens + b.ReasoningTokens,
+		AudioInputTokens:  a.AudioInputTokens + b.AudioInputTokens,
+		AudioOutputTokens: a.AudioOutputTokens + b.AudioOutputTokens,
+		ImageTokens:       a.ImageTokens + b.ImageTokens,
+	}
+}
+
+// loopUsageAttributes returns the aggregated usage attributes for a loop's
+// agent span.
+func loopUsageAttributes(u Usage) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.Int(AttrLoopInputTokens, u.PromptTokens),
+		attribute.Int(AttrLoopOutputTokens, u.CompletionTokens),
+		attribute.Int(AttrLoopTotalTokens, u.TotalTokens),
+	}
+}
@@ -0,0 +1,130 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// scriptedModel returns the given completions in order, one per call.
+func scriptedModel(completions ...Completion) ModelFunc {
+	i := 0
+	return func(ctx context.Context, prompt Prompt) (Completion, Usage, error) {
+		c := completions[i]
+		i++
+		return c, Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, nil
+	}
+}
+
+func toolCallCompletion(name string) Completion {
+	return Completion{Messages: []Message{{
+		Role:      "assistant",
+		ToolCalls: []ToolCall{{ID: "call_" + name, Type: "function", Function: ToolCallFunction{Name: name, Arguments: "{}"}}},
+	}}}
+}
+
+func TestRunToolLoop_CompletesAfterTools(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	model := scriptedModel(
+		toolCallCompletion("search"),
+		Completion{Messages: []Message{{Role: "assistant", Content: "done"}}},
+	)
+	tool := func(ctx context.Context, call ToolCall) (string, error) {
+		if call.Function.Name != "search" {
+			t.Errorf("unexpected tool %q", call.Function.Name)
+		}
+		return "3 results", nil
+	}
+
+	res, err := RunToolLoop(context.Background(), ToolLoopOptions{
+		Name:   "support-agent",
+		Prompt: Prompt{Vendor: "openai", Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "find it"}}},
+	}, model, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StopReason != LoopStopCompleted || res.Iterations != 2 || res.ToolCalls != 1 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if res.Usage.TotalTokens != 30 {
+		t.Errorf("aggregated usage: got %d, want 30", res.Usage.TotalTokens)
+	}
+	if len(res.Messages) != 4 || res.Messages[2].Role != "tool" || res.Messages[2].ToolCallID != "call_search" {
+		t.Errorf("unexpected conversation: %+v", res.Messages)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 2 LLM spans, 1 tool span and the agent span, got %d", len(spans))
+	}
+	agent := spans[3]
+	if agent.Name != "support-agent" {
+		t.Fatalf("expected the agent span last, got %q", agent.Name)
+	}
+	attrs := attrMap(agent.Attributes)
+	if attrs[AttrLoopStopReason] != LoopStopCompleted || attrs[AttrLoopIterations] != int64(2) || attrs[AttrLoopTotalTokens] != int64(30) {
+		t.Errorf("agent attributes: %v", attrs)
+	}
+	for _, s := range spans[:3] {
+		if s.Parent.SpanID() != agent.SpanContext.SpanID() {
+			t.Errorf("%s should be a child of the agent span", s.Name)
+		}
+	}
+	if got := attrMap(spans[1].Attributes)[AttrLoopIteration]; got != int64(1) {
+		t.Errorf("tool span iteration: got %v, want 1", got)
+	}
+}
+
+func TestRunToolLoop_MaxIterations(t *testing.T) {
+	newGlobalTestProvider(t)
+
+	model := func(ctx context.Context, prompt Prompt) (Completion, Usage, error) {
+		return toolCallCompletion("loop"), Usage{}, nil
+	}
+	tool := func(ctx context.Context, call ToolCall) (string, error) { return "", errors.New("nope") }
+
+	res, err := RunToolLoop(context.Background(), ToolLoopOptions{MaxIterations: 3}, model, tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StopReason != LoopStopMaxIterations || res.Iterations != 3 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+	if last := res.Messages[len(res.Messages)-1]; last.Content != "error: nope" {
+		t.Errorf("tool errors should be fed back to the model, got %q", last.Content)
+	}
+}
+
+func TestRunToolLoop_ModelError(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	boom := errors.New("rate limited")
+
+	model := func(ctx context.Context, prompt Prompt) (Completion, Usage, error) {
+		return Completion{}, Usage{}, boom
+	}
+	res, err := RunToolLoop(context.Background(), ToolLoopOptions{}, model, nil)
+	if !errors.Is(err, boom) || res.StopReason != LoopStopModelError {
+		t.Fatalf("got %v / %q", err, res.StopReason)
+	}
+	if n := len(exporter.GetSpans()); n != 2 {
+		t.Errorf("expected the failed LLM span and agent span, got %d", n)
+	}
+}
+
+func TestRunToolLoop_Cancelled(t *testing.T) {
+	newGlobalTestProvider(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	model := func(ctx context.Context, prompt Prompt) (Completion, Usage, error) {
+		cancel()
+		return toolCallCompletion("search"), Usage{}, nil
+	}
+	tool := func(ctx context.Context, call ToolCall) (string, error) { return "ok", nil }
+
+	res, err := RunToolLoop(ctx, ToolLoopOptions{}, model, tool)
+	if !errors.Is(err, context.Canceled) || res.StopReason != LoopStopCancelled || res.Iterations != 1 {
+		t.Errorf("got err=%v result=%+v", err, res)
+	}
+}
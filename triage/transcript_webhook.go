@@ -0,0 +1,202 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// transcriptQueueSize bounds the number of transcripts waiting to be
+	// posted. When full, new transcripts are dropped rather than blocking.
+	transcriptQueueSize = 64
+
+	// transcriptPostTimeout bounds a single webhook delivery.
+	transcriptPostTimeout = 10 * time.Second
+)
+
+// WithTranscriptWebhook POSTs each conversation's transcript as JSON (see
+// Transcript) to url when the session ends, for teams that archive
+// conversations into their own data lake in addition to Triage. A session
+// ends when EndSession is called, once it has been idle for 30 minutes
+// (noticed as other sessions are active), or at Shutdown, which waits for
+// open sessions' transcripts to be delivered. header is sent with every
+// request, e.g. for an Authorization token; it may be nil.
+//
+// PII is redacted from all message content and tool arguments before the
+// transcript leaves the process. Enables session tracking (see
+// WithSessionTracking); nothing is sent when trace content is disabled.
+// Deliveries happen in the background and failures are logged, not retried.
+func WithTranscriptWebhook(url string, header http.Header) Option {
+	return func(c *config) {
+		c.transcriptWebhook = url
+		c.transcriptHeader = header.Clone()
+	}
+}
+
+// transcriptSink posts ended sessions' transcripts to the configured webhook
+// from a background goroutine.
+type transcriptSink struct {
+	url    string
+	header http.Header
+	client *http.Client
+	logger *slog.Logger
+
+	queue chan *Transcript
+	done  chan struct{}
+
+	mu     sync.Mutex // guards closed and sends on queue
+	closed bool
+}
+
+// newTranscriptSink starts the delivery goroutine.
+func newTranscriptSink(cfg *config) *transcriptSink {
+	hc := cfg.httpClient
+	if hc == nil {
+		hc = &http.Client{Timeout: transcriptPostTimeout}
+	}
+	s := &transcriptSink{
+		url:    cfg.transcriptWebhook,
+		header: cfg.transcriptHeader,
+		client: hc,
+		logger: cfg.log(),
+		queue:  make(chan *Transcript, transcriptQueueSize),
+		done:   make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+func (s *transcriptSink) run() {
+	defer close(s.done)
+	for t := range s.queue {
+		guard("transcript webhook", func() {
+			t = redactTranscript(t)
+			if err := s.post(t); err != nil {
+				s.logger.Warn("triage: transcript webhook delivery failed", "session", t.SessionID, "error", err)
+			}
+		})
+	}
+}
+
+func (s *transcriptSink) post(t *Transcript) error {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), transcriptPostTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, vs := range s.header {
+		req.Header[k] = vs
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("triage: transcript webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// enqueue queues t for delivery, dropping it if the queue is full.
+// Transcripts without turns are skipped. t is redacted by the delivery
+// goroutine, off the caller's path, and must not be modified afterwards.
+func (s *transcriptSink) enqueue(t *Transcript) {
+	if len(t.Turns) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- t:
+	default:
+		s.logger.Warn("triage: transcript webhook queue full — dropping transcript", "session", t.SessionID)
+	}
+}
+
+// enqueueWait is enqueue for Shutdown: rather than dropping t when the queue
+// is full, it waits for room until ctx is done.
+func (s *transcriptSink) enqueueWait(ctx context.Context, t *Transcript) {
+	if len(t.Turns) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- t:
+	case <-ctx.Done():
+		s.logger.Warn("triage: transcript webhook flush timed out — dropping transcript", "session", t.SessionID)
+	}
+}
+
+// shutdown stops accepting transcripts and waits for queued deliveries to
+// finish or ctx to expire.
+func (s *transcriptSink) shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("triage: transcript webhook flush: %w", ctx.Err())
+	}
+}
+
+// redactTranscript returns a copy of t with PII redacted from every message,
+// and the redactions counted in Redactions.
+func redactTranscript(t *Transcript) *Transcript {
+	out := *t
+	out.Turns = make([]TranscriptTurn, len(t.Turns))
+	var redacted []redactionMatch
+	for i, turn := range t.Turns {
+		turn.Input = redactMessages(turn.Input, &redacted)
+		turn.Output = redactMessages(turn.Output, &redacted)
+		out.Turns[i] = turn
+	}
+	out.Redactions = redactionCounts(redacted)
+	return &out
+}
+
+// redactMessages returns copies of msgs with PII redacted from content,
+// reasoning and tool call arguments, appending what was redacted to
+// *redacted.
+func redactMessages(msgs []Message, redacted *[]redactionMatch) []Message {
+	out := make([]Message, len(msgs))
+	for i, m := range msgs {
+		m.Content = redactPIIInto(m.Content, redacted)
+		m.Reasoning = redactPIIInto(m.Reasoning, redacted)
+		if len(m.ToolCalls) > 0 {
+			calls := make([]ToolCall, len(m.ToolCalls))
+			for j, tc := range m.ToolCalls {
+				tc.Function.Arguments = redactPIIInto(tc.Function.Arguments, redacted)
+				calls[j] = tc
+			}
+			m.ToolCalls = calls
+		}
+		out[i] = m
+	}
+	return out
+}
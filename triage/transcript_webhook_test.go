@@ -0,0 +1,140 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newWebhookTestSink starts a webhook server recording each delivered
+// transcript and wires a transcript sink to the session manager.
+func newWebhookTestSink(t *testing.T, sm *SessionManager) (*transcriptSink, func() []deliveredTranscript) {
+	t.Helper()
+	var mu sync.Mutex
+	var got []deliveredTranscript
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var tr Transcript
+		if err := json.Unmarshal(body, &tr); err != nil {
+			t.Errorf("webhook body is not a transcript: %v", err)
+		}
+		mu.Lock()
+		got = append(got, deliveredTranscript{transcript: tr, header: r.Header.Clone(), raw: string(body)})
+		mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+
+	sink := newTranscriptSink(&config{
+		transcriptWebhook: srv.URL,
+		transcriptHeader:  http.Header{"Authorization": {"Bearer hook-secret"}},
+	})
+	sm.onEnd = sink.enqueue
+	return sink, func() []deliveredTranscript {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]deliveredTranscript(nil), got...)
+	}
+}
+
+type deliveredTranscript struct {
+	transcript Transcript
+	header     http.Header
+	raw        string
+}
+
+// ---------------------------------------------------------------------------
+// Transcript webhook
+// ---------------------------------------------------------------------------
+
+func TestTranscriptWebhook_PostsRedactedTranscriptAtSessionEnd(t *testing.T) {
+	newGlobalTestProvider(t)
+	sm := newSessionTestManager(t)
+	sink, delivered := newWebhookTestSink(t, sm)
+
+	ctx := WithSession(WithUser(context.Background(), "u_1"), "sess_1")
+	chatTurn(ctx, []Message{{Role: "user", Content: "mail me at jane@example.com"}}, "sure")
+
+	tr, ok := sm.EndSession("sess_1")
+	if !ok || !strings.Contains(tr.Turns[0].Input[0].Content, "jane@example.com") {
+		t.Fatalf("EndSession should still return the unredacted transcript, got %+v", tr)
+	}
+	if err := sink.shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	got := delivered()
+	if len(got) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(got))
+	}
+	if got[0].transcript.SessionID != "sess_1" || got[0].transcript.UserID != "u_1" || len(got[0].transcript.Turns) != 1 {
+		t.Errorf("transcript: got %+v", got[0].transcript)
+	}
+	if strings.Contains(got[0].raw, "jane@example.com") || !strings.Contains(got[0].raw, "[REDACTED_EMAIL]") {
+		t.Errorf("expected PII to be redacted, got %s", got[0].raw)
+	}
+	if n := got[0].transcript.Redactions["email"]; n != 1 || len(got[0].transcript.Redactions) != 1 {
+		t.Errorf("redactions: got %v", got[0].transcript.Redactions)
+	}
+	if got[0].header.Get("Authorization") != "Bearer hook-secret" || got[0].header.Get("Content-Type") != "application/json" {
+		t.Errorf("headers: got %v", got[0].header)
+	}
+}
+
+func TestTranscriptWebhook_IdleSessionsAreDelivered(t *testing.T) {
+	sm := newSessionManager()
+	sink, delivered := newWebhookTestSink(t, sm)
+
+	now := time.Now()
+	sm.sessions["idle"] = &sessionState{lastSeen: now.Add(-time.Hour), turns: []TranscriptTurn{{Number: 1}}}
+	sm.sessions["active"] = &sessionState{lastSeen: now, turns: []TranscriptTurn{{Number: 1}}}
+	sm.sessions["empty"] = &sessionState{lastSeen: now.Add(-time.Hour)}
+	sm.mu.Lock()
+	sm.getOrCreateLocked("new", now)
+	sm.mu.Unlock()
+	sm.deliverEnded()
+	sink.shutdown(context.Background())
+
+	got := delivered()
+	if len(got) != 1 || got[0].transcript.SessionID != "idle" {
+		t.Errorf("expected only the idle session with turns to be delivered, got %+v", got)
+	}
+	if _, ok := sm.sessions["active"]; !ok {
+		t.Error("active sessions should be kept")
+	}
+}
+
+func TestTranscriptWebhook_EndAllDeliversOpenSessions(t *testing.T) {
+	sm := newSessionManager()
+	sink, delivered := newWebhookTestSink(t, sm)
+
+	// More open sessions than the queue holds, all delivered as Shutdown
+	// waits for room.
+	const open = transcriptQueueSize * 2
+	for i := range open {
+		sm.sessions[fmt.Sprint("s", i)] = &sessionState{lastSeen: time.Now(), turns: []TranscriptTurn{{Number: 1}}}
+	}
+	for _, tr := range sm.endAll() {
+		sink.enqueueWait(context.Background(), tr)
+	}
+	sink.shutdown(context.Background())
+
+	if got := delivered(); len(got) != open {
+		t.Errorf("expected all %d open sessions to be delivered, got %d", open, len(got))
+	}
+	if len(sm.sessions) != 0 {
+		t.Errorf("expected no sessions left, got %d", len(sm.sessions))
+	}
+}
+
+func TestTranscriptWebhook_InvalidURL(t *testing.T) {
+	if _, err := resolveConfig(WithAPIKey("k"), WithTranscriptWebhook("not a url", nil)); err == nil {
+		t.Error("expected an error for an invalid webhook URL")
+	}
+}
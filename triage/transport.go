@@ -0,0 +1,115 @@
+package triage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Compile-time check that transport implements http.RoundTripper.
+var _ http.RoundTripper = (*transport)(nil)
+
+// transport is the RoundTripper returned by NewTransport.
+type transport struct {
+	base http.RoundTripper
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) so that OpenAI Chat
+// Completions, OpenAI Responses and Anthropic Messages calls made through it
+// are recorded as LLM spans, exactly as LogPrompt and LogCompletion would
+// record them, with no logging calls in application code:
+//
+//	client := &http.Client{Transport: triage.NewTransport(nil)}
+//	// pass client to the vendor SDK, e.g. option.WithHTTPClient(client)
+//
+// The span is a child of the request's context and carries its triage
+// context. Streamed responses are reassembled as the caller reads them; the
+// completion is logged when the body reaches EOF or is closed. Other
+// requests pass through untouched.
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	api := detectLLMAPI(req)
+	if api == nil || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+	return roundTripLLM(req, api, t.base.RoundTrip, "triage transport")
+}
+
+// roundTripLLM sends req, an LLM call in api's wire format, through next
+// inside an LLM span. who prefixes errors recorded for error statuses.
+// Bodies that don't parse or exceed maxGatewayCapture are sent untouched.
+func roundTripLLM(req *http.Request, api *llmAPI, next func(*http.Request) (*http.Response, error), who string) (*http.Response, error) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxGatewayCapture+1))
+	if err != nil {
+		req.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxGatewayCapture {
+		out := req.Clone(req.Context())
+		out.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		return next(out)
+	}
+	req.Body.Close()
+
+	prompt, err := api.parsePrompt(body)
+	if err != nil {
+		return next(withBody(req, req.Context(), body))
+	}
+	prompt.Endpoint = req.URL.Scheme + "://" + req.URL.Host
+	if v := vendorForHost(req.URL.Hostname()); v != "" {
+		prompt.Vendor = v
+	}
+	applyAzureURL(&prompt, req.URL)
+
+	ls, ctx := LogPrompt(req.Context(), prompt)
+	resp, err := next(withBody(req, ctx, body))
+	if err != nil {
+		ls.SetError(err)
+		ls.End()
+		return nil, err
+	}
+	instrumentResponse(ls, resp, api, who)
+	return resp, nil
+}
+
+// withBody returns a copy of req with context ctx whose body replays body,
+// the already-consumed original.
+func withBody(req *http.Request, ctx context.Context, body []byte) *http.Request {
+	out := req.Clone(ctx)
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	out.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	out.ContentLength = int64(len(body))
+	return out
+}
+
+// detectLLMAPI returns the wire format of req if it is an LLM call the
+// transport instruments, or nil.
+func detectLLMAPI(req *http.Request) *llmAPI {
+	if req.Method != http.MethodPost || req.URL == nil {
+		return nil
+	}
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case strings.HasSuffix(path, "/chat/completions"):
+		return openAIChatAPI
+	case strings.HasSuffix(path, "/responses"):
+		return openAIResponsesAPI
+	case strings.HasSuffix(path, "/v1/messages") &&
+		(req.Header.Get("anthropic-version") != "" || strings.HasSuffix(req.URL.Hostname(), "anthropic.com")):
+		return anthropicMessagesAPI
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package triage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newTransportClient(t *testing.T, upstream http.HandlerFunc) (*http.Client, string) {
+	t.Helper()
+	srv := httptest.NewServer(upstream)
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: NewTransport(nil)}, srv.URL
+}
+
+func doPost(t *testing.T, client *http.Client, req *http.Request) string {
+	t.Helper()
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// ---------------------------------------------------------------------------
+// OpenAI
+// ---------------------------------------------------------------------------
+
+func TestTransport_RecordsOpenAICall(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	const respBody = `{"model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`
+	var upstreamParent trace.SpanContext
+	client, url := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != gatewayRequest {
+			t.Errorf("upstream got body %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, respBody)
+	})
+	client.Transport = NewTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		upstreamParent = trace.SpanContextFromContext(r.Context())
+		return http.DefaultTransport.RoundTrip(r)
+	}))
+
+	ctx := WithUser(context.Background(), "user_1")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, url+"/v1/chat/completions", strings.NewReader(gatewayRequest))
+	if got := doPost(t, client, req); got != respBody {
+		t.Errorf("client got %q", got)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAISystem] != "openai" || attrs["gen_ai.completion.0.content"] != "Hello!" {
+		t.Errorf("system/content: got %v/%v", attrs[AttrGenAISystem], attrs["gen_ai.completion.0.content"])
+	}
+	if attrs[AttrUserID] != "user_1" {
+		t.Errorf("triage context should be recorded, got %v", attrs[AttrUserID])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("total tokens: got %v", attrs["llm.usage.total_tokens"])
+	}
+	if upstreamParent.SpanID() != spans[0].SpanContext.SpanID() {
+		t.Error("the outgoing request should carry the LLM span's context")
+	}
+}
+
+func TestTransport_ReassemblesStream(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	client, url := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`+"\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(gatewayRequest))
+	doPost(t, client, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := attrMap(spans[0].Attributes)["gen_ai.completion.0.content"]; got != "Hi" {
+		t.Errorf("content: got %v", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Anthropic
+// ---------------------------------------------------------------------------
+
+func TestTransport_RecordsAnthropicCall(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	client, url := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"model":"claude-sonnet-4","content":[{"type":"text","text":"Hello!"}],"stop_reason":"end_turn","usage":{"input_tokens":4,"output_tokens":2}}`)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/messages",
+		strings.NewReader(`{"model":"claude-sonnet-4","max_tokens":64,"messages":[{"role":"user","content":"Hi"}]}`))
+	req.Header.Set("anthropic-version", "2023-06-01")
+	doPost(t, client, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs[AttrGenAISystem] != "anthropic" || attrs["gen_ai.prompt.0.content"] != "Hi" || attrs["gen_ai.completion.0.content"] != "Hello!" {
+		t.Errorf("attrs: got %v", attrs)
+	}
+	if attrs["llm.usage.total_tokens"] != int64(6) {
+		t.Errorf("total tokens: got %v", attrs["llm.usage.total_tokens"])
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Errors and pass-through
+// ---------------------------------------------------------------------------
+
+func TestTransport_UpstreamErrorMarksSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	client, url := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"rate limited"}}`, http.StatusTooManyRequests)
+	})
+
+	req, _ := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(gatewayRequest))
+	doPost(t, client, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
+
+func TestTransport_TransportErrorMarksSpan(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	rt := NewTransport(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}))
+
+	req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1/v1/chat/completions", strings.NewReader(gatewayRequest))
+	if _, err := rt.RoundTrip(req); err == nil {
+		t.Fatal("expected the transport error to be returned")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
+
+func TestTransport_PassesThroughOtherRequests(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	client, url := newTransportClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[]}`)
+	})
+
+	for _, req := range []*http.Request{
+		mustRequest(t, http.MethodGet, url+"/v1/models", ""),
+		mustRequest(t, http.MethodPost, url+"/v1/messages", `{}`), // no anthropic-version header
+		mustRequest(t, http.MethodPost, url+"/v1/chat/completions", `not json`),
+	} {
+		doPost(t, client, req)
+	}
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans, got %d", n)
+	}
+}
+
+func mustRequest(t *testing.T, method, url, body string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
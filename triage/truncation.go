@@ -0,0 +1,101 @@
+package triage
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Truncation describes an LLM response cut off by the output token limit.
+type Truncation struct {
+	Vendor           string
+	Model            string
+	FinishReason     string
+	CompletionTokens int
+	TraceID          string
+	SpanID           string
+}
+
+// TruncationHandler is called synchronously from LogCompletion for every
+// truncated response. It should return quickly.
+type TruncationHandler func(ctx context.Context, t Truncation)
+
+// WithTruncationHandler registers a callback invoked whenever a completion's
+// finish reason shows it was truncated by the token limit ("length",
+// "max_tokens"). Truncated outputs are a frequent source of malformed tool
+// calls and broken JSON.
+func WithTruncationHandler(h TruncationHandler) Option {
+	return func(c *config) { c.truncationHandler = h }
+}
+
+// isTruncated reports whether a provider finish reason means the output hit
+// the token limit: OpenAI "length", Anthropic "max_tokens", Gemini
+// "MAX_TOKENS".
+func isTruncated(finishReason string) bool {
+	switch strings.ToLower(finishReason) {
+	case "length", "max_tokens", "max_output_tokens":
+		return true
+	}
+	return false
+}
+
+// finishCategory normalizes a provider finish reason to the OpenAI
+// vocabulary — "stop", "length", "tool_calls" or "content_filter" — or
+// returns "" for reasons with no equivalent (e.g. Anthropic "pause_turn").
+func finishCategory(finishReason string) string {
+	switch strings.ToLower(finishReason) {
+	case "stop", "end_turn", "stop_sequence", "complete":
+		return "stop"
+	case "length", "max_tokens", "max_output_tokens":
+		return "length"
+	case "tool_calls", "tool_use", "function_call":
+		return "tool_calls"
+	case "content_filter", "content_filtered", "refusal", "safety", "recitation", "guardrail_intervened":
+		return "content_filter"
+	}
+	return ""
+}
+
+// recordTruncation adds a triage.truncated event to the span and increments
+// the truncation counter. The TruncationHandler is invoked separately, by
+// notifyTruncation, once the span has ended.
+func (ls *LLMSpan) recordTruncation(model string, completion Completion, usage Usage) {
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAIResponseFinishReason, completion.FinishReason),
+		attribute.Int(AttrGenAIUsageOutputTokens, usage.CompletionTokens),
+	}
+	ls.span.AddEvent(truncationEventName, trace.WithAttributes(attrs...))
+
+	sdkInstruments().truncations.Add(ls.ctx, 1, metric.WithAttributes(
+		attribute.String(AttrGenAISystem, ls.vendor),
+		attribute.String(AttrGenAIRequestModel, model),
+	))
+}
+
+// notifyTruncation invokes the configured TruncationHandler for a truncated
+// completion. LogCompletion calls it after ending the span, under its own
+// guard, so a panicking handler cannot discard the span's attributes.
+func (ls *LLMSpan) notifyTruncation(completion Completion, usage Usage) {
+	cfg := globalCfg
+	if cfg == nil || cfg.truncationHandler == nil || !isTruncated(completion.FinishReason) {
+		return
+	}
+	model := completion.Model
+	if model == "" {
+		model = ls.model
+	}
+	sc := ls.span.SpanContext()
+	guard("truncation handler", func() {
+		cfg.truncationHandler(ls.ctx, Truncation{
+			Vendor:           ls.vendor,
+			Model:            model,
+			FinishReason:     completion.FinishReason,
+			CompletionTokens: usage.CompletionTokens,
+			TraceID:          sc.TraceID().String(),
+			SpanID:           sc.SpanID().String(),
+		})
+	})
+}
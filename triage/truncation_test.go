@@ -0,0 +1,155 @@
+package triage
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// countingMeterProvider records Int64Counter increments by instrument name.
+type countingMeterProvider struct {
+	noop.MeterProvider
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (p *countingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter {
+	return countingMeter{p: p}
+}
+
+type countingMeter struct {
+	noop.Meter
+	p *countingMeterProvider
+}
+
+func (m countingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return countingCounter{p: m.p, name: name}, nil
+}
+
+type countingCounter struct {
+	noop.Int64Counter
+	p    *countingMeterProvider
+	name string
+}
+
+func (c countingCounter) Add(_ context.Context, n int64, _ ...metric.AddOption) {
+	c.p.mu.Lock()
+	defer c.p.mu.Unlock()
+	c.p.counts[c.name] += n
+}
+
+func newCountingMeterProvider(t *testing.T) *countingMeterProvider {
+	t.Helper()
+	mp := &countingMeterProvider{counts: make(map[string]int64)}
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(mp)
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	return mp
+}
+
+func TestIsTruncated(t *testing.T) {
+	for reason, want := range map[string]bool{
+		"length":            true,
+		"max_tokens":        true,
+		"max_output_tokens": true,
+		"MAX_TOKENS":        true,
+		"stop":              false,
+		"tool_calls":        false,
+		"":                  false,
+	} {
+		if got := isTruncated(reason); got != want {
+			t.Errorf("isTruncated(%q): got %v, want %v", reason, got, want)
+		}
+	}
+}
+
+func TestFinishCategory(t *testing.T) {
+	for reason, want := range map[string]string{
+		"stop":              "stop",
+		"end_turn":          "stop",
+		"stop_sequence":     "stop",
+		"max_tokens":        "length",
+		"max_output_tokens": "length",
+		"MAX_TOKENS":        "length",
+		"tool_use":          "tool_calls",
+		"refusal":           "content_filter",
+		"SAFETY":            "content_filter",
+		"pause_turn":        "",
+	} {
+		if got := finishCategory(reason); got != want {
+			t.Errorf("finishCategory(%q): got %q, want %q", reason, got, want)
+		}
+	}
+}
+
+func TestLogCompletion_Truncated(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	mp := newCountingMeterProvider(t)
+
+	var got []Truncation
+	globalCfg = &config{traceContent: true, truncationHandler: func(_ context.Context, tr Truncation) {
+		got = append(got, tr)
+	}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "anthropic", Model: "claude-sonnet-4"})
+	llmSpan.LogCompletion(Completion{FinishReason: "max_tokens"}, Usage{CompletionTokens: 1024})
+
+	span := exporter.GetSpans()[0]
+	attrs := attrMap(span.Attributes)
+	if attrs[AttrGenAIResponseFinishReason] != "max_tokens" {
+		t.Errorf("finish_reason: got %v", attrs[AttrGenAIResponseFinishReason])
+	}
+	if attrs[AttrResponseTruncated] != true {
+		t.Errorf("truncated: got %v, want true", attrs[AttrResponseTruncated])
+	}
+	if len(span.Events) != 1 || span.Events[0].Name != truncationEventName {
+		t.Fatalf("expected one %s event, got %+v", truncationEventName, span.Events)
+	}
+	if mp.counts[truncationMetricName] != 1 {
+		t.Errorf("counter: got %d, want 1", mp.counts[truncationMetricName])
+	}
+	if len(got) != 1 {
+		t.Fatalf("handler calls: got %d, want 1", len(got))
+	}
+	if got[0].Model != "claude-sonnet-4" || got[0].CompletionTokens != 1024 || got[0].SpanID != span.SpanContext.SpanID().String() {
+		t.Errorf("unexpected truncation: %+v", got[0])
+	}
+}
+
+func TestLogCompletion_NotTruncated(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	mp := newCountingMeterProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{FinishReason: "stop"}, Usage{})
+
+	span := exporter.GetSpans()[0]
+	if _, ok := attrMap(span.Attributes)[AttrResponseTruncated]; ok {
+		t.Error("truncated attribute should be omitted")
+	}
+	if len(span.Events) != 0 || mp.counts[truncationMetricName] != 0 {
+		t.Error("no truncation event or metric expected")
+	}
+}
+
+func TestLogCompletion_PanickingTruncationHandlerKeepsAttributes(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+	globalCfg = &config{traceContent: true, truncationHandler: func(context.Context, Truncation) {
+		panic("handler bug")
+	}}
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{
+		FinishReason: "length",
+		Messages:     []Message{{Role: "assistant", Content: "Once upon"}},
+	}, Usage{CompletionTokens: 16})
+
+	attrs := attrMap(exporter.GetSpans()[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Once upon" || attrs[AttrGenAIUsageOutputTokens] != int64(16) {
+		t.Errorf("completion attributes should survive a panicking handler, got %v", attrs)
+	}
+}
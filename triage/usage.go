@@ -0,0 +1,113 @@
+package triage
+
+// Provider usage payloads, mirroring each API's JSON wire format so they can
+// be decoded directly from a response body or filled from a provider SDK's
+// own usage struct. The From*Usage converters normalize them into Usage with
+// the same semantics for every provider:
+//
+//   - PromptTokens counts all input tokens, including cache reads and writes;
+//   - CompletionTokens counts all output tokens, including reasoning tokens;
+//   - TotalTokens is their sum when the provider doesn't report it.
+
+// OpenAIUsage is the usage object of OpenAI Chat Completions responses.
+type OpenAIUsage struct {
+	PromptTokens        int `json:"prompt_tokens"`
+	CompletionTokens    int `json:"completion_tokens"`
+	TotalTokens         int `json:"total_tokens"`
+	PromptTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+		AudioTokens  int `json:"audio_tokens"`
+	} `json:"prompt_tokens_details"`
+	CompletionTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+		AudioTokens     int `json:"audio_tokens"`
+	} `json:"completion_tokens_details"`
+}
+
+// OpenAIResponsesUsage is the usage object of OpenAI Responses API
+// responses.
+type OpenAIResponsesUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	TotalTokens        int `json:"total_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+	OutputTokensDetails struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"output_tokens_details"`
+}
+
+// AnthropicUsage is the usage object of Anthropic Messages responses. Its
+// input_tokens excludes tokens read from or written to the prompt cache.
+type AnthropicUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+// BedrockUsage is the usage object of Amazon Bedrock Converse responses. Its
+// inputTokens excludes tokens read from or written to the prompt cache.
+type BedrockUsage struct {
+	InputTokens           int `json:"inputTokens"`
+	OutputTokens          int `json:"outputTokens"`
+	TotalTokens           int `json:"totalTokens"`
+	CacheReadInputTokens  int `json:"cacheReadInputTokens"`
+	CacheWriteInputTokens int `json:"cacheWriteInputTokens"`
+}
+
+// FromOpenAIUsage converts OpenAI usage into Usage.
+func FromOpenAIUsage(u OpenAIUsage) Usage {
+	return withTotal(Usage{
+		PromptTokens:      u.PromptTokens,
+		CompletionTokens:  u.CompletionTokens,
+		TotalTokens:       u.TotalTokens,
+		CacheReadTokens:   u.PromptTokensDetails.CachedTokens,
+		ReasoningTokens:   u.CompletionTokensDetails.ReasoningTokens,
+		AudioInputTokens:  u.PromptTokensDetails.AudioTokens,
+		AudioOutputTokens: u.CompletionTokensDetails.AudioTokens,
+	})
+}
+
+// FromOpenAIResponsesUsage converts OpenAI Responses API usage into Usage.
+func FromOpenAIResponsesUsage(u OpenAIResponsesUsage) Usage {
+	return withTotal(Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      u.TotalTokens,
+		CacheReadTokens:  u.InputTokensDetails.CachedTokens,
+		ReasoningTokens:  u.OutputTokensDetails.ReasoningTokens,
+	})
+}
+
+// FromAnthropicUsage converts Anthropic usage into Usage, adding cached input
+// tokens back into PromptTokens.
+func FromAnthropicUsage(u AnthropicUsage) Usage {
+	return withTotal(Usage{
+		PromptTokens:     u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens,
+		CompletionTokens: u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheCreationInputTokens,
+	})
+}
+
+// FromBedrockUsage converts Bedrock Converse usage into Usage, adding cached
+// input tokens back into PromptTokens. TotalTokens is recomputed from the
+// normalized counts.
+func FromBedrockUsage(u BedrockUsage) Usage {
+	return withTotal(Usage{
+		PromptTokens:     u.InputTokens + u.CacheReadInputTokens + u.CacheWriteInputTokens,
+		CompletionTokens: u.OutputTokens,
+		CacheReadTokens:  u.CacheReadInputTokens,
+		CacheWriteTokens: u.CacheWriteInputTokens,
+	})
+}
+
+// withTotal fills in TotalTokens when the provider didn't report it.
+func withTotal(u Usage) Usage {
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
@@ -0,0 +1,52 @@
+package triage
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// tokenUsageBuckets are the histogram bucket boundaries the gen_ai semantic
+// conventions advise for gen_ai.client.token.usage.
+var tokenUsageBuckets = []float64{
+	1, 4, 16, 64, 256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304, 16777216, 67108864,
+}
+
+// recordTokenUsage records the call's input, output and total tokens in the
+// gen_ai.client.token.usage histogram on the global OTel meter provider,
+// labeled with the vendor, operation, requested and response model and token
+// type, so dashboards can chart token consumption without aggregating span
+// attributes. Zero counts, e.g. the input and output of usage that only has
+// a total, are not recorded.
+func (ls *LLMSpan) recordTokenUsage(responseModel string, usage Usage) {
+	if usage == (Usage{}) {
+		return
+	}
+	histogram := sdkInstruments().tokenUsage
+	attrs := []attribute.KeyValue{
+		attribute.String(AttrGenAISystem, ls.vendor),
+		attribute.String(AttrGenAIOperationName, ls.operation),
+		attribute.String(AttrGenAIRequestModel, ls.model),
+	}
+	if responseModel != "" {
+		attrs = append(attrs, attribute.String(AttrGenAIResponseModel, responseModel))
+	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.PromptTokens + usage.CompletionTokens
+	}
+	for _, tokens := range []struct {
+		kind  string
+		count int
+	}{
+		{"input", usage.PromptTokens},
+		{"output", usage.CompletionTokens},
+		{"total", total},
+	} {
+		if tokens.count == 0 {
+			continue // not reported, e.g. usage with only a total
+		}
+		histogram.Record(ls.ctx, int64(tokens.count), metric.WithAttributes(
+			append(attrs, attribute.String(AttrGenAITokenType, tokens.kind))...,
+		))
+	}
+}
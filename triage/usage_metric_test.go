@@ -0,0 +1,118 @@
+package triage
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// collectTokenUsage returns the gen_ai.client.token.usage data points read
+// from reader.
+func collectTokenUsage(t *testing.T, reader sdkmetric.Reader) []metricdata.HistogramDataPoint[int64] {
+	t.Helper()
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == tokenUsageMetricName {
+				h, _ := m.Data.(metricdata.Histogram[int64])
+				return h.DataPoints
+			}
+		}
+	}
+	return nil
+}
+
+func newTestMeterReader(t *testing.T) sdkmetric.Reader {
+	t.Helper()
+	reader := sdkmetric.NewManualReader()
+	prev := otel.GetMeterProvider()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	t.Cleanup(func() { otel.SetMeterProvider(prev) })
+	return reader
+}
+
+func TestLogCompletion_TokenUsageHistogram(t *testing.T) {
+	newGlobalTestProvider(t)
+	reader := newTestMeterReader(t)
+
+	for range 2 {
+		llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(Completion{Model: "gpt-4o-2024-08-06"}, Usage{PromptTokens: 100, CompletionTokens: 20})
+	}
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Operation: OperationResponses, Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 7, CompletionTokens: 3, TotalTokens: 10})
+
+	points := collectTokenUsage(t, reader)
+	if len(points) != 6 {
+		t.Fatalf("expected 6 data points (3 token types x 2 operations), got %d", len(points))
+	}
+	type key struct{ operation, tokenType string }
+	want := map[key]struct{ count, sum int64 }{
+		{OperationChat, "input"}:       {2, 200},
+		{OperationChat, "output"}:      {2, 40},
+		{OperationChat, "total"}:       {2, 240},
+		{OperationResponses, "input"}:  {1, 7},
+		{OperationResponses, "output"}: {1, 3},
+		{OperationResponses, "total"}:  {1, 10},
+	}
+	for _, dp := range points {
+		op, _ := dp.Attributes.Value(AttrGenAIOperationName)
+		tokenType, _ := dp.Attributes.Value(AttrGenAITokenType)
+		k := key{op.AsString(), tokenType.AsString()}
+		w, ok := want[k]
+		if !ok {
+			t.Errorf("unexpected data point %+v", k)
+			continue
+		}
+		if int64(dp.Count) != w.count || dp.Sum != w.sum {
+			t.Errorf("%+v: got count=%d sum=%d, want count=%d sum=%d", k, dp.Count, dp.Sum, w.count, w.sum)
+		}
+		if v, _ := dp.Attributes.Value(AttrGenAISystem); v.AsString() != "openai" {
+			t.Errorf("%+v: vendor got %q", k, v.AsString())
+		}
+		if v, _ := dp.Attributes.Value(AttrGenAIRequestModel); v.AsString() != "gpt-4o" {
+			t.Errorf("%+v: request model got %q", k, v.AsString())
+		}
+		v, ok := dp.Attributes.Value(AttrGenAIResponseModel)
+		if k.operation == OperationChat && v.AsString() != "gpt-4o-2024-08-06" {
+			t.Errorf("%+v: response model got %q", k, v.AsString())
+		}
+		if k.operation == OperationResponses && ok {
+			t.Errorf("%+v: response model should be omitted when not reported, got %q", k, v.AsString())
+		}
+	}
+}
+
+func TestLogCompletion_NoUsageRecordsNoTokens(t *testing.T) {
+	newGlobalTestProvider(t)
+	reader := newTestMeterReader(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	if points := collectTokenUsage(t, reader); len(points) != 0 {
+		t.Errorf("a call without usage should not be recorded, got %d data points", len(points))
+	}
+}
+
+func TestLogCompletion_TotalOnlyUsageRecordsOnlyTotal(t *testing.T) {
+	newGlobalTestProvider(t)
+	reader := newTestMeterReader(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "gpt-4o"})
+	llmSpan.LogCompletion(Completion{}, Usage{TotalTokens: 42})
+
+	points := collectTokenUsage(t, reader)
+	if len(points) != 1 {
+		t.Fatalf("expected only the total to be recorded, got %d data points", len(points))
+	}
+	if v, _ := points[0].Attributes.Value(AttrGenAITokenType); v.AsString() != "total" || points[0].Sum != 42 {
+		t.Errorf("got %s=%d, want total=42", v.AsString(), points[0].Sum)
+	}
+}
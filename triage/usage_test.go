@@ -0,0 +1,60 @@
+package triage
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestFromOpenAIUsage(t *testing.T) {
+	var u OpenAIUsage
+	body := `{"prompt_tokens":100,"completion_tokens":50,"total_tokens":150,
+		"prompt_tokens_details":{"cached_tokens":80,"audio_tokens":4},
+		"completion_tokens_details":{"reasoning_tokens":30,"audio_tokens":2}}`
+	if err := json.Unmarshal([]byte(body), &u); err != nil {
+		t.Fatal(err)
+	}
+	got := FromOpenAIUsage(u)
+	want := Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150, CacheReadTokens: 80,
+		ReasoningTokens: 30, AudioInputTokens: 4, AudioOutputTokens: 2}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFromAnthropicUsage_IncludesCachedInput(t *testing.T) {
+	var u AnthropicUsage
+	body := `{"input_tokens":10,"output_tokens":20,"cache_creation_input_tokens":200,"cache_read_input_tokens":1000}`
+	if err := json.Unmarshal([]byte(body), &u); err != nil {
+		t.Fatal(err)
+	}
+	got := FromAnthropicUsage(u)
+	want := Usage{PromptTokens: 1210, CompletionTokens: 20, TotalTokens: 1230, CacheReadTokens: 1000, CacheWriteTokens: 200}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFromBedrockUsage_IncludesCachedInput(t *testing.T) {
+	var u BedrockUsage
+	body := `{"inputTokens":10,"outputTokens":5,"totalTokens":15,"cacheReadInputTokens":100,"cacheWriteInputTokens":50}`
+	if err := json.Unmarshal([]byte(body), &u); err != nil {
+		t.Fatal(err)
+	}
+	got := FromBedrockUsage(u)
+	want := Usage{PromptTokens: 160, CompletionTokens: 5, TotalTokens: 165, CacheReadTokens: 100, CacheWriteTokens: 50}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestLogCompletion_RecordsReasoningTokens(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "openai", Model: "o3"})
+	llmSpan.LogCompletion(Completion{}, Usage{PromptTokens: 5, CompletionTokens: 40, TotalTokens: 45, ReasoningTokens: 32})
+
+	if got := attrMap(exporter.GetSpans()[0].Attributes)[AttrGenAIUsageReasoningTokens]; got != int64(32) {
+		t.Errorf("reasoning tokens: got %v, want 32", got)
+	}
+}
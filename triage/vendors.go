@@ -0,0 +1,93 @@
+package triage
+
+import "strings"
+
+// gen_ai.system values for common providers, following the OpenTelemetry
+// GenAI semantic conventions. LogPrompt maps the usual spellings of each
+// (e.g. "Mistral", "mistralai", "azure") onto these, so spans group by
+// provider however the caller names it.
+const (
+	VendorOpenAI      = "openai"
+	VendorAnthropic   = "anthropic"
+	VendorAzureOpenAI = "az.ai.openai"
+	VendorMistral     = "mistral_ai"
+	VendorGroq        = "groq"
+	VendorCohere      = "cohere"
+	VendorDeepSeek    = "deepseek"
+	VendorGemini      = "gcp.gemini"
+	VendorBedrock     = "aws.bedrock"
+	VendorXAI         = "xai"
+	VendorPerplexity  = "perplexity"
+)
+
+// gen_ai.operation.name values for LLM spans, set with Prompt.Operation.
+const (
+	OperationChat      = "chat"      // Chat Completions and other chat APIs (the default)
+	OperationResponses = "responses" // OpenAI Responses API
+)
+
+// vendorPreset describes a known provider: its gen_ai.system value, the
+// aliases callers commonly use for it, and the API hosts of its
+// OpenAI-compatible endpoints.
+type vendorPreset struct {
+	system  string
+	aliases []string
+	hosts   []string
+}
+
+// vendorPresets is the registry of known providers.
+var vendorPresets = []vendorPreset{
+	{system: VendorOpenAI, aliases: []string{"open_ai", "open-ai"}, hosts: []string{"api.openai.com"}},
+	{system: VendorAnthropic, aliases: []string{"claude"}, hosts: []string{"api.anthropic.com"}},
+	{system: VendorAzureOpenAI, aliases: []string{"azure", "azure_openai", "azure-openai", "azureopenai"}},
+	{system: VendorMistral, aliases: []string{"mistral", "mistralai", "mistral-ai"}, hosts: []string{"api.mistral.ai"}},
+	{system: VendorGroq, aliases: []string{"groqcloud"}, hosts: []string{"api.groq.com"}},
+	{system: VendorCohere, aliases: []string{"cohere-ai", "cohere_ai"}, hosts: []string{"api.cohere.ai", "api.cohere.com"}},
+	{system: VendorDeepSeek, aliases: []string{"deep_seek", "deep-seek"}, hosts: []string{"api.deepseek.com"}},
+	{system: VendorGemini, aliases: []string{"gemini", "google", "google_genai", "googleai"}, hosts: []string{"generativelanguage.googleapis.com"}},
+	{system: VendorBedrock, aliases: []string{"bedrock", "aws_bedrock", "aws-bedrock"}},
+	{system: VendorXAI, aliases: []string{"x.ai", "grok"}, hosts: []string{"api.x.ai"}},
+	{system: VendorPerplexity, aliases: []string{"pplx"}, hosts: []string{"api.perplexity.ai"}},
+}
+
+// normalizeVendor maps a known provider's name or alias, in any case, onto
+// its gen_ai.system value. Unknown vendors are returned unchanged.
+func normalizeVendor(vendor string) string {
+	v := strings.ToLower(vendor)
+	for _, p := range vendorPresets {
+		if v == p.system {
+			return p.system
+		}
+		for _, a := range p.aliases {
+			if v == a {
+				return p.system
+			}
+		}
+	}
+	return vendor
+}
+
+// vendorForHost returns the gen_ai.system value of the provider serving
+// host, or "" if it isn't a known API host.
+func vendorForHost(host string) string {
+	host = strings.ToLower(host)
+	for _, p := range vendorPresets {
+		for _, h := range p.hosts {
+			if host == h {
+				return p.system
+			}
+		}
+	}
+	return ""
+}
+
+// llmSpanName returns the name of an LLM span: "{vendor}.{operation}
+// {model}", e.g. "openai.chat gpt-4o", or "{vendor}.{operation}" when the
+// model is unknown.
+func llmSpanName(vendor, operation, model string) string {
+	name := vendor + "." + operation
+	if model != "" {
+		name += " " + model
+	}
+	return name
+}
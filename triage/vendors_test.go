@@ -0,0 +1,63 @@
+package triage
+
+import (
+	"context"
+	"testing"
+)
+
+// ---------------------------------------------------------------------------
+// Vendor registry
+// ---------------------------------------------------------------------------
+
+func TestNormalizeVendor_Presets(t *testing.T) {
+	for in, want := range map[string]string{
+		"Mistral":    VendorMistral,
+		"mistralai":  VendorMistral,
+		"mistral_ai": VendorMistral,
+		"GROQ":       VendorGroq,
+		"Cohere":     VendorCohere,
+		"deep-seek":  VendorDeepSeek,
+		"DeepSeek":   VendorDeepSeek,
+		"gemini":     VendorGemini,
+		"vllm":       "vllm",
+		"AcmeLLM":    "AcmeLLM",
+	} {
+		if got := normalizeVendor(in); got != want {
+			t.Errorf("normalizeVendor(%q): got %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestVendorForHost(t *testing.T) {
+	for host, want := range map[string]string{
+		"api.groq.com":     VendorGroq,
+		"API.Mistral.ai":   VendorMistral,
+		"api.deepseek.com": VendorDeepSeek,
+		"api.openai.com":   VendorOpenAI,
+		"localhost":        "",
+		"groq.example.com": "",
+	} {
+		if got := vendorForHost(host); got != want {
+			t.Errorf("vendorForHost(%q): got %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestLogPrompt_NormalizesVendor(t *testing.T) {
+	exporter := newGlobalTestProvider(t)
+
+	llmSpan, _ := LogPrompt(context.Background(), Prompt{Vendor: "Mistral", Model: "mistral-large-latest"})
+	llmSpan.LogCompletion(Completion{}, Usage{})
+
+	span := exporter.GetSpans()[0]
+	if span.Name != "mistral_ai.chat mistral-large-latest" {
+		t.Errorf("span name: got %q", span.Name)
+	}
+	attrs := attrMap(span.Attributes)
+	if attrs[AttrGenAISystem] != VendorMistral || attrs["llm.vendor"] != VendorMistral {
+		t.Errorf("vendor: got %v / %v", attrs[AttrGenAISystem], attrs["llm.vendor"])
+	}
+	if attrs[AttrGenAIOperationName] != "chat" {
+		t.Errorf("operation: got %v, want chat", attrs[AttrGenAIOperationName])
+	}
+}
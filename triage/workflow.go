@@ -2,18 +2,26 @@ package triage
 
 import (
 	"context"
+	"runtime/pprof"
+	"time"
 
-	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-// Traceloop span kind constants — matches go-openllmetry / OpenLLMetry conventions.
+// Traceloop span kind constants — matches go-openllmetry / OpenLLMetry
+// conventions. Pass them to WithDisabledSpanKinds to suppress a kind.
 const (
-	spanKindWorkflow = "workflow"
-	spanKindTask     = "task"
-	spanKindAgent    = "agent"
-	spanKindTool     = "tool"
+	SpanKindWorkflow = "workflow"
+	SpanKindTask     = "task"
+	SpanKindAgent    = "agent"
+	SpanKindTool     = "tool"
+)
+
+// pprof label keys attached to goroutines running inside a workflow span.
+const (
+	pprofLabelWorkflow = "triage.workflow"
+	pprofLabelSession  = "triage.session"
 )
 
 // workflowNameKey is an unexported context key for propagating the workflow
@@ -39,6 +47,34 @@ type Workflow struct {
 	span trace.Span
 	ctx  context.Context
 	name string
+
+	// parent is the context the workflow was started from. When profiler
+	// labels are enabled, End restores the goroutine's labels from it.
+	parent  context.Context
+	labeled bool
+
+	budget   *traceBudget  // set if this span opened the trace budget
+	usage    *usageRollup  // LLM usage of all calls beneath the workflow
+	deadline *spanDeadline // set by WorkflowTimeout
+}
+
+// WorkflowOption configures optional behavior for StartWorkflow.
+type WorkflowOption func(*workflowConfig)
+
+// workflowConfig holds the optional settings applied by WorkflowOptions.
+type workflowConfig struct {
+	dataset    *datasetCapture
+	timeout    time.Duration
+	hasTimeout bool
+}
+
+// WorkflowTimeout records the workflow's time budget on its span: the
+// deadline (the earlier of now+d and ctx's deadline) as triage.deadline and
+// d as triage.timeout_ms. If the workflow ends after the deadline, a
+// "deadline_exceeded" event is recorded with the overrun. d <= 0 uses ctx's
+// deadline alone. The option only annotates; it does not cancel anything.
+func WorkflowTimeout(d time.Duration) WorkflowOption {
+	return func(wc *workflowConfig) { wc.timeout, wc.hasTimeout = d, true }
 }
 
 // StartWorkflow creates a new workflow span and returns it along with a
@@ -46,29 +82,112 @@ type Workflow struct {
 //
 //	wf, ctx := triage.StartWorkflow(ctx, "chat-pipeline")
 //	defer wf.End()
-func StartWorkflow(ctx context.Context, name string) (*Workflow, context.Context) {
-	tracer := otel.GetTracerProvider().Tracer(llmTracerName)
+func StartWorkflow(ctx context.Context, name string, opts ...WorkflowOption) (*Workflow, context.Context) {
+	var wc workflowConfig
+	for _, o := range opts {
+		o(&wc)
+	}
+
+	parent := ctx
+	var budget *traceBudget
+	admitted := isSpanKindEnabled(SpanKindWorkflow)
+	if admitted {
+		ctx, budget, admitted = admitSpan(ctx)
+	}
+	if !admitted {
+		// Children still inherit the workflow name and dataset capture;
+		// they attach to the parent span instead. The non-recording span
+		// keeps attribute calls on the workflow harmless.
+		ctx = context.WithValue(ctx, workflowNameKey{}, name)
+		if wc.dataset != nil {
+			ctx = context.WithValue(ctx, datasetCaptureKey{}, wc.dataset)
+		}
+		return &Workflow{span: trace.SpanFromContext(context.Background()), ctx: ctx, name: name, parent: parent}, ctx
+	}
+
+	tracer := sdkTracer()
 	ctx, span := tracer.Start(ctx, name)
 
 	span.SetAttributes(
-		attribute.String("traceloop.span.kind", spanKindWorkflow),
+		attribute.String("traceloop.span.kind", SpanKindWorkflow),
 		attribute.String("traceloop.entity.name", name),
 		attribute.String("traceloop.workflow.name", name),
 	)
 
 	// Store workflow name in context so child spans inherit it.
 	ctx = context.WithValue(ctx, workflowNameKey{}, name)
+	if wc.dataset != nil {
+		ctx = context.WithValue(ctx, datasetCaptureKey{}, wc.dataset)
+	}
+
+	ctx, usage := withUsageRollup(ctx)
+
+	wf := &Workflow{span: span, name: name, parent: parent, budget: budget, usage: usage}
+	if wc.hasTimeout {
+		if wf.deadline = newSpanDeadline(ctx, wc.timeout); wf.deadline != nil {
+			span.SetAttributes(wf.deadline.attributes()...)
+		}
+	}
+	if isProfilerLabelsEnabled() {
+		ctx = withProfilerLabels(ctx, name)
+		pprof.SetGoroutineLabels(ctx)
+		wf.labeled = true
+	}
+	wf.ctx = ctx
 
-	return &Workflow{span: span, ctx: ctx, name: name}, ctx
+	return wf, ctx
 }
 
-// End ends the workflow span.
+// EnsureRootSpan starts a workflow span named name if ctx carries no active
+// span, so library-level instrumentation never produces orphaned, parentless
+// LLM spans when the application didn't create a root. The auto-created span
+// is marked with triage.root.auto=true.
+//
+// If ctx already carries a span (local or propagated from a remote caller),
+// ctx is returned unchanged with a Workflow whose End is a no-op, so callers
+// can always defer End:
+//
+//	wf, ctx := triage.EnsureRootSpan(ctx, "mylib.generate")
+//	defer wf.End()
+func EnsureRootSpan(ctx context.Context, name string, opts ...WorkflowOption) (*Workflow, context.Context) {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return &Workflow{ctx: ctx, name: name, parent: ctx}, ctx
+	}
+	wf, ctx := StartWorkflow(ctx, name, opts...)
+	wf.span.SetAttributes(attribute.Bool(AttrAutoRoot, true))
+	return wf, ctx
+}
+
+// End ends the workflow span, recording the token usage and estimated cost
+// of all LLM calls completed beneath it (triage.workflow.*) and, with
+// WorkflowTimeout, whether the deadline was exceeded. If profiler
+// labels were attached by StartWorkflow, the goroutine's labels are restored
+// to those of the parent context.
 func (w *Workflow) End() {
-	if w != nil && w.span != nil {
+	if w == nil {
+		return
+	}
+	if w.labeled {
+		pprof.SetGoroutineLabels(w.parent)
+	}
+	if w.span != nil {
+		w.usage.report(w.span)
+		w.deadline.check(w.span)
+		w.budget.report(w.span)
 		w.span.End()
 	}
 }
 
+// SetError records a failed workflow on its span and sets the span's status
+// to Error, with the error message scrubbed as for LLMSpan.SetError. Safe to
+// call on a nil Workflow (no-op).
+func (w *Workflow) SetError(err error) {
+	if w == nil || w.span == nil || err == nil {
+		return
+	}
+	recordSpanError(w.span, err)
+}
+
 // Context returns the context carrying this workflow span.
 func (w *Workflow) Context() context.Context {
 	if w == nil {
@@ -77,41 +196,107 @@ func (w *Workflow) Context() context.Context {
 	return w.ctx
 }
 
+// withProfilerLabels returns ctx carrying pprof labels for the workflow name
+// and, when present, the triage session ID.
+func withProfilerLabels(ctx context.Context, workflow string) context.Context {
+	labels := []string{pprofLabelWorkflow, workflow}
+	if sid := getFromContext(ctx).sessionID; sid != "" {
+		labels = append(labels, pprofLabelSession, sid)
+	}
+	return pprof.WithLabels(ctx, pprof.Labels(labels...))
+}
+
+// isSpanKindEnabled returns whether spans of the given kind should be
+// created. All kinds are enabled if the SDK hasn't been initialized yet.
+func isSpanKindEnabled(kind string) bool {
+	if globalCfg == nil {
+		return true
+	}
+	return !globalCfg.disabledKinds[kind]
+}
+
+// isProfilerLabelsEnabled returns whether workflow spans should attach pprof
+// labels. Defaults to false if the SDK hasn't been initialized yet.
+func isProfilerLabelsEnabled() bool {
+	if globalCfg == nil {
+		return false
+	}
+	return globalCfg.profilerLabels
+}
+
 // ---------------------------------------------------------------------------
 // Task
 // ---------------------------------------------------------------------------
 
 // Task represents a traced task span — a discrete step within a workflow.
 type Task struct {
-	span trace.Span
-	ctx  context.Context
-	name string
+	span     trace.Span
+	ctx      context.Context
+	name     string
+	budget   *traceBudget
+	deadline *spanDeadline // set by TaskTimeout
+}
+
+// TaskOption configures optional behavior for StartTask.
+type TaskOption func(*taskConfig)
+
+// taskConfig holds the optional settings applied by TaskOptions.
+type taskConfig struct {
+	timeout    time.Duration
+	hasTimeout bool
+}
+
+// TaskTimeout records the task's time budget, like WorkflowTimeout does for
+// workflows: a "deadline_exceeded" event is recorded if the task ends after
+// its deadline.
+func TaskTimeout(d time.Duration) TaskOption {
+	return func(tc *taskConfig) { tc.timeout, tc.hasTimeout = d, true }
 }
 
 // StartTask creates a new task span. If the context carries a workflow, the
 // task automatically inherits the workflow name:
 //
-//	task, ctx := triage.StartTask(ctx, "parse-input")
+//	task, ctx := triage.StartTask(ctx, "parse-input", triage.TaskTimeout(2*time.Second))
 //	defer task.End()
-func StartTask(ctx context.Context, name string) (*Task, context.Context) {
-	tracer := otel.GetTracerProvider().Tracer(llmTracerName)
+func StartTask(ctx context.Context, name string, opts ...TaskOption) (*Task, context.Context) {
+	if !isSpanKindEnabled(SpanKindTask) {
+		return &Task{ctx: ctx, name: name}, ctx
+	}
+	ctx, budget, ok := admitSpan(ctx)
+	if !ok {
+		return &Task{ctx: ctx, name: name}, ctx
+	}
+	tracer := sdkTracer()
 	ctx, span := tracer.Start(ctx, name)
 
 	attrs := []attribute.KeyValue{
-		attribute.String("traceloop.span.kind", spanKindTask),
+		attribute.String("traceloop.span.kind", SpanKindTask),
 		attribute.String("traceloop.entity.name", name),
 	}
 	if wf := workflowNameFromContext(ctx); wf != "" {
 		attrs = append(attrs, attribute.String("traceloop.workflow.name", wf))
 	}
+	var tc taskConfig
+	for _, o := range opts {
+		o(&tc)
+	}
+	var deadline *spanDeadline
+	if tc.hasTimeout {
+		if deadline = newSpanDeadline(ctx, tc.timeout); deadline != nil {
+			attrs = append(attrs, deadline.attributes()...)
+		}
+	}
 	span.SetAttributes(attrs...)
 
-	return &Task{span: span, ctx: ctx, name: name}, ctx
+	return &Task{span: span, ctx: ctx, name: name, budget: budget, deadline: deadline}, ctx
 }
 
-// End ends the task span.
+// End ends the task span, recording a deadline_exceeded event if it ran past
+// the deadline set with TaskTimeout.
 func (t *Task) End() {
 	if t != nil && t.span != nil {
+		t.deadline.check(t.span)
+		t.budget.report(t.span)
 		t.span.End()
 	}
 }
@@ -131,9 +316,10 @@ func (t *Task) Context() context.Context {
 // Agent represents a traced agent span — an autonomous entity that can make
 // LLM calls and use tools.
 type Agent struct {
-	span trace.Span
-	ctx  context.Context
-	name string
+	span   trace.Span
+	ctx    context.Context
+	name   string
+	budget *traceBudget
 }
 
 // StartAgent creates a new agent span:
@@ -141,11 +327,18 @@ type Agent struct {
 //	agent, ctx := triage.StartAgent(ctx, "research-agent")
 //	defer agent.End()
 func StartAgent(ctx context.Context, name string) (*Agent, context.Context) {
-	tracer := otel.GetTracerProvider().Tracer(llmTracerName)
+	if !isSpanKindEnabled(SpanKindAgent) {
+		return &Agent{ctx: ctx, name: name}, ctx
+	}
+	ctx, budget, ok := admitSpan(ctx)
+	if !ok {
+		return &Agent{ctx: ctx, name: name}, ctx
+	}
+	tracer := sdkTracer()
 	ctx, span := tracer.Start(ctx, name)
 
 	attrs := []attribute.KeyValue{
-		attribute.String("traceloop.span.kind", spanKindAgent),
+		attribute.String("traceloop.span.kind", SpanKindAgent),
 		attribute.String("traceloop.entity.name", name),
 		attribute.String("llm.agent.name", name),
 	}
@@ -154,12 +347,13 @@ func StartAgent(ctx context.Context, name string) (*Agent, context.Context) {
 	}
 	span.SetAttributes(attrs...)
 
-	return &Agent{span: span, ctx: ctx, name: name}, ctx
+	return &Agent{span: span, ctx: ctx, name: name, budget: budget}, ctx
 }
 
 // End ends the agent span.
 func (a *Agent) End() {
 	if a != nil && a.span != nil {
+		a.budget.report(a.span)
 		a.span.End()
 	}
 }
@@ -179,34 +373,105 @@ func (a *Agent) Context() context.Context {
 // ToolSpan represents a traced tool execution span — a function or API call
 // made by an agent during processing.
 type ToolSpan struct {
-	span trace.Span
-	ctx  context.Context
-	name string
+	span   trace.Span
+	ctx    context.Context
+	name   string
+	budget *traceBudget
+}
+
+// ToolOption configures optional behavior for StartTool.
+type ToolOption func(*toolConfig)
+
+// toolConfig holds the optional settings applied by ToolOptions.
+type toolConfig struct {
+	input    any
+	hasInput bool
+}
+
+// ToolInput records the tool's input (e.g. the model's call arguments) on the
+// span as traceloop.entity.input. Strings are recorded as-is; other values
+// are JSON-encoded. Only captured when trace content is enabled.
+func ToolInput(v any) ToolOption {
+	return func(tc *toolConfig) { tc.input, tc.hasInput = v, true }
 }
 
-// StartTool creates a new tool execution span:
+// StartTool creates a new tool execution span. Record what the tool returned
+// to the model with SetResult — tool output is the main vector for indirect
+// prompt injection:
 //
-//	tool, ctx := triage.StartTool(ctx, "get-weather")
+//	tool, ctx := triage.StartTool(ctx, "get-weather", triage.ToolInput(call.Function.Arguments))
 //	defer tool.End()
-func StartTool(ctx context.Context, name string) (*ToolSpan, context.Context) {
-	tracer := otel.GetTracerProvider().Tracer(llmTracerName)
+//	out, err := getWeather(ctx, args)
+//	tool.SetResult(out, err)
+func StartTool(ctx context.Context, name string, opts ...ToolOption) (*ToolSpan, context.Context) {
+	if !isSpanKindEnabled(SpanKindTool) {
+		return &ToolSpan{ctx: ctx, name: name}, ctx
+	}
+	ctx, budget, ok := admitSpan(ctx)
+	if !ok {
+		return &ToolSpan{ctx: ctx, name: name}, ctx
+	}
+	tracer := sdkTracer()
 	ctx, span := tracer.Start(ctx, name)
 
 	attrs := []attribute.KeyValue{
-		attribute.String("traceloop.span.kind", spanKindTool),
+		attribute.String("traceloop.span.kind", SpanKindTool),
 		attribute.String("traceloop.entity.name", name),
 	}
 	if wf := workflowNameFromContext(ctx); wf != "" {
 		attrs = append(attrs, attribute.String("traceloop.workflow.name", wf))
 	}
+	var tc toolConfig
+	for _, o := range opts {
+		o(&tc)
+	}
+	if tc.hasInput && isTraceContentEnabled() {
+		if in, ok := encodeToolValue("StartTool", tc.input); ok {
+			attrs = append(attrs, attribute.String(AttrEntityInput, in))
+		}
+	}
 	span.SetAttributes(attrs...)
 
-	return &ToolSpan{span: span, ctx: ctx, name: name}, ctx
+	return &ToolSpan{span: span, ctx: ctx, name: name, budget: budget}, ctx
+}
+
+// SetResult records the tool's output as traceloop.entity.output (strings
+// as-is, other values JSON-encoded; only when trace content is enabled) and,
+// if err is non-nil, marks the span failed with the scrubbed error message.
+// Safe to call on a nil ToolSpan (no-op).
+func (t *ToolSpan) SetResult(output any, err error) {
+	if t == nil || t.span == nil {
+		return
+	}
+	if output != nil && isTraceContentEnabled() {
+		if out, ok := encodeToolValue("SetResult", output); ok {
+			t.span.SetAttributes(attribute.String(AttrEntityOutput, out))
+		}
+	}
+	if err != nil {
+		recordSpanError(t.span, err)
+	}
+}
+
+// encodeToolValue renders a tool input or output for a span attribute.
+func encodeToolValue(where string, v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+	data, err := safeMarshal(where, v)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
 }
 
 // End ends the tool span.
 func (t *ToolSpan) End() {
 	if t != nil && t.span != nil {
+		t.budget.report(t.span)
 		t.span.End()
 	}
 }
@@ -0,0 +1,53 @@
+// Package triagechi instruments github.com/go-chi/chi/v5 routers so each
+// request runs in a Triage workflow span named after the matched chi route
+// pattern, the way triage.Middleware does for net/http:
+//
+//	r := chi.NewRouter()
+//	r.Use(triagechi.Middleware(triage.WithContextHeaders()))
+//	r.Post("/rag/{collection}/query", queryHandler)
+//	// Workflow: "POST /rag/{collection}/query", with http.route set on the span
+//
+// LLM calls made with the request context are nested under the request's
+// workflow and carry its triage context.
+//
+// It is a separate module so that the core triage module doesn't depend on
+// chi.
+package triagechi
+
+import (
+	"net/http"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"github.com/go-chi/chi/v5"
+)
+
+// Middleware returns chi middleware equivalent to triage.Middleware. It
+// takes the same options; the route pattern is chi's ("/users/{id}"), so
+// triage.WithSkipRoutes takes chi patterns. It works on the root router and
+// on sub-routers created with Route, Group or Mount.
+func Middleware(opts ...triage.MiddlewareOption) func(http.Handler) http.Handler {
+	opts = append([]triage.MiddlewareOption{triage.WithRouteNamer(Route)}, opts...)
+	return func(next http.Handler) http.Handler {
+		return triage.Middleware(next, opts...)
+	}
+}
+
+// Route is a triage.RouteNamer for chi. It looks the request up in the
+// router's tree, so the pattern is known before dispatch, when the workflow
+// starts, falling back to the pattern chi has matched so far.
+func Route(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+	if rctx.Routes != nil {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+		if pattern := rctx.Routes.Find(chi.NewRouteContext(), r.Method, path); pattern != "" {
+			return pattern
+		}
+	}
+	return rctx.RoutePattern()
+}
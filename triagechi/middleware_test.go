@@ -0,0 +1,139 @@
+package triagechi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestExporter installs a global tracer provider backed by an in-memory
+// exporter.
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func attrMap(attrs []attribute.KeyValue) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[string(a.Key)] = a.Value.AsInterface()
+	}
+	return m
+}
+
+func TestMiddleware_NamesWorkflowFromRoutePattern(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware())
+	r.Route("/rag", func(r chi.Router) {
+		r.Post("/{collection}/query", func(w http.ResponseWriter, r *http.Request) {
+			llmSpan, _ := triage.LogPrompt(r.Context(), triage.Prompt{Vendor: "openai", Model: "gpt-4o"})
+			llmSpan.LogCompletion(triage.Completion{}, triage.Usage{})
+			w.WriteHeader(http.StatusAccepted)
+		})
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/rag/docs/query", nil))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/rag/faq/query", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 4 {
+		t.Fatalf("expected 4 spans, got %d", len(spans))
+	}
+	for i := 0; i < len(spans); i += 2 {
+		llm, wf := spans[i], spans[i+1]
+		if wf.Name != "POST /rag/{collection}/query" {
+			t.Errorf("workflow span name: got %q", wf.Name)
+		}
+		if got := attrMap(wf.Attributes)[triage.AttrHTTPRoute]; got != "/rag/{collection}/query" {
+			t.Errorf("http.route: got %v", got)
+		}
+		if got := attrMap(wf.Attributes)[triage.AttrHTTPResponseStatusCode]; got != int64(http.StatusAccepted) {
+			t.Errorf("http.response.status_code: got %v", got)
+		}
+		if llm.Parent.SpanID() != wf.SpanContext.SpanID() {
+			t.Error("LLM span should be nested under the request workflow")
+		}
+	}
+}
+
+func TestMiddleware_SubRouter(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	api := chi.NewRouter()
+	api.Use(Middleware())
+	api.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	r := chi.NewRouter()
+	r.Mount("/api", api)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/users/7", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "GET /api/users/{id}" {
+		t.Fatalf("expected one workflow for the mounted route, got %v", spans)
+	}
+}
+
+func TestMiddleware_UnmatchedRoute(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware())
+	r.Get("/chat", func(w http.ResponseWriter, r *http.Request) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Name != "HTTP GET" {
+		t.Fatalf("expected one %q span, got %v", "HTTP GET", spans)
+	}
+}
+
+func TestMiddleware_SkipRoutes(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	r := chi.NewRouter()
+	r.Use(Middleware(triage.WithSkipRoutes("/healthz")))
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans for a skipped route, got %d", n)
+	}
+}
+
+func TestMiddleware_ContextHeaders(t *testing.T) {
+	newTestExporter(t)
+
+	var session string
+	r := chi.NewRouter()
+	r.Use(Middleware(triage.WithContextHeaders()))
+	r.Get("/chat", func(w http.ResponseWriter, r *http.Request) {
+		h := http.Header{}
+		triage.SetContextHeaders(r.Context(), h)
+		session = h.Get(triage.HeaderSession)
+	})
+	req := httptest.NewRequest("GET", "/chat", nil)
+	req.Header.Set(triage.HeaderSession, "sess_1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if session != "sess_1" {
+		t.Errorf("handler session: got %q", session)
+	}
+}
@@ -0,0 +1,137 @@
+// Package triageecho instruments github.com/labstack/echo/v4 servers so each
+// request runs in a Triage workflow span named after the matched Echo route,
+// the way triage.Middleware does for net/http:
+//
+//	e := echo.New()
+//	e.Use(triageecho.Middleware(
+//	    triage.WithSkipRoutes("/healthz"),
+//	    triage.WithAnonymousSessions(),
+//	))
+//
+// LLM calls made with c.Request().Context() are nested under the request's
+// workflow and carry its triage context.
+//
+// It is a separate module so that the core triage module doesn't depend on
+// Echo.
+package triageecho
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// callKey is the request context key of the in-flight Echo call.
+type callKey struct{}
+
+// call is one request passing through the middleware.
+type call struct {
+	c            echo.Context
+	next         echo.HandlerFunc
+	parent       trace.Span // span of the request before the middleware
+	returnErrors bool
+	err          error // handler error, when returnErrors is set
+}
+
+// Config configures MiddlewareWithConfig.
+type Config struct {
+	// Options are the triage.Middleware options, as taken by Middleware.
+	Options []triage.MiddlewareOption
+
+	// ReturnErrors makes the middleware return errors from handlers to the
+	// middlewares around it, which eventually pass them to Echo's HTTP error
+	// handler, instead of handling them itself. The workflow then ends before
+	// the error response is written, so it records the status the error
+	// stands for: an *echo.HTTPError's code, or 500 for other errors.
+	ReturnErrors bool
+}
+
+// Middleware returns Echo middleware equivalent to triage.Middleware. It
+// takes the same options; the route pattern is Echo's matched path
+// ("GET /users/:id"), so triage.WithSkipRoutes takes Echo paths.
+//
+// Errors returned by handlers are passed to Echo's HTTP error handler inside
+// the workflow, so the span records the status code actually sent, and 5xx
+// responses mark the span as failed. The middleware then returns nil, as
+// Echo's RequestLogger does with HandleError, so middlewares registered
+// before it don't see handler errors; use MiddlewareWithConfig with
+// ReturnErrors if they must.
+func Middleware(opts ...triage.MiddlewareOption) echo.MiddlewareFunc {
+	return MiddlewareWithConfig(Config{Options: opts})
+}
+
+// MiddlewareWithConfig is Middleware with the settings in config.
+func MiddlewareWithConfig(config Config) echo.MiddlewareFunc {
+	opts := append([]triage.MiddlewareOption{triage.WithRouteNamer(echoRoute)}, config.Options...)
+	h := triage.Middleware(http.HandlerFunc(serve), opts...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r := c.Request()
+			cl := &call{
+				c:            c,
+				next:         next,
+				parent:       trace.SpanFromContext(r.Context()),
+				returnErrors: config.ReturnErrors,
+			}
+			ctx := context.WithValue(r.Context(), callKey{}, cl)
+			h.ServeHTTP(c.Response().Writer, r.WithContext(ctx))
+			return cl.err
+		}
+	}
+}
+
+// serve runs the Echo handler chain inside the request's workflow, writing
+// the response through w so triage.Middleware sees its status.
+func serve(w http.ResponseWriter, r *http.Request) {
+	cl := r.Context().Value(callKey{}).(*call)
+	res := cl.c.Response()
+	prev := res.Writer
+	res.Writer = w
+	defer func() { res.Writer = prev }()
+
+	cl.c.SetRequest(r)
+	err := cl.next(cl.c)
+	if err == nil {
+		return
+	}
+	if !cl.returnErrors {
+		cl.c.Error(err)
+		return
+	}
+	cl.err = err
+
+	span := trace.SpanFromContext(r.Context())
+	if span == cl.parent || res.Committed {
+		return // skipped, or the status was already written
+	}
+	status := errorStatus(err)
+	span.SetAttributes(attribute.Int(triage.AttrHTTPResponseStatusCode, status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
+
+// errorStatus returns the status Echo's default HTTP error handler sends for
+// err.
+func errorStatus(err error) int {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
+
+// echoRoute is the triage.RouteNamer for Echo: the path pattern of the
+// matched route, or "" if no route matched.
+func echoRoute(r *http.Request) string {
+	if cl, ok := r.Context().Value(callKey{}).(*call); ok {
+		return cl.c.Path()
+	}
+	return ""
+}
@@ -0,0 +1,189 @@
+package triageecho
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestExporter installs a global tracer provider backed by an in-memory
+// exporter.
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+// newTestServer installs a test exporter and returns an Echo server using
+// Middleware with opts.
+func newTestServer(t *testing.T, opts ...triage.MiddlewareOption) (*echo.Echo, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := newTestExporter(t)
+	e := echo.New()
+	e.Use(Middleware(opts...))
+	return e, exporter
+}
+
+func attrMap(attrs []attribute.KeyValue) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[string(a.Key)] = a.Value.AsInterface()
+	}
+	return m
+}
+
+func TestMiddleware_NamesWorkflowFromEchoRoute(t *testing.T) {
+	e, exporter := newTestServer(t)
+	e.GET("/users/:id", func(c echo.Context) error {
+		llmSpan, _ := triage.LogPrompt(c.Request().Context(), triage.Prompt{Vendor: "openai", Model: "gpt-4o"})
+		llmSpan.LogCompletion(triage.Completion{}, triage.Usage{})
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected LLM and workflow spans, got %d", len(spans))
+	}
+	llm, wf := spans[0], spans[1]
+	if wf.Name != "GET /users/:id" {
+		t.Errorf("workflow span name: got %q", wf.Name)
+	}
+	attrs := attrMap(wf.Attributes)
+	if attrs[triage.AttrHTTPRoute] != "/users/:id" || attrs[triage.AttrHTTPResponseStatusCode] != int64(http.StatusNoContent) {
+		t.Errorf("workflow attributes: %v", attrs)
+	}
+	if llm.Parent.SpanID() != wf.SpanContext.SpanID() {
+		t.Error("LLM span should be nested under the request workflow")
+	}
+}
+
+func TestMiddleware_HandlerErrors(t *testing.T) {
+	e, exporter := newTestServer(t)
+	e.GET("/missing", func(c echo.Context) error { return echo.ErrNotFound })
+	e.GET("/broken", func(c echo.Context) error { return errors.New("boom") })
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		failed bool
+	}{
+		{"/missing", http.StatusNotFound, false},
+		{"/broken", http.StatusInternalServerError, true},
+	} {
+		exporter.Reset()
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+
+		if rec.Code != tc.status {
+			t.Errorf("%s: response status got %d, want %d", tc.path, rec.Code, tc.status)
+		}
+		wf := exporter.GetSpans()[0]
+		if got := attrMap(wf.Attributes)[triage.AttrHTTPResponseStatusCode]; got != int64(tc.status) {
+			t.Errorf("%s: recorded status got %v", tc.path, got)
+		}
+		if failed := wf.Status.Code == codes.Error; failed != tc.failed {
+			t.Errorf("%s: span failed = %v, want %v", tc.path, failed, tc.failed)
+		}
+	}
+}
+
+func TestMiddlewareWithConfig_ReturnErrors(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	var seen []error
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			seen = append(seen, err)
+			return err
+		}
+	})
+	e.Use(MiddlewareWithConfig(Config{ReturnErrors: true}))
+	e.GET("/missing", func(c echo.Context) error { return echo.ErrNotFound })
+	e.GET("/broken", func(c echo.Context) error { return errors.New("boom") })
+
+	for _, tc := range []struct {
+		path   string
+		status int
+		failed bool
+	}{
+		{"/missing", http.StatusNotFound, false},
+		{"/broken", http.StatusInternalServerError, true},
+	} {
+		exporter.Reset()
+		seen = nil
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+
+		if len(seen) != 1 || seen[0] == nil {
+			t.Errorf("%s: the outer middleware should see the handler error, got %v", tc.path, seen)
+		}
+		if rec.Code != tc.status {
+			t.Errorf("%s: response status got %d, want %d", tc.path, rec.Code, tc.status)
+		}
+		wf := exporter.GetSpans()[0]
+		if got := attrMap(wf.Attributes)[triage.AttrHTTPResponseStatusCode]; got != int64(tc.status) {
+			t.Errorf("%s: recorded status got %v", tc.path, got)
+		}
+		if failed := wf.Status.Code == codes.Error; failed != tc.failed {
+			t.Errorf("%s: span failed = %v, want %v", tc.path, failed, tc.failed)
+		}
+	}
+}
+
+func TestMiddleware_SkipRoutes(t *testing.T) {
+	e, exporter := newTestServer(t, triage.WithSkipRoutes("/healthz"))
+	e.GET("/healthz", func(c echo.Context) error { return c.String(http.StatusOK, "ok") })
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("skipped route should still be served, got %d %q", rec.Code, rec.Body.String())
+	}
+	if n := len(exporter.GetSpans()); n != 0 {
+		t.Errorf("expected no spans for a skipped route, got %d", n)
+	}
+}
+
+func TestMiddleware_AnonymousSessions(t *testing.T) {
+	e, _ := newTestServer(t, triage.WithAnonymousSessions())
+	var session string
+	e.GET("/chat", func(c echo.Context) error {
+		h := http.Header{}
+		triage.SetContextHeaders(c.Request().Context(), h)
+		session = h.Get(triage.HeaderSession)
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/chat", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != triage.SessionCookie {
+		t.Fatalf("expected a session cookie, got %v", cookies)
+	}
+	if session != cookies[0].Value {
+		t.Errorf("handler session: got %q, want %q", session, cookies[0].Value)
+	}
+}
@@ -0,0 +1,202 @@
+// Package triagegrpc instruments gRPC servers and clients. Server
+// interceptors run each call in a Triage workflow span named after the
+// method, the way triage.Middleware does for net/http; client interceptors
+// carry the trace and Triage context to the services called:
+//
+//	srv := grpc.NewServer(
+//	    grpc.UnaryInterceptor(triagegrpc.UnaryServerInterceptor(triagegrpc.WithContextHeaders())),
+//	    grpc.StreamInterceptor(triagegrpc.StreamServerInterceptor(triagegrpc.WithContextHeaders())),
+//	)
+//
+// It is a separate module so that the core triage module doesn't import
+// gRPC.
+package triagegrpc
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Option configures the server interceptors.
+type Option func(*config)
+
+// config holds the settings applied by Options.
+type config struct {
+	contextHeaders bool
+	names          triage.HeaderNames
+}
+
+// newConfig applies opts.
+func newConfig(opts []Option) *config {
+	var c config
+	for _, o := range opts {
+		o(&c)
+	}
+	return &c
+}
+
+// WithContextHeaders makes the server interceptors read the user, tenant and
+// session from the Triage context metadata (triage.HeaderUser,
+// triage.HeaderTenant, triage.HeaderSession) set by a caller's
+// UnaryClientInterceptor. Enable it only for services behind a trusted
+// boundary, as for triage.WithContextHeaders.
+func WithContextHeaders() Option {
+	return WithContextHeaderNames(triage.HeaderNames{})
+}
+
+// WithContextHeaderNames is WithContextHeaders reading the Triage context
+// from the given metadata keys instead of the defaults. The same trust
+// caveat applies.
+func WithContextHeaderNames(names triage.HeaderNames) Option {
+	return func(c *config) { c.contextHeaders, c.names = true, names }
+}
+
+// UnaryServerInterceptor returns a gRPC interceptor that runs each call in a
+// root workflow span named after the method ("/pkg.Service/Method"),
+// continuing the caller's trace from the incoming metadata, with LLM calls
+// made by the handler nested beneath it. With WithContextHeaders, the user,
+// tenant and session set by the caller are read from the metadata as well.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	c := newConfig(opts)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		wf, ctx := c.startRPC(ctx, info.FullMethod)
+		resp, err := handler(ctx, req)
+		endRPC(wf, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor: the workflow span covers the whole stream.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	c := newConfig(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wf, ctx := c.startRPC(ss.Context(), info.FullMethod)
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+		endRPC(wf, err)
+		return err
+	}
+}
+
+// UnaryClientInterceptor returns a gRPC interceptor that adds the trace
+// context and the Triage context (user, tenant and session, see
+// triage.HeaderUser) of each call's ctx to its outgoing metadata, so services
+// using UnaryServerInterceptor keep attribution across the hop:
+//
+//	conn, err := grpc.NewClient(target,
+//	    grpc.WithUnaryInterceptor(triagegrpc.UnaryClientInterceptor()),
+//	    grpc.WithStreamInterceptor(triagegrpc.StreamClientInterceptor()),
+//	)
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRPCContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is the streaming counterpart of
+// UnaryClientInterceptor.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRPCContext(ctx), desc, cc, method, opts...)
+	}
+}
+
+// startRPC starts the workflow span of an incoming call to fullMethod,
+// continuing the trace (and, if enabled, the Triage context) carried by the
+// incoming metadata.
+func (c *config) startRPC(ctx context.Context, fullMethod string) (*triage.Workflow, context.Context) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		if c.contextHeaders {
+			h := make(http.Header, len(md))
+			for k, vs := range md {
+				for _, v := range vs {
+					h.Add(k, v)
+				}
+			}
+			ctx = triage.ContextFromHeaderNames(ctx, h, c.names)
+		}
+	}
+	wf, ctx := triage.StartWorkflow(ctx, fullMethod)
+	service, method := splitFullMethod(fullMethod)
+	trace.SpanFromContext(ctx).SetAttributes(
+		attribute.String(triage.AttrRPCSystem, "grpc"),
+		attribute.String(triage.AttrRPCService, service),
+		attribute.String(triage.AttrRPCMethod, method),
+	)
+	return wf, ctx
+}
+
+// endRPC records the call's status code, and its error if any, then ends
+// the workflow span.
+func endRPC(wf *triage.Workflow, err error) {
+	trace.SpanFromContext(wf.Context()).SetAttributes(
+		attribute.Int(triage.AttrRPCGRPCStatusCode, int(status.Code(err))),
+	)
+	wf.SetError(err)
+	wf.End()
+}
+
+// outgoingRPCContext returns ctx with the trace context and Triage context
+// added to its outgoing metadata.
+func outgoingRPCContext(ctx context.Context) context.Context {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	h := http.Header{}
+	triage.SetContextHeaders(ctx, h)
+	for k, vs := range h {
+		md.Set(k, vs...)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// splitFullMethod splits "/pkg.Service/Method" into its service and method.
+func splitFullMethod(fullMethod string) (service, method string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "", service
+	}
+	return service, method
+}
+
+// metadataCarrier adapts gRPC metadata to a propagation.TextMapCarrier.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if vs := metadata.MD(c).Get(key); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// contextServerStream overrides the context of a server stream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,275 @@
+package triagegrpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newTestExporter installs a global tracer provider backed by an in-memory
+// exporter, and the W3C trace context propagator.
+func newTestExporter(t *testing.T) *tracetest.InMemoryExporter {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prevTP, prevProp := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevTP)
+		otel.SetTextMapPropagator(prevProp)
+		_ = tp.Shutdown(context.Background())
+	})
+	return exporter
+}
+
+func attrMap(attrs []attribute.KeyValue) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, a := range attrs {
+		m[string(a.Key)] = a.Value.AsInterface()
+	}
+	return m
+}
+
+// contextHeaders returns the Triage context headers carried by ctx.
+func contextHeaders(ctx context.Context) http.Header {
+	h := http.Header{}
+	triage.SetContextHeaders(ctx, h)
+	return h
+}
+
+// incomingRPC returns a context carrying incoming metadata with a remote
+// trace parent and the Triage context headers.
+func incomingRPC() (context.Context, trace.TraceID) {
+	traceID := trace.TraceID{0xab, 1}
+	md := metadata.Pairs(
+		"traceparent", "00-"+traceID.String()+"-0102030405060708-01",
+		"x-triage-user", "u_1",
+		"x-triage-tenant", "org_1",
+		"x-triage-session", "sess_1",
+		"x-auth-user", "u_2",
+	)
+	return metadata.NewIncomingContext(context.Background(), md), traceID
+}
+
+// ---------------------------------------------------------------------------
+// Server interceptors
+// ---------------------------------------------------------------------------
+
+func TestUnaryServerInterceptor_StartsWorkflowInCallerTrace(t *testing.T) {
+	exporter := newTestExporter(t)
+	ctx, traceID := incomingRPC()
+
+	var handlerCtx context.Context
+	intercept := UnaryServerInterceptor(WithContextHeaders())
+	_, err := intercept(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/support.Agent/Chat"},
+		func(ctx context.Context, req any) (any, error) {
+			handlerCtx = ctx
+			return "resp", nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "/support.Agent/Chat" || span.SpanContext.TraceID() != traceID {
+		t.Errorf("expected a workflow in the caller's trace, got %q in %s", span.Name, span.SpanContext.TraceID())
+	}
+	attrs := attrMap(span.Attributes)
+	want := map[string]any{
+		triage.AttrRPCSystem:         "grpc",
+		triage.AttrRPCService:        "support.Agent",
+		triage.AttrRPCMethod:         "Chat",
+		triage.AttrRPCGRPCStatusCode: int64(0),
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], v)
+		}
+	}
+	h := contextHeaders(handlerCtx)
+	if h.Get(triage.HeaderUser) != "u_1" || h.Get(triage.HeaderTenant) != "org_1" || h.Get(triage.HeaderSession) != "sess_1" {
+		t.Errorf("handler context should carry the triage context, got %v", h)
+	}
+}
+
+func TestUnaryServerInterceptor_IgnoresContextMetadataByDefault(t *testing.T) {
+	newTestExporter(t)
+	ctx, _ := incomingRPC()
+
+	var handlerCtx context.Context
+	intercept := UnaryServerInterceptor()
+	intercept(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/support.Agent/Chat"},
+		func(ctx context.Context, req any) (any, error) {
+			handlerCtx = ctx
+			return nil, nil
+		})
+
+	if h := contextHeaders(handlerCtx); len(h) != 0 {
+		t.Errorf("context metadata should only be trusted with WithContextHeaders, got %v", h)
+	}
+}
+
+func TestUnaryServerInterceptor_ContextHeaderNames(t *testing.T) {
+	newTestExporter(t)
+	ctx, _ := incomingRPC()
+
+	var handlerCtx context.Context
+	intercept := UnaryServerInterceptor(WithContextHeaderNames(triage.HeaderNames{User: "X-Auth-User"}))
+	intercept(ctx, "req", &grpc.UnaryServerInfo{FullMethod: "/support.Agent/Chat"},
+		func(ctx context.Context, req any) (any, error) {
+			handlerCtx = ctx
+			return nil, nil
+		})
+
+	h := contextHeaders(handlerCtx)
+	if h.Get(triage.HeaderUser) != "u_2" || h.Get(triage.HeaderSession) != "sess_1" {
+		t.Errorf("expected the renamed user key and the default session key, got %v", h)
+	}
+}
+
+func TestUnaryServerInterceptor_RecordsErrorStatus(t *testing.T) {
+	exporter := newTestExporter(t)
+
+	intercept := UnaryServerInterceptor()
+	_, err := intercept(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/support.Agent/Chat"},
+		func(ctx context.Context, req any) (any, error) {
+			return nil, status.Error(grpccodes.ResourceExhausted, "quota exceeded")
+		})
+	if status.Code(err) != grpccodes.ResourceExhausted {
+		t.Fatalf("the handler's error should be returned, got %v", err)
+	}
+
+	span := exporter.GetSpans()[0]
+	if span.Status.Code != codes.Error {
+		t.Errorf("expected an error status, got %+v", span.Status)
+	}
+	if got := attrMap(span.Attributes)[triage.AttrRPCGRPCStatusCode]; got != int64(grpccodes.ResourceExhausted) {
+		t.Errorf("status code: got %v", got)
+	}
+}
+
+// fakeServerStream is a grpc.ServerStream with a fixed context.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s fakeServerStream) Context() context.Context { return s.ctx }
+
+func TestStreamServerInterceptor_WrapsStreamContext(t *testing.T) {
+	exporter := newTestExporter(t)
+	ctx, traceID := incomingRPC()
+
+	var streamCtx context.Context
+	intercept := StreamServerInterceptor(WithContextHeaders())
+	err := intercept(nil, fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: "/support.Agent/Stream"},
+		func(srv any, ss grpc.ServerStream) error {
+			streamCtx = ss.Context()
+			return errors.New("stream broke")
+		})
+	if err == nil {
+		t.Fatal("expected the handler's error")
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].SpanContext.TraceID() != traceID {
+		t.Fatalf("expected 1 span in the caller's trace, got %+v", spans)
+	}
+	if trace.SpanContextFromContext(streamCtx).SpanID() != spans[0].SpanContext.SpanID() {
+		t.Error("the stream context should carry the workflow span")
+	}
+	if contextHeaders(streamCtx).Get(triage.HeaderSession) != "sess_1" {
+		t.Error("the stream context should carry the session")
+	}
+	if got := attrMap(spans[0].Attributes)[triage.AttrRPCGRPCStatusCode]; got != int64(grpccodes.Unknown) {
+		t.Errorf("status code: got %v, want Unknown", got)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Client interceptors
+// ---------------------------------------------------------------------------
+
+func TestUnaryClientInterceptor_InjectsContext(t *testing.T) {
+	newTestExporter(t)
+
+	wf, ctx := triage.StartWorkflow(context.Background(), "caller")
+	defer wf.End()
+	ctx = triage.WithUser(ctx, "u_1")
+	ctx = triage.WithSession(ctx, "sess_1")
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-app", "kept")
+
+	var md metadata.MD
+	intercept := UnaryClientInterceptor()
+	err := intercept(ctx, "/support.Agent/Chat", "req", nil, nil,
+		func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := md.Get("x-triage-user"); len(got) != 1 || got[0] != "u_1" {
+		t.Errorf("user: got %v", got)
+	}
+	if got := md.Get("x-triage-session"); len(got) != 1 || got[0] != "sess_1" {
+		t.Errorf("session: got %v", got)
+	}
+	if got := md.Get("x-triage-tenant"); len(got) != 0 {
+		t.Errorf("unset fields should not be sent, got tenant %v", got)
+	}
+	if got := md.Get("x-app"); len(got) != 1 {
+		t.Errorf("existing metadata should be kept, got %v", got)
+	}
+	traceID := trace.SpanContextFromContext(ctx).TraceID().String()
+	if got := md.Get("traceparent"); len(got) != 1 || got[0][3:35] != traceID {
+		t.Errorf("traceparent: got %v", got)
+	}
+}
+
+func TestStreamClientInterceptor_InjectsContext(t *testing.T) {
+	ctx := triage.WithTenant(context.Background(), "org_1")
+
+	var md metadata.MD
+	intercept := StreamClientInterceptor()
+	intercept(ctx, &grpc.StreamDesc{}, nil, "/support.Agent/Stream",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, _ = metadata.FromOutgoingContext(ctx)
+			return nil, nil
+		})
+
+	if got := md.Get("x-triage-tenant"); len(got) != 1 || got[0] != "org_1" {
+		t.Errorf("tenant: got %v", got)
+	}
+}
+
+func TestSplitFullMethod(t *testing.T) {
+	for in, want := range map[string][2]string{
+		"/support.Agent/Chat": {"support.Agent", "Chat"},
+		"Chat":                {"", "Chat"},
+	} {
+		if s, m := splitFullMethod(in); s != want[0] || m != want[1] {
+			t.Errorf("splitFullMethod(%q): got %q, %q", in, s, m)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package triageopenai instruments github.com/sashabaranov/go-openai so chat
+// completions are recorded as Triage LLM spans without duplicating each
+// request and response into triage.Prompt by hand:
+//
+//	client := triageopenai.Wrap(openai.NewClient(apiKey))
+//	resp, err := client.CreateChatCompletion(ctx, req)
+//
+// Spans carry the prompt, tool calls, usage and any triage context (WithUser,
+// WithSession, ...) attached to ctx. Methods other than the chat completion
+// calls are passed through uninstrumented.
+//
+// It is a separate module so that the core triage module doesn't depend on
+// go-openai.
+package triageopenai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/Triage-Sec/triage-sdk-go/triage"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// Client wraps an *openai.Client, instrumenting CreateChatCompletion and
+// CreateChatCompletionStream. All other methods are the embedded client's.
+type Client struct {
+	*openai.Client
+	vendor string
+}
+
+// Option configures Wrap.
+type Option func(*Client)
+
+// Vendor sets the vendor recorded on the client's LLM spans, for
+// OpenAI-compatible backends reached through go-openai (e.g. "azure",
+// "groq"). Defaults to "openai".
+func Vendor(vendor string) Option {
+	return func(c *Client) { c.vendor = vendor }
+}
+
+// Wrap returns an instrumented client that delegates to c.
+func Wrap(c *openai.Client, opts ...Option) *Client {
+	w := &Client{Client: c, vendor: "openai"}
+	for _, o := range opts {
+		o(w)
+	}
+	return w
+}
+
+// CreateChatCompletion calls the wrapped client's CreateChatCompletion inside
+// an LLM span recording the request, response and usage.
+func (c *Client) CreateChatCompletion(ctx context.Context, req openai.ChatCompletionRequest) (openai.ChatCompletionResponse, error) {
+	ls, ctx := triage.LogPrompt(ctx, c.prompt(req))
+	defer ls.End()
+
+	resp, err := c.Client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		ls.SetError(err)
+		return resp, err
+	}
+	ls.RecordResponseHeaders(resp.Header())
+
+	completion, usage := triage.Completion{}, triage.Usage{}
+	if body, err := json.Marshal(resp); err == nil {
+		completion, usage, _ = triage.ParseCompletionFromJSON(body)
+	}
+	ls.LogCompletion(completion, usage)
+	return resp, nil
+}
+
+// CreateChatCompletionStream calls the wrapped client's
+// CreateChatCompletionStream inside an LLM span. The returned stream
+// reassembles the response as it is received; the completion is recorded
+// when Recv returns io.EOF or the stream is closed. Set
+// StreamOptions.IncludeUsage to record token usage.
+func (c *Client) CreateChatCompletionStream(ctx context.Context, req openai.ChatCompletionRequest) (*ChatCompletionStream, error) {
+	ls, ctx := triage.LogPrompt(ctx, c.prompt(req))
+
+	stream, err := c.Client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		ls.SetError(err)
+		ls.End()
+		return nil, err
+	}
+	ls.RecordResponseHeaders(stream.Header())
+	return &ChatCompletionStream{ChatCompletionStream: stream, ls: ls}, nil
+}
+
+// prompt maps req onto a triage.Prompt through its JSON form, so every field
+// go-openai serializes (content parts, tools, stop sequences, service tier)
+// is recorded exactly as sent.
+func (c *Client) prompt(req openai.ChatCompletionRequest) triage.Prompt {
+	var p triage.Prompt
+	if body, err := json.Marshal(req); err == nil {
+		p, _ = triage.ParsePromptFromJSON(body)
+	}
+	if p.Model == "" {
+		p.Model = req.Model
+	}
+	p.Vendor = c.vendor
+	return p
+}
+
+// ChatCompletionStream is an instrumented *openai.ChatCompletionStream.
+type ChatCompletionStream struct {
+	*openai.ChatCompletionStream
+	ls *triage.LLMSpan
+}
+
+// Recv returns the next chunk, recording it on the LLM span. The completion
+// is logged at io.EOF. A stream cut off by the request context ends with
+// finish reason "cancelled" and the partial response (see LLMSpan.LogCancelled);
+// any other error is recorded on the span and ends it.
+func (s *ChatCompletionStream) Recv() (openai.ChatCompletionStreamResponse, error) {
+	chunk, err := s.ChatCompletionStream.Recv()
+	switch {
+	case errors.Is(err, io.EOF):
+		s.ls.End()
+	case err != nil && s.ls.Context().Err() != nil:
+		s.ls.LogCancelled(triage.Completion{}, triage.Usage{})
+	case err != nil:
+		s.ls.SetError(err)
+		s.ls.End()
+	default:
+		s.ls.AppendDelta(delta(chunk))
+	}
+	return chunk, err
+}
+
+// Close closes the underlying stream. A stream closed before io.EOF records
+// the partial response received so far.
+func (s *ChatCompletionStream) Close() error {
+	s.ls.End()
+	return s.ChatCompletionStream.Close()
+}
+
+// delta converts one chunk into a triage.Delta. Only the first choice is
+// kept.
+func delta(chunk openai.ChatCompletionStreamResponse) triage.Delta {
+	d := triage.Delta{Model: chunk.Model}
+	if chunk.Usage != nil {
+		u := usage(chunk.Usage)
+		d.Usage = &u
+	}
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		d.Role = choice.Delta.Role
+		d.Content = choice.Delta.Content
+		d.Reasoning = choice.Delta.ReasoningContent
+		for i, tc := range choice.Delta.ToolCalls {
+			idx := i
+			if tc.Index != nil {
+				idx = *tc.Index
+			}
+			d.ToolCalls = append(d.ToolCalls, triage.ToolCallDelta{
+				Index:     idx,
+				ID:        tc.ID,
+				Type:      string(tc.Type),
+				Name:      tc.Function.Name,
+				Arguments: tc.Function.Arguments,
+			})
+		}
+		d.FinishReason = string(choice.FinishReason)
+	}
+	return d
+}
+
+// usage converts go-openai usage into triage.Usage through its JSON form,
+// which matches triage.OpenAIUsage.
+func usage(u *openai.Usage) triage.Usage {
+	var ou triage.OpenAIUsage
+	if body, err := json.Marshal(u); err == nil {
+		_ = json.Unmarshal(body, &ou)
+	}
+	return triage.FromOpenAIUsage(ou)
+}
@@ -0,0 +1,245 @@
+package triageopenai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestClient installs a global tracer provider backed by an in-memory
+// exporter and returns a wrapped client pointed at an httptest server running
+// handler.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Client, *tracetest.InMemoryExporter) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(tp)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+		_ = tp.Shutdown(context.Background())
+	})
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg := openai.DefaultConfig("sk-test")
+	cfg.BaseURL = srv.URL + "/v1"
+	return Wrap(openai.NewClientWithConfig(cfg), opts...), exporter
+}
+
+func attrMap(kvs []attribute.KeyValue) map[string]any {
+	m := make(map[string]any, len(kvs))
+	for _, kv := range kvs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
+
+var chatRequest = openai.ChatCompletionRequest{
+	Model:    "gpt-4o",
+	Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "Where is order 7?"}},
+	Tools: []openai.Tool{{
+		Type:     openai.ToolTypeFunction,
+		Function: &openai.FunctionDefinition{Name: "lookup", Parameters: map[string]any{"type": "object"}},
+	}},
+}
+
+// ---------------------------------------------------------------------------
+// CreateChatCompletion
+// ---------------------------------------------------------------------------
+
+func TestCreateChatCompletion_RecordsSpan(t *testing.T) {
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req_1")
+		io.WriteString(w, `{"model":"gpt-4o-2024-08-06","choices":[{"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"id\":7}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":8,"total_tokens":20}}`)
+	})
+
+	resp, err := client.CreateChatCompletion(context.Background(), chatRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Choices[0].FinishReason != openai.FinishReasonToolCalls {
+		t.Errorf("response should pass through, got %+v", resp)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	want := map[string]any{
+		"gen_ai.system":                                       "openai",
+		"gen_ai.request.model":                                "gpt-4o",
+		"gen_ai.response.model":                               "gpt-4o-2024-08-06",
+		"gen_ai.prompt.0.content":                             "Where is order 7?",
+		"gen_ai.request.tool.0.function.name":                 "lookup",
+		"gen_ai.completion.0.tool_calls.0.id":                 "call_1",
+		"gen_ai.completion.0.tool_calls.0.function.arguments": `{"id":7}`,
+		"gen_ai.response.finish_reason":                       "tool_calls",
+		"llm.usage.total_tokens":                              int64(20),
+	}
+	for k, v := range want {
+		if attrs[k] != v {
+			t.Errorf("%s: got %v, want %v", k, attrs[k], v)
+		}
+	}
+}
+
+func TestCreateChatCompletion_ErrorMarksSpan(t *testing.T) {
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		io.WriteString(w, `{"error":{"message":"rate limited","type":"rate_limit"}}`)
+	})
+
+	_, err := client.CreateChatCompletion(context.Background(), chatRequest)
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected the go-openai error to be returned, got %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Fatalf("expected 1 errored span, got %+v", spans)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// CreateChatCompletionStream
+// ---------------------------------------------------------------------------
+
+func TestCreateChatCompletionStream_ReassemblesResponse(t *testing.T) {
+	events := []string{
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+		`{"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5}}`,
+	}
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			io.WriteString(w, "data: "+e+"\n\n")
+		}
+		io.WriteString(w, "data: [DONE]\n\n")
+	})
+
+	req := chatRequest
+	req.Stream = true
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	stream, err := client.CreateChatCompletionStream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for {
+		_, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.completion.0.content"] != "Hello" || attrs["gen_ai.response.finish_reason"] != "stop" {
+		t.Errorf("completion: got %v / %v", attrs["gen_ai.completion.0.content"], attrs["gen_ai.response.finish_reason"])
+	}
+	if attrs["llm.usage.total_tokens"] != int64(5) {
+		t.Errorf("total tokens: got %v", attrs["llm.usage.total_tokens"])
+	}
+}
+
+func TestCreateChatCompletionStream_CloseRecordsPartial(t *testing.T) {
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`+"\n\n")
+		io.WriteString(w, `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"content":"lo"}}]}`+"\n\n")
+	})
+
+	stream, err := client.CreateChatCompletionStream(context.Background(), chatRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if got := attrMap(spans[0].Attributes)["gen_ai.completion.0.content"]; got != "Hel" {
+		t.Errorf("partial content: got %v", got)
+	}
+}
+
+func TestCreateChatCompletionStream_CancelledRecordsPartial(t *testing.T) {
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, `data: {"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`+"\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.CreateChatCompletionStream(ctx, chatRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if _, err := stream.Recv(); err == nil {
+		t.Fatal("expected an error after cancellation")
+	}
+
+	// The span may be ended by the cancellation watch on another goroutine.
+	spans := exporter.GetSpans()
+	for deadline := time.Now().Add(time.Second); len(spans) == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+		spans = exporter.GetSpans()
+	}
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	attrs := attrMap(spans[0].Attributes)
+	if attrs["gen_ai.response.finish_reason"] != "cancelled" || attrs["gen_ai.completion.0.content"] != "Hel" {
+		t.Errorf("finish/content: got %v / %v", attrs["gen_ai.response.finish_reason"], attrs["gen_ai.completion.0.content"])
+	}
+	if attrs["error.type"] != "cancelled" {
+		t.Errorf("error.type: got %v", attrs["error.type"])
+	}
+}
+
+func TestWrap_VendorOption(t *testing.T) {
+	client, exporter := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"model":"llama","choices":[]}`)
+	}, Vendor("groq"))
+
+	if _, err := client.CreateChatCompletion(context.Background(), chatRequest); err != nil {
+		t.Fatal(err)
+	}
+	if got := attrMap(exporter.GetSpans()[0].Attributes)["gen_ai.system"]; got != "groq" {
+		t.Errorf("vendor: got %v, want groq", got)
+	}
+}